@@ -2,37 +2,230 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/karprabha/job-queue-backend/internal/alert"
+	"github.com/karprabha/job-queue-backend/internal/certs"
 	"github.com/karprabha/job-queue-backend/internal/config"
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/drain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	internalgrpc "github.com/karprabha/job-queue-backend/internal/grpc"
+	"github.com/karprabha/job-queue-backend/internal/handlers"
 	internalhttp "github.com/karprabha/job-queue-backend/internal/http"
+	"github.com/karprabha/job-queue-backend/internal/leader"
+	"github.com/karprabha/job-queue-backend/internal/notify"
+	"github.com/karprabha/job-queue-backend/internal/outbox"
+	"github.com/karprabha/job-queue-backend/internal/plugin"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/ratelimiter"
 	"github.com/karprabha/job-queue-backend/internal/recovery"
+	"github.com/karprabha/job-queue-backend/internal/remote"
+	"github.com/karprabha/job-queue-backend/internal/scheduler"
+	"github.com/karprabha/job-queue-backend/internal/schema"
+	"github.com/karprabha/job-queue-backend/internal/slo"
 	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/tracing"
+	"github.com/karprabha/job-queue-backend/internal/ui"
+	"github.com/karprabha/job-queue-backend/internal/upgrade"
+	"github.com/karprabha/job-queue-backend/internal/webhook"
 	"github.com/karprabha/job-queue-backend/internal/worker"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	config := config.NewConfig()
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// Every component logs through this handler, including code using
+	// slog.Default or the log package, at a level that can be changed at
+	// runtime
+	logLevel := new(slog.LevelVar)
+	logger, err := newLogger(config, logLevel)
+	if err != nil {
+		log.Fatalf("Logging setup failed: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// Trace context is always propagated; traces are exported over OTLP
+	// when an endpoint is configured
+	tracing.SetupPropagation()
+	shutdownTracing := func(context.Context) error { return nil }
+	if tracing.Enabled() {
+		shutdown, err := tracing.Setup(context.Background())
+		if err != nil {
+			log.Fatalf("Tracing setup failed: %v", err)
+		}
+		shutdownTracing = shutdown
+		logger.Info("Tracing enabled", "event", "tracing_enabled")
+	}
 
 	// 1. Initialize store
-	jobStore := store.NewInMemoryJobStore()
-	metricStore := store.NewInMemoryMetricStore()
+	var fairShare *store.FairShare
+	if config.FairShareEnabled() {
+		fairShare = store.NewFairShare(config.FairShareWeights)
+	}
+	// Jobs survive a restart when JOB_STORE_FILE is set, synced like the
+	// disk queue
+	jobStore, err := store.NewInMemoryJobStore(store.JournalConfig{
+		Path:         config.JobStoreFile,
+		Sync:         config.DiskQueueSync,
+		SyncInterval: config.DiskQueueSyncInterval,
+	}, config.PriorityAgingInterval, config.JobLeaseDuration, fairShare, config.QuarantineAfter)
+	if err != nil {
+		log.Fatalf("Failed to load job store: %v", err)
+	}
+	metricStore := store.NewInMemoryMetricStore(jobStore, config.LatencyBuckets, config.ThroughputRetention)
+	scheduleStore := store.NewInMemoryScheduleStore()
+	workflowStore := store.NewInMemoryWorkflowStore()
+	schemaRegistry := schema.NewRegistry()
+	templateStore := store.NewInMemoryTemplateStore()
+	logStore := store.NewInMemoryLogStore(config.JobLogMaxEntries, config.JobLogMaxAttempts, config.JobLogMaxJobs)
+
+	// Workers, handlers, the sweeper and recovery publish what they do on the
+	// event bus; subscribers keep the metrics, log and deliver webhooks
+	bus := events.NewBus()
+	// Events are kept so clients that lost their stream can catch up; their
+	// IDs carry on from the last run's
+	eventLog, err := store.NewInMemoryEventLog(config.EventLogFile, config.EventLogMaxEntries, config.EventLogRetention, logger)
+	if err != nil {
+		log.Fatalf("Failed to load event log: %v", err)
+	}
+	bus.ContinueAfter(eventLog.LastEventID())
+	bus.SubscribeOrdered(eventLog)
+	bus.Subscribe(store.NewMetricSubscriber(metricStore, logger))
+	bus.Subscribe(events.NewLogSubscriber(logger))
+	eventsCtx, eventsCancel := context.WithCancel(context.Background())
+	defer eventsCancel()
+	var eventsWg sync.WaitGroup
+
+	// Webhook subscriptions and their pending deliveries survive restarts
+	// when WEBHOOK_DIR is set
+	webhookStore, webhookOutbox, err := newWebhookStores(config.WebhookDir)
+	if err != nil {
+		log.Fatalf("Failed to load webhooks: %v", err)
+	}
+	if config.EventWebhookURL != "" {
+		subscription := domain.NewWebhookSubscription(config.EventWebhookURL, config.EventWebhookSecret, config.EventWebhookTypes, config.EventWebhookJobTypes)
+		subscription.ID = configWebhookID
+		if err := webhookStore.PutWebhook(context.Background(), subscription); err != nil {
+			log.Fatalf("Failed to register event webhook: %v", err)
+		}
+		logger.Info("Event webhook enabled", "event", "event_webhook_enabled", "types", config.EventWebhookTypes, "job_types", config.EventWebhookJobTypes)
+	} else if err := webhookStore.DeleteWebhook(context.Background(), configWebhookID); err != nil && !errors.Is(err, store.ErrWebhookNotFound) {
+		log.Fatalf("Failed to remove event webhook: %v", err)
+	}
+	dispatcher := webhook.NewDispatcher(webhookStore, webhookOutbox, logger, webhook.Options{
+		Concurrency:  config.WebhookConcurrency,
+		MaxAttempts:  config.WebhookMaxAttempts,
+		PollInterval: config.WebhookPollInterval,
+	})
+	bus.Subscribe(dispatcher)
+	eventsWg.Go(func() {
+		dispatcher.Run(eventsCtx)
+	})
+
+	// Side effects handlers write to the outbox are carried out once their
+	// job has completed
+	relay := outbox.NewRelay(jobStore, bus, logger, outbox.Options{
+		MaxAttempts:  config.OutboxMaxAttempts,
+		PollInterval: config.OutboxPollInterval,
+	})
+	bus.Subscribe(relay)
+	eventsWg.Go(func() {
+		relay.Run(eventsCtx)
+	})
+
+	// People are told about dead-lettered jobs and high failure rates
+	// through Slack and email, when set up
+	if config.NotificationsEnabled() {
+		notifier := notify.NewNotifier(newNotifySenders(config), notify.Rules{
+			DeadLettered:       slices.Contains(config.NotifyOn, "dead"),
+			FailureRate:        failureRateRule(config),
+			FailureWindow:      config.NotifyFailureWindow,
+			FailureMinAttempts: config.NotifyMinAttempts,
+		}, logger)
+		bus.Subscribe(notifier)
+		eventsWg.Go(func() {
+			notifier.Run(eventsCtx)
+		})
+		logger.Info("Notifications enabled", "event", "notifications_enabled", "rules", config.NotifyOn)
+	}
+
+	// Success-rate objectives are measured from job outcomes on the bus
+	sloTracker := slo.NewTracker(config.SLOTargets, config.SLOWindow, jobStore, bus, logger)
+	if len(config.SLOTargets) > 0 {
+		bus.Subscribe(sloTracker)
+		eventsWg.Go(func() {
+			sloTracker.Run(eventsCtx)
+		})
+	}
+
+	// Admin and mutating API calls are recorded in the audit log
+	auditStore, err := store.NewInMemoryAuditStore(config.AuditLogFile, config.AuditMaxEntries)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
 
 	// 2. Run recovery logic (BEFORE queue initialization and workers)
 	// Initialize queue for recovery (but workers not started yet)
-	jobQueue := make(chan string, config.JobQueueCapacity)
+	jobQueue, err := newJobQueue(config, jobStore, logger)
+	if err != nil {
+		log.Fatalf("Failed to create job queue: %v", err)
+	}
 
-	recoveryCtx := context.Background()
-	if err := recovery.RecoverJobs(recoveryCtx, jobStore, jobQueue, logger); err != nil {
+	// Every queue reports its traffic to queueStats for /metrics, and can be
+	// paused as one through valve
+	queueStats := queue.NewStats()
+	valve := queue.NewValve()
+	jobQueue = valve.Wrap(queue.Instrument(jobQueue, queue.DefaultName, queueStats))
+
+	// Named queues get their own channel and workers; the router sends each
+	// enqueued job to its queue
+	defaultQueue := jobQueue
+	if len(config.Queues) > 0 {
+		if config.QueueBackend != "channel" {
+			log.Fatalf("QUEUES requires QUEUE_BACKEND=channel")
+		}
+
+		queues := map[string]queue.Queue{queue.DefaultName: jobQueue}
+		for _, named := range config.Queues {
+			queues[named.Name] = valve.Wrap(queue.Instrument(queue.NewChannelQueue(named.Capacity), named.Name, queueStats))
+		}
+		for jobType, name := range config.QueueRoutes {
+			if _, ok := queues[name]; !ok {
+				log.Fatalf("QUEUE_ROUTES sends %s to unknown queue %q", jobType, name)
+			}
+		}
+
+		jobQueue = queue.NewRouter(queues, queue.NewRouting(config.QueueRoutes), jobStore.GetJob)
+		logger.Info("Named queues configured", "event", "queues_configured", "queues", jobQueue.(*queue.Router).Names())
+	}
+
+	// Listeners are taken over from the previous process when this one was
+	// started by an upgrade
+	upgrader := upgrade.New(config.PIDFile)
+
+	// Jobs the previous run left processing go back to pending before any
+	// worker claims; re-enqueueing the backlog waits until the server is up.
+	// After an upgrade, which needs a shared job store, the previous process
+	// is still draining them and releases whatever it doesn't finish
+	recoveryProgress := recovery.NewProgress()
+	if upgrader.Inherited() {
+		logger.Info("Started by an upgrade, leaving processing jobs to the previous process", "event", "recovery_skipped")
+	} else if err := recovery.RecoverProcessingJobs(context.Background(), jobStore, bus, logger, recoveryProgress); err != nil {
 		log.Fatalf("Recovery failed: %v", err)
 	}
 
@@ -47,18 +240,153 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
 	defer shutdownCancel()
 
-	var wg sync.WaitGroup
+	// Handlers that execute each job type
+	fallback, err := worker.ParseFallbackAction(config.UnknownJobTypeAction)
+	if err != nil {
+		log.Fatalf("Invalid UNKNOWN_JOB_TYPE_ACTION: %v", err)
+	}
+	registry := worker.NewRegistry(fallback, config.JobTimeout)
+	for jobType, timeout := range config.JobTimeouts {
+		registry.SetTimeout(jobType, timeout)
+	}
+	for jobType, limit := range config.JobRateLimits {
+		registry.SetRateLimit(jobType, limit.Rate, limit.Burst)
+	}
+	for _, jobType := range config.RemoteJobTypes {
+		registry.SetRemote(jobType)
+	}
+	// Handlers run in a span, with their duration observed and their outcome
+	// logged
+	registry.Use(worker.Tracing(), worker.Metrics(metricStore, logger), worker.Logging())
+	if config.ChaosEnabled && len(config.ChaosFaults) > 0 {
+		faults := make(map[string]worker.ChaosFault, len(config.ChaosFaults))
+		for jobType, fault := range config.ChaosFaults {
+			faults[jobType] = worker.ChaosFault(fault)
+		}
+		registry.Use(worker.Chaos(faults))
+		logger.Warn("Chaos mode enabled, injecting handler faults", "event", "chaos_enabled", "faults", len(faults))
+	}
+	registerJobHandlers(registry, config)
+
+	// WASM plugins replace any other handler for their job type
+	var pluginHost *plugin.Host
+	if config.PluginsDir != "" {
+		pluginHost, err = plugin.NewHost(context.Background(), config.PluginsDir, registry, logger)
+		if err != nil {
+			log.Fatalf("Plugin host setup failed: %v", err)
+		}
+		defer pluginHost.Close(context.Background())
+
+		if err := pluginHost.Load(context.Background()); err != nil {
+			log.Fatalf("Failed to load plugins: %v", err)
+		}
+	}
+
+	// Gate shared by all workers so processing can be paused via the admin API
+	gate := worker.NewGate()
+
+	// Jobs being processed, so the cancel endpoint can interrupt them. Reaped
+	// attempts are interrupted as the reap is published
+	runningJobs := worker.NewRunningJobs()
+	bus.Subscribe(runningJobs)
+
+	// Idle workers of one named queue may take jobs from the others
+	var stealer *worker.Stealer
+	if len(config.QueueStealLimits) > 0 {
+		router, ok := jobQueue.(*queue.Router)
+		if !ok {
+			log.Fatalf("QUEUE_STEAL_LIMITS requires QUEUES")
+		}
+		for name := range config.QueueStealLimits {
+			if router.Named(name) == nil {
+				log.Fatalf("QUEUE_STEAL_LIMITS sets a limit for unknown queue %q", name)
+			}
+		}
+		stealer = worker.NewStealer(router, config.QueueStealLimits, config.QueueStealIdle)
+	}
+
+	// Pool owns the worker goroutines so the count can change at runtime.
+	// Every pool reports what its workers do to utilization, for /metrics
+	// and /admin/workers
+	utilization := worker.NewUtilization()
+	pool := worker.NewPool(workerCtx, queue.DefaultName, func(id int) *worker.Worker {
+		return worker.NewWorker(id, jobStore, metricStore, bus, logStore, logger, jobQueue, gate, registry, runningJobs, queue.DefaultName, stealer)
+	}, metricStore, utilization, logger)
+
+	// Named queues have fixed-size pools; resizing and autoscaling apply to
+	// the default queue's pool
+	var queuePools []*worker.Pool
+	for _, named := range config.Queues {
+		queuePool := worker.NewPool(workerCtx, named.Name, func(id int) *worker.Worker {
+			return worker.NewWorker(id, jobStore, metricStore, bus, logStore, logger, jobQueue, gate, registry, runningJobs, named.Name, stealer)
+		}, metricStore, utilization, logger)
+		queuePool.Resize(named.Workers)
+		queuePools = append(queuePools, queuePool)
+	}
 
-	for i := 0; i < config.WorkerCount; i++ {
-		workerID := i // Capture loop variable to avoid closure issue
-		worker := worker.NewWorker(workerID, jobStore, metricStore, logger, jobQueue)
-		wg.Go(func() {
-			worker.Start(workerCtx)
+	// With autoscaling on, WORKER_COUNT is only the starting size
+	autoscalerCtx, autoscalerCancel := context.WithCancel(context.Background())
+	defer autoscalerCancel()
+
+	var autoscalerWg sync.WaitGroup
+	if config.AutoscaleEnabled() {
+		pool.Resize(min(max(config.WorkerCount, config.AutoscaleMinWorkers), config.AutoscaleMaxWorkers))
+
+		autoscaler := worker.NewAutoscaler(pool, jobStore, metricStore, gate, defaultQueue, logger, worker.AutoscalerConfig{
+			MinWorkers: config.AutoscaleMinWorkers,
+			MaxWorkers: config.AutoscaleMaxWorkers,
+			Interval:   config.AutoscaleInterval,
+			MaxLatency: config.AutoscaleMaxLatency,
+			Cooldown:   config.AutoscaleCooldown,
+		})
+		autoscalerWg.Go(func() {
+			autoscaler.Run(autoscalerCtx)
 		})
+	} else {
+		pool.Resize(config.WorkerCount)
+	}
+
+	// The depth monitor shares the autoscaler's lifetime
+	if config.QueueAlertsEnabled() {
+		for name := range config.QueueDepthAlerts {
+			if !queue.Exists(jobQueue, name) {
+				log.Fatalf("QUEUE_DEPTH_ALERTS sets a threshold for unknown queue %q", name)
+			}
+		}
+
+		depthMonitor := alert.NewDepthMonitor(queueStats, metricStore, logger, alert.DepthThresholds{
+			Queues: config.QueueDepthAlerts,
+			Total:  config.QueueDepthAlertTotal,
+		}, config.QueueAlertInterval, config.QueueAlertWebhookURL, config.QueueAlertWebhookSecret)
+		autoscalerWg.Go(func() {
+			depthMonitor.Run(autoscalerCtx)
+		})
+	}
+
+	// The slow job monitor shares the autoscaler's lifetime too
+	slowMonitor := alert.NewSlowMonitor(jobStore, metricStore, logger, alert.SlowThresholds{
+		Default:    config.SlowJobThreshold,
+		ByType:     config.SlowJobThresholds,
+		MinSamples: config.SlowJobMinSamples,
+	}, config.SlowJobCheckInterval)
+	autoscalerWg.Go(func() {
+		slowMonitor.Run(autoscalerCtx)
+	})
+
+	elector, err := newElector(config, jobStore, logger)
+	if err != nil {
+		log.Fatalf("Failed to set up leader election: %v", err)
 	}
 
 	// Start sweeper (runs periodically to retry failed jobs and enqueue pending)
-	sweeper := store.NewInMemorySweeper(jobStore, metricStore, logger, config.SweeperInterval, jobQueue)
+	sweeper := store.NewInMemorySweeper(jobStore, bus, logger, store.SweeperSchedule{
+		Interval:  config.SweeperInterval,
+		Jitter:    config.SweeperJitter,
+		BatchSize: config.SweeperBatchSize,
+	}, jobQueue, store.StuckThresholds{
+		Default: config.StuckJobThreshold,
+		ByType:  config.StuckJobThresholds,
+	}, ratelimiter.NewRetryBudget(config.RetryBudget, config.RetryBudgets), elector)
 
 	sweeperCtx, sweeperCancel := context.WithCancel(context.Background())
 	defer sweeperCancel()
@@ -68,46 +396,360 @@ func main() {
 		sweeper.Run(sweeperCtx)
 	})
 
+	// Leader election shares the sweeper's lifetime; stopping it hands
+	// leadership over
+	if redisElector, ok := elector.(*leader.RedisElector); ok {
+		sweeperWg.Go(func() {
+			redisElector.Run(sweeperCtx)
+		})
+	}
+
+	// Lease reaper shares the sweeper's lifetime
+	leaseReaper := store.NewLeaseReaper(jobStore, bus, logger, config.LeaseReaperInterval, jobQueue, elector)
+	sweeperWg.Go(func() {
+		leaseReaper.Run(sweeperCtx)
+	})
+
+	// Plugin rescans share the sweeper's lifetime
+	if pluginHost != nil {
+		sweeperWg.Go(func() {
+			pluginHost.Run(sweeperCtx, config.PluginsReloadInterval)
+		})
+	}
+
 	mux := http.NewServeMux()
 
-	metricHandler := internalhttp.NewMetricHandler(metricStore, logger)
-	jobHandler := internalhttp.NewJobHandler(jobStore, metricStore, logger, jobQueue, shutdownCtx)
+	drainController := drain.NewController(jobStore, logger)
+
+	// Start scheduler (creates jobs from recurring schedules as they come due)
+	jobScheduler := scheduler.NewScheduler(scheduleStore, jobStore, bus, drainController, jobQueue, logger, config.SchedulerInterval, elector)
 
-	// Health Route
-	mux.HandleFunc("GET /health", internalhttp.HealthCheckHandler)
+	schedulerCtx, schedulerCancel := context.WithCancel(context.Background())
+	defer schedulerCancel()
+
+	var schedulerWg sync.WaitGroup
+	schedulerWg.Go(func() {
+		jobScheduler.Run(schedulerCtx)
+	})
+
+	// Readiness waits for recovery to finish
+	healthHandler := internalhttp.NewHealthHandler(jobStore, metricStore, jobQueue, recoveryProgress, logger, shutdownCtx)
+	metricHandler := internalhttp.NewMetricHandler(jobStore, metricStore, logger, jobQueue, queueStats, utilization, sloTracker, elector)
+	adminHandler := internalhttp.NewAdminHandler(jobStore, bus, jobQueue, gate, valve, drainController, pool, utilization, sweeper, recoveryProgress, logger, config.MaxAdminBodyBytes)
+	jobHandler := internalhttp.NewJobHandler(jobStore, bus, logStore, logger, jobQueue, shutdownCtx, drainController, runningJobs, schemaRegistry, templateStore, config.MaxJobBodyBytes)
+	scheduleHandler := internalhttp.NewScheduleHandler(scheduleStore, logger, config.MaxJobBodyBytes)
+	dlqHandler := internalhttp.NewDLQHandler(jobStore, bus, logger, jobQueue)
+	quarantineHandler := internalhttp.NewQuarantineHandler(jobStore, bus, logger, jobQueue)
+	eventHandler := internalhttp.NewEventHandler(bus, eventLog, logger)
+	ingestHandler := internalhttp.NewIngestHandler(config.IngestSources, jobHandler, logger, config.MaxJobBodyBytes)
+	workflowHandler := internalhttp.NewWorkflowHandler(workflowStore, jobHandler, logger, config.MaxJobBodyBytes)
+	schemaHandler := internalhttp.NewSchemaHandler(schemaRegistry, logger, config.MaxJobBodyBytes)
+	templateHandler := internalhttp.NewTemplateHandler(templateStore, logger, config.MaxJobBodyBytes)
+	debugHandler := internalhttp.NewDebugHandler(logger)
+	slowJobHandler := internalhttp.NewSlowJobHandler(slowMonitor, logger)
+	sloHandler := internalhttp.NewSLOHandler(sloTracker, logger)
+	auditHandler := internalhttp.NewAuditHandler(auditStore, logger)
+	logLevelHandler := internalhttp.NewLogLevelHandler(logLevel, logger, config.MaxAdminBodyBytes)
+	webhookHandler := internalhttp.NewWebhookHandler(webhookStore, webhookOutbox, dispatcher, logger, config.MaxAdminBodyBytes)
+	if config.JobTemplatesFile != "" {
+		if err := templateHandler.LoadTemplates(context.Background(), config.JobTemplatesFile); err != nil {
+			log.Fatalf("Failed to load job templates: %v", err)
+		}
+	}
+	remoteService := remote.NewService(jobStore, bus, logger, jobQueue)
+	remoteWorkerHandler := internalhttp.NewRemoteWorkerHandler(remoteService, jobHandler, gate, valve, logger, config.JobLeaseDuration, config.MaxAdminBodyBytes)
+
+	// Health Routes
+	mux.HandleFunc("GET /healthz", healthHandler.Liveness)
+	mux.HandleFunc("GET /readyz", healthHandler.Readiness)
+	mux.HandleFunc("GET /health", healthHandler.Health)
+
+	// Version Route
+	mux.HandleFunc("GET /version", internalhttp.VersionHandler)
+
+	loadShedder := internalhttp.NewLoadShedder(jobQueue, metricStore, logger, config.LoadShedHighWaterMark, config.LoadShedRetryAfter)
+
+	// Per-route request deadlines
+	withRequestTimeout := func(h http.HandlerFunc) http.Handler {
+		return internalhttp.Timeout(config.RequestTimeout, h)
+	}
+	withExportTimeout := func(h http.HandlerFunc) http.Handler {
+		return internalhttp.Timeout(config.ExportTimeout, h)
+	}
 
 	// Job Routes
-	mux.HandleFunc("GET /jobs", jobHandler.GetJobs)
-	mux.HandleFunc("POST /jobs", jobHandler.CreateJob)
+	mux.Handle("GET /jobs", withRequestTimeout(jobHandler.GetJobs))
+	mux.Handle("POST /jobs", loadShedder.Middleware(withRequestTimeout(jobHandler.CreateJob)))
+	mux.Handle("GET /jobs/export", withExportTimeout(jobHandler.ExportJobs))
+	mux.Handle("GET /jobs/{id}", withRequestTimeout(jobHandler.GetJob))
+	mux.Handle("GET /jobs/{id}/result", withRequestTimeout(jobHandler.GetJobResult))
+	mux.Handle("GET /jobs/{id}/logs", withRequestTimeout(jobHandler.GetJobLogs))
+	mux.Handle("POST /jobs/{id}/retry", withRequestTimeout(jobHandler.RetryJob))
+	mux.Handle("POST /jobs/{id}/cancel", withRequestTimeout(jobHandler.CancelJob))
+	mux.Handle("POST /jobs/{id}/replay", withRequestTimeout(jobHandler.ReplayJob))
+	mux.Handle("POST /jobs/{id}/progress", withRequestTimeout(jobHandler.UpdateProgress))
+	// Long-lived Server-Sent Events stream; bounded by the server WriteTimeout
+	mux.HandleFunc("GET /jobs/{id}/events", jobHandler.StreamJob)
+	mux.HandleFunc("GET /events", eventHandler.StreamEvents)
+	mux.Handle("GET /events/history", withRequestTimeout(eventHandler.GetEventHistory))
+
+	// Remote Worker Routes
+	mux.Handle("POST /workers/lease", withRequestTimeout(remoteWorkerHandler.Lease))
+	mux.Handle("POST /jobs/{id}/heartbeat", withRequestTimeout(remoteWorkerHandler.Heartbeat))
+	mux.Handle("POST /jobs/{id}/ack", withRequestTimeout(remoteWorkerHandler.Ack))
+	mux.Handle("POST /jobs/{id}/nack", withRequestTimeout(remoteWorkerHandler.Nack))
+
+	// Schedule Routes
+	mux.Handle("POST /schedules", withRequestTimeout(scheduleHandler.CreateSchedule))
+	mux.Handle("GET /schedules", withRequestTimeout(scheduleHandler.ListSchedules))
+	mux.Handle("GET /schedules/{id}", withRequestTimeout(scheduleHandler.GetSchedule))
+	mux.Handle("DELETE /schedules/{id}", withRequestTimeout(scheduleHandler.DeleteSchedule))
 
-	// Metric Routes
-	mux.HandleFunc("GET /metrics", metricHandler.GetMetrics)
+	// Workflow Routes
+	mux.Handle("POST /workflows", loadShedder.Middleware(withRequestTimeout(workflowHandler.CreateWorkflow)))
+	mux.Handle("GET /workflows", withRequestTimeout(workflowHandler.ListWorkflows))
+	mux.Handle("GET /workflows/{id}", withRequestTimeout(workflowHandler.GetWorkflow))
+
+	// Payload Schema Routes
+	mux.Handle("GET /schemas", withRequestTimeout(schemaHandler.ListSchemas))
+	mux.Handle("GET /schemas/{type}", withRequestTimeout(schemaHandler.GetSchema))
+	mux.Handle("PUT /schemas/{type}", withRequestTimeout(schemaHandler.PutSchema))
+	mux.Handle("DELETE /schemas/{type}", withRequestTimeout(schemaHandler.DeleteSchema))
+
+	// Template Routes
+	mux.Handle("GET /templates", withRequestTimeout(templateHandler.ListTemplates))
+	mux.Handle("GET /templates/{name}", withRequestTimeout(templateHandler.GetTemplate))
+	mux.Handle("PUT /templates/{name}", withRequestTimeout(templateHandler.PutTemplate))
+	mux.Handle("DELETE /templates/{name}", withRequestTimeout(templateHandler.DeleteTemplate))
+
+	// Dead-Letter Queue Routes
+	mux.Handle("GET /dlq", withRequestTimeout(dlqHandler.ListDeadJobs))
+	mux.Handle("POST /dlq/{id}/requeue", withRequestTimeout(dlqHandler.RequeueDeadJob))
+
+	// Quarantine Routes
+	mux.Handle("GET /admin/quarantine", withRequestTimeout(quarantineHandler.ListQuarantinedJobs))
+	mux.Handle("POST /admin/quarantine/{id}/release", withRequestTimeout(quarantineHandler.ReleaseQuarantinedJob))
+
+	// Webhook Subscription Routes
+	mux.Handle("POST /webhooks", withRequestTimeout(webhookHandler.CreateWebhook))
+	mux.Handle("GET /webhooks", withRequestTimeout(webhookHandler.ListWebhooks))
+	mux.Handle("GET /webhooks/{id}", withRequestTimeout(webhookHandler.GetWebhook))
+	mux.Handle("DELETE /webhooks/{id}", withRequestTimeout(webhookHandler.DeleteWebhook))
+	mux.Handle("GET /webhooks/{id}/deliveries", withRequestTimeout(webhookHandler.ListDeliveries))
+
+	// Webhook Ingestion Routes
+	mux.Handle("POST /ingest/{source}", withRequestTimeout(ingestHandler.Ingest))
+
+	// Metric Routes: Prometheus exposition format, and the same metrics as JSON
+	mux.Handle("GET /metrics", withRequestTimeout(metricHandler.Prometheus().ServeHTTP))
+	mux.Handle("GET /metrics.json", withRequestTimeout(metricHandler.GetMetrics))
+	mux.Handle("GET /stats", withRequestTimeout(metricHandler.GetStats))
+	mux.Handle("GET /metrics/timeseries", withRequestTimeout(metricHandler.GetTimeseries))
+	mux.Handle("GET /slo", withRequestTimeout(sloHandler.GetSLOs))
+
+	// Dashboard
+	mux.Handle("GET /ui/", ui.Handler())
+	mux.Handle("GET /ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+
+	// Admin Routes
+	mux.Handle("POST /admin/pause", withRequestTimeout(adminHandler.Pause))
+	mux.Handle("POST /admin/resume", withRequestTimeout(adminHandler.Resume))
+	mux.Handle("POST /admin/types/{type}/pause", withRequestTimeout(adminHandler.PauseType))
+	mux.Handle("POST /admin/types/{type}/resume", withRequestTimeout(adminHandler.ResumeType))
+	mux.Handle("POST /admin/drain", withRequestTimeout(adminHandler.Drain))
+	mux.Handle("GET /admin/drain/status", withRequestTimeout(adminHandler.DrainStatus))
+	mux.Handle("DELETE /admin/drain", withRequestTimeout(adminHandler.StopDrain))
+	mux.Handle("GET /admin/workers", withRequestTimeout(adminHandler.Workers))
+	mux.Handle("PUT /admin/workers", withRequestTimeout(adminHandler.ResizeWorkers))
+	mux.Handle("POST /admin/requeue-stuck", withRequestTimeout(adminHandler.RequeueStuck))
+	mux.Handle("GET /admin/sweeper", withRequestTimeout(adminHandler.SweeperStatus))
+	mux.Handle("GET /admin/recovery", withRequestTimeout(adminHandler.RecoveryStatus))
+	mux.Handle("GET /admin/slow", withRequestTimeout(slowJobHandler.GetSlowJobs))
+	mux.Handle("GET /admin/audit", withRequestTimeout(auditHandler.GetAudit))
+	mux.Handle("GET /admin/queue", withRequestTimeout(adminHandler.QueueStatus))
+	mux.Handle("POST /admin/queue/pause", withRequestTimeout(adminHandler.PauseQueue))
+	mux.Handle("POST /admin/queue/resume", withRequestTimeout(adminHandler.ResumeQueue))
+	mux.Handle("GET /admin/debug", withRequestTimeout(debugHandler.Summary))
+	mux.Handle("GET /admin/loglevel", withRequestTimeout(logLevelHandler.GetLogLevel))
+	mux.Handle("PUT /admin/loglevel", withRequestTimeout(logLevelHandler.PutLogLevel))
+
+	auditor := internalhttp.NewAuditor(auditStore, logger, config.AuditActorHeader)
+	// Without an exporter the spans are not recorded, but a traceparent on
+	// the request still reaches the jobs it submits
+	handler := internalhttp.Trace(internalhttp.Gzip(logger, auditor.Middleware(mux)))
 
 	// Create http.Server instance
 	srv := &http.Server{
-		Addr:    ":" + config.Port,
-		Handler: mux,
+		Addr:              ":" + config.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	// Configure TLS (and mTLS when a client CA is set)
+	var certReloader *certs.Reloader
+	var tlsConfig *tls.Config
+	if err := config.ValidateTLS(); err != nil {
+		log.Fatalf("TLS setup failed: %v", err)
+	}
+	if config.TLSEnabled() {
+		reloader, err := certs.NewReloader(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile)
+		if err != nil {
+			log.Fatalf("TLS setup failed: %v", err)
+		}
+
+		tlsConfig = certs.NewServerTLSConfig(reloader)
+		certReloader = reloader
+		srv.TLSConfig = tlsConfig
+	}
+
+	httpListener, err := upgrader.Listen("http", srv.Addr)
+	if err != nil {
+		log.Fatalf("Server listen failed: %v", err)
 	}
 
 	// Start server in goroutine
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if certReloader != nil {
+			logger.Info("Server starting with TLS", "event", "server_started", "port", config.Port, "mtls", config.TLSClientCAFile != "")
+			// Certificates are served from TLSConfig.GetCertificate
+			err = srv.ServeTLS(httpListener, "", "")
+		} else {
+			err = srv.Serve(httpListener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
 
+	// The backlog is re-enqueued while the server serves and workers drain
+	// the queue; /readyz fails until it is done. Shutdown cuts it short
+	go func() {
+		if err := recovery.ReEnqueuePendingJobs(shutdownCtx, jobStore, jobQueue, bus, logger, recoveryProgress); err != nil && shutdownCtx.Err() == nil {
+			log.Fatalf("Recovery failed: %v", err)
+		}
+	}()
+
+	// gRPC worker protocol, sharing the HTTP server's TLS settings
+	var grpcServer *grpc.Server
+	if config.GRPCEnabled() {
+		workerServer := internalgrpc.NewWorkerServer(remoteService, gate, valve, shutdownCtx, logger, config.JobLeaseDuration, config.GRPCPollInterval)
+		grpcServer = internalgrpc.NewServer(workerServer, tlsConfig)
+
+		listener, err := upgrader.Listen("grpc", ":"+config.GRPCPort)
+		if err != nil {
+			log.Fatalf("gRPC listen failed: %v", err)
+		}
+		go func() {
+			logger.Info("gRPC server starting", "event", "grpc_server_started", "port", config.GRPCPort, "tls", tlsConfig != nil)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
+
+	// Profiling and runtime diagnostics on their own listener, kept off the
+	// API port so it can be bound to localhost or a private network
+	var debugServer *http.Server
+	if config.DebugAddr != "" {
+		debugServer = &http.Server{
+			Addr:              config.DebugAddr,
+			Handler:           internalhttp.DebugMux(debugHandler),
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+		listener, err := upgrader.Listen("debug", config.DebugAddr)
+		if err != nil {
+			log.Fatalf("Debug server listen failed: %v", err)
+		}
+		go func() {
+			logger.Info("Debug server starting", "event", "debug_server_started", "addr", config.DebugAddr)
+			if err := debugServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Debug server failed: %v", err)
+			}
+		}()
+	}
+
+	// Every listener is open, so the previous process, if this one replaces
+	// it, can stop accepting
+	if upgrader.Inherited() {
+		logger.Info("Listeners taken over from previous process", "event", "upgrade_ready")
+	}
+	if err := upgrader.Ready(); err != nil {
+		log.Fatalf("Upgrade readiness failed: %v", err)
+	}
+
+	// Reload the certificate, key and client CA bundle on SIGHUP
+	if certReloader != nil {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		go func() {
+			for range hupChan {
+				if err := certReloader.Reload(); err != nil {
+					logger.Error("Failed to reload TLS certificate", "event", "tls_reload_failed", "error", err)
+					continue
+				}
+				logger.Info("TLS certificate and client CA reloaded", "event", "tls_reloaded")
+			}
+		}()
+	}
+
+	// On SIGUSR2 a new process takes over the listeners, and this one shuts
+	// down once it serves. If it fails to start, this one carries on. Jobs
+	// this process accepted but hasn't run would be lost with a store only
+	// it can see, so the upgrade is refused then
+	upgraded := make(chan struct{})
+	usr2Chan := make(chan os.Signal, 1)
+	signal.Notify(usr2Chan, syscall.SIGUSR2)
+	go func() {
+		for range usr2Chan {
+			if !jobStore.Shared() {
+				logger.Error("Upgrade refused: jobs are kept by this process and the new one would not see them; restart instead", "event", "upgrade_refused")
+				continue
+			}
+			logger.Info("Upgrade requested", "event", "upgrade_started")
+			pid, err := upgrader.Upgrade(config.UpgradeTimeout)
+			if err != nil {
+				logger.Error("Upgrade failed, still serving", "event", "upgrade_failed", "error", err)
+				continue
+			}
+			logger.Info("New process is serving", "event", "upgrade_handed_over", "pid", pid)
+			close(upgraded)
+			return
+		}
+	}()
+
 	// Set up signal handling
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// Wait for shutdown signal
-	<-sigChan
+	// Wait for shutdown signal, or for a new process to take over
+	select {
+	case <-sigChan:
+	case <-upgraded:
+	}
 	logger.Info("Shutting down...")
 
 	// 1. Signal shutdown to handlers (they will reject new jobs)
 	shutdownCancel()
 	logger.Info("Shutdown signal sent to handlers")
 
+	// Readiness now fails; keep serving long enough for load balancers to
+	// see it and route elsewhere. After an upgrade the new process already
+	// takes the traffic
+	select {
+	case <-upgraded:
+	default:
+		if config.ShutdownReadinessDelay > 0 {
+			logger.Info("Waiting for load balancers to stop routing", "event", "shutdown_readiness_delay", "delay", config.ShutdownReadinessDelay)
+			time.Sleep(config.ShutdownReadinessDelay)
+		}
+	}
+
 	// 2. Shutdown HTTP server (stops accepting new requests, waits for in-flight)
 	serverShutdownCtx, serverShutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer serverShutdownCancel()
@@ -120,18 +762,346 @@ func main() {
 		}
 	}
 
-	// 3. Cancel sweeper and wait
+	// An in-progress profile is cut short rather than waited for
+	if debugServer != nil {
+		debugServer.Close()
+	}
+
+	// Worker streams are long-lived, so they are closed rather than waited
+	// for; their jobs stay processing and are recovered on the next start
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+
+	// Stop any admin drain watcher
+	drainController.Stop()
+
+	// 3. Cancel autoscaler, scheduler and sweeper and wait
+	autoscalerCancel()
+	autoscalerWg.Wait()
+
+	schedulerCancel()
+	schedulerWg.Wait()
+	logger.Info("Scheduler stopped")
+
 	sweeperCancel()
 	sweeperWg.Wait()
-	logger.Info("Sweeper stopped")
+	logger.Info("Sweeper and lease reaper stopped")
 
-	// 4. Cancel workers (stops picking new jobs) and wait for them to finish current jobs
+	// 4. Stop workers picking new jobs and give them the grace period to
+	// finish current ones; anything still running is aborted and returned
+	// to pending
+	var drainWg sync.WaitGroup
+	var graceExceeded atomic.Bool
+	for _, p := range append([]*worker.Pool{pool}, queuePools...) {
+		drainWg.Go(func() {
+			if !p.Drain(config.ShutdownGracePeriod) {
+				graceExceeded.Store(true)
+			}
+		})
+	}
+	drainWg.Wait()
+	if graceExceeded.Load() {
+		logger.Warn("Shutdown grace period exceeded, aborting in-flight jobs", "event", "shutdown_grace_exceeded", "grace_period", config.ShutdownGracePeriod)
+	}
 	workerCancel()
-	wg.Wait()
+	pool.Wait()
+	for _, queuePool := range queuePools {
+		queuePool.Wait()
+	}
 	logger.Info("Workers stopped")
 
-	// 5. Close the job queue (safe now that workers are done)
-	close(jobQueue)
+	// 5. Close the job queue (safe now that workers are done) and stop
+	// delivering events
+	jobQueue.Close()
+	eventsCancel()
+	eventsWg.Wait()
+	if err := auditStore.Close(); err != nil {
+		logger.Error("Failed to close audit log", "error", err)
+	}
+	if err := eventLog.Close(); err != nil {
+		logger.Error("Failed to close event log", "error", err)
+	}
+	if err := jobStore.Close(); err != nil {
+		logger.Error("Failed to close job store", "error", err)
+	}
+
+	// 6. Export the spans of the last jobs
+	tracingShutdownCtx, tracingShutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingShutdownCancel()
+	if err := shutdownTracing(tracingShutdownCtx); err != nil {
+		logger.Error("Tracing shutdown error", "error", err)
+	}
 
 	logger.Info("Server stopped")
 }
+
+// newLogger creates the server logger in the configured format, starting at
+// the configured level, which is held in level.
+func newLogger(cfg *config.Config, level *slog.LevelVar) (*slog.Logger, error) {
+	var initial slog.Level
+	if err := initial.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q", cfg.LogLevel)
+	}
+	level.Set(initial)
+
+	options := &slog.HandlerOptions{Level: level}
+	switch cfg.LogFormat {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown LOG_FORMAT %q", cfg.LogFormat)
+	}
+}
+
+// newNotifySenders returns a sender for each notification channel configured.
+func newNotifySenders(cfg *config.Config) []notify.Sender {
+	var senders []notify.Sender
+	if cfg.NotifySlackWebhookURL != "" {
+		senders = append(senders, notify.NewSlackSender(cfg.NotifySlackWebhookURL))
+	}
+	if cfg.NotifySMTPAddr != "" && len(cfg.NotifySMTPTo) > 0 {
+		senders = append(senders, notify.NewSMTPSender(notify.SMTPConfig{
+			Addr:     cfg.NotifySMTPAddr,
+			Username: cfg.NotifySMTPUsername,
+			Password: cfg.NotifySMTPPassword,
+			From:     cfg.NotifySMTPFrom,
+			To:       cfg.NotifySMTPTo,
+		}))
+	}
+	return senders
+}
+
+// failureRateRule is the failure rate to notify at, or zero when the
+// failure_rate rule is off.
+func failureRateRule(cfg *config.Config) float64 {
+	if !slices.Contains(cfg.NotifyOn, "failure_rate") {
+		return 0
+	}
+	return cfg.NotifyFailureRate
+}
+
+// configWebhookID is the ID of the subscription made from EVENT_WEBHOOK_URL,
+// replaced on every start so the environment stays its source of truth.
+const configWebhookID = "env"
+
+// newWebhookStores opens the webhook subscriptions and outbox kept under dir,
+// or in memory only when dir is empty.
+func newWebhookStores(dir string) (*store.InMemoryWebhookStore, *store.InMemoryWebhookOutbox, error) {
+	if dir == "" {
+		webhookStore, _ := store.NewInMemoryWebhookStore("")
+		outbox, _ := store.NewInMemoryWebhookOutbox("")
+		return webhookStore, outbox, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	webhookStore, err := store.NewInMemoryWebhookStore(filepath.Join(dir, "subscriptions.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	outbox, err := store.NewInMemoryWebhookOutbox(filepath.Join(dir, "outbox"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return webhookStore, outbox, nil
+}
+
+// newJobQueue creates the job queue selected by QUEUE_BACKEND.
+func newJobQueue(cfg *config.Config, jobStore store.JobStore, logger *slog.Logger) (queue.Queue, error) {
+	switch cfg.QueueBackend {
+	case "channel":
+		return queue.NewChannelQueue(cfg.JobQueueCapacity), nil
+	case "heap":
+		logger.Info("Using priority heap job queue", "event", "queue_backend", "backend", cfg.QueueBackend)
+		return queue.NewHeapQueue(cfg.JobQueueCapacity, jobStore.GetJob), nil
+	case "disk":
+		switch cfg.DiskQueueSync {
+		case queue.SyncAlways, queue.SyncInterval, queue.SyncNever:
+		default:
+			return nil, fmt.Errorf("unknown DISK_QUEUE_SYNC %q", cfg.DiskQueueSync)
+		}
+		logger.Info("Using disk job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "dir", cfg.DiskQueueDir, "sync", cfg.DiskQueueSync)
+		return queue.NewDiskQueue(queue.DiskConfig{
+			Dir:          cfg.DiskQueueDir,
+			SegmentSize:  cfg.DiskQueueSegmentSize,
+			Capacity:     cfg.JobQueueCapacity,
+			Sync:         cfg.DiskQueueSync,
+			SyncInterval: cfg.DiskQueueSyncInterval,
+		}, logger)
+	case "redis":
+		logger.Info("Using Redis Streams job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "addr", cfg.RedisAddr, "stream", cfg.RedisStream, "consumer", cfg.RedisConsumer)
+		return queue.NewRedisQueue(context.Background(), queue.RedisConfig{
+			Addr:         cfg.RedisAddr,
+			Password:     cfg.RedisPassword,
+			DB:           cfg.RedisDB,
+			Stream:       cfg.RedisStream,
+			Group:        cfg.RedisGroup,
+			Consumer:     cfg.RedisConsumer,
+			MaxLen:       cfg.JobQueueCapacity,
+			ClaimIdle:    cfg.RedisClaimIdle,
+			BlockTimeout: time.Second,
+		}, logger)
+	case "jetstream":
+		logger.Info("Using NATS JetStream job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "url", cfg.NATSURL, "stream", cfg.NATSStream, "durable", cfg.NATSDurable)
+		return queue.NewJetStreamQueue(context.Background(), queue.JetStreamConfig{
+			URL:          cfg.NATSURL,
+			Stream:       cfg.NATSStream,
+			Subject:      cfg.NATSSubject,
+			Durable:      cfg.NATSDurable,
+			MaxLen:       cfg.JobQueueCapacity,
+			AckWait:      cfg.NATSAckWait,
+			MaxDeliver:   cfg.NATSMaxRetries + 1,
+			BlockTimeout: time.Second,
+		}, logger)
+	case "kafka":
+		topics, route, err := kafkaRouting(cfg, jobStore)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Using Kafka job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "brokers", cfg.KafkaBrokers, "topics", topics, "group_id", cfg.KafkaGroupID)
+		return queue.NewKafkaQueue(queue.KafkaConfig{
+			Brokers: cfg.KafkaBrokers,
+			Topics:  topics,
+			Route:   route,
+			GroupID: cfg.KafkaGroupID,
+		}), nil
+	case "amqp":
+		logger.Info("Using AMQP job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "exchange", cfg.AMQPExchange, "queue", cfg.AMQPQueue, "dead_letter_queue", cfg.AMQPDeadLetterQueue)
+		return queue.NewAMQPQueue(queue.AMQPConfig{
+			URL:      cfg.AMQPURL,
+			Exchange: cfg.AMQPExchange,
+			// Routing keys are job types; IDs that can't be looked up are
+			// still delivered through a "#" binding
+			Route: func(ctx context.Context, jobID string) string {
+				job, err := jobStore.GetJob(ctx, jobID)
+				if err != nil {
+					return "unknown"
+				}
+				return job.Type
+			},
+			Queue:              cfg.AMQPQueue,
+			BindingKeys:        cfg.AMQPBindingKeys,
+			DeadLetterExchange: cfg.AMQPDeadLetterExchange,
+			DeadLetterQueue:    cfg.AMQPDeadLetterQueue,
+			MaxLen:             cfg.JobQueueCapacity,
+			Prefetch:           cfg.AMQPPrefetch,
+		})
+	case "sqs":
+		if cfg.SQSQueueURL == "" {
+			return nil, errors.New("SQS_QUEUE_URL is required for the sqs queue backend")
+		}
+		logger.Info("Using SQS job queue", "event", "queue_backend", "backend", cfg.QueueBackend, "queue_url", cfg.SQSQueueURL, "dead_letter_queue_url", cfg.SQSDeadLetterQueueURL)
+		return queue.NewSQSQueue(context.Background(), queue.SQSConfig{
+			QueueURL:           cfg.SQSQueueURL,
+			Region:             cfg.SQSRegion,
+			Endpoint:           cfg.SQSEndpoint,
+			VisibilityTimeout:  cfg.SQSVisibilityTimeout,
+			WaitTime:           5 * time.Second,
+			DeadLetterQueueURL: cfg.SQSDeadLetterQueueURL,
+			MaxReceiveCount:    cfg.SQSMaxReceiveCount,
+		})
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.QueueBackend)
+	}
+}
+
+// newElector creates the leader elector selected by LEADER_ELECTION. Electing
+// a leader needs a job store every instance shares: with one per instance, a
+// follower's jobs would never be swept, reaped or scheduled.
+func newElector(cfg *config.Config, jobStore store.JobStore, logger *slog.Logger) (leader.Elector, error) {
+	switch cfg.LeaderElection {
+	case "":
+		return leader.Single{}, nil
+	case "redis":
+		if !jobStore.Shared() {
+			return nil, errors.New("LEADER_ELECTION needs a job store shared between instances; the in-memory store is not")
+		}
+		hostname, _ := os.Hostname()
+		id := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+		logger.Info("Using Redis leader election", "event", "leader_election", "addr", cfg.RedisAddr, "key", cfg.LeaderLockKey, "ttl", cfg.LeaderLockTTL, "id", id)
+		return leader.NewRedisElector(leader.RedisConfig{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			Key:      cfg.LeaderLockKey,
+			TTL:      cfg.LeaderLockTTL,
+			ID:       id,
+		}, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown LEADER_ELECTION %q", cfg.LeaderElection)
+	}
+}
+
+// kafkaRouting returns the topics a Kafka queue consumes and how it picks one
+// for a job: KAFKA_TOPIC_ROUTING=priority gives each priority a topic, and
+// =type gives each of KAFKA_TYPE_TOPICS its own topic. Everything else, and
+// jobs that can't be looked up, go to KAFKA_TOPIC.
+func kafkaRouting(cfg *config.Config, jobStore store.JobStore) ([]string, func(ctx context.Context, jobID string) string, error) {
+	topicFor := func(name string) string {
+		return cfg.KafkaTopic + "." + name
+	}
+
+	topics := []string{cfg.KafkaTopic}
+	var key func(job *domain.Job) string
+	switch cfg.KafkaTopicRouting {
+	case "":
+		return []string{cfg.KafkaTopic}, func(context.Context, string) string { return cfg.KafkaTopic }, nil
+	case "priority":
+		for _, priority := range []domain.Priority{domain.PriorityHigh, domain.PriorityNormal, domain.PriorityLow} {
+			topics = append(topics, topicFor(priority.String()))
+		}
+		key = func(job *domain.Job) string { return job.Priority.String() }
+	case "type":
+		routed := make(map[string]bool)
+		for _, jobType := range cfg.KafkaTypeTopics {
+			topics = append(topics, topicFor(jobType))
+			routed[jobType] = true
+		}
+		key = func(job *domain.Job) string {
+			if !routed[job.Type] {
+				return ""
+			}
+			return job.Type
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown KAFKA_TOPIC_ROUTING %q", cfg.KafkaTopicRouting)
+	}
+
+	route := func(ctx context.Context, jobID string) string {
+		job, err := jobStore.GetJob(ctx, jobID)
+		if err != nil {
+			return cfg.KafkaTopic
+		}
+		if name := key(job); name != "" {
+			return topicFor(name)
+		}
+		return cfg.KafkaTopic
+	}
+
+	return topics, route, nil
+}
+
+// registerJobHandlers wires the handler for every job type the server runs.
+func registerJobHandlers(registry *worker.Registry, cfg *config.Config) {
+	registry.Register("email", handlers.Email)
+	registry.Register("email_send", handlers.EmailSend)
+
+	// Webhook sources submit jobs of their configured type
+	for _, source := range cfg.IngestSources {
+		registry.Register(source.JobType, handlers.Simulated)
+	}
+
+	// Exec and callback types replace any built-in handler for the type
+	for jobType, command := range cfg.ExecCommands {
+		registry.Register(jobType, worker.Exec(command))
+	}
+
+	for jobType, url := range cfg.CallbackURLs {
+		registry.Register(jobType, worker.Callback(url, cfg.CallbackSecret, cfg.CallbackTimeout))
+	}
+}
@@ -0,0 +1,174 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook request body,
+// prefixed with "sha256=", as for job callbacks.
+const SignatureHeader = "X-Signature-256"
+
+// TotalScope is the Queue of alerts on the depth summed over every queue.
+const TotalScope = "*"
+
+// webhookTimeout bounds each webhook request, so a slow receiver can't hold
+// up the next check for long.
+const webhookTimeout = 10 * time.Second
+
+// DepthThresholds are the high-water marks the DepthMonitor watches.
+type DepthThresholds struct {
+	// Per queue depth thresholds by queue name
+	Queues map[string]int
+	// Threshold on the depth summed over every queue, zero for none
+	Total int
+}
+
+// DepthAlert is a queue crossing its high-water mark, in either direction.
+type DepthAlert struct {
+	// Queue name, or TotalScope for the summed depth
+	Queue     string    `json:"queue"`
+	State     string    `json:"state"` // "firing" or "resolved"
+	Depth     int       `json:"depth"`
+	Threshold int       `json:"threshold"`
+	At        time.Time `json:"at"`
+}
+
+// DepthMonitor checks queue depths every interval. A queue reaching its
+// threshold fires an alert, which resolves once the depth falls back below
+// it; each transition is logged, counted in the metric store and, when a
+// webhook URL is set, POSTed to it.
+type DepthMonitor struct {
+	stats       *queue.Stats
+	metricStore store.MetricStore
+	logger      *slog.Logger
+	thresholds  DepthThresholds
+	interval    time.Duration
+
+	webhookURL    string
+	webhookSecret string
+	client        *http.Client
+
+	// Queues whose alert is firing
+	firing map[string]bool
+}
+
+// NewDepthMonitor watches the queues instrumented with stats. webhookURL may
+// be empty; requests to it are signed with webhookSecret when that is set.
+func NewDepthMonitor(stats *queue.Stats, metricStore store.MetricStore, logger *slog.Logger, thresholds DepthThresholds, interval time.Duration, webhookURL, webhookSecret string) *DepthMonitor {
+	return &DepthMonitor{
+		stats:         stats,
+		metricStore:   metricStore,
+		logger:        logger,
+		thresholds:    thresholds,
+		interval:      interval,
+		webhookURL:    webhookURL,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: webhookTimeout},
+		firing:        make(map[string]bool),
+	}
+}
+
+func (m *DepthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("Queue depth monitor shutting down", "event", "depth_monitor_stopped")
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *DepthMonitor) check(ctx context.Context) {
+	total := 0
+	for _, stats := range m.stats.Snapshot() {
+		total += stats.Depth
+		if threshold, ok := m.thresholds.Queues[stats.Name]; ok {
+			m.evaluate(ctx, stats.Name, stats.Depth, threshold)
+		}
+	}
+
+	if m.thresholds.Total > 0 {
+		m.evaluate(ctx, TotalScope, total, m.thresholds.Total)
+	}
+}
+
+// evaluate fires or resolves the alert for name when depth has crossed
+// threshold since the last check.
+func (m *DepthMonitor) evaluate(ctx context.Context, name string, depth, threshold int) {
+	above := depth >= threshold
+	if above == m.firing[name] {
+		return
+	}
+	m.firing[name] = above
+
+	alert := DepthAlert{
+		Queue:     name,
+		State:     "firing",
+		Depth:     depth,
+		Threshold: threshold,
+		At:        time.Now().UTC(),
+	}
+	if above {
+		m.logger.Warn("Queue depth above high-water mark", "event", "queue_high_water", "queue", name, "depth", depth, "threshold", threshold)
+	} else {
+		alert.State = "resolved"
+		m.logger.Info("Queue depth back below high-water mark", "event", "queue_high_water_resolved", "queue", name, "depth", depth, "threshold", threshold)
+	}
+
+	if err := m.metricStore.RecordDepthAlert(ctx, above); err != nil {
+		m.logger.Error("Failed to record queue depth alert", "event", "metric_error", "queue", name, "error", err)
+	}
+
+	if m.webhookURL != "" {
+		if err := m.notify(ctx, alert); err != nil {
+			m.logger.Error("Queue depth alert webhook failed", "event", "depth_alert_webhook_failed", "queue", name, "error", err)
+		}
+	}
+}
+
+func (m *DepthMonitor) notify(ctx context.Context, alert DepthAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if m.webhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(m.webhookSecret))
+		mac.Write(body)
+		request.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package alert
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// Sources of a slow job threshold.
+const (
+	ThresholdConfigured = "configured"
+	ThresholdP99        = "p99"
+)
+
+// SlowThresholds decide how long an attempt may process before it is slow.
+// A job type's entry in ByType wins; without one, a type with at least
+// MinSamples observed attempts uses the p99 of its processing time, and any
+// other falls back to Default, zero for none.
+type SlowThresholds struct {
+	Default    time.Duration
+	ByType     map[string]time.Duration
+	MinSamples int
+}
+
+// SlowJob is an attempt that has been processing for longer than its type's
+// threshold.
+type SlowJob struct {
+	JobID     string
+	Type      string
+	Queue     string
+	Attempt   int
+	ClaimedBy string
+	StartedAt time.Time
+	Threshold time.Duration
+	// ThresholdConfigured or ThresholdP99
+	Source  string
+	Payload json.RawMessage
+}
+
+// SlowMonitor checks processing jobs every interval. An attempt running past
+// its threshold is logged and counted in the metric store once, and listed by
+// Slow until it finishes.
+type SlowMonitor struct {
+	jobStore    store.JobStore
+	metricStore store.MetricStore
+	logger      *slog.Logger
+	thresholds  SlowThresholds
+	interval    time.Duration
+
+	mu sync.Mutex
+	// Slow attempts as of the last check, by job ID
+	slow map[string]SlowJob
+}
+
+func NewSlowMonitor(jobStore store.JobStore, metricStore store.MetricStore, logger *slog.Logger, thresholds SlowThresholds, interval time.Duration) *SlowMonitor {
+	return &SlowMonitor{
+		jobStore:    jobStore,
+		metricStore: metricStore,
+		logger:      logger,
+		thresholds:  thresholds,
+		interval:    interval,
+		slow:        make(map[string]SlowJob),
+	}
+}
+
+func (m *SlowMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("Slow job monitor shutting down", "event", "slow_monitor_stopped")
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+// Slow returns the slow attempts found by the last check, longest running
+// first.
+func (m *SlowMonitor) Slow() []SlowJob {
+	m.mu.Lock()
+	jobs := make([]SlowJob, 0, len(m.slow))
+	for _, job := range m.slow {
+		jobs = append(jobs, job)
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(jobs, func(a, b SlowJob) int {
+		return cmp.Or(a.StartedAt.Compare(b.StartedAt), cmp.Compare(a.JobID, b.JobID))
+	})
+	return jobs
+}
+
+func (m *SlowMonitor) check(ctx context.Context) {
+	jobs, err := m.jobStore.GetProcessingJobs(ctx)
+	if err != nil {
+		m.logger.Error("Failed to get processing jobs", "event", "slow_check_error", "error", err)
+		return
+	}
+
+	metrics, err := m.metricStore.GetMetrics(ctx)
+	if err != nil {
+		m.logger.Error("Failed to get metrics", "event", "slow_check_error", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	previous := m.slow
+	m.mu.Unlock()
+
+	now := time.Now()
+	slow := make(map[string]SlowJob)
+	for _, job := range jobs {
+		if job.StartedAt == nil {
+			continue
+		}
+		threshold, source := m.threshold(job.Type, metrics)
+		elapsed := now.Sub(*job.StartedAt)
+		if threshold <= 0 || elapsed <= threshold {
+			continue
+		}
+
+		slow[job.ID] = SlowJob{
+			JobID:     job.ID,
+			Type:      job.Type,
+			Queue:     job.Queue,
+			Attempt:   job.Attempts,
+			ClaimedBy: job.ClaimedBy,
+			StartedAt: *job.StartedAt,
+			Threshold: threshold,
+			Source:    source,
+			Payload:   job.Payload,
+		}
+
+		// Flag each attempt once, however many checks it stays slow for
+		if seen, ok := previous[job.ID]; ok && seen.Attempt == job.Attempts {
+			continue
+		}
+		m.logger.Warn("Job processing slower than threshold", "event", "job_slow", "job_id", job.ID, "job_type", job.Type, "attempt", job.Attempts, "worker", job.ClaimedBy, "elapsed", elapsed.Round(time.Millisecond).String(), "threshold", threshold.String(), "threshold_source", source, "payload_bytes", len(job.Payload))
+		if err := m.metricStore.IncrementJobsSlow(ctx); err != nil {
+			m.logger.Error("Failed to increment slow jobs metric", "event", "metric_error", "job_id", job.ID, "error", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.slow = slow
+	m.mu.Unlock()
+}
+
+// threshold returns how long an attempt of jobType may process, and where
+// that limit comes from.
+func (m *SlowMonitor) threshold(jobType string, metrics *domain.Metric) (time.Duration, string) {
+	if threshold, ok := m.thresholds.ByType[jobType]; ok {
+		return threshold, ThresholdConfigured
+	}
+
+	if histogram, ok := metrics.JobProcessing[jobType]; ok && histogram.Count >= m.thresholds.MinSamples {
+		return time.Duration(histogram.Quantile(0.99) * float64(time.Second)), ThresholdP99
+	}
+
+	return m.thresholds.Default, ThresholdConfigured
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How the actor of an audit entry was identified.
+const (
+	ActorSourceCertificate = "certificate"
+	ActorSourceHeader      = "header"
+	ActorSourceAPIKey      = "api_key"
+	ActorSourceAnonymous   = "anonymous"
+)
+
+// AuditEntry records one administrative or mutating API call: who made it,
+// what it asked for and how it went.
+type AuditEntry struct {
+	ID string
+	At time.Time
+	// Actor is the client certificate subject, the identity set by a trusted
+	// proxy, or a fingerprint of the API key; ActorSource says which
+	Actor       string
+	ActorSource string
+	RemoteAddr  string
+	// Action names what was done, such as "job.cancel" or "workers.resize"
+	Action string
+	// Target is the job, type, schedule or other resource acted on, if any
+	Target string
+	Method string
+	Path   string
+	Query  string
+	// Status is the HTTP status the call was answered with
+	Status int
+	// Request is the JSON body of actions whose body is recorded
+	Request json.RawMessage
+}
+
+func NewAuditEntry(action string) *AuditEntry {
+	return &AuditEntry{
+		ID:     uuid.New().String(),
+		At:     time.Now().UTC(),
+		Action: action,
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffPolicy decides how the delay between retries grows.
+type BackoffPolicy string
+
+const (
+	// BackoffExponential doubles the delay after every attempt.
+	BackoffExponential BackoffPolicy = "exponential"
+	// BackoffFixed waits the base delay between every attempt.
+	BackoffFixed BackoffPolicy = "fixed"
+)
+
+const (
+	// RetryBaseDelay is the default delay before the first retry.
+	RetryBaseDelay = 1 * time.Second
+	// RetryMaxDelay caps the backoff so long-failing jobs are still retried.
+	RetryMaxDelay = 5 * time.Minute
+)
+
+// NextRetryDelay returns how long to wait before retrying the job's last
+// attempt under its backoff policy.
+func (j *Job) NextRetryDelay() time.Duration {
+	if j.BackoffPolicy == BackoffFixed {
+		return j.BackoffBaseDelay
+	}
+	return RetryDelay(j.Attempts, j.BackoffBaseDelay)
+}
+
+// RetryDelay returns how long to wait before retrying after the given
+// attempt: exponential backoff from base, capped at RetryMaxDelay, with
+// "equal jitter" (half fixed, half random) so failed jobs don't retry in
+// lockstep.
+func RetryDelay(attempt int, base time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, RetryMaxDelay)
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
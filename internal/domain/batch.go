@@ -0,0 +1,34 @@
+package domain
+
+import "encoding/json"
+
+// BatchProgress counts how many of a batch parent's children have finished.
+// Failed covers children that ended dead or cancelled.
+type BatchProgress struct {
+	Total     int
+	Completed int
+	Failed    int
+}
+
+// Done reports whether every child has reached a final state.
+func (b *BatchProgress) Done() bool {
+	return b.Completed+b.Failed >= b.Total
+}
+
+// NewChildJob creates a batch child that inherits the parent's queue, retry,
+// priority, timeout, schedule, expiry and concurrency settings.
+func NewChildJob(parent *Job, jobType string, payload json.RawMessage) *Job {
+	child := NewJob(jobType, payload)
+	child.ParentID = parent.ID
+	child.Queue = parent.Queue
+	child.MaxRetries = parent.MaxRetries
+	child.BackoffPolicy = parent.BackoffPolicy
+	child.BackoffBaseDelay = parent.BackoffBaseDelay
+	child.Timeout = parent.Timeout
+	child.Priority = parent.Priority
+	child.RunAt = parent.RunAt
+	child.ExpiresAt = parent.ExpiresAt
+	child.ConcurrencyKey = parent.ConcurrencyKey
+	child.ConcurrencyLimit = parent.ConcurrencyLimit
+	return child
+}
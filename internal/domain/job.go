@@ -14,8 +14,46 @@ const (
 	StatusProcessing JobStatus = "processing"
 	StatusCompleted  JobStatus = "completed"
 	StatusFailed     JobStatus = "failed"
+	StatusCancelled  JobStatus = "cancelled"
+	// StatusDead marks a job that exhausted its retries (the dead-letter queue)
+	StatusDead JobStatus = "dead"
+	// StatusBlocked marks a job waiting for its dependencies to complete
+	StatusBlocked JobStatus = "blocked"
+	// StatusExpired marks a job that was still waiting when its ExpiresAt
+	// passed
+	StatusExpired JobStatus = "expired"
+	// StatusQuarantined marks a job whose attempts kept crashing or timing
+	// out its worker; it is not retried until an operator releases it
+	StatusQuarantined JobStatus = "quarantined"
 )
 
+// DependencyPolicy decides what happens to a blocked job when one of its
+// dependencies fails for good (dead or cancelled).
+type DependencyPolicy string
+
+const (
+	// DependencyFail moves the blocked job to dead.
+	DependencyFail DependencyPolicy = "fail"
+	// DependencyHold keeps the job blocked in case the dependency is requeued.
+	DependencyHold DependencyPolicy = "hold"
+)
+
+// Error classes recorded on failed jobs describe why the last attempt failed.
+const (
+	ErrorClassHandler    = "handler_error"
+	ErrorClassTimeout    = "timeout"
+	ErrorClassNoHandler  = "no_handler"
+	ErrorClassPanic      = "panic"
+	ErrorClassPermanent  = "permanent"
+	ErrorClassRetryable  = "retryable"
+	ErrorClassDependency = "dependency_failed"
+	ErrorClassStuck      = "stuck"
+	ErrorClassExpired    = "expired"
+)
+
+// DefaultMaxRetries applies when a job is submitted without max_retries.
+const DefaultMaxRetries = 3
+
 type Job struct {
 	ID         string
 	Type       string
@@ -23,24 +61,143 @@ type Job struct {
 	Payload    json.RawMessage
 	MaxRetries int
 	Attempts   int
+	Priority   Priority
+	// At most ConcurrencyLimit jobs sharing a non-empty ConcurrencyKey
+	// process at the same time
+	ConcurrencyKey   string
+	ConcurrencyLimit int
+	// UniqueKey, if set, allows only one pending or processing job per key
+	UniqueKey string
+	// DependsOn lists jobs that must complete before this one becomes pending
+	DependsOn        []string
+	DependencyPolicy DependencyPolicy
+	WorkflowID       string // Set on jobs created as a workflow step
+	// A batch parent has Batch set and stays blocked until all its children
+	// finish; children point back with ParentID
+	ParentID string
+	Batch    *BatchProgress
+	// Retry backoff; see NextRetryDelay
+	BackoffPolicy    BackoffPolicy
+	BackoffBaseDelay time.Duration
+	// Timeout overrides the job type's execution timeout when non-zero
+	Timeout time.Duration
+	// Template is the name of the template the job was submitted from
+	Template string
+	// Queue is the named queue the job was submitted to; when empty the job
+	// is routed by type
+	Queue      string
 	LastError  *string
-	CreatedAt  time.Time
+	ErrorClass string // Set alongside LastError when an attempt fails
+	// NextRetryAt is when a failed job becomes eligible for retry; nil once
+	// it has no retries left
+	NextRetryAt *time.Time
+	CreatedAt   time.Time
+	RunAt       *time.Time // Earliest time the job may run; nil means immediately
+	// ExpiresAt is when a job that is still waiting to run is given up on;
+	// nil means never
+	ExpiresAt *time.Time
+	StartedAt *time.Time
+	// LeaseExpiresAt is set while processing; the worker renews it with
+	// heartbeats, and a job whose lease lapses is returned to pending
+	LeaseExpiresAt *time.Time
+	// ClaimedBy identifies the worker (host, PID and worker ID) that claimed
+	// the latest attempt, and ClaimedAt when
+	ClaimedBy string
+	ClaimedAt *time.Time
+	// ExecutionToken is unique to the latest claim. Handlers can pass it to
+	// downstream systems as an idempotency key, and the store only records
+	// the outcome of the claim holding it, so a reaped attempt that finishes
+	// late can't complete the job over the one that replaced it
+	ExecutionToken string
+	// Deliveries counts every claim, including ones that ended without an
+	// outcome and so don't count toward MaxRetries
+	Deliveries int
+	// Crashes counts the attempts in a row that panicked, timed out or lost
+	// their worker (reaped, or interrupted by a server crash). Any other
+	// outcome resets it
+	Crashes int
+	// Redelivered is set once a claim ends without its outcome recorded (its
+	// lease lapsed, it was reaped or released at shutdown, or the server
+	// crashed), so the next handler knows the job may have run before. It is
+	// cleared when a failure is recorded
+	Redelivered bool
+	UpdatedAt   time.Time
+	Version     int // Incremented on every state change
+	// Progress is reported by the handler while the job is processing
+	ProgressPercent int
+	ProgressMessage string
+	// Result is the output a handler produced on completion, if any
+	Result json.RawMessage
+	// ReplayedFrom is the ID of the job this one was replayed from
+	ReplayedFrom string
+	// W3C trace context of the request that submitted the job, so its
+	// processing continues the same trace
+	TraceParent string
+	TraceState  string
 }
 
 func NewJob(jobType string, jobPayload json.RawMessage) *Job {
 	const attempts = 0
-	const maxRetries = 3
+	const version = 1
+
+	now := time.Now().UTC()
 
 	job := &Job{
-		ID:         uuid.New().String(),
-		Type:       jobType,
-		Status:     StatusPending,
-		Payload:    jobPayload,
-		MaxRetries: maxRetries,
-		Attempts:   attempts,
-		LastError:  nil,
-		CreatedAt:  time.Now().UTC(),
+		ID:               uuid.New().String(),
+		Type:             jobType,
+		Status:           StatusPending,
+		Payload:          jobPayload,
+		MaxRetries:       DefaultMaxRetries,
+		Attempts:         attempts,
+		Priority:         PriorityNormal,
+		BackoffPolicy:    BackoffExponential,
+		BackoffBaseDelay: RetryBaseDelay,
+		LastError:        nil,
+		CreatedAt:        now,
+		StartedAt:        nil,
+		UpdatedAt:        now,
+		Version:          version,
 	}
 
 	return job
 }
+
+// NewReplayJob creates a fresh copy of original to run again: same type,
+// payload, queue, retry, priority, timeout and concurrency settings, but a
+// new ID and no attempts. Dependencies, unique key, schedule and expiry are not carried
+// over since they applied to the original submission.
+func NewReplayJob(original *Job) *Job {
+	job := NewJob(original.Type, original.Payload)
+	job.ReplayedFrom = original.ID
+	job.MaxRetries = original.MaxRetries
+	job.BackoffPolicy = original.BackoffPolicy
+	job.BackoffBaseDelay = original.BackoffBaseDelay
+	job.Priority = original.Priority
+	job.Timeout = original.Timeout
+	job.Template = original.Template
+	job.Queue = original.Queue
+	job.ConcurrencyKey = original.ConcurrencyKey
+	job.ConcurrencyLimit = original.ConcurrencyLimit
+	return job
+}
+
+// Terminal reports whether a job in status s will not change again on its
+// own.
+func (s JobStatus) Terminal() bool {
+	switch s {
+	case StatusCompleted, StatusDead, StatusCancelled, StatusExpired, StatusQuarantined:
+		return true
+	default:
+		return false
+	}
+}
+
+// Due reports whether the job's scheduled run time has arrived.
+func (j *Job) Due(now time.Time) bool {
+	return j.RunAt == nil || !j.RunAt.After(now)
+}
+
+// Expired reports whether the job's expiry time has passed.
+func (j *Job) Expired(now time.Time) bool {
+	return j.ExpiresAt != nil && !j.ExpiresAt.After(now)
+}
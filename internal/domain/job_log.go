@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// LogEntry is a single log line captured while a job attempt was running.
+type LogEntry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Attrs   map[string]string
+}
+
+// AttemptLog holds the captured output of one processing attempt.
+type AttemptLog struct {
+	Attempt   int
+	Entries   []LogEntry
+	Truncated bool
+}
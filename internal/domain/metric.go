@@ -1,19 +1,119 @@
 package domain
 
+import (
+	"slices"
+	"time"
+)
+
 type Metric struct {
 	TotalJobsCreated int
 	JobsCompleted    int
-	JobsFailed       int
+	JobsFailed       int // Failed attempts, including ones retried since
 	JobsRetried      int
-	JobsInProgress   int
+	JobsInProgress   int // Jobs processing now
+	JobsCancelled    int
+	JobsExpired      int // Jobs that expired before they started
+	JobsPanicked     int
+	JobsDead         int // Jobs currently in the dead-letter queue
+	JobsQuarantined  int // Jobs currently quarantined
+	JobsReaped       int // Jobs the sweeper took back from processing
+	FailuresByClass  map[string]int
+	WorkerCount      int
+	// Autoscaler resizes of the worker pool
+	WorkerScaleUps   int
+	WorkerScaleDowns int
+	// Sweeper runs and what they did; SweeperDuration is the total time
+	// spent sweeping
+	SweeperRuns         int
+	SweeperJobsRetried  int
+	SweeperJobsEnqueued int
+	SweeperSkippedFull  int
+	SweeperDuration     time.Duration
+	SweeperLastDuration time.Duration
+	// Retries the retry budget left for a later run
+	SweeperRetriesDeferred int
+	// Queue depth high-water mark alerts fired since startup, and how many
+	// are firing now
+	QueueDepthAlerts       int
+	QueueDepthAlertsFiring int
+	// Jobs run by workers of another queue than their own
+	JobsStolen int
+	// Attempts flagged for processing longer than their type's threshold
+	JobsSlow int
+	// How long handlers ran, by job type
+	JobDurations map[string]*Histogram
+	// How long jobs waited from becoming due to their first start, and how
+	// long each attempt took from start to outcome, by job type
+	JobWaits      map[string]*Histogram
+	JobProcessing map[string]*Histogram
+}
+
+// DurationBuckets are the default upper bounds, in seconds, of the job
+// duration and latency histogram buckets.
+var DurationBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// Histogram counts observations into cumulative buckets, one per bound in
+// Bounds.
+type Histogram struct {
+	Bounds  []float64
+	Buckets []int
+	Count   int
+	Sum     float64
+}
+
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		Bounds:  bounds,
+		Buckets: make([]int, len(bounds)),
+	}
+}
+
+func (h *Histogram) Observe(value float64) {
+	for i, bound := range h.Bounds {
+		if value <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += value
+}
+
+func (h *Histogram) Clone() *Histogram {
+	clone := *h
+	clone.Buckets = slices.Clone(h.Buckets)
+	return &clone
 }
 
-func NewMetric() *Metric {
-	return &Metric{
-		TotalJobsCreated: 0,
-		JobsCompleted:    0,
-		JobsFailed:       0,
-		JobsRetried:      0,
-		JobsInProgress:   0,
+// Merge adds the observations of other, which must have the same bounds.
+func (h *Histogram) Merge(other *Histogram) {
+	for i := range h.Buckets {
+		h.Buckets[i] += other.Buckets[i]
 	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
+
+// Quantile estimates the q-quantile (0 to 1) of the observations by linear
+// interpolation within the bucket it falls in, as Prometheus'
+// histogram_quantile does. Observations above the highest bound are reported
+// as that bound.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.Count)
+	lower, below := 0.0, 0
+	for i, bound := range h.Bounds {
+		if float64(h.Buckets[i]) >= rank {
+			inBucket := h.Buckets[i] - below
+			if inBucket == 0 {
+				return bound
+			}
+			return lower + (bound-lower)*(rank-float64(below))/float64(inBucket)
+		}
+		lower, below = bound, h.Buckets[i]
+	}
+
+	return h.Bounds[len(h.Bounds)-1]
 }
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxKind says what an outbox message asks for.
+type OutboxKind string
+
+const (
+	// OutboxWebhook POSTs the payload to the URL in Target.
+	OutboxWebhook OutboxKind = "webhook"
+	// OutboxEvent publishes the payload on the event bus as an event named
+	// Target.
+	OutboxEvent OutboxKind = "event"
+)
+
+// OutboxMessage is a side effect a handler asked for while processing a job.
+// It is stored in the same update that completes the job and carried out
+// afterwards by the outbox relay, so it happens once the job has completed
+// and never for an attempt whose outcome was not recorded.
+type OutboxMessage struct {
+	ID      string
+	JobID   string
+	JobType string
+	Kind    OutboxKind
+	// Target is the URL of a webhook or the name of an event
+	Target        string
+	Payload       json.RawMessage
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+func NewOutboxMessage(job *Job, kind OutboxKind, target string, payload json.RawMessage) *OutboxMessage {
+	now := time.Now().UTC()
+
+	return &OutboxMessage{
+		ID:            uuid.New().String(),
+		JobID:         job.ID,
+		JobType:       job.Type,
+		Kind:          kind,
+		Target:        target,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
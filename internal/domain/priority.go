@@ -0,0 +1,37 @@
+package domain
+
+import "fmt"
+
+// Priority orders pending jobs for dispatch; higher runs first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// ParsePriority accepts "high", "normal" or "low"; empty means normal.
+func ParsePriority(value string) (Priority, error) {
+	switch value {
+	case "high":
+		return PriorityHigh, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	default:
+		return PriorityNormal, fmt.Errorf("priority must be \"high\", \"normal\" or \"low\", got %q", value)
+	}
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
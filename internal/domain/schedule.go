@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MissedRunPolicy decides what a schedule does with ticks that passed while
+// the scheduler was not running (e.g. during a restart).
+type MissedRunPolicy string
+
+const (
+	// MissedRunSkip runs once for the missed ticks and carries on.
+	MissedRunSkip MissedRunPolicy = "skip"
+	// MissedRunCatchUp runs once for every missed tick.
+	MissedRunCatchUp MissedRunPolicy = "catch_up"
+)
+
+// Schedule is a recurring job definition: a job of JobType is created from
+// PayloadTemplate every time CronExpr fires in Timezone.
+type Schedule struct {
+	ID              string
+	CronExpr        string
+	Timezone        string
+	JobType         string
+	PayloadTemplate json.RawMessage
+	MissedRunPolicy MissedRunPolicy
+	NextRunAt       time.Time
+	LastRunAt       *time.Time
+	CreatedAt       time.Time
+}
+
+func NewSchedule(cronExpr, timezone, jobType string, payloadTemplate json.RawMessage, missedRunPolicy MissedRunPolicy, nextRunAt time.Time) *Schedule {
+	return &Schedule{
+		ID:              uuid.New().String(),
+		CronExpr:        cronExpr,
+		Timezone:        timezone,
+		JobType:         jobType,
+		PayloadTemplate: payloadTemplate,
+		MissedRunPolicy: missedRunPolicy,
+		NextRunAt:       nextRunAt,
+		LastRunAt:       nil,
+		CreatedAt:       time.Now().UTC(),
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+)
+
+// Template is a named set of job defaults, so producers can submit a job by
+// template name and send only what differs.
+type Template struct {
+	Name    string
+	JobType string
+	// Payload holds default payload fields; see MergePayload
+	Payload          json.RawMessage
+	MaxRetries       int
+	BackoffPolicy    BackoffPolicy
+	BackoffBaseDelay time.Duration
+	Priority         Priority
+	Timeout          time.Duration // Zero leaves the job type's timeout
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func NewTemplate(name, jobType string, payload json.RawMessage) *Template {
+	now := time.Now().UTC()
+
+	return &Template{
+		Name:             name,
+		JobType:          jobType,
+		Payload:          payload,
+		MaxRetries:       DefaultMaxRetries,
+		BackoffPolicy:    BackoffExponential,
+		BackoffBaseDelay: RetryBaseDelay,
+		Priority:         PriorityNormal,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// Apply sets the template's retry, priority and timeout settings on job.
+func (t *Template) Apply(job *Job) {
+	job.Template = t.Name
+	job.MaxRetries = t.MaxRetries
+	job.BackoffPolicy = t.BackoffPolicy
+	job.BackoffBaseDelay = t.BackoffBaseDelay
+	job.Priority = t.Priority
+	job.Timeout = t.Timeout
+}
+
+// MergePayload returns the payload for a job submitted with payload. When
+// both the template's payload and payload are JSON objects, their top-level
+// fields are merged with payload's taking precedence. Otherwise a non-empty
+// payload replaces the template's.
+func (t *Template) MergePayload(payload json.RawMessage) json.RawMessage {
+	if len(payload) == 0 {
+		return t.Payload
+	}
+
+	var defaults, overrides map[string]json.RawMessage
+	if json.Unmarshal(t.Payload, &defaults) != nil || defaults == nil {
+		return payload
+	}
+	if json.Unmarshal(payload, &overrides) != nil || overrides == nil {
+		return payload
+	}
+
+	maps.Copy(defaults, overrides)
+	merged, err := json.Marshal(defaults)
+	if err != nil {
+		return payload
+	}
+
+	return merged
+}
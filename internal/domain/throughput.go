@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// ThroughputPoint counts what happened to jobs in one minute.
+type ThroughputPoint struct {
+	// Start of the minute, in UTC
+	Minute    time.Time
+	Created   int
+	Completed int
+	// Failed attempts, including ones retried since
+	Failed int
+}
+
+// ThroughputSeries keeps per-minute job counts for a fixed number of minutes
+// in a ring buffer. Each slot holds the minute it was last written for, so
+// minutes with nothing to count read as zero without the ring having to be
+// advanced.
+type ThroughputSeries struct {
+	points []ThroughputPoint
+}
+
+// NewThroughputSeries keeps the counts of the last minutes minutes, at least
+// one.
+func NewThroughputSeries(minutes int) *ThroughputSeries {
+	return &ThroughputSeries{points: make([]ThroughputPoint, max(minutes, 1))}
+}
+
+// Minutes is how many minutes the series keeps.
+func (s *ThroughputSeries) Minutes() int {
+	return len(s.points)
+}
+
+func (s *ThroughputSeries) AddCreated(at time.Time) {
+	s.at(at).Created++
+}
+
+func (s *ThroughputSeries) AddCompleted(at time.Time) {
+	s.at(at).Completed++
+}
+
+func (s *ThroughputSeries) AddFailed(at time.Time) {
+	s.at(at).Failed++
+}
+
+// at returns the slot for the minute of t, emptied if it last held an older
+// minute.
+func (s *ThroughputSeries) at(t time.Time) *ThroughputPoint {
+	minute := t.UTC().Truncate(time.Minute)
+	point := &s.points[s.index(minute)]
+	if !point.Minute.Equal(minute) {
+		*point = ThroughputPoint{Minute: minute}
+	}
+	return point
+}
+
+// Points returns the counts of the last minutes minutes up to and including
+// the one now falls in, oldest first, capped at what the series keeps.
+func (s *ThroughputSeries) Points(now time.Time, minutes int) []ThroughputPoint {
+	minutes = min(max(minutes, 1), len(s.points))
+	end := now.UTC().Truncate(time.Minute)
+
+	points := make([]ThroughputPoint, 0, minutes)
+	for i := minutes - 1; i >= 0; i-- {
+		minute := end.Add(-time.Duration(i) * time.Minute)
+		point := s.points[s.index(minute)]
+		if !point.Minute.Equal(minute) {
+			point = ThroughputPoint{Minute: minute}
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
+
+func (s *ThroughputSeries) index(minute time.Time) int {
+	return int(minute.Unix()/60) % len(s.points)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription asks for the events published on the event bus to be
+// POSTed to URL as they happen.
+type WebhookSubscription struct {
+	ID  string
+	URL string
+	// Secret signs each delivery; empty for unsigned deliveries
+	Secret string
+	// Event types delivered; empty for all
+	EventTypes []string
+	// Job types whose events are delivered; empty for all. When set, events
+	// that are not about a job are not delivered
+	JobTypes  []string
+	CreatedAt time.Time
+}
+
+func NewWebhookSubscription(url, secret string, eventTypes, jobTypes []string) *WebhookSubscription {
+	return &WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		JobTypes:   jobTypes,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// Matches reports whether an event of eventType about a job of jobType (empty
+// for events not about a job) should be delivered to the subscription.
+func (s *WebhookSubscription) Matches(eventType, jobType string) bool {
+	if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, eventType) {
+		return false
+	}
+	if len(s.JobTypes) > 0 && !slices.Contains(s.JobTypes, jobType) {
+		return false
+	}
+	return true
+}
+
+// WebhookDelivery is an event waiting to be POSTed to a subscription. The body
+// and its signature are fixed when the delivery is created, so it can be
+// retried after a restart exactly as first sent.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	URL            string
+	EventType      string
+	Body           json.RawMessage
+	// Signature header value; empty for unsigned deliveries
+	Signature     string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+func NewWebhookDelivery(subscription *WebhookSubscription, eventType string, body json.RawMessage, signature string) *WebhookDelivery {
+	now := time.Now().UTC()
+
+	return &WebhookDelivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscription.ID,
+		URL:            subscription.URL,
+		EventType:      eventType,
+		Body:           body,
+		Signature:      signature,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkflowStatus string
+
+const (
+	WorkflowPending   WorkflowStatus = "pending"
+	WorkflowRunning   WorkflowStatus = "running"
+	WorkflowCompleted WorkflowStatus = "completed"
+	WorkflowFailed    WorkflowStatus = "failed"
+)
+
+// Workflow is a named DAG of steps submitted together. Each step runs as a
+// job whose dependencies are the jobs of the steps it depends on.
+type Workflow struct {
+	ID        string
+	Name      string
+	Steps     []WorkflowStep
+	CreatedAt time.Time
+}
+
+// WorkflowStep links a step name to the job that runs it.
+type WorkflowStep struct {
+	Name      string
+	JobID     string
+	DependsOn []string // Step names
+}
+
+func NewWorkflow(name string, steps []WorkflowStep) *Workflow {
+	return &Workflow{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Steps:     steps,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// AggregateWorkflowStatus derives a workflow's status from its step jobs: it
+// has failed once any step is dead, cancelled or expired, completed once all steps
+// have, and is running once any step has started.
+func AggregateWorkflowStatus(statuses []JobStatus) WorkflowStatus {
+	completed := 0
+	started := false
+
+	for _, status := range statuses {
+		switch status {
+		case StatusDead, StatusCancelled, StatusExpired:
+			return WorkflowFailed
+		case StatusCompleted:
+			completed++
+			started = true
+		case StatusProcessing, StatusFailed, StatusQuarantined:
+			started = true
+		}
+	}
+
+	switch {
+	case completed == len(statuses):
+		return WorkflowCompleted
+	case started:
+		return WorkflowRunning
+	default:
+		return WorkflowPending
+	}
+}
@@ -0,0 +1,184 @@
+package drain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+type State string
+
+const (
+	StateIdle     State = "idle"
+	StateDraining State = "draining"
+	StateDrained  State = "drained"
+	StateTimedOut State = "timed_out"
+)
+
+var ErrAlreadyDraining = errors.New("drain already in progress")
+
+const pollInterval = 500 * time.Millisecond
+
+// Status is a snapshot of drain progress.
+type Status struct {
+	State          State
+	StartedAt      time.Time
+	Deadline       time.Time
+	FinishedAt     time.Time
+	PendingJobs    int
+	ProcessingJobs int
+}
+
+// Controller puts the server into maintenance mode: new submissions are
+// rejected while workers finish everything already accepted. It is
+// independent of process shutdown, so the server keeps running afterwards.
+type Controller struct {
+	jobStore store.JobStore
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	status Status
+	cancel context.CancelFunc
+}
+
+func NewController(jobStore store.JobStore, logger *slog.Logger) *Controller {
+	return &Controller{
+		jobStore: jobStore,
+		logger:   logger,
+		status:   Status{State: StateIdle},
+	}
+}
+
+// Accepting reports whether new jobs may be submitted.
+func (c *Controller) Accepting() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.status.State == StateIdle
+}
+
+// Start begins draining and returns immediately; progress is tracked in the
+// background until no jobs are pending or processing, or timeout elapses.
+func (c *Controller) Start(timeout time.Duration) (Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status.State == StateDraining {
+		return c.status, ErrAlreadyDraining
+	}
+
+	now := time.Now().UTC()
+	c.status = Status{
+		State:     StateDraining,
+		StartedAt: now,
+		Deadline:  now.Add(timeout),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	c.cancel = cancel
+
+	go c.watch(ctx)
+
+	c.logger.Info("Drain started", "event", "drain_started", "timeout", timeout.String())
+
+	return c.status, nil
+}
+
+// Stop ends a drain (finished or not) and re-opens job submission.
+func (c *Controller) Stop() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+
+	if c.status.State != StateIdle {
+		c.logger.Info("Drain stopped, accepting jobs again", "event", "drain_stopped")
+	}
+	c.status = Status{State: StateIdle}
+
+	return c.status
+}
+
+func (c *Controller) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.status
+}
+
+func (c *Controller) watch(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := c.refresh(ctx)
+		if err != nil && ctx.Err() == nil {
+			c.logger.Error("Drain error counting outstanding jobs", "event", "drain_error", "error", err)
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			c.finish(ctx, StateTimedOut)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh updates the outstanding job counts and reports whether the drain
+// has completed.
+func (c *Controller) refresh(ctx context.Context) (bool, error) {
+	pending, err := c.jobStore.GetPendingJobs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	processing, err := c.jobStore.GetProcessingJobs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.status.PendingJobs = len(pending)
+	c.status.ProcessingJobs = len(processing)
+	c.mu.Unlock()
+
+	if len(pending) == 0 && len(processing) == 0 {
+		c.finish(ctx, StateDrained)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (c *Controller) finish(ctx context.Context, state State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Stop() may have raced with us and already reset the drain
+	if c.status.State != StateDraining || ctx.Err() == context.Canceled {
+		return
+	}
+
+	c.status.State = state
+	c.status.FinishedAt = time.Now().UTC()
+
+	// Release the timeout context; intake stays closed until Stop()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+
+	c.logger.Info("Drain finished", "event", "drain_finished", "state", string(state),
+		"pending_jobs", c.status.PendingJobs, "processing_jobs", c.status.ProcessingJobs)
+}
@@ -0,0 +1,213 @@
+// Package events carries what happens to jobs and workers from the code that
+// does it to the parts of the server that react to it. Workers, handlers, the
+// sweeper and recovery publish events on a Bus; subscribers keep the metrics,
+// write the logs, stream events to clients and deliver them to webhooks.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// Type names what happened.
+type Type string
+
+const (
+	// A job was accepted: stored and, when due, enqueued
+	JobCreated Type = "job.created"
+	// A worker claimed the job and started an attempt
+	JobStarted   Type = "job.started"
+	JobCompleted Type = "job.completed"
+	// An attempt failed; the job may still be retried
+	JobFailed Type = "job.failed"
+	// A failed job was returned to pending for another attempt
+	JobRetried Type = "job.retried"
+	// The handler panicked, after the JobFailed event for the attempt
+	JobPanicked  Type = "job.panicked"
+	JobCancelled Type = "job.cancelled"
+	JobExpired   Type = "job.expired"
+	// The job exhausted its retries or failed permanently and moved to the
+	// dead-letter queue
+	JobDead Type = "job.dead"
+	// A dead job was requeued from the dead-letter queue
+	JobResurrected Type = "job.resurrected"
+	// A processing job was returned to pending without using up an attempt:
+	// on shutdown, lease expiry, or a stuck job requeue
+	JobReleased Type = "job.released"
+	// The sweeper took a stuck job back from processing
+	JobReaped Type = "job.reaped"
+	// A processing job was found after a restart and returned to pending
+	JobRecovered Type = "job.recovered"
+	// A worker took the job from another queue than its own
+	JobStolen Type = "job.stolen"
+	// The job's attempts kept crashing or timing out its worker, so it was
+	// set aside instead of retried; Reason says how the last one ended
+	JobQuarantined Type = "job.quarantined"
+	// A quarantined job was released back to pending
+	JobUnquarantined Type = "job.unquarantined"
+	// The outbox relay published an event a completed job's handler wrote to
+	// its outbox; Name is the handler's name for it and Data its payload
+	JobEmitted Type = "job.emitted"
+
+	WorkerStarted Type = "worker.started"
+	WorkerStopped Type = "worker.stopped"
+
+	// The sweeper finished a run; Data is the store.SweepResult
+	SweepCompleted Type = "sweep.completed"
+	// Startup recovery finished
+	RecoveryCompleted Type = "recovery.completed"
+
+	// A job type used up the error budget of its success-rate objective, or
+	// got some back as failures aged out of the window; JobType names it and
+	// Data carries the target, success rate and budget left
+	SLOBudgetExhausted Type = "slo.budget_exhausted"
+	SLOBudgetRestored  Type = "slo.budget_restored"
+)
+
+// Event is something that happened to a job or a worker. Fields that don't
+// apply to its type are left empty.
+type Event struct {
+	// ID orders the events of a bus, so clients can resume after the last
+	// one they saw
+	ID      int64     `json:"id"`
+	Type    Type      `json:"type"`
+	At      time.Time `json:"at"`
+	JobID   string    `json:"job_id,omitempty"`
+	JobType string    `json:"job_type,omitempty"`
+	Attempt int       `json:"attempt,omitempty"`
+	// Redelivered is set on events about a job an earlier delivery may have
+	// run already
+	Redelivered bool `json:"redelivered,omitempty"`
+	// Status the job was in before the event, where it matters (a job
+	// cancelled while processing rather than pending)
+	From domain.JobStatus `json:"from,omitempty"`
+	// Name of the worker involved, as recorded on the jobs it claims
+	Worker     string `json:"worker,omitempty"`
+	Queue      string `json:"queue,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
+	// Why a worker stopped or a job was released
+	Reason string `json:"reason,omitempty"`
+	// Name of an event a handler emitted through the outbox
+	Name string `json:"name,omitempty"`
+	// Details of events that are not about one job
+	Data any `json:"data,omitempty"`
+}
+
+// ForJob returns an event of type t about job.
+func ForJob(t Type, job *domain.Job) Event {
+	return Event{
+		Type:        t,
+		JobID:       job.ID,
+		JobType:     job.Type,
+		Attempt:     job.Attempts,
+		Redelivered: job.Redelivered,
+	}
+}
+
+// Subscriber receives the events published on a Bus. Handle is called on the
+// publisher's goroutine, so it must not block; subscribers doing slow work
+// hand events off to a goroutine of their own.
+type Subscriber interface {
+	Handle(ctx context.Context, event Event)
+}
+
+// SubscriberFunc adapts a function to a Subscriber.
+type SubscriberFunc func(ctx context.Context, event Event)
+
+func (f SubscriberFunc) Handle(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// Bus delivers published events to its subscribers, in the order they
+// subscribed.
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers []subscription
+	ordered     []subscription
+	// publishMu numbers events and delivers them to the ordered subscribers
+	// in one step, so those see IDs in order without gaps
+	publishMu   sync.Mutex
+	lastEventID int64
+}
+
+type subscription struct {
+	id         int
+	subscriber Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe delivers every event published from now on to subscriber. The
+// returned function unsubscribes it.
+func (b *Bus) Subscribe(subscriber Subscriber) func() {
+	return b.subscribe(&b.subscribers, subscriber)
+}
+
+// SubscribeOrdered is Subscribe for subscribers that must see events in ID
+// order, such as the event log clients resume from. They are called while
+// the next event waits for its ID, before the other subscribers, so they must
+// be quick and must not publish.
+func (b *Bus) SubscribeOrdered(subscriber Subscriber) func() {
+	return b.subscribe(&b.ordered, subscriber)
+}
+
+func (b *Bus) subscribe(list *[]subscription, subscriber Subscriber) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	*list = append(*list, subscription{id: id, subscriber: subscriber})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, s := range *list {
+			if s.id == id {
+				*list = append((*list)[:i:i], (*list)[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// ContinueAfter numbers the events published from now on after id, so IDs
+// carry on from those of an earlier run.
+func (b *Bus) ContinueAfter(id int64) {
+	b.publishMu.Lock()
+	defer b.publishMu.Unlock()
+
+	b.lastEventID = id
+}
+
+// Publish stamps event with the next ID and the current time, unless it has
+// one, and hands it to every subscriber before returning.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	ordered := b.ordered
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	b.publishMu.Lock()
+	b.lastEventID++
+	event.ID = b.lastEventID
+	if event.At.IsZero() {
+		event.At = time.Now().UTC()
+	}
+	for _, s := range ordered {
+		s.subscriber.Handle(ctx, event)
+	}
+	b.publishMu.Unlock()
+
+	for _, s := range subscribers {
+		s.subscriber.Handle(ctx, event)
+	}
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// LogSubscriber logs every event. Worker and recovery events are logged at
+// info level; job and sweep events at debug level, as the code handling a job
+// already logs it in context and into the job's attempt logs.
+type LogSubscriber struct {
+	logger *slog.Logger
+}
+
+func NewLogSubscriber(logger *slog.Logger) *LogSubscriber {
+	return &LogSubscriber{logger: logger}
+}
+
+func (s *LogSubscriber) Handle(ctx context.Context, event Event) {
+	// Log events use underscores: job.completed is logged as job_completed
+	attrs := []any{"event", strings.ReplaceAll(string(event.Type), ".", "_")}
+
+	switch event.Type {
+	case WorkerStarted:
+		s.logger.InfoContext(ctx, "Worker started", append(attrs, "worker", event.Worker, "queue", event.Queue)...)
+	case WorkerStopped:
+		s.logger.InfoContext(ctx, "Worker stopped", append(attrs, "worker", event.Worker, "queue", event.Queue, "reason", event.Reason)...)
+	case JobRecovered:
+		s.logger.InfoContext(ctx, "Recovered processing job", append(attrs, "job_id", event.JobID, "job_type", event.JobType)...)
+	case RecoveryCompleted:
+		s.logger.InfoContext(ctx, "Recovery completed", append(attrs, "data", event.Data)...)
+	case SweepCompleted:
+		s.logger.DebugContext(ctx, "Sweep finished", append(attrs, "data", event.Data)...)
+	default:
+		if event.JobID != "" {
+			attrs = append(attrs, "job_id", event.JobID, "job_type", event.JobType)
+		}
+		if event.Attempt > 0 {
+			attrs = append(attrs, "attempt", event.Attempt)
+		}
+		if event.Worker != "" {
+			attrs = append(attrs, "worker", event.Worker)
+		}
+		if event.Error != "" {
+			attrs = append(attrs, "error", event.Error, "error_class", event.ErrorClass)
+		}
+		if event.Reason != "" {
+			attrs = append(attrs, "reason", event.Reason)
+		}
+		if event.Name != "" {
+			attrs = append(attrs, "name", event.Name)
+		}
+		s.logger.DebugContext(ctx, "Event published", attrs...)
+	}
+}
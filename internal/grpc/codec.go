@@ -0,0 +1,29 @@
+package grpc
+
+import "fmt"
+
+// codec marshals the hand-written WorkerService messages. It is registered
+// under the "proto" name so standard generated clients talk to it unchanged.
+type codec struct{}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	switch message := v.(type) {
+	case *ServerMessage:
+		return message.marshalProto(), nil
+	default:
+		return nil, fmt.Errorf("cannot marshal %T", v)
+	}
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	switch message := v.(type) {
+	case *WorkerMessage:
+		return message.unmarshalProto(data)
+	default:
+		return fmt.Errorf("cannot unmarshal into %T", v)
+	}
+}
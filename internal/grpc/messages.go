@@ -0,0 +1,268 @@
+package grpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Hand-written encoders for the WorkerService messages in
+// api/proto/workstream.proto. Field numbers here must stay in sync with that
+// file.
+
+// WorkerMessage is sent by the worker. Exactly one field is set; the first
+// message on a stream must be a Subscribe.
+type WorkerMessage struct {
+	Subscribe *Subscribe
+	Progress  *Progress
+	Result    *Result
+}
+
+// Subscribe names the job types the worker runs and how many it takes at once.
+type Subscribe struct {
+	WorkerID    string
+	Types       []string
+	MaxInFlight int
+}
+
+// Progress and Result name the lease by its execution token; without one
+// they apply to the lease the session holds on the job.
+type Progress struct {
+	JobID   string
+	Attempt int
+	Percent int
+	Message string
+	Token   string
+}
+
+// Result reports the outcome of an attempt. A failed attempt is retried with
+// backoff unless Permanent is set.
+type Result struct {
+	JobID     string
+	Attempt   int
+	Success   bool
+	Output    json.RawMessage
+	Error     string
+	Permanent bool
+	Token     string
+}
+
+// ServerMessage is sent by the server.
+type ServerMessage struct {
+	Assignment *Assignment
+}
+
+// Assignment hands a leased job to the worker. The server keeps the lease
+// alive for as long as the stream is open.
+type Assignment struct {
+	JobID      string
+	Type       string
+	Payload    json.RawMessage
+	Attempt    int
+	MaxRetries int
+	Priority   string
+	// Redelivered is set when an earlier delivery may have run the job
+	Redelivered bool
+	// Token is the execution token of the lease
+	Token string
+}
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+func appendProtoMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func (m *ServerMessage) marshalProto() []byte {
+	var b []byte
+	if m.Assignment != nil {
+		b = appendProtoMessage(b, 1, m.Assignment.marshalProto())
+	}
+	return b
+}
+
+func (a *Assignment) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, a.JobID)
+	b = appendProtoString(b, 2, a.Type)
+	if len(a.Payload) > 0 {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, a.Payload)
+	}
+	b = appendProtoInt(b, 4, a.Attempt)
+	b = appendProtoInt(b, 5, a.MaxRetries)
+	b = appendProtoString(b, 6, a.Priority)
+	if a.Redelivered {
+		b = protowire.AppendTag(b, 7, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	b = appendProtoString(b, 8, a.Token)
+	return b
+}
+
+// consumeFields walks the fields of a message, calling field for each one.
+// field returns the number of bytes it consumed, or -1 if it does not know
+// the field, in which case the value is skipped.
+func consumeFields(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+			}
+		}
+		b = b[n:]
+	}
+
+	return nil
+}
+
+func consumeString(b []byte, dst *string) (int, error) {
+	v, n := protowire.ConsumeString(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*dst = v
+	return n, nil
+}
+
+func consumeInt(b []byte, dst *int) (int, error) {
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*dst = int(int64(v))
+	return n, nil
+}
+
+func consumeBool(b []byte, dst *bool) (int, error) {
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*dst = protowire.DecodeBool(v)
+	return n, nil
+}
+
+// consumeMessage decodes an embedded message with unmarshal.
+func consumeMessage(b []byte, unmarshal func([]byte) error) (int, error) {
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return n, unmarshal(v)
+}
+
+func (m *WorkerMessage) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType {
+			return -1, nil
+		}
+		switch num {
+		case 1:
+			m.Subscribe = &Subscribe{}
+			return consumeMessage(b, m.Subscribe.unmarshalProto)
+		case 2:
+			m.Progress = &Progress{}
+			return consumeMessage(b, m.Progress.unmarshalProto)
+		case 3:
+			m.Result = &Result{}
+			return consumeMessage(b, m.Result.unmarshalProto)
+		}
+		return -1, nil
+	})
+}
+
+func (s *Subscribe) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &s.WorkerID)
+		case num == 2 && typ == protowire.BytesType:
+			var jobType string
+			n, err := consumeString(b, &jobType)
+			s.Types = append(s.Types, jobType)
+			return n, err
+		case num == 3 && typ == protowire.VarintType:
+			return consumeInt(b, &s.MaxInFlight)
+		}
+		return -1, nil
+	})
+}
+
+func (p *Progress) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &p.JobID)
+		case num == 2 && typ == protowire.VarintType:
+			return consumeInt(b, &p.Attempt)
+		case num == 3 && typ == protowire.VarintType:
+			return consumeInt(b, &p.Percent)
+		case num == 4 && typ == protowire.BytesType:
+			return consumeString(b, &p.Message)
+		case num == 5 && typ == protowire.BytesType:
+			return consumeString(b, &p.Token)
+		}
+		return -1, nil
+	})
+}
+
+func (r *Result) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &r.JobID)
+		case num == 2 && typ == protowire.VarintType:
+			return consumeInt(b, &r.Attempt)
+		case num == 3 && typ == protowire.VarintType:
+			return consumeBool(b, &r.Success)
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			if len(v) > 0 {
+				if !json.Valid(v) {
+					return 0, errors.New("result output must be valid JSON")
+				}
+				r.Output = json.RawMessage(append([]byte(nil), v...))
+			}
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			return consumeString(b, &r.Error)
+		case num == 6 && typ == protowire.VarintType:
+			return consumeBool(b, &r.Permanent)
+		case num == 7 && typ == protowire.BytesType:
+			return consumeString(b, &r.Token)
+		}
+		return -1, nil
+	})
+}
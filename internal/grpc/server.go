@@ -0,0 +1,314 @@
+// Package grpc serves the WorkerService streaming protocol for remote
+// workers: a worker subscribes to job types over one bidirectional stream,
+// receives assignments as jobs become available, and streams back progress
+// and results. Leases are tracked server-side and renewed for as long as the
+// stream stays open.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/remote"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxInFlightLimit caps how many jobs one stream may hold at once.
+const maxInFlightLimit = 100
+
+// workerService is the HandlerType of the service descriptor; there is no
+// generated interface to point at.
+type workerService interface {
+	connect(stream grpc.ServerStream) error
+}
+
+var workerServiceDesc = grpc.ServiceDesc{
+	ServiceName: "workstream.v1.WorkerService",
+	HandlerType: (*workerService)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       connectHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api/proto/workstream.proto",
+}
+
+func connectHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(workerService).connect(stream)
+}
+
+// WorkerServer implements WorkerService on top of remote.Service.
+type WorkerServer struct {
+	service       *remote.Service
+	gate          *worker.Gate
+	valve         *queue.Valve
+	shutdownCtx   context.Context
+	logger        *slog.Logger
+	leaseDuration time.Duration
+	pollInterval  time.Duration
+}
+
+func NewWorkerServer(service *remote.Service, gate *worker.Gate, valve *queue.Valve, shutdownCtx context.Context, logger *slog.Logger, leaseDuration time.Duration, pollInterval time.Duration) *WorkerServer {
+	return &WorkerServer{
+		service:       service,
+		gate:          gate,
+		valve:         valve,
+		shutdownCtx:   shutdownCtx,
+		logger:        logger,
+		leaseDuration: leaseDuration,
+		pollInterval:  pollInterval,
+	}
+}
+
+// NewServer returns a gRPC server with WorkerService registered, serving TLS
+// when tlsConfig is set.
+func NewServer(workerServer *WorkerServer, tlsConfig *tls.Config) *grpc.Server {
+	options := []grpc.ServerOption{grpc.ForceServerCodec(codec{})}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(options...)
+	server.RegisterService(&workerServiceDesc, workerServer)
+
+	return server
+}
+
+// session is the state of one connected worker.
+type session struct {
+	subscribe *Subscribe
+
+	mu       sync.Mutex
+	inFlight map[string]string // job ID -> execution token
+
+	// freed wakes the dispatch loop when a result frees a slot
+	freed chan struct{}
+}
+
+func (s *session) free(jobID string) {
+	s.mu.Lock()
+	delete(s.inFlight, jobID)
+	s.mu.Unlock()
+
+	select {
+	case s.freed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *session) snapshot() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.inFlight)
+}
+
+// token returns the execution token a message names, or else the one of the
+// lease the session holds on jobID.
+func (s *session) token(jobID string, token string) string {
+	if token != "" {
+		return token
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inFlight[jobID]
+}
+
+func (w *WorkerServer) connect(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+
+	var first WorkerMessage
+	if err := stream.RecvMsg(&first); err != nil {
+		return err
+	}
+	subscribe := first.Subscribe
+	if subscribe == nil {
+		return status.Error(codes.InvalidArgument, "first message must be a subscribe")
+	}
+	if len(subscribe.Types) == 0 {
+		return status.Error(codes.InvalidArgument, "subscribe must list at least one job type")
+	}
+	if subscribe.MaxInFlight <= 0 {
+		subscribe.MaxInFlight = 1
+	}
+	if subscribe.MaxInFlight > maxInFlightLimit {
+		subscribe.MaxInFlight = maxInFlightLimit
+	}
+	if subscribe.WorkerID == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			subscribe.WorkerID = p.Addr.String()
+		}
+	}
+
+	s := &session{
+		subscribe: subscribe,
+		inFlight:  make(map[string]string),
+		freed:     make(chan struct{}, 1),
+	}
+	w.logger.Info("Remote worker connected", "event", "grpc_worker_connected", "worker_id", subscribe.WorkerID, "types", subscribe.Types, "max_in_flight", subscribe.MaxInFlight)
+
+	// Whatever the worker still held when the stream ended is handed back
+	defer w.abandon(s)
+
+	recvErr := make(chan error, 1)
+	go func() {
+		recvErr <- w.receive(ctx, stream, s)
+	}()
+
+	poll := time.NewTicker(w.pollInterval)
+	defer poll.Stop()
+	renew := time.NewTicker(w.leaseDuration / 3)
+	defer renew.Stop()
+
+	for {
+		if err := w.dispatch(ctx, stream, s); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.shutdownCtx.Done():
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case err := <-recvErr:
+			w.logger.Info("Remote worker disconnected", "event", "grpc_worker_disconnected", "worker_id", subscribe.WorkerID, "error", err)
+			return err
+		case <-renew.C:
+			w.renew(ctx, s)
+		case <-poll.C:
+		case <-s.freed:
+		}
+	}
+}
+
+// dispatch leases jobs into the session's free slots and sends them.
+func (w *WorkerServer) dispatch(ctx context.Context, stream grpc.ServerStream, s *session) error {
+	if w.gate.Paused() || w.valve.Paused() {
+		return nil
+	}
+
+	s.mu.Lock()
+	free := s.subscribe.MaxInFlight - len(s.inFlight)
+	s.mu.Unlock()
+	if free <= 0 {
+		return nil
+	}
+
+	types := w.gate.UnpausedTypes(s.subscribe.Types)
+	if len(types) == 0 {
+		return nil
+	}
+
+	jobs, err := w.service.Lease(ctx, types, free, s.subscribe.WorkerID, w.leaseDuration)
+	if err != nil {
+		w.logger.Error("Failed to lease jobs for remote worker", "event", "job_lease_error", "worker_id", s.subscribe.WorkerID, "error", err)
+		return nil
+	}
+
+	for _, job := range jobs {
+		s.mu.Lock()
+		s.inFlight[job.ID] = job.ExecutionToken
+		s.mu.Unlock()
+
+		if err := stream.SendMsg(assignment(job)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// receive applies progress and results from the worker until the stream
+// ends.
+func (w *WorkerServer) receive(ctx context.Context, stream grpc.ServerStream, s *session) error {
+	for {
+		var message WorkerMessage
+		if err := stream.RecvMsg(&message); err != nil {
+			return err
+		}
+
+		switch {
+		case message.Progress != nil:
+			progress := message.Progress
+			if err := w.service.Progress(ctx, progress.JobID, s.token(progress.JobID, progress.Token), progress.Percent, progress.Message); err != nil {
+				w.logger.Warn("Remote worker progress rejected", "event", "job_progress_rejected", "worker_id", s.subscribe.WorkerID, "job_id", progress.JobID, "error", err)
+			}
+		case message.Result != nil:
+			w.applyResult(ctx, s, message.Result)
+		}
+	}
+}
+
+func (w *WorkerServer) applyResult(ctx context.Context, s *session, result *Result) {
+	token := s.token(result.JobID, result.Token)
+	defer s.free(result.JobID)
+
+	var err error
+	if result.Success {
+		err = w.service.Ack(ctx, result.JobID, token, result.Output)
+	} else {
+		err = w.service.Nack(ctx, result.JobID, token, result.Error, result.Permanent)
+	}
+	if err != nil {
+		w.logger.Warn("Remote worker result rejected", "event", "job_result_rejected", "worker_id", s.subscribe.WorkerID, "job_id", result.JobID, "error", err)
+	}
+}
+
+// renew extends the lease of every job the session holds. Jobs whose lease
+// was lost (reaped, cancelled) are dropped from the session.
+func (w *WorkerServer) renew(ctx context.Context, s *session) {
+	for jobID, token := range s.snapshot() {
+		if _, err := w.service.Extend(ctx, jobID, token, w.leaseDuration); err != nil {
+			w.logger.Warn("Failed to renew remote job lease", "event", "job_lease_renew_failed", "worker_id", s.subscribe.WorkerID, "job_id", jobID, "error", err)
+			if errors.Is(err, store.ErrLeaseLost) || errors.Is(err, store.ErrJobNotFound) {
+				s.free(jobID)
+			}
+		}
+	}
+}
+
+// abandon expires the leases of jobs still held when the stream ends, so the
+// lease reaper offers them again promptly.
+func (w *WorkerServer) abandon(s *session) {
+	// The stream context is already cancelled
+	ctx := context.WithoutCancel(w.shutdownCtx)
+
+	for jobID, token := range s.snapshot() {
+		if err := w.service.Abandon(ctx, jobID, token); err != nil && !errors.Is(err, store.ErrLeaseLost) {
+			w.logger.Error("Failed to abandon remote job lease", "event", "job_update_error", "worker_id", s.subscribe.WorkerID, "job_id", jobID, "error", err)
+			continue
+		}
+		w.logger.Info("Job abandoned by disconnected remote worker", "event", "job_abandoned", "worker_id", s.subscribe.WorkerID, "job_id", jobID)
+	}
+}
+
+func assignment(job domain.Job) *ServerMessage {
+	return &ServerMessage{Assignment: &Assignment{
+		JobID:       job.ID,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Attempt:     job.Attempts,
+		MaxRetries:  job.MaxRetries,
+		Priority:    job.Priority.String(),
+		Redelivered: job.Redelivered,
+		Token:       job.ExecutionToken,
+	}}
+}
@@ -0,0 +1,64 @@
+// Package handlers holds the built-in job handlers the server registers.
+// They simulate their work until real integrations replace them.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+)
+
+// workDuration is how long each simulated job takes.
+const workDuration = time.Second
+
+// Email handles "email" jobs. It always fails after the simulated work, so
+// retries and the dead-letter queue can be exercised.
+func Email(ctx context.Context, job *domain.Job) error {
+	if err := simulateWork(ctx, workDuration); err != nil {
+		return err
+	}
+	return errors.New("Email sending failed")
+}
+
+// EmailSend handles "email_send" jobs, whose payload is an EmailPayload.
+var EmailSend = worker.Handle(func(ctx context.Context, email EmailPayload) error {
+	return simulateWork(ctx, workDuration)
+})
+
+// Simulated handles jobs of any type by simulating their work, e.g. for the
+// job types of webhook ingestion sources.
+func Simulated(ctx context.Context, job *domain.Job) error {
+	return simulateWork(ctx, workDuration)
+}
+
+// EmailPayload is the payload of email_send jobs.
+type EmailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (p EmailPayload) Validate() error {
+	if p.To != "" && !strings.Contains(p.To, "@") {
+		return fmt.Errorf("to %q is not an email address", p.To)
+	}
+	return nil
+}
+
+// simulateWork stands in for real job execution until handlers do actual work.
+func simulateWork(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,526 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/drain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/recovery"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+)
+
+const (
+	defaultDrainTimeout = 5 * time.Minute
+	defaultStuckJobsAge = 5 * time.Minute
+	maxWorkerCount      = 1000
+)
+
+type AdminHandler struct {
+	jobStore     store.JobStore
+	events       *events.Bus
+	jobQueue     queue.Queue
+	gate         *worker.Gate
+	valve        *queue.Valve
+	drain        *drain.Controller
+	pool         *worker.Pool
+	utilization  *worker.Utilization
+	sweeper      store.Sweeper
+	recovery     *recovery.Progress
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewAdminHandler(jobStore store.JobStore, bus *events.Bus, jobQueue queue.Queue, gate *worker.Gate, valve *queue.Valve, drain *drain.Controller, pool *worker.Pool, utilization *worker.Utilization, sweeper store.Sweeper, recoveryProgress *recovery.Progress, logger *slog.Logger, maxBodyBytes int64) *AdminHandler {
+	return &AdminHandler{
+		jobStore:     jobStore,
+		events:       bus,
+		jobQueue:     jobQueue,
+		gate:         gate,
+		valve:        valve,
+		drain:        drain,
+		pool:         pool,
+		utilization:  utilization,
+		sweeper:      sweeper,
+		recovery:     recoveryProgress,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type ResizeWorkersRequest struct {
+	Count int `json:"count"`
+}
+
+type WorkerPoolResponse struct {
+	Count int `json:"count"`
+}
+
+// WorkersResponse tells whether the workers or the queue hold jobs back: pools
+// near 100% saturation need more workers, while idle pools with jobs waiting
+// point at the queue.
+type WorkersResponse struct {
+	Pools   []WorkerPoolUtilizationResponse `json:"pools"`
+	Workers []WorkerStatusResponse          `json:"workers"`
+}
+
+type WorkerPoolUtilizationResponse struct {
+	Queue string `json:"queue"`
+	Size  int    `json:"size"`
+	// Workers running, more than size while removed ones finish their job
+	Workers int `json:"workers"`
+	Busy    int `json:"busy"`
+	// Share of the pool busy now
+	SaturationPercent float64 `json:"saturation_percent"`
+	// Share of worker time spent busy since startup
+	UtilizationPercent float64 `json:"utilization_percent"`
+	BusySeconds        float64 `json:"busy_seconds"`
+	IdleSeconds        float64 `json:"idle_seconds"`
+}
+
+type WorkerStatusResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Queue     string `json:"queue"`
+	State     string `json:"state"`
+	StartedAt string `json:"started_at"`
+	// Set while the worker is busy
+	JobID              string  `json:"job_id,omitempty"`
+	JobType            string  `json:"job_type,omitempty"`
+	JobStartedAt       string  `json:"job_started_at,omitempty"`
+	BusySeconds        float64 `json:"busy_seconds"`
+	IdleSeconds        float64 `json:"idle_seconds"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+}
+
+// utilizationPercent is the share of busy in busy and idle time, to one
+// decimal.
+func utilizationPercent(busy, idle time.Duration) float64 {
+	if busy+idle <= 0 {
+		return 0
+	}
+	return math.Round(float64(busy)/float64(busy+idle)*1000) / 10
+}
+
+func workersToResponse(pools []worker.PoolUtilization, workers []worker.WorkerStatus) WorkersResponse {
+	response := WorkersResponse{
+		Pools:   make([]WorkerPoolUtilizationResponse, 0, len(pools)),
+		Workers: make([]WorkerStatusResponse, 0, len(workers)),
+	}
+	for _, pool := range pools {
+		response.Pools = append(response.Pools, WorkerPoolUtilizationResponse{
+			Queue:              pool.Queue,
+			Size:               pool.Size,
+			Workers:            pool.Workers,
+			Busy:               pool.BusyWorkers,
+			SaturationPercent:  math.Round(pool.Saturation*1000) / 10,
+			UtilizationPercent: utilizationPercent(pool.Busy, pool.Idle),
+			BusySeconds:        pool.Busy.Seconds(),
+			IdleSeconds:        pool.Idle.Seconds(),
+		})
+	}
+	for _, status := range workers {
+		entry := WorkerStatusResponse{
+			ID:                 status.ID,
+			Name:               status.Name,
+			Queue:              status.Queue,
+			State:              "idle",
+			StartedAt:          status.StartedAt.Format(time.RFC3339),
+			BusySeconds:        status.Busy.Seconds(),
+			IdleSeconds:        status.Idle.Seconds(),
+			UtilizationPercent: utilizationPercent(status.Busy, status.Idle),
+		}
+		if status.JobID != "" {
+			entry.State = "busy"
+			entry.JobID = status.JobID
+			entry.JobType = status.JobType
+			entry.JobStartedAt = status.JobStartedAt.UTC().Format(time.RFC3339)
+		}
+		response.Workers = append(response.Workers, entry)
+	}
+
+	return response
+}
+
+type RequeueStuckResponse struct {
+	Requeued int      `json:"requeued"`
+	JobIDs   []string `json:"job_ids"`
+}
+
+type ProcessingStateResponse struct {
+	Paused      bool     `json:"paused"`
+	PausedTypes []string `json:"paused_types"`
+}
+
+type DrainStatusResponse struct {
+	State          string `json:"state"`
+	StartedAt      string `json:"started_at,omitempty"`
+	Deadline       string `json:"deadline,omitempty"`
+	FinishedAt     string `json:"finished_at,omitempty"`
+	PendingJobs    int    `json:"pending_jobs"`
+	ProcessingJobs int    `json:"processing_jobs"`
+}
+
+func drainStatusToResponse(status drain.Status) DrainStatusResponse {
+	response := DrainStatusResponse{
+		State:          string(status.State),
+		PendingJobs:    status.PendingJobs,
+		ProcessingJobs: status.ProcessingJobs,
+	}
+	if !status.StartedAt.IsZero() {
+		response.StartedAt = status.StartedAt.Format(time.RFC3339)
+		response.Deadline = status.Deadline.Format(time.RFC3339)
+	}
+	if !status.FinishedAt.IsZero() {
+		response.FinishedAt = status.FinishedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+type QueueStateResponse struct {
+	Paused   bool   `json:"paused"`
+	PausedAt string `json:"paused_at,omitempty"`
+	// Jobs submitted during the current or last pause, persisted to the
+	// store but not enqueued
+	Buffered int `json:"buffered"`
+	// Set by resume: what the catch-up enqueued, and what it left for the
+	// sweeper because the queue filled up or the batch size was reached
+	CaughtUp *int `json:"caught_up,omitempty"`
+	Deferred *int `json:"deferred,omitempty"`
+}
+
+type SweeperStatusResponse struct {
+	Interval  string               `json:"interval"`
+	Jitter    string               `json:"jitter"`
+	BatchSize int                  `json:"batch_size"`
+	Runs      int                  `json:"runs"`
+	NextRunAt string               `json:"next_run_at,omitempty"`
+	LastRun   *SweepResultResponse `json:"last_run"`
+}
+
+// RecoveryStatusResponse is how far startup recovery has got. Remaining is
+// the due pending jobs still to be re-enqueued.
+type RecoveryStatusResponse struct {
+	State               string `json:"state"`
+	StartedAt           string `json:"started_at"`
+	FinishedAt          string `json:"finished_at,omitempty"`
+	ProcessingRecovered int    `json:"processing_recovered"`
+	PendingTotal        int    `json:"pending_total"`
+	PendingReEnqueued   int    `json:"pending_re_enqueued"`
+	Remaining           int    `json:"remaining"`
+	Error               string `json:"error,omitempty"`
+}
+
+type SweepResultResponse struct {
+	StartedAt string  `json:"started_at"`
+	Duration  float64 `json:"duration_seconds"`
+	Reaped    int     `json:"reaped"`
+	Expired   int     `json:"expired"`
+	Retried   int     `json:"retried"`
+	// Retries left for a later run by the retry budget
+	RetriesDeferred int    `json:"retries_deferred"`
+	Enqueued        int    `json:"enqueued"`
+	SkippedFull     int    `json:"skipped_full"`
+	Deferred        int    `json:"deferred"`
+	Error           string `json:"error,omitempty"`
+}
+
+func sweeperStatusToResponse(status store.SweeperStatus) SweeperStatusResponse {
+	response := SweeperStatusResponse{
+		Interval:  status.Schedule.Interval.String(),
+		Jitter:    status.Schedule.Jitter.String(),
+		BatchSize: status.Schedule.BatchSize,
+		Runs:      status.Runs,
+	}
+	if !status.NextRunAt.IsZero() {
+		response.NextRunAt = status.NextRunAt.Format(time.RFC3339)
+	}
+	if run := status.LastRun; run != nil {
+		response.LastRun = &SweepResultResponse{
+			StartedAt:       run.StartedAt.Format(time.RFC3339),
+			Duration:        run.Duration.Seconds(),
+			Reaped:          run.Reaped,
+			Expired:         run.Expired,
+			Retried:         run.Retried,
+			RetriesDeferred: run.RetriesDeferred,
+			Enqueued:        run.Enqueued,
+			SkippedFull:     run.SkippedFull,
+			Deferred:        run.Deferred,
+		}
+		if run.Err != nil {
+			response.LastRun.Error = run.Err.Error()
+		}
+	}
+
+	return response
+}
+
+func recoveryStatusToResponse(status recovery.Status) RecoveryStatusResponse {
+	response := RecoveryStatusResponse{
+		State:               status.State,
+		StartedAt:           status.StartedAt.Format(time.RFC3339),
+		ProcessingRecovered: status.ProcessingRecovered,
+		PendingTotal:        status.PendingTotal,
+		PendingReEnqueued:   status.PendingReEnqueued,
+		Remaining:           status.Remaining(),
+		Error:               status.Error,
+	}
+	if status.FinishedAt != nil {
+		response.FinishedAt = status.FinishedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+// Pause stops workers from claiming new jobs. Jobs already being processed
+// finish normally; new submissions keep accumulating as pending.
+func (h *AdminHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	if h.gate.Pause() {
+		h.logger.Info("Job processing paused", "event", "processing_paused")
+	}
+
+	h.writeProcessingState(w, r)
+}
+
+func (h *AdminHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	if h.gate.Resume() {
+		h.logger.Info("Job processing resumed", "event", "processing_resumed")
+	}
+
+	h.writeProcessingState(w, r)
+}
+
+// PauseType stops workers from claiming jobs of one type while other types
+// keep being processed. Its pending jobs stay pending.
+func (h *AdminHandler) PauseType(w http.ResponseWriter, r *http.Request) {
+	jobType := r.PathValue("type")
+	if h.gate.PauseType(jobType) {
+		h.logger.Info("Job type paused", "event", "job_type_paused", "job_type", jobType)
+	}
+
+	h.writeProcessingState(w, r)
+}
+
+func (h *AdminHandler) ResumeType(w http.ResponseWriter, r *http.Request) {
+	jobType := r.PathValue("type")
+	if h.gate.ResumeType(jobType) {
+		h.logger.Info("Job type resumed", "event", "job_type_resumed", "job_type", jobType)
+	}
+
+	h.writeProcessingState(w, r)
+}
+
+func (h *AdminHandler) writeProcessingState(w http.ResponseWriter, r *http.Request) {
+	response := ProcessingStateResponse{
+		Paused:      h.gate.Paused(),
+		PausedTypes: slices.Sorted(maps.Keys(h.gate.PausedTypes())),
+	}
+	if response.PausedTypes == nil {
+		response.PausedTypes = []string{}
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// Drain stops accepting new jobs and waits in the background for accepted
+// jobs to finish. Poll DrainStatus for progress; StopDrain re-opens intake.
+func (h *AdminHandler) Drain(w http.ResponseWriter, r *http.Request) {
+	timeout := defaultDrainTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			ErrorResponse(w, "timeout must be a positive duration (e.g. 30s, 5m)", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	status, err := h.drain.Start(timeout)
+	if err != nil {
+		if errors.Is(err, drain.ErrAlreadyDraining) {
+			ErrorResponse(w, "Drain already in progress", http.StatusConflict)
+			return
+		}
+
+		ErrorResponse(w, "Failed to start drain", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeDrainStatus(w, r, status, http.StatusAccepted)
+}
+
+func (h *AdminHandler) DrainStatus(w http.ResponseWriter, r *http.Request) {
+	h.writeDrainStatus(w, r, h.drain.Status(), http.StatusOK)
+}
+
+func (h *AdminHandler) StopDrain(w http.ResponseWriter, r *http.Request) {
+	h.writeDrainStatus(w, r, h.drain.Stop(), http.StatusOK)
+}
+
+func (h *AdminHandler) writeDrainStatus(w http.ResponseWriter, r *http.Request, status drain.Status, statusCode int) {
+	if err := WriteResponse(w, r, drainStatusToResponse(status), statusCode); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// ResizeWorkers scales the worker pool at runtime. Removed workers finish the
+// job they are processing before exiting.
+func (h *AdminHandler) ResizeWorkers(w http.ResponseWriter, r *http.Request) {
+	var request ResizeWorkersRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	if request.Count < 1 || request.Count > maxWorkerCount {
+		ErrorResponse(w, "count must be between 1 and 1000", http.StatusBadRequest)
+		return
+	}
+
+	h.pool.Resize(request.Count)
+
+	if err := WriteResponse(w, r, WorkerPoolResponse{Count: h.pool.Size()}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// Workers reports each worker pool's saturation and utilization, and what
+// every worker is doing.
+func (h *AdminHandler) Workers(w http.ResponseWriter, r *http.Request) {
+	response := workersToResponse(h.utilization.Pools(), h.utilization.Workers())
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// RequeueStuck flips jobs stuck in processing for longer than older_than
+// (default 5m) back to pending and tries to enqueue them. Jobs that do not fit
+// in the queue are picked up by the sweeper.
+func (h *AdminHandler) RequeueStuck(w http.ResponseWriter, r *http.Request) {
+	olderThan := defaultStuckJobsAge
+	if raw := r.URL.Query().Get("older_than"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			ErrorResponse(w, "older_than must be a positive duration (e.g. 30s, 5m)", http.StatusBadRequest)
+			return
+		}
+		olderThan = parsed
+	}
+
+	jobs, err := h.jobStore.RequeueStuckJobs(r.Context(), olderThan)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to requeue stuck jobs")
+		return
+	}
+
+	jobIDs := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		jobID := job.ID
+		jobIDs = append(jobIDs, jobID)
+		h.logger.Info("Stuck job requeued", "event", "job_requeued", "job_id", jobID, "older_than", olderThan.String())
+
+		// A handler still running the attempt here is cancelled
+		event := events.ForJob(events.JobReleased, &job)
+		event.Reason = "stuck"
+		h.events.Publish(r.Context(), event)
+
+		switch err := h.jobQueue.Enqueue(r.Context(), jobID); {
+		case err == nil:
+			h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", jobID)
+		case errors.Is(err, queue.ErrPaused):
+			// Enqueued by the catch-up on resume
+			h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", jobID)
+		default:
+			h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", jobID, "error", err)
+		}
+	}
+
+	response := RequeueStuckResponse{
+		Requeued: len(jobIDs),
+		JobIDs:   jobIDs,
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// SweeperStatus reports the sweeper's schedule and what its last run did.
+func (h *AdminHandler) SweeperStatus(w http.ResponseWriter, r *http.Request) {
+	if err := WriteResponse(w, r, sweeperStatusToResponse(h.sweeper.Status()), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// RecoveryStatus reports the progress of startup recovery, which runs while
+// the server already serves.
+func (h *AdminHandler) RecoveryStatus(w http.ResponseWriter, r *http.Request) {
+	if err := WriteResponse(w, r, recoveryStatusToResponse(h.recovery.Status()), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// PauseQueue stops workers receiving jobs from the queue while submissions
+// keep being accepted: they are persisted as pending and enqueued once the
+// queue resumes. Use it for planned downstream maintenance.
+func (h *AdminHandler) PauseQueue(w http.ResponseWriter, r *http.Request) {
+	if h.valve.Pause() {
+		h.logger.Info("Job queue paused", "event", "queue_paused")
+	}
+
+	h.writeQueueState(w, r, QueueStateResponse{})
+}
+
+// ResumeQueue reopens the queue and catches up on the jobs submitted while it
+// was paused, highest priority and oldest first. Jobs that don't fit are left
+// for the sweeper.
+func (h *AdminHandler) ResumeQueue(w http.ResponseWriter, r *http.Request) {
+	var response QueueStateResponse
+
+	buffered := h.valve.Status().Buffered
+	if h.valve.Resume() {
+		result := h.sweeper.CatchUp(r.Context())
+		deferred := result.SkippedFull + result.Deferred
+		response.CaughtUp = &result.Enqueued
+		response.Deferred = &deferred
+		h.logger.Info("Job queue resumed", "event", "queue_resumed", "buffered", buffered, "caught_up", result.Enqueued, "deferred", deferred)
+	}
+
+	h.writeQueueState(w, r, response)
+}
+
+func (h *AdminHandler) QueueStatus(w http.ResponseWriter, r *http.Request) {
+	h.writeQueueState(w, r, QueueStateResponse{})
+}
+
+func (h *AdminHandler) writeQueueState(w http.ResponseWriter, r *http.Request, response QueueStateResponse) {
+	status := h.valve.Status()
+	response.Paused = status.Paused
+	response.Buffered = status.Buffered
+	if status.Paused {
+		response.PausedAt = status.PausedAt.Format(time.RFC3339)
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
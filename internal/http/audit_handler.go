@@ -0,0 +1,259 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+const (
+	// APIKeyHeader carries an API key; only a fingerprint of it is recorded
+	APIKeyHeader = "X-API-Key"
+
+	// Largest request body recorded with an audit entry
+	maxAuditBodyBytes = 4096
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditRoute names the action of an audited route.
+type auditRoute struct {
+	action string
+	// Record the request body; only for routes whose bodies hold no secrets
+	// or payloads
+	body bool
+}
+
+// auditRoutes are the routes the Auditor records, by mux pattern: the admin
+// API and the calls that change jobs or configuration on an operator's
+// behalf. Job submission and the remote worker protocol are traffic rather
+// than operations and are left out.
+var auditRoutes = map[string]auditRoute{
+	"POST /jobs/{id}/retry":               {action: "job.retry"},
+	"POST /jobs/{id}/cancel":              {action: "job.cancel"},
+	"POST /jobs/{id}/replay":              {action: "job.replay"},
+	"POST /dlq/{id}/requeue":              {action: "dlq.requeue"},
+	"POST /admin/quarantine/{id}/release": {action: "quarantine.release"},
+	"POST /schedules":                     {action: "schedule.create"},
+	"DELETE /schedules/{id}":              {action: "schedule.delete"},
+	"PUT /schemas/{type}":                 {action: "schema.put"},
+	"DELETE /schemas/{type}":              {action: "schema.delete"},
+	"PUT /templates/{name}":               {action: "template.put"},
+	"DELETE /templates/{name}":            {action: "template.delete"},
+	"POST /webhooks":                      {action: "webhook.create"},
+	"DELETE /webhooks/{id}":               {action: "webhook.delete"},
+	"POST /admin/pause":                   {action: "processing.pause"},
+	"POST /admin/resume":                  {action: "processing.resume"},
+	"POST /admin/types/{type}/pause":      {action: "type.pause"},
+	"POST /admin/types/{type}/resume":     {action: "type.resume"},
+	"POST /admin/queue/pause":             {action: "queue.pause"},
+	"POST /admin/queue/resume":            {action: "queue.resume"},
+	"POST /admin/drain":                   {action: "drain.start"},
+	"DELETE /admin/drain":                 {action: "drain.stop"},
+	"PUT /admin/workers":                  {action: "workers.resize", body: true},
+	"POST /admin/requeue-stuck":           {action: "jobs.requeue_stuck"},
+	"PUT /admin/loglevel":                 {action: "loglevel.set", body: true},
+}
+
+// Auditor records who called which audited route, and how it went, in the
+// audit store.
+type Auditor struct {
+	auditStore store.AuditStore
+	logger     *slog.Logger
+	// Header a trusted proxy sets to the authenticated caller; empty to
+	// ignore it
+	actorHeader string
+}
+
+func NewAuditor(auditStore store.AuditStore, logger *slog.Logger, actorHeader string) *Auditor {
+	return &Auditor{
+		auditStore:  auditStore,
+		logger:      logger,
+		actorHeader: actorHeader,
+	}
+}
+
+// Middleware records the calls to mux's audited routes once they are
+// answered. A call that can't be recorded is logged but still answered.
+func (a *Auditor) Middleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		route, ok := auditRoutes[pattern]
+		if !ok {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		entry := domain.NewAuditEntry(route.action)
+		entry.Actor, entry.ActorSource = a.actor(r)
+		entry.RemoteAddr = r.RemoteAddr
+		entry.Method = r.Method
+		entry.Path = r.URL.Path
+		entry.Query = r.URL.RawQuery
+
+		if route.body && r.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodyBytes+1))
+			if err == nil && len(body) <= maxAuditBodyBytes && json.Valid(body) {
+				entry.Request = body
+			}
+			// The handler reads the body as if it had not been touched
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		sw := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		mux.ServeHTTP(sw, r)
+
+		entry.Status = sw.statusCode
+		for _, name := range []string{"id", "type", "name"} {
+			if value := r.PathValue(name); value != "" {
+				entry.Target = value
+				break
+			}
+		}
+
+		a.logger.Info("Audited action", "event", "audit", "action", entry.Action, "actor", entry.Actor, "actor_source", entry.ActorSource, "target", entry.Target, "status", entry.Status, "remote_addr", entry.RemoteAddr)
+		// The request context may be cancelled once the response is written
+		if err := a.auditStore.AppendAudit(context.WithoutCancel(r.Context()), entry); err != nil {
+			a.logger.Error("Failed to record audit entry", "event", "audit_error", "action", entry.Action, "actor", entry.Actor, "error", err)
+		}
+	})
+}
+
+// actor identifies the caller: by the subject of its verified client
+// certificate, by the identity a trusted proxy put in the actor header, or by
+// a fingerprint of its API key, so keys can be told apart without being
+// stored.
+func (a *Auditor) actor(r *http.Request) (string, string) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		if subject.CommonName != "" {
+			return subject.CommonName, domain.ActorSourceCertificate
+		}
+		return subject.String(), domain.ActorSourceCertificate
+	}
+
+	if a.actorHeader != "" {
+		if actor := strings.TrimSpace(r.Header.Get(a.actorHeader)); actor != "" {
+			return actor, domain.ActorSourceHeader
+		}
+	}
+
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if key = strings.TrimSpace(key); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8]), domain.ActorSourceAPIKey
+	}
+
+	return "anonymous", domain.ActorSourceAnonymous
+}
+
+// AuditHandler serves the audit log.
+type AuditHandler struct {
+	auditStore store.AuditStore
+	logger     *slog.Logger
+}
+
+func NewAuditHandler(auditStore store.AuditStore, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditStore: auditStore,
+		logger:     logger,
+	}
+}
+
+type AuditEntryResponse struct {
+	ID          string          `json:"id"`
+	At          string          `json:"at"`
+	Actor       string          `json:"actor"`
+	ActorSource string          `json:"actor_source"`
+	RemoteAddr  string          `json:"remote_addr"`
+	Action      string          `json:"action"`
+	Target      string          `json:"target,omitempty"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Query       string          `json:"query,omitempty"`
+	Status      int             `json:"status"`
+	Request     json.RawMessage `json:"request,omitempty"`
+}
+
+func auditEntryToResponse(entry domain.AuditEntry) AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:          entry.ID,
+		At:          entry.At.Format(time.RFC3339Nano),
+		Actor:       entry.Actor,
+		ActorSource: entry.ActorSource,
+		RemoteAddr:  entry.RemoteAddr,
+		Action:      entry.Action,
+		Target:      entry.Target,
+		Method:      entry.Method,
+		Path:        entry.Path,
+		Query:       entry.Query,
+		Status:      entry.Status,
+		Request:     entry.Request,
+	}
+}
+
+// GetAudit handles GET /admin/audit: audit entries newest first, filtered by
+// the actor, action and target query parameters, and by since and until as
+// RFC 3339 times, up to limit entries (default 100, at most 1000).
+func (h *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+		Limit:  defaultAuditLimit,
+	}
+
+	for name, at := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			ErrorResponse(w, name+" must be an RFC 3339 time, e.g. 2026-01-01T00:00:00Z", http.StatusBadRequest)
+			return
+		}
+		*at = parsed
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			ErrorResponse(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditStore.GetAudit(r.Context(), filter)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get audit log")
+		return
+	}
+
+	response := make([]AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, auditEntryToResponse(entry))
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
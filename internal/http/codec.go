@@ -0,0 +1,90 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeMsgpack  = "application/msgpack"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// protoMarshaler is implemented by response types that have a protobuf
+// representation (see api/proto/workstream.proto).
+type protoMarshaler interface {
+	marshalProto() []byte
+}
+
+// normalizeContentType maps the aliases clients commonly send onto the
+// content types the server supports. Unknown types map to JSON.
+func normalizeContentType(mediaType string) string {
+	switch mediaType {
+	case contentTypeMsgpack, "application/x-msgpack", "application/vnd.msgpack":
+		return contentTypeMsgpack
+	case contentTypeProtobuf, "application/protobuf", "application/vnd.google.protobuf":
+		return contentTypeProtobuf
+	default:
+		return contentTypeJSON
+	}
+}
+
+// negotiateContentType picks the response format from the Accept header,
+// honouring the first supported type listed. JSON is the default.
+func negotiateContentType(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		switch normalized := normalizeContentType(mediaType); {
+		case normalized != contentTypeJSON:
+			return normalized
+		case mediaType == contentTypeJSON:
+			return contentTypeJSON
+		}
+	}
+
+	return contentTypeJSON
+}
+
+// requestContentType returns the normalized Content-Type of the request body.
+func requestContentType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return contentTypeJSON
+	}
+
+	return normalizeContentType(mediaType)
+}
+
+// encodeResponse encodes envelope in contentType and returns the content type
+// actually used. Protobuf has a fixed schema per message, so only the data is
+// encoded; types without a protobuf encoding fall back to JSON.
+func encodeResponse(contentType string, envelope Envelope) (string, []byte, error) {
+	switch contentType {
+	case contentTypeMsgpack:
+		var buf bytes.Buffer
+		encoder := msgpack.NewEncoder(&buf)
+		// Reuse the JSON field names so every format has the same shape
+		encoder.SetCustomStructTag("json")
+		if err := encoder.Encode(envelope); err != nil {
+			return "", nil, err
+		}
+		return contentTypeMsgpack, buf.Bytes(), nil
+	case contentTypeProtobuf:
+		if message, ok := envelope.Data.(protoMarshaler); ok {
+			return contentTypeProtobuf, message.marshalProto(), nil
+		}
+	}
+
+	responseBytes, err := json.Marshal(envelope)
+	return contentTypeJSON, responseBytes, err
+}
@@ -0,0 +1,461 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Hand-written encoders for the messages in api/proto/workstream.proto.
+// Field numbers here must stay in sync with that file.
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendProtoMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func (j JobResponse) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, j.ID)
+	b = appendProtoString(b, 2, j.Type)
+	b = appendProtoString(b, 3, j.Status)
+	b = appendProtoString(b, 4, j.CreatedAt)
+	if j.Progress != nil {
+		b = appendProtoInt(b, 5, j.Progress.Percent)
+		b = appendProtoString(b, 6, j.Progress.Message)
+	}
+	b = appendProtoString(b, 7, j.LastError)
+	b = appendProtoString(b, 8, j.ErrorClass)
+	b = appendProtoString(b, 9, j.NextRetryAt)
+	b = appendProtoInt(b, 10, j.Attempts)
+	b = appendProtoInt(b, 11, j.MaxRetries)
+	b = appendProtoString(b, 12, j.RunAt)
+	b = appendProtoString(b, 13, j.Priority)
+	b = appendProtoString(b, 14, j.ConcurrencyKey)
+	b = appendProtoInt(b, 15, j.ConcurrencyLimit)
+	b = appendProtoString(b, 16, j.UniqueKey)
+	for _, id := range j.DependsOn {
+		b = appendProtoString(b, 17, id)
+	}
+	b = appendProtoString(b, 18, j.DependencyPolicy)
+	b = appendProtoString(b, 19, j.WorkflowID)
+	b = appendProtoString(b, 20, j.ParentID)
+	if j.Batch != nil {
+		b = appendProtoInt(b, 21, j.Batch.Total)
+		b = appendProtoInt(b, 22, j.Batch.Completed)
+		b = appendProtoInt(b, 23, j.Batch.Failed)
+	}
+	b = appendProtoString(b, 24, j.ClaimedBy)
+	b = appendProtoString(b, 25, j.ClaimedAt)
+	b = appendProtoString(b, 26, j.ExpiresAt)
+	b = appendProtoString(b, 27, j.ReplayedFrom)
+	b = appendProtoString(b, 28, j.Template)
+	b = appendProtoString(b, 29, j.Timeout)
+	b = appendProtoString(b, 30, j.Queue)
+	b = appendProtoInt(b, 31, j.Deliveries)
+	b = appendProtoBool(b, 32, j.Redelivered)
+	b = appendProtoInt(b, 33, j.Crashes)
+	return b
+}
+
+// JobListResponse is a list of jobs; it encodes as a bare JSON array.
+type JobListResponse []JobResponse
+
+func (l JobListResponse) marshalProto() []byte {
+	var b []byte
+	for _, job := range l {
+		b = appendProtoMessage(b, 1, job.marshalProto())
+	}
+	return b
+}
+
+func (v VersionResponse) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, v.Version)
+	b = appendProtoString(b, 2, v.GitSHA)
+	b = appendProtoString(b, 3, v.BuildDate)
+	b = appendProtoString(b, 4, v.GoVersion)
+	return b
+}
+
+func (g BuildInfoGauge) marshalProto() []byte {
+	var b []byte
+	b = appendProtoInt(b, 1, g.Value)
+	b = appendProtoMessage(b, 2, g.Labels.marshalProto())
+	return b
+}
+
+func (m MetricResponse) marshalProto() []byte {
+	var b []byte
+	b = appendProtoInt(b, 1, m.TotalJobsCreated)
+	b = appendProtoInt(b, 2, m.JobsCompleted)
+	b = appendProtoInt(b, 3, m.JobsFailed)
+	b = appendProtoInt(b, 4, m.JobsRetried)
+	b = appendProtoInt(b, 5, m.JobsInProgress)
+	b = appendProtoInt(b, 6, m.JobsCancelled)
+	b = appendProtoInt(b, 7, m.WorkerCount)
+	b = appendProtoInt(b, 8, m.QueueDepth)
+	b = appendProtoInt(b, 9, m.QueueCapacity)
+	b = appendProtoMessage(b, 10, m.BuildInfo.marshalProto())
+	b = appendProtoInt(b, 11, m.JobsPanicked)
+	b = appendProtoInt(b, 12, m.JobsDead)
+	for _, errorClass := range slices.Sorted(maps.Keys(m.FailuresByClass)) {
+		var entry []byte
+		entry = appendProtoString(entry, 1, errorClass)
+		entry = appendProtoInt(entry, 2, m.FailuresByClass[errorClass])
+		b = appendProtoMessage(b, 13, entry)
+	}
+	b = appendProtoInt(b, 14, m.WorkerScaleUps)
+	b = appendProtoInt(b, 15, m.WorkerScaleDowns)
+	b = appendProtoInt(b, 16, m.JobsReaped)
+	b = appendProtoInt(b, 17, m.JobsExpired)
+	b = appendProtoInt(b, 18, m.QueueEnqueued)
+	b = appendProtoInt(b, 19, m.QueueDequeued)
+	b = appendProtoInt(b, 20, m.QueueRejected)
+	b = appendProtoMessage(b, 21, m.QueueWaitSeconds.marshalProto())
+	for _, q := range m.Queues {
+		b = appendProtoMessage(b, 22, q.marshalProto())
+	}
+	b = appendProtoInt(b, 23, m.SweeperRuns)
+	b = appendProtoInt(b, 24, m.SweeperJobsRetried)
+	b = appendProtoInt(b, 25, m.SweeperJobsEnqueued)
+	b = appendProtoInt(b, 26, m.SweeperSkippedFull)
+	b = appendProtoDouble(b, 27, m.SweeperDurationSeconds)
+	b = appendProtoDouble(b, 28, m.SweeperLastDurationSeconds)
+	b = appendProtoInt(b, 29, m.JobsAwaitingRetry)
+	b = appendProtoInt(b, 30, m.QueueDepthAlerts)
+	b = appendProtoInt(b, 31, m.QueueDepthAlertsFiring)
+	b = appendProtoInt(b, 32, m.JobsStolen)
+	b = appendProtoHistogramMap(b, 33, m.JobDurationSeconds)
+	b = appendProtoHistogramMap(b, 34, m.JobWaitSeconds)
+	b = appendProtoHistogramMap(b, 35, m.JobProcessingSeconds)
+	for _, pool := range m.WorkerPools {
+		b = appendProtoMessage(b, 36, pool.marshalProto())
+	}
+	b = appendProtoInt(b, 37, m.SlowJobs)
+	b = appendProtoInt(b, 38, m.JobsQuarantined)
+	b = appendProtoInt(b, 39, m.SweeperRetriesDeferred)
+	return b
+}
+
+// appendProtoHistogramMap encodes a map<string, Histogram> field, ordering
+// the entries by key so the output is stable.
+func appendProtoHistogramMap(b []byte, field protowire.Number, histograms map[string]HistogramResponse) []byte {
+	for _, key := range slices.Sorted(maps.Keys(histograms)) {
+		var entry []byte
+		entry = appendProtoString(entry, 1, key)
+		entry = appendProtoMessage(entry, 2, histograms[key].marshalProto())
+		b = appendProtoMessage(b, field, entry)
+	}
+	return b
+}
+
+func (q QueueMetricResponse) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, q.Name)
+	b = appendProtoInt(b, 2, q.Depth)
+	b = appendProtoInt(b, 3, q.Capacity)
+	b = appendProtoInt(b, 4, q.Enqueued)
+	b = appendProtoInt(b, 5, q.Dequeued)
+	b = appendProtoInt(b, 6, q.Rejected)
+	b = appendProtoMessage(b, 7, q.WaitSeconds.marshalProto())
+	return b
+}
+
+func (p WorkerPoolMetricResponse) marshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, p.Queue)
+	b = appendProtoInt(b, 2, p.Size)
+	b = appendProtoInt(b, 3, p.Busy)
+	b = appendProtoDouble(b, 4, p.Saturation)
+	b = appendProtoDouble(b, 5, p.BusySeconds)
+	b = appendProtoDouble(b, 6, p.IdleSeconds)
+	return b
+}
+
+func (h HistogramResponse) marshalProto() []byte {
+	var b []byte
+	for _, bucket := range h.Buckets {
+		var entry []byte
+		entry = appendProtoDouble(entry, 1, bucket.UpperBound)
+		entry = appendProtoInt(entry, 2, bucket.Count)
+		b = appendProtoMessage(b, 1, entry)
+	}
+	b = appendProtoInt(b, 2, h.Count)
+	b = appendProtoDouble(b, 3, h.Sum)
+	return b
+}
+
+// unmarshalProto decodes a workstream.v1.CreateJobRequest. Unknown fields are
+// skipped so older servers accept newer clients.
+func (c *CreateJobRequest) unmarshalProto(b []byte) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Type = v
+			b = b[n:]
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if len(v) > 0 {
+				if !json.Valid(v) {
+					return errors.New("payload must be valid JSON")
+				}
+				c.Payload = json.RawMessage(append([]byte(nil), v...))
+			}
+			b = b[n:]
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			maxRetries := int(int64(v))
+			c.MaxRetries = &maxRetries
+			b = b[n:]
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Backoff = &BackoffRequest{}
+			if err := c.Backoff.unmarshalProto(v); err != nil {
+				return err
+			}
+			b = b[n:]
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.RunAt = v
+			b = b[n:]
+		case num == 6 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Delay = v
+			b = b[n:]
+		case num == 7 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Priority = v
+			b = b[n:]
+		case num == 8 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.ConcurrencyKey = v
+			b = b[n:]
+		case num == 9 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.ConcurrencyLimit = int(int64(v))
+			b = b[n:]
+		case num == 10 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.UniqueKey = v
+			b = b[n:]
+		case num == 11 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.OnDuplicate = v
+			b = b[n:]
+		case num == 12 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.DependsOn = append(c.DependsOn, v)
+			b = b[n:]
+		case num == 13 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.DependencyPolicy = v
+			b = b[n:]
+		case num == 14 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			var child ChildJobRequest
+			if err := child.unmarshalProto(v); err != nil {
+				return err
+			}
+			c.Children = append(c.Children, child)
+			b = b[n:]
+		case num == 15 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.ExpiresAt = v
+			b = b[n:]
+		case num == 16 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Template = v
+			b = b[n:]
+		case num == 18 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Queue = v
+			b = b[n:]
+		case num == 17 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Timeout = v
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+
+	return nil
+}
+
+func (b *BackoffRequest) unmarshalProto(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b.Policy = v
+			data = data[n:]
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b.BaseDelay = v
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+
+	return nil
+}
+
+func (c *ChildJobRequest) unmarshalProto(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			c.Type = v
+			data = data[n:]
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if len(v) > 0 {
+				if !json.Valid(v) {
+					return errors.New("child payload must be valid JSON")
+				}
+				c.Payload = json.RawMessage(append([]byte(nil), v...))
+			}
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// publishExpvarsOnce guards expvar.Publish, which panics on a second
+// registration of the same name.
+var publishExpvarsOnce sync.Once
+
+// DebugHandler reports on the Go runtime for profiling the server in
+// production.
+type DebugHandler struct {
+	logger    *slog.Logger
+	startedAt time.Time
+}
+
+func NewDebugHandler(logger *slog.Logger) *DebugHandler {
+	h := &DebugHandler{
+		logger:    logger,
+		startedAt: time.Now().UTC(),
+	}
+
+	// /debug/vars already carries memstats and cmdline
+	publishExpvarsOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() any {
+			return runtime.NumGoroutine()
+		}))
+		expvar.Publish("uptime_seconds", expvar.Func(func() any {
+			return time.Since(h.startedAt).Seconds()
+		}))
+	})
+
+	return h
+}
+
+type DebugResponse struct {
+	GoVersion     string            `json:"go_version"`
+	StartedAt     string            `json:"started_at"`
+	UptimeSeconds float64           `json:"uptime_seconds"`
+	Goroutines    int               `json:"goroutines"`
+	GOMAXPROCS    int               `json:"gomaxprocs"`
+	NumCPU        int               `json:"num_cpu"`
+	Heap          DebugHeapResponse `json:"heap"`
+	GC            DebugGCResponse   `json:"gc"`
+}
+
+type DebugHeapResponse struct {
+	// Bytes of live and not yet swept heap objects
+	AllocBytes uint64 `json:"alloc_bytes"`
+	// Bytes in in-use spans
+	InuseBytes    uint64 `json:"inuse_bytes"`
+	IdleBytes     uint64 `json:"idle_bytes"`
+	ReleasedBytes uint64 `json:"released_bytes"`
+	// Bytes of heap memory obtained from the OS
+	SysBytes uint64 `json:"sys_bytes"`
+	Objects  uint64 `json:"objects"`
+	// Cumulative bytes allocated, including freed objects
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	// Total bytes obtained from the OS by the runtime, heap or not
+	RuntimeSysBytes uint64 `json:"runtime_sys_bytes"`
+	// GOMEMLIMIT, or math.MaxInt64 when unset
+	MemoryLimitBytes int64 `json:"memory_limit_bytes"`
+}
+
+type DebugGCResponse struct {
+	Cycles uint32 `json:"cycles"`
+	// Heap size at which the next cycle starts
+	NextTargetBytes uint64  `json:"next_target_bytes"`
+	LastAt          string  `json:"last_at,omitempty"`
+	LastPauseMs     float64 `json:"last_pause_ms"`
+	PauseTotalMs    float64 `json:"pause_total_ms"`
+	// Share of CPU time spent in GC since the server started
+	CPUFraction float64 `json:"cpu_fraction"`
+	// GOGC; 0 when the collector is off
+	Percent int `json:"percent"`
+}
+
+// Summary reports goroutine, heap and GC figures. Reading them briefly stops
+// the world, so it is meant for people, not for scraping; use /metrics for
+// that.
+func (h *DebugHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	settings := []metrics.Sample{
+		{Name: "/gc/gogc:percent"},
+		{Name: "/gc/gomemlimit:bytes"},
+	}
+	metrics.Read(settings)
+
+	response := DebugResponse{
+		GoVersion:     runtime.Version(),
+		StartedAt:     h.startedAt.Format(time.RFC3339),
+		UptimeSeconds: time.Since(h.startedAt).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		Heap: DebugHeapResponse{
+			AllocBytes:       stats.HeapAlloc,
+			InuseBytes:       stats.HeapInuse,
+			IdleBytes:        stats.HeapIdle,
+			ReleasedBytes:    stats.HeapReleased,
+			SysBytes:         stats.HeapSys,
+			Objects:          stats.HeapObjects,
+			TotalAllocBytes:  stats.TotalAlloc,
+			RuntimeSysBytes:  stats.Sys,
+			MemoryLimitBytes: int64(settings[1].Value.Uint64()),
+		},
+		GC: DebugGCResponse{
+			Cycles:          stats.NumGC,
+			NextTargetBytes: stats.NextGC,
+			PauseTotalMs:    float64(stats.PauseTotalNs) / float64(time.Millisecond),
+			CPUFraction:     stats.GCCPUFraction,
+			Percent:         int(settings[0].Value.Uint64()),
+		},
+	}
+
+	if stats.NumGC > 0 {
+		response.GC.LastAt = time.Unix(0, int64(stats.LastGC)).UTC().Format(time.RFC3339Nano)
+		response.GC.LastPauseMs = float64(stats.PauseNs[(stats.NumGC+255)%256]) / float64(time.Millisecond)
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// DebugMux serves net/http/pprof under /debug/pprof/, expvar at /debug/vars
+// and the runtime summary at /admin/debug. Profiles can run for as long as
+// their seconds parameter asks, so the mux is meant for its own listener
+// without the API's write timeout, and not for the public port.
+func DebugMux(h *DebugHandler) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	mux.HandleFunc("GET /admin/debug", h.Summary)
+
+	return mux
+}
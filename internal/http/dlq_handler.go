@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// DLQHandler exposes the dead-letter queue: jobs that exhausted their retries.
+type DLQHandler struct {
+	store    store.JobStore
+	events   *events.Bus
+	logger   *slog.Logger
+	jobQueue queue.Queue
+}
+
+func NewDLQHandler(store store.JobStore, bus *events.Bus, logger *slog.Logger, jobQueue queue.Queue) *DLQHandler {
+	return &DLQHandler{
+		store:    store,
+		events:   bus,
+		logger:   logger,
+		jobQueue: jobQueue,
+	}
+}
+
+// ListDeadJobs returns every job in the dead-letter queue.
+func (h *DLQHandler) ListDeadJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.store.GetDeadJobs(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get dead jobs")
+		return
+	}
+
+	response := make(JobListResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, jobToResponse(&job))
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// RequeueDeadJob moves a dead job back to pending with its attempts reset
+// and enqueues it.
+func (h *DLQHandler) RequeueDeadJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	if err := h.store.RequeueDeadJob(r.Context(), jobID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrJobNotFound):
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrInvalidTransition):
+			ErrorResponse(w, "Job is not in the dead-letter queue", http.StatusConflict)
+		default:
+			StoreErrorResponse(w, err, "Failed to requeue job")
+		}
+		return
+	}
+	h.logger.Info("Dead job requeued", "event", "dead_job_requeued", "job_id", jobID)
+
+	h.events.Publish(r.Context(), events.Event{Type: events.JobResurrected, JobID: jobID})
+
+	switch err := h.jobQueue.Enqueue(r.Context(), jobID); {
+	case err == nil:
+		h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", jobID)
+	case errors.Is(err, queue.ErrPaused):
+		// Enqueued by the catch-up on resume
+		h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", jobID)
+	default:
+		// Job stays pending; the sweeper will enqueue it once there is room
+		h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", jobID, "error", err)
+	}
+
+	job, err := h.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	if err := WriteResponse(w, r, jobToResponse(job), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
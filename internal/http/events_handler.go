@@ -0,0 +1,294 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// streamPollInterval is how often the store is checked for job changes.
+const streamPollInterval = 500 * time.Millisecond
+
+// eventStreamBuffer is how many events a GET /events client may fall behind
+// by before its stream is ended; it can reconnect and carry on.
+const eventStreamBuffer = 256
+
+// StreamJob sends the job as a Server-Sent Event each time it changes
+// (status, progress, ...) until it reaches a final state or the client
+// disconnects.
+func (h *JobHandler) StreamJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		ErrorResponse(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := h.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, store.ErrJobNotFound) {
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	lastVersion := 0
+	for {
+		if job.Version != lastVersion {
+			if err := writeJobEvent(w, job); err != nil {
+				h.logger.Error("Failed to write event", "event", "stream_error", "job_id", jobID, "error", err)
+				return
+			}
+			flusher.Flush()
+			lastVersion = job.Version
+		}
+
+		if job.Status == domain.StatusCompleted || job.Status == domain.StatusCancelled || job.Status == domain.StatusExpired {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		job, err = h.store.GetJob(r.Context(), jobID)
+		if err != nil {
+			// Deleted or store unavailable: end the stream, the client may reconnect
+			return
+		}
+	}
+}
+
+func writeJobEvent(w http.ResponseWriter, job *domain.Job) error {
+	data, err := json.Marshal(jobToResponse(job))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: job\ndata: %s\n\n", job.Version, data)
+	return err
+}
+
+// EventHandler streams the events published on the bus to clients, and
+// serves the ones kept in the event log to clients catching up.
+type EventHandler struct {
+	bus      *events.Bus
+	eventLog store.EventLog
+	logger   *slog.Logger
+}
+
+func NewEventHandler(bus *events.Bus, eventLog store.EventLog, logger *slog.Logger) *EventHandler {
+	return &EventHandler{
+		bus:      bus,
+		eventLog: eventLog,
+		logger:   logger,
+	}
+}
+
+const (
+	defaultEventHistoryLimit = 100
+	maxEventHistoryLimit     = 1000
+)
+
+type EventHistoryResponse struct {
+	Events []events.Event `json:"events"`
+	// Cursor is the since of the next page: the ID of the last event
+	// returned, or the since asked for when there were none
+	Cursor  int64 `json:"cursor"`
+	HasMore bool  `json:"has_more"`
+}
+
+// eventFilterFromQuery reads the ?type= (comma-separated event types) and
+// ?job_type= filters shared by the stream and the history.
+func eventFilterFromQuery(r *http.Request) store.EventFilter {
+	filter := store.EventFilter{
+		Types:   make(map[events.Type]bool),
+		JobType: r.URL.Query().Get("job_type"),
+	}
+	for _, t := range strings.Split(r.URL.Query().Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types[events.Type(t)] = true
+		}
+	}
+	return filter
+}
+
+// parseEventID parses an event ID given by a client; zero is the start of
+// the log.
+func parseEventID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid event ID")
+	}
+	return id, nil
+}
+
+// GetEventHistory returns kept events after ?since=, oldest first, filtered
+// like the stream.
+func (h *EventHandler) GetEventHistory(w http.ResponseWriter, r *http.Request) {
+	filter := eventFilterFromQuery(r)
+	filter.Limit = defaultEventHistoryLimit
+
+	query := r.URL.Query()
+	if value := query.Get("since"); value != "" {
+		since, err := parseEventID(value)
+		if err != nil {
+			ErrorResponse(w, "since must be an event ID", http.StatusBadRequest)
+			return
+		}
+		filter.After = since
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxEventHistoryLimit {
+			ErrorResponse(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	history, hasMore, err := h.eventLog.GetEvents(r.Context(), filter)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get event history")
+		return
+	}
+
+	response := EventHistoryResponse{
+		Events:  history,
+		Cursor:  filter.After,
+		HasMore: hasMore,
+	}
+	if len(history) > 0 {
+		response.Cursor = history[len(history)-1].ID
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// StreamEvents sends every event published from now on as a Server-Sent
+// Event, until the client disconnects. ?type= (comma-separated event types)
+// and ?job_type= narrow the stream. A client resuming with Last-Event-ID, or
+// ?since=, first gets the kept events it missed.
+func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		ErrorResponse(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := eventFilterFromQuery(r)
+
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("since")
+	}
+	if resume != "" {
+		after, err := parseEventID(resume)
+		if err != nil {
+			ErrorResponse(w, "Last-Event-ID and since must be an event ID", http.StatusBadRequest)
+			return
+		}
+		filter.After = after
+	}
+
+	pending := make(chan events.Event, eventStreamBuffer)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	unsubscribe := h.bus.SubscribeOrdered(events.SubscriberFunc(func(ctx context.Context, event events.Event) {
+		if !filter.Matches(event) {
+			return
+		}
+
+		select {
+		case pending <- event:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	}))
+	defer unsubscribe()
+
+	// Subscribed first, so nothing published while the history is read is
+	// missed; live events already replayed are skipped below
+	var missed []events.Event
+	if resume != "" {
+		var err error
+		missed, _, err = h.eventLog.GetEvents(r.Context(), filter)
+		if err != nil {
+			StoreErrorResponse(w, err, "Failed to get event history")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	lastSent := filter.After
+	for _, event := range missed {
+		if err := h.writeEvent(w, event); err != nil {
+			return
+		}
+		lastSent = event.ID
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-overflow:
+			h.logger.Warn("Event stream client fell behind, ending stream", "event", "event_stream_overflow")
+			return
+		case event := <-pending:
+			if event.ID <= lastSent {
+				continue
+			}
+			if err := h.writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *EventHandler) writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Failed to encode event", "event", "stream_error", "error", err)
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// exportFlushEvery controls how many records are written between flushes so
+// clients receive data progressively over chunked transfer encoding.
+const exportFlushEvery = 100
+
+var exportCSVHeader = []string{"id", "type", "status", "attempts", "max_retries", "last_error", "created_at"}
+
+type ExportJobRecord struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	Attempts   int             `json:"attempts"`
+	MaxRetries int             `json:"max_retries"`
+	LastError  *string         `json:"last_error"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+func jobToExportRecord(job *domain.Job) ExportJobRecord {
+	return ExportJobRecord{
+		ID:         job.ID,
+		Type:       job.Type,
+		Status:     string(job.Status),
+		Attempts:   job.Attempts,
+		MaxRetries: job.MaxRetries,
+		LastError:  job.LastError,
+		Payload:    job.Payload,
+		CreatedAt:  job.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ExportJobs streams jobs matching ?status= and ?type= as NDJSON (default) or
+// CSV. Records are encoded one at a time straight to the response.
+func (h *JobHandler) ExportJobs(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		ErrorResponse(w, "format must be ndjson or csv", http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := h.store.GetJobs(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get jobs")
+		return
+	}
+
+	filter := jobFilterFromRequest(r)
+	flusher, _ := w.(http.Flusher)
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="jobs.csv"`)
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="jobs.ndjson"`)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+
+	if format == "csv" {
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			h.logger.Error("Failed to write export", "event", "export_error", "error", err)
+			return
+		}
+	}
+
+	written := 0
+	for _, job := range jobs {
+		if !filter.matches(&job) {
+			continue
+		}
+
+		// Stop early if the client went away
+		if r.Context().Err() != nil {
+			return
+		}
+
+		if format == "csv" {
+			err = csvWriter.Write(jobToCSVRow(&job))
+		} else {
+			err = encoder.Encode(jobToExportRecord(&job))
+		}
+		if err != nil {
+			h.logger.Error("Failed to write export", "event", "export_error", "error", err)
+			return
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			csvWriter.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		h.logger.Error("Failed to write export", "event", "export_error", "error", err)
+		return
+	}
+
+	h.logger.Info("Jobs exported", "event", "jobs_exported", "format", format, "count", written)
+}
+
+func jobToCSVRow(job *domain.Job) []string {
+	lastError := ""
+	if job.LastError != nil {
+		lastError = *job.LastError
+	}
+
+	return []string{
+		job.ID,
+		job.Type,
+		string(job.Status),
+		strconv.Itoa(job.Attempts),
+		strconv.Itoa(job.MaxRetries),
+		lastError,
+		job.CreatedAt.Format(time.RFC3339),
+	}
+}
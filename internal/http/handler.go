@@ -1,29 +1,189 @@
 package http
 
 import (
-	"encoding/json"
+	"context"
+	"log/slog"
 	"net/http"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/recovery"
+	"github.com/karprabha/job-queue-backend/internal/store"
 )
 
+type HealthHandler struct {
+	store       store.JobStore
+	metricStore store.MetricStore
+	jobQueue    queue.Queue
+	logger      *slog.Logger
+	shutdownCtx context.Context
+	recovery    *recovery.Progress
+}
+
+func NewHealthHandler(store store.JobStore, metricStore store.MetricStore, jobQueue queue.Queue, recoveryProgress *recovery.Progress, logger *slog.Logger, shutdownCtx context.Context) *HealthHandler {
+	return &HealthHandler{
+		store:       store,
+		metricStore: metricStore,
+		jobQueue:    jobQueue,
+		logger:      logger,
+		shutdownCtx: shutdownCtx,
+		recovery:    recoveryProgress,
+	}
+}
+
 type HealthCheckResponse struct {
 	Status string `json:"status"`
 }
 
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Critical  bool    `json:"critical"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type DependencyHealthResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+const (
+	healthStatusHealthy   = "healthy"
+	healthStatusDegraded  = "degraded"
+	healthStatusUnhealthy = "unhealthy"
+)
+
+// Liveness reports that the process is up and serving HTTP.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
 	responseData := HealthCheckResponse{
 		Status: "ok",
 	}
 
-	jsonBytes, err := json.Marshal(responseData)
-	if err != nil {
-		ErrorResponse(w, "Failed to marshal response", http.StatusInternalServerError)
+	if err := WriteResponse(w, r, responseData, http.StatusOK); err != nil {
+		return
+	}
+}
+
+// Readiness reports whether the server should receive traffic: the store is
+// reachable, startup recovery has finished and shutdown has not begun.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ready := true
+	checks := make(map[string]string, 3)
+
+	pingCtx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.store.Ping(pingCtx); err != nil {
+		ready = false
+		checks["store"] = err.Error()
+	} else {
+		checks["store"] = "ok"
+	}
+
+	// Liveness doesn't wait for recovery, so a long one isn't mistaken for a
+	// hung process
+	switch status := h.recovery.Status(); status.State {
+	case recovery.StateCompleted:
+		checks["recovery"] = "ok"
+	case recovery.StateFailed:
+		ready = false
+		checks["recovery"] = status.Error
+	default:
+		ready = false
+		checks["recovery"] = "in progress"
+	}
+
+	select {
+	case <-h.shutdownCtx.Done():
+		ready = false
+		checks["shutdown"] = "in progress"
+	default:
+		checks["shutdown"] = "ok"
+	}
+
+	responseData := ReadinessResponse{
+		Status: "ready",
+		Checks: checks,
+	}
+	statusCode := http.StatusOK
+	if !ready {
+		responseData.Status = "not ready"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	if err := WriteResponse(w, r, responseData, statusCode); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
 		return
 	}
+}
+
+// Health pings every dependency and reports per-dependency status and latency.
+// A failing critical dependency makes the service unhealthy (503); a failing
+// non-critical one only degrades it.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	dependencies := map[string]DependencyStatus{
+		"job_store":    checkDependency(r.Context(), h.store.Ping, true),
+		"metric_store": checkDependency(r.Context(), h.metricStore.Ping, false),
+		// Pings the broker for broker-backed queues; in-process ones are
+		// always healthy
+		"queue": checkDependency(r.Context(), func(ctx context.Context) error {
+			return queue.Ping(ctx, h.jobQueue)
+		}, true),
+	}
+
+	overall := healthStatusHealthy
+	for name, dependency := range dependencies {
+		if dependency.Status == healthStatusHealthy {
+			continue
+		}
+
+		h.logger.Warn("Dependency check failed", "event", "dependency_unhealthy", "dependency", name, "error", dependency.Error)
+
+		if dependency.Critical {
+			overall = healthStatusUnhealthy
+		} else if overall == healthStatusHealthy {
+			overall = healthStatusDegraded
+		}
+	}
+
+	statusCode := http.StatusOK
+	if overall == healthStatusUnhealthy {
+		statusCode = http.StatusServiceUnavailable
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	response := DependencyHealthResponse{
+		Status:       overall,
+		Dependencies: dependencies,
+	}
 
-	if _, err := w.Write(jsonBytes); err != nil {
+	if err := WriteResponse(w, r, response, statusCode); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
 		return
 	}
 }
+
+func checkDependency(ctx context.Context, ping func(context.Context) error, critical bool) DependencyStatus {
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(pingCtx)
+	latency := time.Since(start)
+
+	dependency := DependencyStatus{
+		Status:    healthStatusHealthy,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		Critical:  critical,
+	}
+	if err != nil {
+		dependency.Status = healthStatusUnhealthy
+		dependency.Error = err.Error()
+	}
+
+	return dependency
+}
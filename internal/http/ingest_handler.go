@@ -0,0 +1,102 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/karprabha/job-queue-backend/internal/config"
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// IngestHandler turns signed third-party webhooks into jobs, so external
+// systems can enqueue work without a custom client.
+type IngestHandler struct {
+	sources      map[string]config.IngestSource
+	jobs         *JobHandler
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewIngestHandler(sources []config.IngestSource, jobs *JobHandler, logger *slog.Logger, maxBodyBytes int64) *IngestHandler {
+	sourcesByName := make(map[string]config.IngestSource, len(sources))
+	for _, source := range sources {
+		sourcesByName[source.Name] = source
+	}
+
+	return &IngestHandler{
+		sources:      sourcesByName,
+		jobs:         jobs,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// Ingest verifies the HMAC-SHA256 signature of the raw body and creates a job
+// of the source's mapped type with the webhook body as payload.
+func (h *IngestHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("source")
+
+	source, ok := h.sources[name]
+	if !ok {
+		ErrorResponse(w, "Unknown ingest source", http.StatusNotFound)
+		return
+	}
+
+	if source.Secret == "" {
+		h.logger.Error("Ingest source has no signing secret", "event", "ingest_misconfigured", "source", name)
+		ErrorResponse(w, "Ingest source is not configured", http.StatusForbidden)
+		return
+	}
+
+	if !h.jobs.acceptingJobs(w) {
+		return
+	}
+
+	body, err := readBody(w, r, h.maxBodyBytes)
+	if err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	if !validSignature(source.Secret, body, r.Header.Get(source.SignatureHeader)) {
+		h.logger.Warn("Webhook signature rejected", "event", "ingest_signature_invalid", "source", name)
+		ErrorResponse(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if !json.Valid(body) {
+		ErrorResponse(w, "Webhook payload must be valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Webhook received", "event", "webhook_received", "source", name, "job_type", source.JobType)
+
+	if !h.jobs.validPayload(w, source.JobType, body) {
+		return
+	}
+
+	job := domain.NewJob(source.JobType, json.RawMessage(body))
+
+	h.jobs.submitJob(w, r, job, false)
+}
+
+// validSignature checks a hex HMAC-SHA256 signature, with or without the
+// "sha256=" prefix used by GitHub and others.
+func validSignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+
+	provided, err := hex.DecodeString(signature)
+	if err != nil || len(provided) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(provided, mac.Sum(nil))
+}
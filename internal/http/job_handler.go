@@ -3,159 +3,1154 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/drain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/schema"
 	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/tracing"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 type JobHandler struct {
-	store         store.JobStore
-	metricStore   store.MetricStore
-	logger        *slog.Logger
-	jobQueue      chan string
-	shutdownCtx   context.Context
+	store        store.JobStore
+	events       *events.Bus
+	logStore     store.LogStore
+	logger       *slog.Logger
+	jobQueue     queue.Queue
+	shutdownCtx  context.Context
+	drain        *drain.Controller
+	running      *worker.RunningJobs
+	schemas      *schema.Registry
+	templates    store.TemplateStore
+	maxBodyBytes int64
 }
 
-func NewJobHandler(store store.JobStore, metricStore store.MetricStore, logger *slog.Logger, jobQueue chan string, shutdownCtx context.Context) *JobHandler {
+func NewJobHandler(store store.JobStore, bus *events.Bus, logStore store.LogStore, logger *slog.Logger, jobQueue queue.Queue, shutdownCtx context.Context, drain *drain.Controller, running *worker.RunningJobs, schemas *schema.Registry, templates store.TemplateStore, maxBodyBytes int64) *JobHandler {
 	return &JobHandler{
-		store:       store,
-		metricStore: metricStore,
-		logger:      logger,
-		jobQueue:    jobQueue,
-		shutdownCtx: shutdownCtx,
+		store:        store,
+		events:       bus,
+		logStore:     logStore,
+		logger:       logger,
+		jobQueue:     jobQueue,
+		shutdownCtx:  shutdownCtx,
+		drain:        drain,
+		running:      running,
+		schemas:      schemas,
+		templates:    templates,
+		maxBodyBytes: maxBodyBytes,
 	}
 }
 
 type CreateJobRequest struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	// Template names a registered template supplying the type and defaults
+	// for anything not set here; the payload is merged over its payload
+	Template   string          `json:"template,omitempty"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries *int            `json:"max_retries,omitempty"`
+	Backoff    *BackoffRequest `json:"backoff,omitempty"`
+	// Timeout (Go duration) overrides the job type's execution timeout
+	Timeout string `json:"timeout,omitempty"`
+	// RunAt (RFC 3339) or Delay (Go duration) postpones the job; at most one may be set
+	RunAt string `json:"run_at,omitempty"`
+	Delay string `json:"delay,omitempty"`
+	// ExpiresAt (RFC 3339) is when the job expires if it has not started
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// Queue names the queue to run the job on instead of its type's route
+	Queue string `json:"queue,omitempty"`
+	// Priority is high, normal or low; it defaults to the X-Job-Priority header
+	Priority string `json:"priority,omitempty"`
+	// Jobs sharing ConcurrencyKey run at most ConcurrencyLimit (default 1) at a time
+	ConcurrencyKey   string `json:"concurrency_key,omitempty"`
+	ConcurrencyLimit int    `json:"concurrency_limit,omitempty"`
+	// While a job with UniqueKey is pending or processing, resubmitting it
+	// returns that job, or 409 when OnDuplicate is "reject"
+	UniqueKey   string `json:"unique_key,omitempty"`
+	OnDuplicate string `json:"on_duplicate,omitempty"`
+	// The job stays blocked until every job in DependsOn completes.
+	// DependencyPolicy is "fail" (default) or "hold"
+	DependsOn        []string `json:"depends_on,omitempty"`
+	DependencyPolicy string   `json:"dependency_policy,omitempty"`
+	// Children turns the job into a batch parent that completes once every
+	// child job has finished
+	Children []ChildJobRequest `json:"children,omitempty"`
 }
+
+// ChildJobRequest is one job in a batch; Type defaults to the parent's type.
+type ChildJobRequest struct {
+	Type    string          `json:"type,omitempty" msgpack:"type"`
+	Payload json.RawMessage `json:"payload,omitempty" msgpack:"payload"`
+}
+
+// maxBatchChildren bounds how many child jobs a batch submission creates.
+const maxBatchChildren = 10000
+
+const (
+	onDuplicateReturnExisting = "return_existing"
+	onDuplicateReject         = "reject"
+)
+
+// BackoffRequest overrides the default retry backoff for a job.
+type BackoffRequest struct {
+	Policy    string `json:"policy" msgpack:"policy"`
+	BaseDelay string `json:"base_delay" msgpack:"base_delay"`
+}
+
+// maxRetriesLimit bounds max_retries so a job can't be retried forever.
+const maxRetriesLimit = 25
+
 type JobResponse struct {
-	ID        string `json:"id"`
-	Type      string `json:"type"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	Status      string            `json:"status"`
+	CreatedAt   string            `json:"created_at"`
+	Progress    *ProgressResponse `json:"progress,omitempty"`
+	LastError   string            `json:"last_error,omitempty"`
+	ErrorClass  string            `json:"error_class,omitempty"`
+	NextRetryAt string            `json:"next_retry_at,omitempty"`
+	Attempts    int               `json:"attempts"`
+	MaxRetries  int               `json:"max_retries"`
+	Timeout     string            `json:"timeout,omitempty"`
+	Template    string            `json:"template,omitempty"`
+	Queue       string            `json:"queue,omitempty"`
+	RunAt       string            `json:"run_at,omitempty"`
+	ExpiresAt   string            `json:"expires_at,omitempty"`
+	Priority    string            `json:"priority"`
+	// ConcurrencyKey and ConcurrencyLimit are only set for keyed jobs
+	ConcurrencyKey   string `json:"concurrency_key,omitempty"`
+	ConcurrencyLimit int    `json:"concurrency_limit,omitempty"`
+	UniqueKey        string `json:"unique_key,omitempty"`
+	// DependsOn and DependencyPolicy are only set for jobs with dependencies
+	DependsOn        []string `json:"depends_on,omitempty"`
+	DependencyPolicy string   `json:"dependency_policy,omitempty"`
+	WorkflowID       string   `json:"workflow_id,omitempty"`
+	ParentID         string   `json:"parent_id,omitempty"`
+	ReplayedFrom     string   `json:"replayed_from,omitempty"`
+	// TraceID is the trace of the request that submitted the job, which its
+	// attempts' spans and logs carry too
+	TraceID string `json:"trace_id,omitempty"`
+	// ClaimedBy and ClaimedAt describe the worker that claimed the latest attempt
+	ClaimedBy string `json:"claimed_by,omitempty"`
+	ClaimedAt string `json:"claimed_at,omitempty"`
+	// Deliveries counts every claim; Redelivered is set once one ended
+	// without an outcome, so the job may have run before
+	Deliveries  int  `json:"deliveries"`
+	Redelivered bool `json:"redelivered,omitempty"`
+	// Crashes counts the latest attempts in a row that panicked, timed out
+	// or lost their worker; enough of them quarantine the job
+	Crashes int `json:"crashes,omitempty"`
+	// Batch is only set on batch parents
+	Batch *BatchResponse `json:"batch,omitempty"`
+}
+
+type BatchResponse struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+type ProgressResponse struct {
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
+}
+
+type JobResultResponse struct {
+	ID     string          `json:"id"`
+	Result json.RawMessage `json:"result"`
+}
+
+type AttemptLogResponse struct {
+	Attempt   int                `json:"attempt"`
+	Truncated bool               `json:"truncated"`
+	Entries   []LogEntryResponse `json:"entries"`
+}
+
+type LogEntryResponse struct {
+	Time    string            `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+type UpdateProgressRequest struct {
+	// Token is the execution token of the lease reporting progress
+	Token   string `json:"token"`
+	Percent int    `json:"percent"`
+	Message string `json:"message"`
+}
+
+// decodeCreateJobRequest parses a POST /jobs body in JSON, MessagePack or
+// protobuf depending on the request Content-Type.
+func (h *JobHandler) decodeCreateJobRequest(w http.ResponseWriter, r *http.Request) (CreateJobRequest, error) {
+	var request CreateJobRequest
+
+	contentType := requestContentType(r)
+	if contentType == contentTypeJSON {
+		err := decodeJSONBody(w, r, h.maxBodyBytes, &request)
+		return request, err
+	}
+
+	body, err := readBody(w, r, h.maxBodyBytes)
+	if err != nil {
+		return request, err
+	}
+
+	switch contentType {
+	case contentTypeMsgpack:
+		var decoded struct {
+			Template         string          `msgpack:"template"`
+			Type             string          `msgpack:"type"`
+			Payload          any             `msgpack:"payload"`
+			MaxRetries       *int            `msgpack:"max_retries"`
+			Backoff          *BackoffRequest `msgpack:"backoff"`
+			Timeout          string          `msgpack:"timeout"`
+			RunAt            string          `msgpack:"run_at"`
+			Delay            string          `msgpack:"delay"`
+			ExpiresAt        string          `msgpack:"expires_at"`
+			Queue            string          `msgpack:"queue"`
+			Priority         string          `msgpack:"priority"`
+			ConcurrencyKey   string          `msgpack:"concurrency_key"`
+			ConcurrencyLimit int             `msgpack:"concurrency_limit"`
+			UniqueKey        string          `msgpack:"unique_key"`
+			OnDuplicate      string          `msgpack:"on_duplicate"`
+			DependsOn        []string        `msgpack:"depends_on"`
+			DependencyPolicy string          `msgpack:"dependency_policy"`
+			Children         []struct {
+				Type    string `msgpack:"type"`
+				Payload any    `msgpack:"payload"`
+			} `msgpack:"children"`
+		}
+		if err := msgpack.Unmarshal(body, &decoded); err != nil {
+			return request, err
+		}
+
+		request.Template = decoded.Template
+		request.Type = decoded.Type
+		request.MaxRetries = decoded.MaxRetries
+		request.Backoff = decoded.Backoff
+		request.Timeout = decoded.Timeout
+		request.RunAt = decoded.RunAt
+		request.Delay = decoded.Delay
+		request.ExpiresAt = decoded.ExpiresAt
+		request.Queue = decoded.Queue
+		request.Priority = decoded.Priority
+		request.ConcurrencyKey = decoded.ConcurrencyKey
+		request.ConcurrencyLimit = decoded.ConcurrencyLimit
+		request.UniqueKey = decoded.UniqueKey
+		request.OnDuplicate = decoded.OnDuplicate
+		request.DependsOn = decoded.DependsOn
+		request.DependencyPolicy = decoded.DependencyPolicy
+		for _, decodedChild := range decoded.Children {
+			child := ChildJobRequest{Type: decodedChild.Type}
+			if decodedChild.Payload != nil {
+				payload, err := json.Marshal(decodedChild.Payload)
+				if err != nil {
+					return request, err
+				}
+				child.Payload = payload
+			}
+			request.Children = append(request.Children, child)
+		}
+		if decoded.Payload != nil {
+			// Payloads are stored as JSON regardless of the wire format
+			payload, err := json.Marshal(decoded.Payload)
+			if err != nil {
+				return request, err
+			}
+			request.Payload = payload
+		}
+	case contentTypeProtobuf:
+		if err := request.unmarshalProto(body); err != nil {
+			return request, err
+		}
+	}
+
+	return request, nil
 }
 
 func jobToResponse(job *domain.Job) JobResponse {
-	return JobResponse{
-		ID:        job.ID,
-		Type:      job.Type,
-		Status:    string(job.Status),
-		CreatedAt: job.CreatedAt.Format(time.RFC3339),
+	response := JobResponse{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      string(job.Status),
+		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
+		Attempts:    job.Attempts,
+		MaxRetries:  job.MaxRetries,
+		Deliveries:  job.Deliveries,
+		Redelivered: job.Redelivered,
+		Crashes:     job.Crashes,
+		Priority:    job.Priority.String(),
+		Template:    job.Template,
+		Queue:       job.Queue,
+		TraceID:     tracing.TraceID(job),
+	}
+
+	if job.Timeout > 0 {
+		response.Timeout = job.Timeout.String()
+	}
+
+	if job.ConcurrencyKey != "" {
+		response.ConcurrencyKey = job.ConcurrencyKey
+		response.ConcurrencyLimit = job.ConcurrencyLimit
+	}
+
+	response.UniqueKey = job.UniqueKey
+
+	if len(job.DependsOn) > 0 {
+		response.DependsOn = job.DependsOn
+		response.DependencyPolicy = string(job.DependencyPolicy)
+	}
+
+	response.WorkflowID = job.WorkflowID
+	response.ParentID = job.ParentID
+	response.ReplayedFrom = job.ReplayedFrom
+	response.ClaimedBy = job.ClaimedBy
+
+	if job.ClaimedAt != nil {
+		response.ClaimedAt = job.ClaimedAt.Format(time.RFC3339)
+	}
+
+	if job.Batch != nil {
+		response.Batch = &BatchResponse{
+			Total:     job.Batch.Total,
+			Completed: job.Batch.Completed,
+			Failed:    job.Batch.Failed,
+		}
+	}
+
+	if job.LastError != nil {
+		response.LastError = *job.LastError
+		response.ErrorClass = job.ErrorClass
+	}
+
+	if job.NextRetryAt != nil {
+		response.NextRetryAt = job.NextRetryAt.Format(time.RFC3339)
+	}
+
+	if job.RunAt != nil {
+		response.RunAt = job.RunAt.Format(time.RFC3339)
+	}
+
+	if job.ExpiresAt != nil {
+		response.ExpiresAt = job.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if job.ProgressPercent > 0 || job.ProgressMessage != "" {
+		response.Progress = &ProgressResponse{
+			Percent: job.ProgressPercent,
+			Message: job.ProgressMessage,
+		}
+	}
+
+	return response
+}
+
+// jobFilter holds the query-string filters shared by job listing endpoints.
+type jobFilter struct {
+	status  string
+	jobType string
+}
+
+func jobFilterFromRequest(r *http.Request) jobFilter {
+	return jobFilter{
+		status:  r.URL.Query().Get("status"),
+		jobType: r.URL.Query().Get("type"),
+	}
+}
+
+func (f jobFilter) matches(job *domain.Job) bool {
+	if f.status != "" && string(job.Status) != f.status {
+		return false
+	}
+	if f.jobType != "" && job.Type != f.jobType {
+		return false
 	}
+	return true
 }
 
 func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	if !h.acceptingJobs(w) {
+		return
+	}
+
+	request, err := h.decodeCreateJobRequest(w, r)
+	if err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	var template *domain.Template
+	if request.Template != "" {
+		template, err = h.templates.GetTemplate(r.Context(), request.Template)
+		if err != nil {
+			if errors.Is(err, store.ErrTemplateNotFound) {
+				ErrorResponse(w, fmt.Sprintf("Template %q not found", request.Template), http.StatusBadRequest)
+				return
+			}
+			StoreErrorResponse(w, err, "Failed to get template")
+			return
+		}
+
+		if request.Type != "" && request.Type != template.JobType {
+			ErrorResponse(w, fmt.Sprintf("type must be omitted or match the template's type %q", template.JobType), http.StatusBadRequest)
+			return
+		}
+		request.Type = template.JobType
+		request.Payload = template.MergePayload(request.Payload)
+	}
+
+	if request.Type == "" {
+		ErrorResponse(w, "Job type is required and must be non-empty", http.StatusBadRequest)
+		return
+	}
+
+	// A batch parent's payload is never handled, so only its children are
+	// checked against their schemas
+	if len(request.Children) == 0 && !h.validPayload(w, request.Type, request.Payload) {
+		return
+	}
+
+	job := domain.NewJob(request.Type, request.Payload)
+	if template != nil {
+		template.Apply(job)
+	}
+
+	if request.Queue != "" {
+		if !queue.Exists(h.jobQueue, request.Queue) {
+			ErrorResponse(w, fmt.Sprintf("Queue %q not found", request.Queue), http.StatusBadRequest)
+			return
+		}
+		job.Queue = request.Queue
+	}
+
+	if err := applyRetryPolicy(job, request); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := applyTimeout(job, request); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := applySchedule(job, request); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Without a priority in the body or header, the job keeps the default
+	// or its template's
+	if request.Priority == "" {
+		request.Priority = r.Header.Get(PriorityHeader)
+	}
+	if request.Priority != "" {
+		priority, err := domain.ParsePriority(request.Priority)
+		if err != nil {
+			ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job.Priority = priority
+	}
+
+	if err := applyConcurrency(job, request); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch request.OnDuplicate {
+	case "", onDuplicateReturnExisting, onDuplicateReject:
+	default:
+		ErrorResponse(w, fmt.Sprintf("on_duplicate must be %q or %q", onDuplicateReturnExisting, onDuplicateReject), http.StatusBadRequest)
+		return
+	}
+	job.UniqueKey = request.UniqueKey
+
+	if err := applyDependencies(job, request); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Children) > 0 {
+		h.submitBatch(w, r, job, request)
+		return
+	}
+
+	h.submitJob(w, r, job, request.OnDuplicate == onDuplicateReject)
+}
+
+// validPayload checks payload against the schema registered for jobType,
+// answering with field-level errors and returning false if it doesn't match.
+func (h *JobHandler) validPayload(w http.ResponseWriter, jobType string, payload json.RawMessage) bool {
+	err := h.schemas.Validate(jobType, payload)
+	if err == nil {
+		return true
+	}
+
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		ValidationErrorResponse(w, validationErr)
+		return false
+	}
+
+	ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	return false
+}
+
+// applyRetryPolicy validates the request's retry settings and stores them on
+// the job, leaving the defaults for anything not set.
+func applyRetryPolicy(job *domain.Job, request CreateJobRequest) error {
+	if request.MaxRetries != nil {
+		if *request.MaxRetries < 0 || *request.MaxRetries > maxRetriesLimit {
+			return fmt.Errorf("max_retries must be between 0 and %d", maxRetriesLimit)
+		}
+		job.MaxRetries = *request.MaxRetries
+	}
+
+	if request.Backoff == nil {
+		return nil
+	}
+
+	switch policy := domain.BackoffPolicy(request.Backoff.Policy); policy {
+	case domain.BackoffExponential, domain.BackoffFixed:
+		job.BackoffPolicy = policy
+	case "":
+	default:
+		return fmt.Errorf("backoff.policy must be %q or %q", domain.BackoffExponential, domain.BackoffFixed)
+	}
+
+	if request.Backoff.BaseDelay != "" {
+		baseDelay, err := time.ParseDuration(request.Backoff.BaseDelay)
+		if err != nil || baseDelay <= 0 || baseDelay > domain.RetryMaxDelay {
+			return fmt.Errorf("backoff.base_delay must be a duration between 0 and %s", domain.RetryMaxDelay)
+		}
+		job.BackoffBaseDelay = baseDelay
+	}
+
+	return nil
+}
+
+// applyTimeout validates and stores the job's execution timeout override.
+func applyTimeout(job *domain.Job, request CreateJobRequest) error {
+	if request.Timeout == "" {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(request.Timeout)
+	if err != nil || timeout <= 0 {
+		return errors.New("timeout must be a positive duration")
+	}
+
+	job.Timeout = timeout
+	return nil
+}
+
+// applySchedule sets the job's run time from run_at or delay, and its expiry
+// from expires_at.
+func applySchedule(job *domain.Job, request CreateJobRequest) error {
+	if request.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, request.ExpiresAt)
+		if err != nil {
+			return errors.New("expires_at must be an RFC 3339 timestamp")
+		}
+		expiresAt = expiresAt.UTC()
+		if !expiresAt.After(job.CreatedAt) {
+			return errors.New("expires_at must be in the future")
+		}
+		job.ExpiresAt = &expiresAt
+	}
+
+	var runAt time.Time
+
+	switch {
+	case request.RunAt != "" && request.Delay != "":
+		return errors.New("run_at and delay cannot both be set")
+	case request.RunAt != "":
+		parsed, err := time.Parse(time.RFC3339, request.RunAt)
+		if err != nil {
+			return errors.New("run_at must be an RFC 3339 timestamp")
+		}
+		runAt = parsed.UTC()
+	case request.Delay != "":
+		delay, err := time.ParseDuration(request.Delay)
+		if err != nil || delay <= 0 {
+			return errors.New("delay must be a positive duration")
+		}
+		runAt = job.CreatedAt.Add(delay)
+	default:
+		return nil
+	}
+
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(runAt) {
+		return errors.New("expires_at must be after the run time")
+	}
+
+	job.RunAt = &runAt
+	return nil
+}
+
+// applyConcurrency validates and stores the job's concurrency key and limit.
+func applyConcurrency(job *domain.Job, request CreateJobRequest) error {
+	if request.ConcurrencyKey == "" {
+		if request.ConcurrencyLimit != 0 {
+			return errors.New("concurrency_limit requires concurrency_key")
+		}
+		return nil
+	}
+
+	limit := request.ConcurrencyLimit
+	if limit == 0 {
+		limit = 1
+	}
+	if limit < 0 {
+		return errors.New("concurrency_limit must be positive")
+	}
+
+	job.ConcurrencyKey = request.ConcurrencyKey
+	job.ConcurrencyLimit = limit
+	return nil
+}
+
+// applyDependencies validates and stores the job's dependencies and the
+// policy applied when one of them fails.
+func applyDependencies(job *domain.Job, request CreateJobRequest) error {
+	if len(request.DependsOn) == 0 {
+		if request.DependencyPolicy != "" {
+			return errors.New("dependency_policy requires depends_on")
+		}
+		return nil
+	}
+
+	for _, id := range request.DependsOn {
+		if id == "" {
+			return errors.New("depends_on must not contain empty job IDs")
+		}
+	}
+
+	policy := domain.DependencyPolicy(request.DependencyPolicy)
+	switch policy {
+	case "":
+		policy = domain.DependencyFail
+	case domain.DependencyFail, domain.DependencyHold:
+	default:
+		return fmt.Errorf("dependency_policy must be %q or %q", domain.DependencyFail, domain.DependencyHold)
+	}
+
+	job.DependsOn = request.DependsOn
+	job.DependencyPolicy = policy
+	return nil
+}
+
+// acceptingJobs rejects the request with 503 when the server is shutting down
+// or draining. It reports whether the caller may go on to submit a job.
+func (h *JobHandler) acceptingJobs(w http.ResponseWriter) bool {
 	// Check if server is shutting down - reject new jobs during shutdown
 	select {
 	case <-h.shutdownCtx.Done():
 		ErrorResponse(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return false
+	default:
+	}
+
+	// Reject new jobs while an admin drain is in progress
+	if !h.drain.Accepting() {
+		ErrorResponse(w, "Server is draining", http.StatusServiceUnavailable)
+		return false
+	}
+
+	return true
+}
+
+// submitJob stores and enqueues a new job and writes the 201 response. If the
+// queue is full the job is rolled back and 429 is returned. If an active job
+// holds the same unique key, that job is returned with 200, or 409 is
+// returned when rejectDuplicate is set.
+func (h *JobHandler) submitJob(w http.ResponseWriter, r *http.Request, job *domain.Job, rejectDuplicate bool) {
+	tracing.InjectJob(r.Context(), job)
+	err := h.store.CreateJob(r.Context(), job)
+	var duplicateErr *store.DuplicateJobError
+	if errors.As(err, &duplicateErr) {
+		h.logger.Info("Duplicate job submission", "event", "job_duplicate", "unique_key", job.UniqueKey, "existing_job_id", duplicateErr.ExistingJobID)
+		if rejectDuplicate {
+			ErrorResponse(w, "A job with this unique_key is already pending or processing", http.StatusConflict)
+			return
+		}
+
+		existing, err := h.store.GetJob(r.Context(), duplicateErr.ExistingJobID)
+		if err != nil {
+			StoreErrorResponse(w, err, "Failed to get job")
+			return
+		}
+		h.writeJob(w, r, existing, http.StatusOK)
+		return
+	}
+	if errors.Is(err, store.ErrDependencyMissing) {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, store.ErrDependencyFailed) {
+		ErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to create job")
+		return
+	}
+	h.logger.Info("Job created", "event", "job_created", "job_id", job.ID)
+
+	// The job.created event waits until the job is accepted, as a full
+	// queue still turns it away below
+
+	// Blocked jobs are enqueued once their dependencies complete
+	if job.Status == domain.StatusBlocked {
+		h.logger.Info("Job blocked on dependencies", "event", "job_blocked", "job_id", job.ID, "depends_on", job.DependsOn)
+		h.events.Publish(r.Context(), events.ForJob(events.JobCreated, job))
+		h.writeJob(w, r, job, http.StatusCreated)
+		return
+	}
+
+	// Scheduled jobs stay pending until the sweeper finds them due, unless
+	// the queue can hold them until then. Either way the sweeper is the
+	// fallback, so a failed enqueue leaves the job pending.
+	if !job.Due(time.Now().UTC()) {
+		if queue.HoldsDelayed(h.jobQueue) {
+			if err := h.jobQueue.Enqueue(r.Context(), job.ID); err != nil {
+				h.logger.Info("Scheduled job left for the sweeper", "event", "job_enqueue_failed", "job_id", job.ID, "error", err)
+			}
+		}
+		h.logger.Info("Job scheduled", "event", "job_scheduled", "job_id", job.ID, "run_at", job.RunAt)
+		h.events.Publish(r.Context(), events.ForJob(events.JobCreated, job))
+		h.writeJob(w, r, job, http.StatusCreated)
+		return
+	}
+
+	err = h.jobQueue.Enqueue(r.Context(), job.ID)
+	switch {
+	case err == nil:
+		h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", job.ID)
+	case errors.Is(err, queue.ErrPaused):
+		// Persisted and pending; enqueued by the catch-up on resume
+		h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", job.ID)
+	case errors.Is(err, queue.ErrFull):
+		// A worker may already have claimed the job from the store; then it
+		// is running and the submission stands
+		if rollbackErr := h.store.DeletePendingJob(r.Context(), job.ID); rollbackErr != nil {
+			h.logger.Info("Job claimed before the full queue turned it away", "event", "job_enqueue_failed", "job_id", job.ID, "error", rollbackErr)
+			break
+		}
+		h.logger.Error("Failed to enqueue job", "event", "job_enqueue_failed", "job_id", job.ID, "error", "queue_full")
+		ErrorResponse(w, "Job queue is full", http.StatusTooManyRequests)
 		return
 	default:
+		// The job stays pending and the sweeper enqueues it, so the
+		// submission is accepted; an error here would invite a duplicate
+		h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", job.ID, "error", err)
+	}
+
+	h.events.Publish(r.Context(), events.ForJob(events.JobCreated, job))
+	h.writeJob(w, r, job, http.StatusCreated)
+}
+
+// submitBatch stores job as a batch parent with one child per request entry
+// and enqueues the children. Children that don't fit in the queue stay pending
+// for the sweeper rather than failing the whole batch.
+func (h *JobHandler) submitBatch(w http.ResponseWriter, r *http.Request, parent *domain.Job, request CreateJobRequest) {
+	if len(request.Children) > maxBatchChildren {
+		ErrorResponse(w, fmt.Sprintf("A batch may have at most %d children", maxBatchChildren), http.StatusBadRequest)
+		return
+	}
+	if len(parent.DependsOn) > 0 || parent.UniqueKey != "" {
+		ErrorResponse(w, "depends_on and unique_key cannot be used with children", http.StatusBadRequest)
+		return
+	}
+
+	children := make([]*domain.Job, 0, len(request.Children))
+	for _, childRequest := range request.Children {
+		childType := childRequest.Type
+		if childType == "" {
+			childType = parent.Type
+		}
+		if !h.validPayload(w, childType, childRequest.Payload) {
+			return
+		}
+		children = append(children, domain.NewChildJob(parent, childType, childRequest.Payload))
+	}
+
+	tracing.InjectJob(r.Context(), parent)
+	for _, child := range children {
+		tracing.InjectJob(r.Context(), child)
+	}
+	if err := h.store.CreateBatch(r.Context(), parent, children); err != nil {
+		StoreErrorResponse(w, err, "Failed to create batch")
+		return
+	}
+	h.logger.Info("Batch created", "event", "batch_created", "job_id", parent.ID, "children", len(children))
+
+	h.events.Publish(r.Context(), events.ForJob(events.JobCreated, parent))
+	for _, child := range children {
+		h.events.Publish(r.Context(), events.ForJob(events.JobCreated, child))
+	}
+
+	// Scheduled children wait for the sweeper like any other scheduled job,
+	// unless the queue can hold them until they are due
+	if parent.Due(time.Now().UTC()) || queue.HoldsDelayed(h.jobQueue) {
+		for _, child := range children {
+			// Child stays pending if the queue is full; the sweeper will
+			// enqueue it once there is room
+			h.jobQueue.Enqueue(r.Context(), child.ID)
+		}
+	}
+
+	h.writeJob(w, r, parent, http.StatusCreated)
+}
+
+// GetJobs lists jobs, optionally filtered by ?status= and ?type=.
+func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.store.GetJobs(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get jobs")
+		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024) // 1MB max
+	filter := jobFilterFromRequest(r)
+
+	response := make(JobListResponse, 0, len(jobs))
+	for _, job := range jobs {
+		if !filter.matches(&job) {
+			continue
+		}
+		response = append(response, jobToResponse(&job))
+	}
 
-	bodyBytes, err := io.ReadAll(r.Body)
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
 
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.store.GetJob(r.Context(), r.PathValue("id"))
 	if err != nil {
-		// Detect if it's too large
-		if strings.Contains(err.Error(), "request body too large") {
-			ErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		if errors.Is(err, store.ErrJobNotFound) {
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
 			return
 		}
 
-		ErrorResponse(w, "Failed to read request body", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get job")
 		return
 	}
 
-	var request CreateJobRequest
-	if err := json.Unmarshal(bodyBytes, &request); err != nil {
-		ErrorResponse(w, "Failed to parse request body", http.StatusBadRequest)
+	etag := jobETag(job)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	if request.Type == "" {
-		ErrorResponse(w, "Job type is required and must be non-empty", http.StatusBadRequest)
+	h.writeJob(w, r, job, http.StatusOK)
+}
+
+// jobETag derives a weak ETag from the job version. It is weak because the
+// representation also varies by Accept and Accept-Encoding.
+func jobETag(job *domain.Job) string {
+	return fmt.Sprintf(`W/"%s-%d"`, job.ID, job.Version)
+}
+
+// etagMatches implements the weak comparison used for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryJob moves a failed job back to pending and enqueues it immediately
+// instead of waiting for the sweeper.
+func (h *JobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	err := h.store.RetryFailedJob(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrJobNotFound):
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrInvalidTransition):
+			ErrorResponse(w, "Only failed jobs can be retried", http.StatusConflict)
+		default:
+			StoreErrorResponse(w, err, "Failed to retry job")
+		}
 		return
 	}
+	h.logger.Info("Job retried", "event", "job_retried", "job_id", jobID)
 
-	job := domain.NewJob(request.Type, request.Payload)
+	h.events.Publish(r.Context(), events.Event{Type: events.JobRetried, JobID: jobID})
+
+	switch err := h.jobQueue.Enqueue(r.Context(), jobID); {
+	case err == nil:
+		h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", jobID)
+	case errors.Is(err, queue.ErrPaused):
+		// Enqueued by the catch-up on resume
+		h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", jobID)
+	default:
+		// Job stays pending; the sweeper will enqueue it once there is room
+		h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", jobID, "error", err)
+	}
 
-	err = h.store.CreateJob(r.Context(), job)
+	job, err := h.store.GetJob(r.Context(), jobID)
 	if err != nil {
-		ErrorResponse(w, "Failed to create job", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get job")
 		return
 	}
-	h.logger.Info("Job created", "event", "job_created", "job_id", job.ID)
 
-	err = h.metricStore.IncrementJobsCreated(r.Context())
+	h.writeJob(w, r, job, http.StatusOK)
+}
+
+// ReplayJob submits a new job with the type, payload and settings of a
+// terminal job, for reprocessing it after a handler fix. The new job links
+// back through replayed_from.
+func (h *JobHandler) ReplayJob(w http.ResponseWriter, r *http.Request) {
+	if !h.acceptingJobs(w) {
+		return
+	}
+
+	original, err := h.store.GetJob(r.Context(), r.PathValue("id"))
 	if err != nil {
-		h.logger.Error("Failed to increment jobs created", "error", err)
+		if errors.Is(err, store.ErrJobNotFound) {
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
 	}
 
-	select {
-	case h.jobQueue <- job.ID:
-		h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", job.ID)
-	case <-r.Context().Done():
-		ErrorResponse(w, "Request cancelled", http.StatusRequestTimeout)
+	if !original.Status.Terminal() {
+		ErrorResponse(w, "Only completed, dead, cancelled or expired jobs can be replayed", http.StatusConflict)
 		return
-	default:
-		h.store.DeleteJob(r.Context(), job.ID)
-		err = h.metricStore.DecrementJobsCreated(r.Context())
+	}
+	if original.Batch != nil {
+		ErrorResponse(w, "Batch parents cannot be replayed; replay their children instead", http.StatusConflict)
+		return
+	}
+
+	job := domain.NewReplayJob(original)
+	h.logger.Info("Job replayed", "event", "job_replayed", "job_id", job.ID, "replayed_from", original.ID)
+
+	h.submitJob(w, r, job, false)
+}
+
+// CancelJob cancels a pending, failed or blocked job so it is never processed
+// again. Blocked jobs that depend on it are resolved by their policy. A job
+// being processed has its handler's context cancelled and 202 is returned;
+// the worker records it as cancelled once the handler returns.
+func (h *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	if h.running.Cancel(jobID) {
+		h.logger.Info("Cancellation requested for processing job", "event", "job_cancel_requested", "job_id", jobID)
+
+		job, err := h.store.GetJob(r.Context(), jobID)
 		if err != nil {
-			h.logger.Error("Failed to decrement jobs created", "event", "metric_error", "error", err)
+			StoreErrorResponse(w, err, "Failed to get job")
+			return
 		}
-		h.logger.Error("Failed to enqueue job", "event", "job_enqueue_failed", "job_id", job.ID, "error", "queue_full")
-		ErrorResponse(w, "Job queue is full", http.StatusTooManyRequests)
+		h.writeJob(w, r, job, http.StatusAccepted)
 		return
 	}
 
-	response := jobToResponse(job)
+	err := h.store.CancelJob(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrJobNotFound):
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrInvalidTransition):
+			ErrorResponse(w, "Only pending, failed or blocked jobs can be cancelled", http.StatusConflict)
+		default:
+			StoreErrorResponse(w, err, "Failed to cancel job")
+		}
+		return
+	}
+	h.logger.Info("Job cancelled", "event", "job_cancelled", "job_id", jobID)
 
-	responseBytes, err := json.Marshal(response)
+	h.events.Publish(r.Context(), events.Event{Type: events.JobCancelled, JobID: jobID})
+	// Cancelling the last child of a batch completes the parent, which may
+	// unblock jobs depending on it
+	h.resolveDependents(r.Context(), jobID)
+
+	job, err := h.store.GetJob(r.Context(), jobID)
 	if err != nil {
-		ErrorResponse(w, "Failed to marshal response", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get job")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	h.writeJob(w, r, job, http.StatusOK)
+}
 
-	if _, err := w.Write(responseBytes); err != nil {
+// resolveDependents releases or fails the blocked jobs that depend on jobID
+// now that it has finished, and enqueues the ones that became pending.
+func (h *JobHandler) resolveDependents(ctx context.Context, jobID string) {
+	unblocked, failed, err := h.store.ResolveDependents(ctx, jobID)
+	if err != nil {
+		h.logger.Error("Failed to resolve dependent jobs", "event", "job_dependents_error", "job_id", jobID, "error", err)
+	}
+	for _, id := range unblocked {
+		switch err := h.jobQueue.Enqueue(ctx, id); {
+		case err == nil:
+		case errors.Is(err, queue.ErrPaused):
+			// Enqueued by the catch-up on resume
+			h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", id)
+		default:
+			// The job stays pending; the sweeper will enqueue it once
+			// there is room
+			h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", id, "error", err)
+		}
+	}
+	for _, id := range failed {
+		h.logger.Warn("Dependent job failed", "event", "job_dependency_failed", "job_id", id, "dependency_id", jobID)
+		h.events.Publish(ctx, events.Event{Type: events.JobDead, JobID: id, Reason: "dependency_failed"})
+	}
+}
+
+// GetJobResult returns the output stored when the job completed.
+func (h *JobHandler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, err := h.store.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrJobNotFound) {
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	if job.Status != domain.StatusCompleted {
+		ErrorResponse(w, "Job has not completed", http.StatusConflict)
+		return
+	}
+
+	result := job.Result
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+
+	response := JobResultResponse{
+		ID:     job.ID,
+		Result: result,
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
 		h.logger.Error("Failed to write response", "error", err)
 		return
 	}
 }
 
-func (h *JobHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
-	jobs, err := h.store.GetJobs(r.Context())
+// GetJobLogs returns the log output captured for each processing attempt.
+func (h *JobHandler) GetJobLogs(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	if _, err := h.store.GetJob(r.Context(), jobID); err != nil {
+		if errors.Is(err, store.ErrJobNotFound) {
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	logs, err := h.logStore.GetLogs(r.Context(), jobID)
 	if err != nil {
-		ErrorResponse(w, "Failed to get jobs", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get job logs")
+		return
+	}
+
+	response := make([]AttemptLogResponse, len(logs))
+	for i, attempt := range logs {
+		entries := make([]LogEntryResponse, len(attempt.Entries))
+		for j, entry := range attempt.Entries {
+			entries[j] = LogEntryResponse{
+				Time:    entry.Time.Format(time.RFC3339Nano),
+				Level:   entry.Level,
+				Message: entry.Message,
+				Attrs:   entry.Attrs,
+			}
+		}
+
+		response[i] = AttemptLogResponse{
+			Attempt:   attempt.Attempt,
+			Truncated: attempt.Truncated,
+			Entries:   entries,
+		}
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// UpdateProgress lets external workers report progress on a job they hold
+// the lease for.
+func (h *JobHandler) UpdateProgress(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	var request UpdateProgressRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
 		return
 	}
 
-	response := make([]JobResponse, len(jobs))
-	for i, job := range jobs {
-		response[i] = jobToResponse(&job)
+	if request.Percent < 0 || request.Percent > 100 {
+		ErrorResponse(w, "percent must be between 0 and 100", http.StatusBadRequest)
+		return
 	}
 
-	responseBytes, err := json.Marshal(response)
+	err := h.store.UpdateProgress(r.Context(), jobID, request.Token, request.Percent, request.Message)
 	if err != nil {
-		ErrorResponse(w, "Failed to marshal response", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, store.ErrJobNotFound):
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrJobNotProcessing):
+			ErrorResponse(w, "Progress can only be reported for processing jobs", http.StatusConflict)
+		case errors.Is(err, store.ErrLeaseLost):
+			ErrorResponse(w, "Lease is no longer held; the job was reclaimed or already finished", http.StatusConflict)
+		default:
+			StoreErrorResponse(w, err, "Failed to update progress")
+		}
 		return
 	}
+	h.logger.Info("Job progress updated", "event", "job_progress", "job_id", jobID, "percent", request.Percent)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	job, err := h.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	h.writeJob(w, r, job, http.StatusOK)
+}
 
-	if _, err := w.Write(responseBytes); err != nil {
+func (h *JobHandler) writeJob(w http.ResponseWriter, r *http.Request, job *domain.Job, statusCode int) {
+	if err := WriteResponse(w, r, jobToResponse(job), statusCode); err != nil {
 		h.logger.Error("Failed to write response", "error", err)
 		return
 	}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// PriorityHeader lets producers mark submissions that may be shed first. It
+// also sets the job priority when the body doesn't.
+const PriorityHeader = "X-Job-Priority"
+
+// LoadShedder rejects low-priority submissions before the queue is actually
+// full, so producers back off early instead of everything piling up in the
+// store. High and normal priority requests are never shed here; they still
+// get 429 once the queue is full.
+type LoadShedder struct {
+	jobQueue      queue.Queue
+	metricStore   store.MetricStore
+	logger        *slog.Logger
+	highWaterMark float64
+	retryAfter    time.Duration
+}
+
+func NewLoadShedder(jobQueue queue.Queue, metricStore store.MetricStore, logger *slog.Logger, highWaterMark float64, retryAfter time.Duration) *LoadShedder {
+	return &LoadShedder{
+		jobQueue:      jobQueue,
+		metricStore:   metricStore,
+		logger:        logger,
+		highWaterMark: highWaterMark,
+		retryAfter:    retryAfter,
+	}
+}
+
+// overloaded reports whether the queue is above the high-water mark, or every
+// worker is busy while jobs are already waiting.
+func (s *LoadShedder) overloaded(r *http.Request) (bool, string) {
+	// An unbounded queue has no high-water mark
+	if capacity := s.jobQueue.Cap(); capacity > 0 && float64(s.jobQueue.Len()) >= s.highWaterMark*float64(capacity) {
+		return true, "queue_high_water"
+	}
+
+	metrics, err := s.metricStore.GetMetrics(r.Context())
+	if err != nil {
+		// Never shed on missing data
+		return false, ""
+	}
+
+	if metrics.WorkerCount > 0 && metrics.JobsInProgress >= metrics.WorkerCount && s.jobQueue.Len() > 0 {
+		return true, "workers_saturated"
+	}
+
+	return false, ""
+}
+
+// Middleware wraps job submission routes.
+func (s *LoadShedder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(PriorityHeader) != "low" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if overloaded, reason := s.overloaded(r); overloaded {
+			s.logger.Warn("Low-priority submission shed", "event", "request_shed", "reason", reason, "queue_depth", s.jobQueue.Len())
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.retryAfter.Seconds())))
+			ErrorResponse(w, "Server is overloaded, retry later", http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// LogLevelHandler reads and changes the server log level while it runs.
+type LogLevelHandler struct {
+	level        *slog.LevelVar
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewLogLevelHandler(level *slog.LevelVar, logger *slog.Logger, maxBodyBytes int64) *LogLevelHandler {
+	return &LogLevelHandler{
+		level:        level,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+func (h *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if err := WriteResponse(w, r, LogLevelResponse{Level: h.level.Level().String()}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// PutLogLevel sets the level every component logs at, until the next change
+// or restart.
+func (h *LogLevelHandler) PutLogLevel(w http.ResponseWriter, r *http.Request) {
+	var request LogLevelRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(request.Level)); err != nil {
+		ErrorResponse(w, "level must be debug, info, warn or error, optionally with an offset such as warn+2", http.StatusBadRequest)
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	// Logged at Warn so the change is on record whatever the new level
+	h.logger.Warn("Log level changed", "event", "log_level_changed", "from", previous.String(), "to", level.String())
+
+	if err := WriteResponse(w, r, LogLevelResponse{Level: level.String()}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
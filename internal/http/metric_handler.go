@@ -1,22 +1,39 @@
 package http
 
 import (
-	"encoding/json"
 	"log/slog"
 	"net/http"
 
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/leader"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/slo"
 	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/version"
+	"github.com/karprabha/job-queue-backend/internal/worker"
 )
 
 type MetricHandler struct {
+	jobStore    store.JobStore
 	metricStore store.MetricStore
 	logger      *slog.Logger
+	jobQueue    queue.Queue
+	queueStats  *queue.Stats
+	utilization *worker.Utilization
+	slos        *slo.Tracker
+	elector     leader.Elector
 }
 
-func NewMetricHandler(metricStore store.MetricStore, logger *slog.Logger) *MetricHandler {
+func NewMetricHandler(jobStore store.JobStore, metricStore store.MetricStore, logger *slog.Logger, jobQueue queue.Queue, queueStats *queue.Stats, utilization *worker.Utilization, slos *slo.Tracker, elector leader.Elector) *MetricHandler {
 	return &MetricHandler{
+		jobStore:    jobStore,
 		metricStore: metricStore,
 		logger:      logger,
+		jobQueue:    jobQueue,
+		queueStats:  queueStats,
+		utilization: utilization,
+		slos:        slos,
+		elector:     elector,
 	}
 }
 
@@ -25,34 +42,231 @@ type MetricResponse struct {
 	JobsCompleted    int `json:"jobs_completed"`
 	JobsFailed       int `json:"jobs_failed"`
 	JobsRetried      int `json:"jobs_retried"`
-	JobsInProgress   int `json:"jobs_in_progress"`
+	// Jobs failed and waiting for their retry, counted from the job store
+	JobsAwaitingRetry int `json:"jobs_awaiting_retry"`
+	JobsInProgress    int `json:"jobs_in_progress"`
+	JobsCancelled     int `json:"jobs_cancelled"`
+	JobsExpired       int `json:"jobs_expired"`
+	JobsPanicked      int `json:"job_panicked"`
+	JobsDead          int `json:"jobs_dead"`
+	JobsQuarantined   int `json:"jobs_quarantined"`
+	JobsReaped        int `json:"jobs_reaped"`
+	// FailuresByClass counts failed attempts by error class
+	FailuresByClass map[string]int `json:"failures_by_class"`
+	WorkerCount     int            `json:"worker_count"`
+	// Autoscaler resizes since startup
+	WorkerScaleUps   int `json:"worker_scale_ups"`
+	WorkerScaleDowns int `json:"worker_scale_downs"`
+	// Sweeper runs since startup and what they did
+	SweeperRuns         int `json:"sweeper_runs"`
+	SweeperJobsRetried  int `json:"sweeper_jobs_retried"`
+	SweeperJobsEnqueued int `json:"sweeper_jobs_enqueued"`
+	// Retries the retry budget left for a later run
+	SweeperRetriesDeferred int `json:"sweeper_retries_deferred"`
+	// Due pending jobs the sweeper could not enqueue because the queue was
+	// full
+	SweeperSkippedFull         int     `json:"sweeper_skipped_full"`
+	SweeperDurationSeconds     float64 `json:"sweeper_duration_seconds"`
+	SweeperLastDurationSeconds float64 `json:"sweeper_last_duration_seconds"`
+	QueueDepth                 int     `json:"queue_depth"`
+	QueueCapacity              int     `json:"queue_capacity"`
+	// Queue traffic since startup, summed over every queue
+	QueueEnqueued int `json:"queue_enqueued"`
+	QueueDequeued int `json:"queue_dequeued"`
+	// Enqueues refused because the queue was full
+	QueueRejected    int               `json:"queue_rejected"`
+	QueueWaitSeconds HistogramResponse `json:"queue_wait_seconds"`
+	// Queues breaks the queue metrics down per named queue
+	Queues []QueueMetricResponse `json:"queues"`
+	// Queue depth high-water mark alerts fired since startup, and how many
+	// are firing now
+	QueueDepthAlerts       int `json:"queue_depth_alerts"`
+	QueueDepthAlertsFiring int `json:"queue_depth_alerts_firing"`
+	// Jobs run by workers of another queue than their own
+	JobsStolen int `json:"jobs_stolen"`
+	// Attempts flagged for processing longer than their type's threshold
+	SlowJobs int `json:"slow_jobs"`
+	// How long handlers ran, by job type
+	JobDurationSeconds map[string]HistogramResponse `json:"job_duration_seconds"`
+	// How long jobs waited from becoming due to their first start, and how
+	// long attempts took from start to outcome, by job type
+	JobWaitSeconds       map[string]HistogramResponse `json:"job_wait_seconds"`
+	JobProcessingSeconds map[string]HistogramResponse `json:"job_processing_seconds"`
+	// WorkerPools gives how busy each queue's worker pool is
+	WorkerPools []WorkerPoolMetricResponse `json:"worker_pools"`
+	// Whether this instance is the leader running the sweeper, lease reaper
+	// and scheduler
+	IsLeader bool `json:"is_leader"`
+	// BuildInfo mirrors the Prometheus build_info convention: a constant
+	// gauge of 1 labelled with the running build.
+	BuildInfo BuildInfoGauge `json:"build_info"`
+}
+
+// QueueMetricResponse is one named queue's depth and traffic.
+type QueueMetricResponse struct {
+	Name        string            `json:"name"`
+	Depth       int               `json:"depth"`
+	Capacity    int               `json:"capacity"`
+	Enqueued    int               `json:"enqueued"`
+	Dequeued    int               `json:"dequeued"`
+	Rejected    int               `json:"rejected"`
+	WaitSeconds HistogramResponse `json:"wait_seconds"`
+}
+
+// WorkerPoolMetricResponse is one queue's worker pool utilization.
+type WorkerPoolMetricResponse struct {
+	Queue string `json:"queue"`
+	Size  int    `json:"size"`
+	Busy  int    `json:"busy"`
+	// Share of the pool busy now, from 0 to 1; near 1 for long, more workers
+	// would help
+	Saturation float64 `json:"saturation"`
+	// Worker time spent busy and idle since startup
+	BusySeconds float64 `json:"busy_seconds"`
+	IdleSeconds float64 `json:"idle_seconds"`
+}
+
+func workerPoolsToResponse(pools []worker.PoolUtilization) []WorkerPoolMetricResponse {
+	response := make([]WorkerPoolMetricResponse, 0, len(pools))
+	for _, pool := range pools {
+		response = append(response, WorkerPoolMetricResponse{
+			Queue:       pool.Queue,
+			Size:        pool.Size,
+			Busy:        pool.BusyWorkers,
+			Saturation:  pool.Saturation,
+			BusySeconds: pool.Busy.Seconds(),
+			IdleSeconds: pool.Idle.Seconds(),
+		})
+	}
+	return response
+}
+
+// HistogramResponse follows the Prometheus histogram convention: bucket
+// counts are cumulative, each counting observations up to its bound.
+type HistogramResponse struct {
+	Buckets []HistogramBucket `json:"buckets"`
+	Count   int               `json:"count"`
+	Sum     float64           `json:"sum"`
+}
+
+type HistogramBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      int     `json:"count"`
+}
+
+func histogramToResponse(h queue.Histogram) HistogramResponse {
+	buckets := make([]HistogramBucket, len(queue.WaitBuckets))
+	for i, bound := range queue.WaitBuckets {
+		buckets[i] = HistogramBucket{UpperBound: bound, Count: h.Buckets[i]}
+	}
+
+	return HistogramResponse{
+		Buckets: buckets,
+		Count:   h.Count,
+		Sum:     h.Sum,
+	}
+}
+
+func durationHistogramToResponse(h *domain.Histogram) HistogramResponse {
+	buckets := make([]HistogramBucket, len(h.Bounds))
+	for i, bound := range h.Bounds {
+		buckets[i] = HistogramBucket{UpperBound: bound, Count: h.Buckets[i]}
+	}
+
+	return HistogramResponse{
+		Buckets: buckets,
+		Count:   h.Count,
+		Sum:     h.Sum,
+	}
+}
+
+func durationHistogramsToResponse(histograms map[string]*domain.Histogram) map[string]HistogramResponse {
+	responses := make(map[string]HistogramResponse, len(histograms))
+	for jobType, histogram := range histograms {
+		responses[jobType] = durationHistogramToResponse(histogram)
+	}
+	return responses
+}
+
+type BuildInfoGauge struct {
+	Value  int             `json:"value"`
+	Labels VersionResponse `json:"labels"`
 }
 
 func (h *MetricHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
 	metrics, err := h.metricStore.GetMetrics(r.Context())
 	if err != nil {
-		ErrorResponse(w, "Failed to get metrics", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get metrics")
 		return
 	}
 
-	response := MetricResponse{
-		TotalJobsCreated: metrics.TotalJobsCreated,
-		JobsCompleted:    metrics.JobsCompleted,
-		JobsFailed:       metrics.JobsFailed,
-		JobsRetried:      metrics.JobsRetried,
-		JobsInProgress:   metrics.JobsInProgress,
-	}
-
-	responseBytes, err := json.Marshal(response)
+	failedJobs, err := h.jobStore.GetFailedJobs(r.Context())
 	if err != nil {
-		ErrorResponse(w, "Failed to marshal response", http.StatusInternalServerError)
+		StoreErrorResponse(w, err, "Failed to get metrics")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	queueTotal := h.queueStats.Total()
+	queues := make([]QueueMetricResponse, 0)
+	for _, stats := range h.queueStats.Snapshot() {
+		queues = append(queues, QueueMetricResponse{
+			Name:        stats.Name,
+			Depth:       stats.Depth,
+			Capacity:    stats.Capacity,
+			Enqueued:    stats.Enqueued,
+			Dequeued:    stats.Dequeued,
+			Rejected:    stats.Rejected,
+			WaitSeconds: histogramToResponse(stats.Wait),
+		})
+	}
+
+	response := MetricResponse{
+		TotalJobsCreated:           metrics.TotalJobsCreated,
+		JobsCompleted:              metrics.JobsCompleted,
+		JobsFailed:                 metrics.JobsFailed,
+		JobsRetried:                metrics.JobsRetried,
+		JobsAwaitingRetry:          len(failedJobs),
+		JobsInProgress:             metrics.JobsInProgress,
+		JobsCancelled:              metrics.JobsCancelled,
+		JobsExpired:                metrics.JobsExpired,
+		JobsPanicked:               metrics.JobsPanicked,
+		JobsDead:                   metrics.JobsDead,
+		JobsQuarantined:            metrics.JobsQuarantined,
+		JobsReaped:                 metrics.JobsReaped,
+		FailuresByClass:            metrics.FailuresByClass,
+		WorkerCount:                metrics.WorkerCount,
+		WorkerScaleUps:             metrics.WorkerScaleUps,
+		WorkerScaleDowns:           metrics.WorkerScaleDowns,
+		SweeperRuns:                metrics.SweeperRuns,
+		SweeperJobsRetried:         metrics.SweeperJobsRetried,
+		SweeperRetriesDeferred:     metrics.SweeperRetriesDeferred,
+		SweeperJobsEnqueued:        metrics.SweeperJobsEnqueued,
+		SweeperSkippedFull:         metrics.SweeperSkippedFull,
+		SweeperDurationSeconds:     metrics.SweeperDuration.Seconds(),
+		SweeperLastDurationSeconds: metrics.SweeperLastDuration.Seconds(),
+		QueueDepth:                 h.jobQueue.Len(),
+		QueueCapacity:              h.jobQueue.Cap(),
+		QueueEnqueued:              queueTotal.Enqueued,
+		QueueDequeued:              queueTotal.Dequeued,
+		QueueRejected:              queueTotal.Rejected,
+		QueueWaitSeconds:           histogramToResponse(queueTotal.Wait),
+		Queues:                     queues,
+		QueueDepthAlerts:           metrics.QueueDepthAlerts,
+		QueueDepthAlertsFiring:     metrics.QueueDepthAlertsFiring,
+		JobsStolen:                 metrics.JobsStolen,
+		SlowJobs:                   metrics.JobsSlow,
+		JobDurationSeconds:         durationHistogramsToResponse(metrics.JobDurations),
+		JobWaitSeconds:             durationHistogramsToResponse(metrics.JobWaits),
+		JobProcessingSeconds:       durationHistogramsToResponse(metrics.JobProcessing),
+		WorkerPools:                workerPoolsToResponse(h.utilization.Pools()),
+		IsLeader:                   h.elector.IsLeader(),
+		BuildInfo: BuildInfoGauge{
+			Value:  1,
+			Labels: versionToResponse(version.Get()),
+		},
+	}
 
-	if _, err := w.Write(responseBytes); err != nil {
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
 		h.logger.Error("Failed to write response", "error", err)
 		return
 	}
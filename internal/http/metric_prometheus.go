@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/slo"
+	"github.com/karprabha/job-queue-backend/internal/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricNamespace prefixes every exported Prometheus metric.
+const metricNamespace = "workstream"
+
+// prometheusCollector exports the metric store, job store and queue stats as
+// Prometheus metrics, read fresh on every scrape.
+type prometheusCollector struct {
+	handler *MetricHandler
+
+	jobs              *prometheus.Desc
+	jobsCreated       *prometheus.Desc
+	jobsCompleted     *prometheus.Desc
+	jobsFailed        *prometheus.Desc
+	jobsRetried       *prometheus.Desc
+	jobsCancelled     *prometheus.Desc
+	jobsExpired       *prometheus.Desc
+	jobsPanicked      *prometheus.Desc
+	jobsReaped        *prometheus.Desc
+	jobsStolen        *prometheus.Desc
+	jobsSlow          *prometheus.Desc
+	jobsInProgress    *prometheus.Desc
+	jobsDead          *prometheus.Desc
+	jobsQuarantined   *prometheus.Desc
+	jobFailures       *prometheus.Desc
+	jobDuration       *prometheus.Desc
+	jobWait           *prometheus.Desc
+	jobProcessing     *prometheus.Desc
+	workers           *prometheus.Desc
+	workerScaleEvents *prometheus.Desc
+	workersBusy       *prometheus.Desc
+	workerSaturation  *prometheus.Desc
+	workerBusyTime    *prometheus.Desc
+	workerIdleTime    *prometheus.Desc
+	sloTarget         *prometheus.Desc
+	sloSuccess        *prometheus.Desc
+	sloBudget         *prometheus.Desc
+	sloBurnRate       *prometheus.Desc
+	queueDepth        *prometheus.Desc
+	queueCapacity     *prometheus.Desc
+	queueEnqueued     *prometheus.Desc
+	queueDequeued     *prometheus.Desc
+	queueRejected     *prometheus.Desc
+	queueWait         *prometheus.Desc
+	depthAlerts       *prometheus.Desc
+	depthAlertsFiring *prometheus.Desc
+	sweeperRuns       *prometheus.Desc
+	sweeperEnqueued   *prometheus.Desc
+	sweeperRetried    *prometheus.Desc
+	retriesDeferred   *prometheus.Desc
+	sweeperSkipped    *prometheus.Desc
+	sweeperDuration   *prometheus.Desc
+	isLeader          *prometheus.Desc
+	buildInfo         *prometheus.Desc
+}
+
+func newPrometheusCollector(handler *MetricHandler) *prometheusCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricNamespace, "", name), help, labels, nil)
+	}
+
+	return &prometheusCollector{
+		handler:           handler,
+		jobs:              desc("jobs", "Jobs in the store by type and status.", "type", "status"),
+		jobsCreated:       desc("jobs_created_total", "Jobs created."),
+		jobsCompleted:     desc("jobs_completed_total", "Jobs completed."),
+		jobsFailed:        desc("jobs_failed_total", "Failed attempts, including ones retried since."),
+		jobsRetried:       desc("jobs_retried_total", "Failed jobs returned to pending for another attempt."),
+		jobsCancelled:     desc("jobs_cancelled_total", "Jobs cancelled."),
+		jobsExpired:       desc("jobs_expired_total", "Jobs that expired before they started."),
+		jobsPanicked:      desc("jobs_panicked_total", "Handler panics."),
+		jobsReaped:        desc("jobs_reaped_total", "Jobs the sweeper took back from processing."),
+		jobsStolen:        desc("jobs_stolen_total", "Jobs run by workers of another queue than their own."),
+		jobsSlow:          desc("slow_jobs_total", "Attempts that processed longer than their type's slow job threshold."),
+		jobsInProgress:    desc("jobs_in_progress", "Jobs being processed by local workers."),
+		jobsDead:          desc("jobs_dead", "Jobs in the dead-letter queue."),
+		jobsQuarantined:   desc("jobs_quarantined", "Jobs quarantined for crashing or timing out their worker repeatedly."),
+		jobFailures:       desc("job_failures_total", "Failed attempts by error class.", "class"),
+		jobDuration:       desc("job_duration_seconds", "How long handlers ran.", "type"),
+		jobWait:           desc("job_wait_seconds", "How long jobs waited from becoming due to their first start.", "type"),
+		jobProcessing:     desc("job_processing_seconds", "How long attempts took from start to outcome.", "type"),
+		workers:           desc("workers", "Workers in the pools."),
+		workerScaleEvents: desc("worker_scale_events_total", "Autoscaler resizes of the worker pool.", "direction"),
+		workersBusy:       desc("workers_busy", "Workers processing a job.", "queue"),
+		workerSaturation:  desc("worker_saturation_ratio", "Share of the worker pool busy, from 0 to 1.", "queue"),
+		workerBusyTime:    desc("worker_busy_seconds_total", "Worker time spent processing jobs.", "queue"),
+		workerIdleTime:    desc("worker_idle_seconds_total", "Worker time spent waiting for jobs.", "queue"),
+		sloTarget:         desc("slo_target_ratio", "Success-rate objective of the job type.", "type"),
+		sloSuccess:        desc("slo_success_ratio", "Share of jobs completed rather than dead-lettered over the SLO window.", "type"),
+		sloBudget:         desc("slo_error_budget_remaining_ratio", "Share of the error budget left over the SLO window; negative once overspent.", "type"),
+		sloBurnRate:       desc("slo_burn_rate", "How fast the error budget is spent; 1 spends it exactly over the SLO window.", "type", "window"),
+		queueDepth:        desc("queue_depth", "IDs waiting in the queue.", "queue"),
+		queueCapacity:     desc("queue_capacity", "Queue capacity, zero when unbounded.", "queue"),
+		queueEnqueued:     desc("queue_enqueued_total", "IDs enqueued.", "queue"),
+		queueDequeued:     desc("queue_dequeued_total", "IDs dequeued.", "queue"),
+		queueRejected:     desc("queue_rejected_total", "Enqueues refused because the queue was full.", "queue"),
+		queueWait:         desc("queue_wait_seconds", "How long IDs waited in the queue.", "queue"),
+		depthAlerts:       desc("queue_depth_alerts_total", "Queue depth high-water mark alerts fired."),
+		depthAlertsFiring: desc("queue_depth_alerts_firing", "Queue depth high-water mark alerts firing now."),
+		sweeperRuns:       desc("sweeper_runs_total", "Sweeper runs."),
+		sweeperEnqueued:   desc("sweeper_jobs_enqueued_total", "Pending jobs the sweeper enqueued."),
+		sweeperRetried:    desc("sweeper_jobs_retried_total", "Failed jobs the sweeper returned to pending."),
+		retriesDeferred:   desc("sweeper_retries_deferred_total", "Retries the retry budget left for a later sweeper run."),
+		sweeperSkipped:    desc("sweeper_skipped_full_total", "Due jobs the sweeper could not enqueue because the queue was full."),
+		sweeperDuration:   desc("sweeper_duration_seconds_total", "Time spent sweeping."),
+		isLeader:          desc("is_leader", "1 while this instance is the leader running the sweeper, lease reaper and scheduler."),
+		buildInfo:         desc("build_info", "The running build; always 1.", "version", "git_sha", "build_date", "go_version"),
+	}
+}
+
+func (c *prometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *prometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	counter := func(desc *prometheus.Desc, value int, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+	}
+	gauge := func(desc *prometheus.Desc, value int, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value), labels...)
+	}
+
+	metrics, err := c.handler.metricStore.GetMetrics(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.jobsCreated, err)
+		return
+	}
+
+	counter(c.jobsCreated, metrics.TotalJobsCreated)
+	counter(c.jobsCompleted, metrics.JobsCompleted)
+	counter(c.jobsFailed, metrics.JobsFailed)
+	counter(c.jobsRetried, metrics.JobsRetried)
+	counter(c.jobsCancelled, metrics.JobsCancelled)
+	counter(c.jobsExpired, metrics.JobsExpired)
+	counter(c.jobsPanicked, metrics.JobsPanicked)
+	counter(c.jobsReaped, metrics.JobsReaped)
+	counter(c.jobsStolen, metrics.JobsStolen)
+	counter(c.jobsSlow, metrics.JobsSlow)
+	gauge(c.jobsInProgress, metrics.JobsInProgress)
+	gauge(c.jobsDead, metrics.JobsDead)
+	gauge(c.jobsQuarantined, metrics.JobsQuarantined)
+	for errorClass, count := range metrics.FailuresByClass {
+		counter(c.jobFailures, count, errorClass)
+	}
+	for jobType, histogram := range metrics.JobDurations {
+		ch <- durationHistogramMetric(c.jobDuration, histogram, jobType)
+	}
+	for jobType, histogram := range metrics.JobWaits {
+		ch <- durationHistogramMetric(c.jobWait, histogram, jobType)
+	}
+	for jobType, histogram := range metrics.JobProcessing {
+		ch <- durationHistogramMetric(c.jobProcessing, histogram, jobType)
+	}
+	gauge(c.workers, metrics.WorkerCount)
+	counter(c.workerScaleEvents, metrics.WorkerScaleUps, "up")
+	counter(c.workerScaleEvents, metrics.WorkerScaleDowns, "down")
+	counter(c.depthAlerts, metrics.QueueDepthAlerts)
+	gauge(c.depthAlertsFiring, metrics.QueueDepthAlertsFiring)
+	counter(c.sweeperRuns, metrics.SweeperRuns)
+	counter(c.sweeperEnqueued, metrics.SweeperJobsEnqueued)
+	counter(c.sweeperRetried, metrics.SweeperJobsRetried)
+	counter(c.retriesDeferred, metrics.SweeperRetriesDeferred)
+	counter(c.sweeperSkipped, metrics.SweeperSkippedFull)
+	ch <- prometheus.MustNewConstMetric(c.sweeperDuration, prometheus.CounterValue, metrics.SweeperDuration.Seconds())
+
+	for _, stats := range c.handler.queueStats.Snapshot() {
+		gauge(c.queueDepth, stats.Depth, stats.Name)
+		gauge(c.queueCapacity, stats.Capacity, stats.Name)
+		counter(c.queueEnqueued, stats.Enqueued, stats.Name)
+		counter(c.queueDequeued, stats.Dequeued, stats.Name)
+		counter(c.queueRejected, stats.Rejected, stats.Name)
+		ch <- waitHistogramMetric(c.queueWait, stats.Wait, stats.Name)
+	}
+
+	for _, pool := range c.handler.utilization.Pools() {
+		gauge(c.workersBusy, pool.BusyWorkers, pool.Queue)
+		ch <- prometheus.MustNewConstMetric(c.workerSaturation, prometheus.GaugeValue, pool.Saturation, pool.Queue)
+		ch <- prometheus.MustNewConstMetric(c.workerBusyTime, prometheus.CounterValue, pool.Busy.Seconds(), pool.Queue)
+		ch <- prometheus.MustNewConstMetric(c.workerIdleTime, prometheus.CounterValue, pool.Idle.Seconds(), pool.Queue)
+	}
+
+	for _, status := range c.handler.slos.Status() {
+		ch <- prometheus.MustNewConstMetric(c.sloTarget, prometheus.GaugeValue, status.Target, status.JobType)
+		ch <- prometheus.MustNewConstMetric(c.sloSuccess, prometheus.GaugeValue, status.SuccessRate, status.JobType)
+		ch <- prometheus.MustNewConstMetric(c.sloBudget, prometheus.GaugeValue, status.BudgetRemaining, status.JobType)
+		for _, burn := range status.BurnRates {
+			ch <- prometheus.MustNewConstMetric(c.sloBurnRate, prometheus.GaugeValue, burn.Rate, status.JobType, slo.WindowName(burn.Window))
+		}
+	}
+
+	isLeader := 0
+	if c.handler.elector.IsLeader() {
+		isLeader = 1
+	}
+	gauge(c.isLeader, isLeader)
+
+	info := version.Get()
+	gauge(c.buildInfo, 1, info.Version, info.GitSHA, info.BuildDate, info.GoVersion)
+
+	jobs, err := c.handler.jobStore.GetJobs(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.jobs, err)
+		return
+	}
+	type typeStatus struct {
+		jobType string
+		status  domain.JobStatus
+	}
+	counts := make(map[typeStatus]int)
+	for _, job := range jobs {
+		counts[typeStatus{job.Type, job.Status}]++
+	}
+	for key, count := range counts {
+		gauge(c.jobs, count, key.jobType, string(key.status))
+	}
+}
+
+func durationHistogramMetric(desc *prometheus.Desc, h *domain.Histogram, labels ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.Bounds))
+	for i, bound := range h.Bounds {
+		buckets[bound] = uint64(h.Buckets[i])
+	}
+	return prometheus.MustNewConstHistogram(desc, uint64(h.Count), h.Sum, buckets, labels...)
+}
+
+func waitHistogramMetric(desc *prometheus.Desc, h queue.Histogram, labels ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(queue.WaitBuckets))
+	for i, bound := range queue.WaitBuckets {
+		buckets[bound] = uint64(h.Buckets[i])
+	}
+	return prometheus.MustNewConstHistogram(desc, uint64(h.Count), h.Sum, buckets, labels...)
+}
+
+// Prometheus returns a handler serving the metrics in the Prometheus text
+// exposition format, along with the Go runtime and process metrics.
+func (h *MetricHandler) Prometheus() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		newPrometheusCollector(h),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
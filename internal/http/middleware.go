@@ -0,0 +1,305 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// gzipMinSize is the smallest response worth compressing; below this the
+// gzip framing overhead outweighs the savings.
+const gzipMinSize = 1024
+
+var gzipWriterPool = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// Gzip decompresses request bodies sent with Content-Encoding: gzip and
+// compresses responses of at least gzipMinSize bytes for clients that send
+// Accept-Encoding: gzip. Handlers see plain bytes in both directions, so body
+// size limits apply to the decompressed payload.
+func Gzip(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			gzipReader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				ErrorResponse(w, "Invalid gzip request body", http.StatusBadRequest)
+				return
+			}
+			defer gzipReader.Close()
+
+			r.Body = gzipReader
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		}
+
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		defer func() {
+			if err := gw.close(); err != nil {
+				logger.Error("Failed to write response", "error", err)
+			}
+		}()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(encoding), "gzip") && strings.TrimSpace(params) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether
+// it is large enough to compress.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	gz          *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(statusCode int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	g.statusCode = statusCode
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= gzipMinSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush commits to compression so streamed responses (e.g. exports) are
+// delivered progressively.
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		if err := g.decide(true); err != nil {
+			return
+		}
+	}
+
+	if g.gz != nil {
+		if err := g.gz.Flush(); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipResponseWriter) decide(compress bool) error {
+	g.decided = true
+
+	header := g.ResponseWriter.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	// Never double-encode, and bodiless responses have nothing to compress
+	if header.Get("Content-Encoding") != "" || g.statusCode == http.StatusNoContent || g.statusCode == http.StatusNotModified {
+		compress = false
+	}
+
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+
+		g.gz = gzipWriterPool.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+
+	g.ResponseWriter.WriteHeader(g.statusCode)
+
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(buf)
+	} else {
+		_, err = g.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (g *gzipResponseWriter) close() error {
+	if !g.decided {
+		// Small (or empty) response: send it uncompressed
+		if !g.wroteHeader {
+			return nil
+		}
+		return g.decide(false)
+	}
+
+	if g.gz == nil {
+		return nil
+	}
+
+	err := g.gz.Close()
+	gzipWriterPool.Put(g.gz)
+	g.gz = nil
+
+	return err
+}
+
+// Timeout gives each request a deadline of d. Handlers observe it through
+// r.Context(); if one gives up without writing a response, a 503 is sent so
+// timeouts look the same on every route.
+func Timeout(d time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		tw := &timeoutResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r.WithContext(ctx))
+
+		if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.Header().Set("Retry-After", "1")
+			ErrorResponse(w, "Request timed out", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+type timeoutResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (t *timeoutResponseWriter) WriteHeader(statusCode int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *timeoutResponseWriter) Write(p []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(p)
+}
+
+func (t *timeoutResponseWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		t.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (t *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// tracer starts the server span of each request.
+var tracer = otel.Tracer("github.com/karprabha/job-queue-backend/internal/http")
+
+// Trace runs each request in a server span, continuing the caller's trace
+// when the request carries a W3C traceparent header. The span is named after
+// the matched route pattern.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+		defer span.End()
+
+		sw := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+
+		// The mux records the matched pattern on the request it was given
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.statusCode))
+		if sw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.statusCode))
+		}
+	})
+}
+
+// statusResponseWriter remembers the response status for the span.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+}
+
+func (s *statusResponseWriter) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.statusCode = statusCode
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusResponseWriter) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(p)
+}
+
+func (s *statusResponseWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		s.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (s *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// QuarantineHandler exposes quarantined jobs: poison pills whose attempts
+// kept crashing or timing out their worker, held back until an operator
+// releases them.
+type QuarantineHandler struct {
+	store    store.JobStore
+	events   *events.Bus
+	logger   *slog.Logger
+	jobQueue queue.Queue
+}
+
+func NewQuarantineHandler(store store.JobStore, bus *events.Bus, logger *slog.Logger, jobQueue queue.Queue) *QuarantineHandler {
+	return &QuarantineHandler{
+		store:    store,
+		events:   bus,
+		logger:   logger,
+		jobQueue: jobQueue,
+	}
+}
+
+// ListQuarantinedJobs returns every quarantined job. Their last_error and
+// error_class tell how the last attempt ended.
+func (h *QuarantineHandler) ListQuarantinedJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.store.GetQuarantinedJobs(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get quarantined jobs")
+		return
+	}
+
+	response := make(JobListResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, jobToResponse(&job))
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// ReleaseQuarantinedJob moves a quarantined job back to pending with its
+// attempts and crashes reset, and enqueues it.
+func (h *QuarantineHandler) ReleaseQuarantinedJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	if err := h.store.ReleaseQuarantinedJob(r.Context(), jobID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrJobNotFound):
+			ErrorResponse(w, "Job not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrInvalidTransition):
+			ErrorResponse(w, "Job is not quarantined", http.StatusConflict)
+		default:
+			StoreErrorResponse(w, err, "Failed to release job")
+		}
+		return
+	}
+	h.logger.Info("Quarantined job released", "event", "job_unquarantined", "job_id", jobID)
+
+	job, err := h.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	h.events.Publish(r.Context(), events.ForJob(events.JobUnquarantined, job))
+
+	switch err := h.jobQueue.Enqueue(r.Context(), jobID); {
+	case err == nil:
+		h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", jobID)
+	case errors.Is(err, queue.ErrPaused):
+		// Enqueued by the catch-up on resume
+		h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", jobID)
+	default:
+		// Job stays pending; the sweeper will enqueue it once there is room
+		h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", jobID, "error", err)
+	}
+
+	if err := WriteResponse(w, r, jobToResponse(job), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
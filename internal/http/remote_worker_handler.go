@@ -0,0 +1,281 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/remote"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+)
+
+// maxLeaseBatch caps how many jobs one POST /workers/lease call may claim.
+const maxLeaseBatch = 100
+
+// RemoteWorkerHandler lets workers outside this process consume the queue:
+// they lease jobs with a visibility timeout, heartbeat to keep the lease, and
+// ack or nack the outcome. A lease that lapses is reclaimed by the lease
+// reaper like a local worker's.
+type RemoteWorkerHandler struct {
+	service           *remote.Service
+	jobs              *JobHandler
+	gate              *worker.Gate
+	valve             *queue.Valve
+	logger            *slog.Logger
+	defaultVisibility time.Duration
+	maxBodyBytes      int64
+}
+
+func NewRemoteWorkerHandler(service *remote.Service, jobs *JobHandler, gate *worker.Gate, valve *queue.Valve, logger *slog.Logger, defaultVisibility time.Duration, maxBodyBytes int64) *RemoteWorkerHandler {
+	return &RemoteWorkerHandler{
+		service:           service,
+		jobs:              jobs,
+		gate:              gate,
+		valve:             valve,
+		logger:            logger,
+		defaultVisibility: defaultVisibility,
+		maxBodyBytes:      maxBodyBytes,
+	}
+}
+
+// LeasedJobResponse is a job handed to a remote worker. Token identifies the
+// lease and must be echoed back on heartbeat, ack and nack.
+type LeasedJobResponse struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Attempt        int             `json:"attempt"`
+	MaxRetries     int             `json:"max_retries"`
+	Priority       string          `json:"priority"`
+	LeaseExpiresAt string          `json:"lease_expires_at"`
+	// Redelivered is set when an earlier delivery may have run the job
+	Redelivered bool `json:"redelivered,omitempty"`
+	// Token is the lease's execution token, also usable downstream as an
+	// idempotency key
+	Token string `json:"token"`
+}
+
+type HeartbeatRequest struct {
+	Token             string `json:"token"`
+	VisibilityTimeout string `json:"visibility_timeout"`
+}
+
+type HeartbeatResponse struct {
+	LeaseExpiresAt string `json:"lease_expires_at"`
+}
+
+type AckRequest struct {
+	Token  string          `json:"token"`
+	Result json.RawMessage `json:"result"`
+}
+
+type NackRequest struct {
+	Token string `json:"token"`
+	Error string `json:"error"`
+	// Permanent sends the job straight to the dead-letter queue
+	Permanent bool `json:"permanent"`
+}
+
+// Lease claims up to max due pending jobs of the requested types. An empty
+// list means there is no work right now; workers are expected to poll.
+// Leasing stops at shutdown and while processing is paused, but continues
+// during a drain so queued work can finish.
+func (h *RemoteWorkerHandler) Lease(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-h.jobs.shutdownCtx.Done():
+		ErrorResponse(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	query := r.URL.Query()
+
+	types := make([]string, 0)
+	for _, jobType := range strings.Split(query.Get("types"), ",") {
+		if jobType = strings.TrimSpace(jobType); jobType != "" {
+			types = append(types, jobType)
+		}
+	}
+	if len(types) == 0 {
+		ErrorResponse(w, "types is required, e.g. ?types=email_send,report", http.StatusBadRequest)
+		return
+	}
+
+	limit := 1
+	if value := query.Get("max"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLeaseBatch {
+			ErrorResponse(w, fmt.Sprintf("max must be between 1 and %d", maxLeaseBatch), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	visibility, err := h.visibilityTimeout(query.Get("visibility_timeout"))
+	if err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workerID := query.Get("worker_id")
+	if workerID == "" {
+		workerID = r.Header.Get("X-Worker-ID")
+	}
+	if workerID == "" {
+		workerID = r.RemoteAddr
+	}
+
+	// Paused job types, and everything while processing or the queue is
+	// paused, are left pending just as for local workers
+	types = h.gate.UnpausedTypes(types)
+
+	jobs := make([]domain.Job, 0)
+	if !h.gate.Paused() && !h.valve.Paused() && len(types) > 0 {
+		jobs, err = h.service.Lease(r.Context(), types, limit, workerID, visibility)
+		if err != nil {
+			StoreErrorResponse(w, err, "Failed to lease jobs")
+			return
+		}
+	}
+
+	responses := make([]LeasedJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, LeasedJobResponse{
+			ID:             job.ID,
+			Type:           job.Type,
+			Payload:        job.Payload,
+			Attempt:        job.Attempts,
+			MaxRetries:     job.MaxRetries,
+			Priority:       job.Priority.String(),
+			LeaseExpiresAt: job.LeaseExpiresAt.Format(time.RFC3339),
+			Redelivered:    job.Redelivered,
+			Token:          job.ExecutionToken,
+		})
+	}
+
+	if err := WriteResponseWithMeta(w, r, responses, &Meta{Count: len(responses)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// Heartbeat extends a lease by the visibility timeout, which defaults to the
+// server's lease duration.
+func (h *RemoteWorkerHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	var request HeartbeatRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+	if request.Token == "" {
+		ErrorResponse(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	visibility, err := h.visibilityTimeout(request.VisibilityTimeout)
+	if err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	leaseExpiresAt, err := h.service.Extend(r.Context(), jobID, request.Token, visibility)
+	if err != nil {
+		leaseErrorResponse(w, err, "Failed to extend lease")
+		return
+	}
+
+	if err := WriteResponse(w, r, HeartbeatResponse{LeaseExpiresAt: leaseExpiresAt.Format(time.RFC3339)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// Ack completes a leased job, storing the optional result.
+func (h *RemoteWorkerHandler) Ack(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	var request AckRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+	if request.Token == "" {
+		ErrorResponse(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Ack(r.Context(), jobID, request.Token, request.Result); err != nil {
+		leaseErrorResponse(w, err, "Failed to complete job")
+		return
+	}
+
+	h.writeJob(w, r, jobID)
+}
+
+// Nack fails the leased attempt. The job is retried with backoff while it has
+// retries left, unless the worker marks the failure permanent.
+func (h *RemoteWorkerHandler) Nack(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	var request NackRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+	if request.Token == "" {
+		ErrorResponse(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Nack(r.Context(), jobID, request.Token, request.Error, request.Permanent); err != nil {
+		leaseErrorResponse(w, err, "Failed to fail job")
+		return
+	}
+
+	h.writeJob(w, r, jobID)
+}
+
+func (h *RemoteWorkerHandler) visibilityTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return h.defaultVisibility, nil
+	}
+
+	visibility, err := time.ParseDuration(value)
+	if err != nil || visibility <= 0 {
+		return 0, errors.New("visibility_timeout must be a positive duration such as 30s")
+	}
+
+	return visibility, nil
+}
+
+func (h *RemoteWorkerHandler) writeJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	job, err := h.jobs.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get job")
+		return
+	}
+
+	h.jobs.writeJob(w, r, job, http.StatusOK)
+}
+
+// leaseErrorResponse maps store errors from lease operations to responses.
+func leaseErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrJobNotFound):
+		ErrorResponse(w, "Job not found", http.StatusNotFound)
+	case errors.Is(err, store.ErrLeaseLost), errors.Is(err, store.ErrInvalidTransition):
+		ErrorResponse(w, "Lease is no longer held; the job was reclaimed or already finished", http.StatusConflict)
+	default:
+		StoreErrorResponse(w, err, message)
+	}
+}
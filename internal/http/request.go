@@ -0,0 +1,56 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// readBody reads the whole request body, capped at maxBytes. Exceeding the
+// cap returns an error wrapping *http.MaxBytesError.
+func readBody(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	return io.ReadAll(r.Body)
+}
+
+// decodeJSONBody streams a single JSON value from the request body into dst,
+// capped at maxBytes. Unknown fields and trailing data are rejected.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+
+	// Anything after the first value (other than whitespace) is an error
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return errors.New("request body must contain a single JSON value")
+	}
+
+	return nil
+}
+
+// bodyErrorResponse maps an error from readBody/decodeJSONBody to a response.
+func bodyErrorResponse(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if errors.Is(err, io.EOF) {
+		ErrorResponse(w, "Request body is empty", http.StatusBadRequest)
+		return
+	}
+
+	ErrorResponse(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+}
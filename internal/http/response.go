@@ -1,12 +1,46 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/schema"
 )
 
+// Envelope is the shape of every JSON (and MessagePack) response body:
+// exactly one of Data or Error is set, and Meta carries optional
+// cross-cutting information such as list counts.
+type Envelope struct {
+	Data  any        `json:"data,omitempty"`
+	Meta  *Meta      `json:"meta,omitempty"`
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+type Meta struct {
+	Count int `json:"count"`
+}
+
+// ErrorBody carries a human-readable message and, for payload validation
+// failures, one entry per offending field.
+type ErrorBody struct {
+	Message string              `json:"message"`
+	Details []schema.FieldError `json:"details,omitempty"`
+}
+
 func ErrorResponse(w http.ResponseWriter, message string, statusCode int) {
-	jsonBytes, err := json.Marshal(map[string]string{"error": message})
+	writeError(w, &ErrorBody{Message: message}, statusCode)
+}
+
+// ValidationErrorResponse answers a payload that failed its job type's schema
+// with a 400 listing every field-level error.
+func ValidationErrorResponse(w http.ResponseWriter, err *schema.ValidationError) {
+	writeError(w, &ErrorBody{Message: "Payload does not match the schema for job type " + err.JobType, Details: err.Fields}, http.StatusBadRequest)
+}
+
+func writeError(w http.ResponseWriter, body *ErrorBody, statusCode int) {
+	jsonBytes, err := json.Marshal(Envelope{Error: body})
 	if err != nil {
 		// If we can't marshal, fall back to plain text error
 		// Headers haven't been written yet, so http.Error is safe
@@ -14,7 +48,7 @@ func ErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentTypeJSON)
 	w.WriteHeader(statusCode)
 
 	if _, err := w.Write(jsonBytes); err != nil {
@@ -23,3 +57,40 @@ func ErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 		return
 	}
 }
+
+// WriteResponse wraps data in an Envelope, encodes it in the format negotiated
+// from the Accept header and writes it with statusCode. Encoding failures are
+// answered with a 500; the returned error only reports failures writing to
+// the client.
+func WriteResponse(w http.ResponseWriter, r *http.Request, data any, statusCode int) error {
+	return WriteResponseWithMeta(w, r, data, nil, statusCode)
+}
+
+func WriteResponseWithMeta(w http.ResponseWriter, r *http.Request, data any, meta *Meta, statusCode int) error {
+	contentType, responseBytes, err := encodeResponse(negotiateContentType(r), Envelope{Data: data, Meta: meta})
+	if err != nil {
+		ErrorResponse(w, "Failed to marshal response", http.StatusInternalServerError)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write(responseBytes)
+	return err
+}
+
+// StoreErrorResponse answers a failed store call. Context errors caused by the
+// request deadline or the client going away map to 503 and 408 respectively;
+// anything else is a 500 with the given message.
+func StoreErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		ErrorResponse(w, "Request timed out", http.StatusServiceUnavailable)
+	case errors.Is(err, context.Canceled):
+		ErrorResponse(w, "Request cancelled", http.StatusRequestTimeout)
+	default:
+		ErrorResponse(w, message, http.StatusInternalServerError)
+	}
+}
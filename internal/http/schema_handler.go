@@ -0,0 +1,112 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/schema"
+)
+
+// SchemaHandler manages the JSON Schemas that job payloads are validated
+// against, one per job type.
+type SchemaHandler struct {
+	schemas      *schema.Registry
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewSchemaHandler(schemas *schema.Registry, logger *slog.Logger, maxBodyBytes int64) *SchemaHandler {
+	return &SchemaHandler{
+		schemas:      schemas,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type SchemaResponse struct {
+	Type   string          `json:"type"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// PutSchema registers the request body as the schema for a job type,
+// replacing any existing one. Jobs already submitted are not re-checked.
+func (h *SchemaHandler) PutSchema(w http.ResponseWriter, r *http.Request) {
+	jobType := r.PathValue("type")
+
+	body, err := readBody(w, r, h.maxBodyBytes)
+	if err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+	if len(body) == 0 {
+		ErrorResponse(w, "Request body is empty", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.schemas.Set(jobType, body); err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.Info("Schema registered", "event", "schema_registered", "job_type", jobType)
+
+	if err := WriteResponse(w, r, SchemaResponse{Type: jobType, Schema: body}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *SchemaHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	jobType := r.PathValue("type")
+
+	source, err := h.schemas.Get(jobType)
+	if err != nil {
+		if errors.Is(err, schema.ErrSchemaNotFound) {
+			ErrorResponse(w, "Schema not found", http.StatusNotFound)
+			return
+		}
+		ErrorResponse(w, "Failed to get schema", http.StatusInternalServerError)
+		return
+	}
+
+	if err := WriteResponse(w, r, SchemaResponse{Type: jobType, Schema: source}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *SchemaHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	types := h.schemas.Types()
+
+	responses := make([]SchemaResponse, 0, len(types))
+	for _, jobType := range types {
+		source, err := h.schemas.Get(jobType)
+		if err != nil {
+			// Deleted since Types was read
+			continue
+		}
+		responses = append(responses, SchemaResponse{Type: jobType, Schema: source})
+	}
+
+	if err := WriteResponseWithMeta(w, r, responses, &Meta{Count: len(responses)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *SchemaHandler) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+	jobType := r.PathValue("type")
+
+	if err := h.schemas.Delete(jobType); err != nil {
+		if errors.Is(err, schema.ErrSchemaNotFound) {
+			ErrorResponse(w, "Schema not found", http.StatusNotFound)
+			return
+		}
+		ErrorResponse(w, "Failed to delete schema", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Schema deleted", "event", "schema_deleted", "job_type", jobType)
+
+	w.WriteHeader(http.StatusNoContent)
+}
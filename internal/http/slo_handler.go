@@ -0,0 +1,67 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/slo"
+)
+
+// SLOHandler reports the job types' success-rate objectives and their error
+// budgets.
+type SLOHandler struct {
+	tracker *slo.Tracker
+	logger  *slog.Logger
+}
+
+func NewSLOHandler(tracker *slo.Tracker, logger *slog.Logger) *SLOHandler {
+	return &SLOHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+type SLOResponse struct {
+	Type   string  `json:"type"`
+	Target float64 `json:"target"`
+	Window string  `json:"window"`
+	// Jobs completed and dead-lettered within the window
+	Good        int     `json:"good"`
+	Bad         int     `json:"bad"`
+	SuccessRate float64 `json:"success_rate"`
+	// Share of the error budget left; negative once overspent
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+	Exhausted            bool    `json:"exhausted"`
+	// Burn rate by window, e.g. "1h"; 1 spends the budget exactly over the
+	// SLO window
+	BurnRates map[string]float64 `json:"burn_rates"`
+}
+
+// GetSLOs handles GET /slo: every objective in SLO_TARGETS, by job type.
+func (h *SLOHandler) GetSLOs(w http.ResponseWriter, r *http.Request) {
+	statuses := h.tracker.Status()
+
+	response := make([]SLOResponse, 0, len(statuses))
+	for _, status := range statuses {
+		entry := SLOResponse{
+			Type:                 status.JobType,
+			Target:               status.Target,
+			Window:               slo.WindowName(status.Window),
+			Good:                 status.Good,
+			Bad:                  status.Bad,
+			SuccessRate:          status.SuccessRate,
+			ErrorBudgetRemaining: status.BudgetRemaining,
+			Exhausted:            status.Exhausted,
+			BurnRates:            make(map[string]float64, len(status.BurnRates)),
+		}
+		for _, burn := range status.BurnRates {
+			entry.BurnRates[slo.WindowName(burn.Window)] = burn.Rate
+		}
+		response = append(response, entry)
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/alert"
+)
+
+// SlowJobHandler lists the jobs the slow job monitor has flagged.
+type SlowJobHandler struct {
+	monitor *alert.SlowMonitor
+	logger  *slog.Logger
+}
+
+func NewSlowJobHandler(monitor *alert.SlowMonitor, logger *slog.Logger) *SlowJobHandler {
+	return &SlowJobHandler{
+		monitor: monitor,
+		logger:  logger,
+	}
+}
+
+type SlowJobResponse struct {
+	JobID     string `json:"job_id"`
+	Type      string `json:"type"`
+	Queue     string `json:"queue,omitempty"`
+	Attempt   int    `json:"attempt"`
+	Worker    string `json:"worker,omitempty"`
+	StartedAt string `json:"started_at"`
+	// How long the attempt has been processing, and the limit it passed
+	ElapsedSeconds   float64 `json:"elapsed_seconds"`
+	ThresholdSeconds float64 `json:"threshold_seconds"`
+	// "configured", or "p99" when the threshold is the type's p99 processing
+	// time
+	ThresholdSource string          `json:"threshold_source"`
+	Payload         json.RawMessage `json:"payload"`
+}
+
+// GetSlowJobs handles GET /admin/slow: jobs still processing that passed
+// their type's slow threshold at the last check, longest running first.
+func (h *SlowJobHandler) GetSlowJobs(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	slow := h.monitor.Slow()
+
+	response := make([]SlowJobResponse, 0, len(slow))
+	for _, job := range slow {
+		response = append(response, SlowJobResponse{
+			JobID:            job.JobID,
+			Type:             job.Type,
+			Queue:            job.Queue,
+			Attempt:          job.Attempt,
+			Worker:           job.ClaimedBy,
+			StartedAt:        job.StartedAt.UTC().Format(time.RFC3339),
+			ElapsedSeconds:   now.Sub(job.StartedAt).Seconds(),
+			ThresholdSeconds: job.Threshold.Seconds(),
+			ThresholdSource:  job.Source,
+			Payload:          job.Payload,
+		})
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// StatsResponse summarizes the job latency histograms, overall and by job
+// type.
+type StatsResponse struct {
+	WaitSeconds       LatencySummary            `json:"wait_seconds"`
+	ProcessingSeconds LatencySummary            `json:"processing_seconds"`
+	Types             map[string]TypeStatsEntry `json:"types"`
+}
+
+type TypeStatsEntry struct {
+	WaitSeconds       LatencySummary `json:"wait_seconds"`
+	ProcessingSeconds LatencySummary `json:"processing_seconds"`
+}
+
+// LatencySummary gives percentiles estimated from histogram buckets, so they
+// are only as precise as the bucket bounds.
+type LatencySummary struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+func latencySummary(h *domain.Histogram) LatencySummary {
+	if h == nil || h.Count == 0 {
+		return LatencySummary{}
+	}
+
+	return LatencySummary{
+		Count: h.Count,
+		Mean:  h.Sum / float64(h.Count),
+		P50:   h.Quantile(0.50),
+		P95:   h.Quantile(0.95),
+		P99:   h.Quantile(0.99),
+	}
+}
+
+// mergeHistograms sums the histograms of every job type into one.
+func mergeHistograms(histograms map[string]*domain.Histogram) *domain.Histogram {
+	var merged *domain.Histogram
+	for _, histogram := range histograms {
+		if merged == nil {
+			merged = histogram.Clone()
+			continue
+		}
+		merged.Merge(histogram)
+	}
+	return merged
+}
+
+// GetStats handles GET /stats: queue wait and processing time percentiles
+// from the latency histograms.
+func (h *MetricHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	metrics, err := h.metricStore.GetMetrics(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get stats")
+		return
+	}
+
+	types := make(map[string]TypeStatsEntry)
+	for jobType, histogram := range metrics.JobWaits {
+		entry := types[jobType]
+		entry.WaitSeconds = latencySummary(histogram)
+		types[jobType] = entry
+	}
+	for jobType, histogram := range metrics.JobProcessing {
+		entry := types[jobType]
+		entry.ProcessingSeconds = latencySummary(histogram)
+		types[jobType] = entry
+	}
+
+	response := StatsResponse{
+		WaitSeconds:       latencySummary(mergeHistograms(metrics.JobWaits)),
+		ProcessingSeconds: latencySummary(mergeHistograms(metrics.JobProcessing)),
+		Types:             types,
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// TemplateHandler manages named job templates.
+type TemplateHandler struct {
+	store        store.TemplateStore
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewTemplateHandler(store store.TemplateStore, logger *slog.Logger, maxBodyBytes int64) *TemplateHandler {
+	return &TemplateHandler{
+		store:        store,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type TemplateRequest struct {
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	MaxRetries *int            `json:"max_retries,omitempty"`
+	Backoff    *BackoffRequest `json:"backoff,omitempty"`
+	Priority   string          `json:"priority,omitempty"`
+	Timeout    string          `json:"timeout,omitempty"`
+}
+
+type TemplateResponse struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	MaxRetries int             `json:"max_retries"`
+	Backoff    BackoffRequest  `json:"backoff"`
+	Priority   string          `json:"priority"`
+	Timeout    string          `json:"timeout,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+	UpdatedAt  string          `json:"updated_at"`
+}
+
+func templateToResponse(template *domain.Template) TemplateResponse {
+	response := TemplateResponse{
+		Name:       template.Name,
+		Type:       template.JobType,
+		Payload:    template.Payload,
+		MaxRetries: template.MaxRetries,
+		Backoff: BackoffRequest{
+			Policy:    string(template.BackoffPolicy),
+			BaseDelay: template.BackoffBaseDelay.String(),
+		},
+		Priority:  template.Priority.String(),
+		CreatedAt: template.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: template.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if template.Timeout > 0 {
+		response.Timeout = template.Timeout.String()
+	}
+
+	return response
+}
+
+// toTemplate validates the request with the same rules as a job submission.
+func (r TemplateRequest) toTemplate(name string) (*domain.Template, error) {
+	if r.Type == "" {
+		return nil, errors.New("type is required and must be non-empty")
+	}
+
+	// Settings are checked by applying them to a job, so templates accept
+	// exactly what submissions do
+	job := domain.NewJob(r.Type, r.Payload)
+	settings := CreateJobRequest{
+		MaxRetries: r.MaxRetries,
+		Backoff:    r.Backoff,
+		Timeout:    r.Timeout,
+	}
+	if err := applyRetryPolicy(job, settings); err != nil {
+		return nil, err
+	}
+	if err := applyTimeout(job, settings); err != nil {
+		return nil, err
+	}
+
+	priority, err := domain.ParsePriority(r.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	template := domain.NewTemplate(name, r.Type, r.Payload)
+	template.MaxRetries = job.MaxRetries
+	template.BackoffPolicy = job.BackoffPolicy
+	template.BackoffBaseDelay = job.BackoffBaseDelay
+	template.Priority = priority
+	template.Timeout = job.Timeout
+
+	return template, nil
+}
+
+// PutTemplate registers a template under the path's name, replacing any
+// existing one. Jobs already submitted from it are not changed.
+func (h *TemplateHandler) PutTemplate(w http.ResponseWriter, r *http.Request) {
+	var request TemplateRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	template, err := request.toTemplate(r.PathValue("name"))
+	if err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.PutTemplate(r.Context(), template); err != nil {
+		StoreErrorResponse(w, err, "Failed to save template")
+		return
+	}
+	h.logger.Info("Template registered", "event", "template_registered", "template", template.Name, "job_type", template.JobType)
+
+	if err := WriteResponse(w, r, templateToResponse(template), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.store.GetTemplates(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get templates")
+		return
+	}
+
+	response := make([]TemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, templateToResponse(&template))
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.store.GetTemplate(r.Context(), r.PathValue("name"))
+	if err != nil {
+		if errors.Is(err, store.ErrTemplateNotFound) {
+			ErrorResponse(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get template")
+		return
+	}
+
+	if err := WriteResponse(w, r, templateToResponse(template), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	if err := h.store.DeleteTemplate(r.Context(), name); err != nil {
+		if errors.Is(err, store.ErrTemplateNotFound) {
+			ErrorResponse(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to delete template")
+		return
+	}
+	h.logger.Info("Template deleted", "event", "template_deleted", "template", name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoadTemplates registers the templates in a JSON file mapping template
+// names to template definitions, as accepted by PutTemplate.
+func (h *TemplateHandler) LoadTemplates(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var requests map[string]TemplateRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for name, request := range requests {
+		template, err := request.toTemplate(name)
+		if err != nil {
+			return fmt.Errorf("template %q: %w", name, err)
+		}
+
+		if err := h.store.PutTemplate(ctx, template); err != nil {
+			return err
+		}
+	}
+	h.logger.Info("Templates loaded", "event", "templates_loaded", "path", path, "count", len(requests))
+
+	return nil
+}
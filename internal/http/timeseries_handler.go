@@ -0,0 +1,61 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+// TimeseriesResponse gives job throughput per minute, oldest first. The last
+// point is the current minute, still being counted.
+type TimeseriesResponse struct {
+	Interval string                 `json:"interval"`
+	Points   []TimeseriesPointEntry `json:"points"`
+}
+
+type TimeseriesPointEntry struct {
+	Time      string `json:"time"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	// Failed attempts, including ones retried since
+	Failed int `json:"failed"`
+}
+
+// GetTimeseries handles GET /metrics/timeseries: jobs created, completed and
+// failed per minute over the window query parameter, a Go duration, or over
+// everything kept when it is absent.
+func (h *MetricHandler) GetTimeseries(w http.ResponseWriter, r *http.Request) {
+	minutes := math.MaxInt
+	if value := r.URL.Query().Get("window"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < time.Minute {
+			ErrorResponse(w, "window must be a duration of at least 1m, e.g. 1h", http.StatusBadRequest)
+			return
+		}
+		minutes = int(window / time.Minute)
+	}
+
+	points, err := h.metricStore.GetThroughput(r.Context(), minutes)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get throughput")
+		return
+	}
+
+	response := TimeseriesResponse{
+		Interval: "1m",
+		Points:   make([]TimeseriesPointEntry, 0, len(points)),
+	}
+	for _, point := range points {
+		response.Points = append(response.Points, TimeseriesPointEntry{
+			Time:      point.Minute.Format(time.RFC3339),
+			Created:   point.Created,
+			Completed: point.Completed,
+			Failed:    point.Failed,
+		})
+	}
+
+	if err := WriteResponse(w, r, response, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/karprabha/job-queue-backend/internal/version"
+)
+
+type VersionResponse struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"git_sha"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+func versionToResponse(info version.Info) VersionResponse {
+	return VersionResponse{
+		Version:   info.Version,
+		GitSHA:    info.GitSHA,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+	}
+}
+
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := WriteResponse(w, r, versionToResponse(version.Get()), http.StatusOK); err != nil {
+		return
+	}
+}
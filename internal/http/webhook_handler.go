@@ -0,0 +1,208 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/webhook"
+)
+
+// WebhookHandler manages the webhook subscriptions events are delivered to.
+type WebhookHandler struct {
+	store        store.WebhookStore
+	outbox       store.WebhookOutbox
+	dispatcher   *webhook.Dispatcher
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewWebhookHandler(store store.WebhookStore, outbox store.WebhookOutbox, dispatcher *webhook.Dispatcher, logger *slog.Logger, maxBodyBytes int64) *WebhookHandler {
+	return &WebhookHandler{
+		store:        store,
+		outbox:       outbox,
+		dispatcher:   dispatcher,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	JobTypes   []string `json:"job_types,omitempty"`
+}
+
+// WebhookResponse describes a subscription. The secret is never returned.
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	HasSecret  bool     `json:"has_secret"`
+	EventTypes []string `json:"event_types"`
+	JobTypes   []string `json:"job_types"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            string `json:"id"`
+	EventType     string `json:"event_type"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt string `json:"next_attempt_at"`
+	LastError     string `json:"last_error,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func webhookToResponse(subscription *domain.WebhookSubscription) WebhookResponse {
+	response := WebhookResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		HasSecret:  subscription.Secret != "",
+		EventTypes: subscription.EventTypes,
+		JobTypes:   subscription.JobTypes,
+		CreatedAt:  subscription.CreatedAt.Format(time.RFC3339),
+	}
+
+	if response.EventTypes == nil {
+		response.EventTypes = []string{}
+	}
+	if response.JobTypes == nil {
+		response.JobTypes = []string{}
+	}
+
+	return response
+}
+
+func (r WebhookRequest) toSubscription() (*domain.WebhookSubscription, error) {
+	target, err := url.Parse(r.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.New("url is required and must be an absolute http or https URL")
+	}
+
+	return domain.NewWebhookSubscription(r.URL, r.Secret, r.EventTypes, r.JobTypes), nil
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var request WebhookRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	subscription, err := request.toSubscription()
+	if err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.PutWebhook(r.Context(), subscription); err != nil {
+		StoreErrorResponse(w, err, "Failed to save webhook")
+		return
+	}
+	h.logger.Info("Webhook registered", "event", "webhook_registered", "webhook_id", subscription.ID, "url", subscription.URL, "event_types", subscription.EventTypes, "job_types", subscription.JobTypes)
+
+	if err := WriteResponse(w, r, webhookToResponse(subscription), http.StatusCreated); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.store.GetWebhooks(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get webhooks")
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		response = append(response, webhookToResponse(&subscription))
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.store.GetWebhook(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			ErrorResponse(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get webhook")
+		return
+	}
+
+	if err := WriteResponse(w, r, webhookToResponse(subscription), http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// DeleteWebhook removes a subscription along with its pending deliveries.
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.store.DeleteWebhook(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			ErrorResponse(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to delete webhook")
+		return
+	}
+	if err := h.dispatcher.Forget(r.Context(), id); err != nil {
+		h.logger.Error("Failed to drop pending webhook deliveries", "event", "webhook_outbox_failed", "webhook_id", id, "error", err)
+	}
+	h.logger.Info("Webhook deleted", "event", "webhook_deleted", "webhook_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries lists the deliveries to a subscription still waiting to be
+// sent or retried.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, err := h.store.GetWebhook(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			ErrorResponse(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get webhook")
+		return
+	}
+
+	deliveries, err := h.outbox.GetDeliveries(r.Context(), id)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, WebhookDeliveryResponse{
+			ID:            delivery.ID,
+			EventType:     delivery.EventType,
+			Attempts:      delivery.Attempts,
+			NextAttemptAt: delivery.NextAttemptAt.Format(time.RFC3339),
+			LastError:     delivery.LastError,
+			CreatedAt:     delivery.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/tracing"
+)
+
+// maxWorkflowSteps bounds how many jobs a single workflow submission creates.
+const maxWorkflowSteps = 100
+
+// WorkflowHandler submits and tracks workflows. Steps are created as regular
+// jobs through the JobHandler's stores and queue.
+type WorkflowHandler struct {
+	store        store.WorkflowStore
+	jobs         *JobHandler
+	logger       *slog.Logger
+	maxBodyBytes int64
+}
+
+func NewWorkflowHandler(store store.WorkflowStore, jobs *JobHandler, logger *slog.Logger, maxBodyBytes int64) *WorkflowHandler {
+	return &WorkflowHandler{
+		store:        store,
+		jobs:         jobs,
+		logger:       logger,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+type CreateWorkflowRequest struct {
+	Name  string                `json:"name"`
+	Steps []WorkflowStepRequest `json:"steps"`
+}
+
+// WorkflowStepRequest describes one step. DependsOn names other steps in the
+// same workflow; steps without dependencies start immediately.
+type WorkflowStepRequest struct {
+	Name             string          `json:"name"`
+	Type             string          `json:"type"`
+	Payload          json.RawMessage `json:"payload"`
+	MaxRetries       *int            `json:"max_retries,omitempty"`
+	Priority         string          `json:"priority,omitempty"`
+	DependsOn        []string        `json:"depends_on,omitempty"`
+	DependencyPolicy string          `json:"dependency_policy,omitempty"`
+}
+
+type WorkflowResponse struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Status    string                 `json:"status"`
+	CreatedAt string                 `json:"created_at"`
+	Steps     []WorkflowStepResponse `json:"steps"`
+}
+
+type WorkflowStepResponse struct {
+	Name      string   `json:"name"`
+	JobID     string   `json:"job_id"`
+	Status    string   `json:"status"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// CreateWorkflow validates the DAG and creates one job per step, each
+// blocked on the jobs of the steps it depends on.
+func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
+	if !h.jobs.acceptingJobs(w) {
+		return
+	}
+
+	var request CreateWorkflowRequest
+	if err := decodeJSONBody(w, r, h.maxBodyBytes, &request); err != nil {
+		bodyErrorResponse(w, err)
+		return
+	}
+
+	if request.Name == "" {
+		ErrorResponse(w, "Workflow name is required and must be non-empty", http.StatusBadRequest)
+		return
+	}
+
+	order, err := workflowOrder(request.Steps)
+	if err != nil {
+		ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobs := make(map[string]*domain.Job, len(order))
+	for _, step := range order {
+		job, err := workflowStepJob(step, jobs)
+		if err != nil {
+			ErrorResponse(w, fmt.Sprintf("step %q: %s", step.Name, err), http.StatusBadRequest)
+			return
+		}
+		if !h.jobs.validPayload(w, step.Type, step.Payload) {
+			return
+		}
+		jobs[step.Name] = job
+	}
+
+	workflowSteps := make([]domain.WorkflowStep, 0, len(request.Steps))
+	for _, step := range request.Steps {
+		workflowSteps = append(workflowSteps, domain.WorkflowStep{
+			Name:      step.Name,
+			JobID:     jobs[step.Name].ID,
+			DependsOn: step.DependsOn,
+		})
+	}
+	workflow := domain.NewWorkflow(request.Name, workflowSteps)
+
+	// Dependencies must exist before their dependents, so create in
+	// topological order and roll everything back if any step fails
+	created := make([]*domain.Job, 0, len(order))
+	for _, step := range order {
+		job := jobs[step.Name]
+		job.WorkflowID = workflow.ID
+		tracing.InjectJob(r.Context(), job)
+		if err := h.jobs.store.CreateJob(r.Context(), job); err != nil {
+			h.deleteJobs(r.Context(), created)
+			StoreErrorResponse(w, err, "Failed to create workflow")
+			return
+		}
+		created = append(created, job)
+	}
+
+	if err := h.store.CreateWorkflow(r.Context(), workflow); err != nil {
+		h.deleteJobs(r.Context(), created)
+		StoreErrorResponse(w, err, "Failed to create workflow")
+		return
+	}
+	h.logger.Info("Workflow created", "event", "workflow_created", "workflow_id", workflow.ID, "name", workflow.Name, "steps", len(workflow.Steps))
+
+	for _, job := range created {
+		h.jobs.events.Publish(r.Context(), events.ForJob(events.JobCreated, job))
+
+		if job.Status != domain.StatusPending {
+			continue
+		}
+		switch err := h.jobs.jobQueue.Enqueue(r.Context(), job.ID); {
+		case err == nil:
+			h.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", job.ID, "workflow_id", workflow.ID)
+		case errors.Is(err, queue.ErrPaused):
+			// Enqueued by the catch-up on resume
+			h.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", job.ID, "workflow_id", workflow.ID)
+		default:
+			// Job stays pending; the sweeper will enqueue it once there is room
+			h.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", job.ID, "workflow_id", workflow.ID, "error", err)
+		}
+	}
+
+	h.writeWorkflow(w, r, workflow, http.StatusCreated)
+}
+
+// workflowOrder validates the steps and returns them in an order where every
+// step comes after the steps it depends on.
+func workflowOrder(steps []WorkflowStepRequest) ([]WorkflowStepRequest, error) {
+	if len(steps) == 0 {
+		return nil, errors.New("workflow must have at least one step")
+	}
+	if len(steps) > maxWorkflowSteps {
+		return nil, fmt.Errorf("workflow must have at most %d steps", maxWorkflowSteps)
+	}
+
+	byName := make(map[string]WorkflowStepRequest, len(steps))
+	for _, step := range steps {
+		if step.Name == "" {
+			return nil, errors.New("every step needs a name")
+		}
+		if _, ok := byName[step.Name]; ok {
+			return nil, fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		byName[step.Name] = step
+	}
+
+	// Kahn's algorithm: repeatedly take steps whose dependencies are all placed
+	remaining := make(map[string]int, len(steps))
+	dependents := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		for _, dependency := range step.DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", step.Name, dependency)
+			}
+			dependents[dependency] = append(dependents[dependency], step.Name)
+		}
+		remaining[step.Name] = len(step.DependsOn)
+	}
+
+	order := make([]WorkflowStepRequest, 0, len(steps))
+	for _, step := range steps {
+		if remaining[step.Name] == 0 {
+			order = append(order, step)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, name := range dependents[order[i].Name] {
+			remaining[name]--
+			if remaining[name] == 0 {
+				order = append(order, byName[name])
+			}
+		}
+	}
+
+	if len(order) != len(steps) {
+		return nil, errors.New("workflow steps contain a dependency cycle")
+	}
+
+	return order, nil
+}
+
+// workflowStepJob builds the job for step. Jobs of the steps it depends on
+// must already be in jobs.
+func workflowStepJob(step WorkflowStepRequest, jobs map[string]*domain.Job) (*domain.Job, error) {
+	if step.Type == "" {
+		return nil, errors.New("job type is required and must be non-empty")
+	}
+
+	job := domain.NewJob(step.Type, step.Payload)
+
+	if err := applyRetryPolicy(job, CreateJobRequest{MaxRetries: step.MaxRetries}); err != nil {
+		return nil, err
+	}
+
+	priority, err := domain.ParsePriority(step.Priority)
+	if err != nil {
+		return nil, err
+	}
+	job.Priority = priority
+
+	dependsOn := make([]string, 0, len(step.DependsOn))
+	for _, name := range step.DependsOn {
+		dependsOn = append(dependsOn, jobs[name].ID)
+	}
+	request := CreateJobRequest{DependsOn: dependsOn, DependencyPolicy: step.DependencyPolicy}
+	if err := applyDependencies(job, request); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (h *WorkflowHandler) deleteJobs(ctx context.Context, jobs []*domain.Job) {
+	for _, job := range jobs {
+		if err := h.jobs.store.DeleteJob(ctx, job.ID); err != nil {
+			h.logger.Error("Failed to roll back workflow job", "event", "workflow_rollback_error", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+func (h *WorkflowHandler) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	workflows, err := h.store.GetWorkflows(r.Context())
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get workflows")
+		return
+	}
+
+	response := make([]WorkflowResponse, 0, len(workflows))
+	for _, workflow := range workflows {
+		workflowResponse, err := h.workflowToResponse(r.Context(), &workflow)
+		if err != nil {
+			StoreErrorResponse(w, err, "Failed to get workflow steps")
+			return
+		}
+		response = append(response, workflowResponse)
+	}
+
+	if err := WriteResponseWithMeta(w, r, response, &Meta{Count: len(response)}, http.StatusOK); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+func (h *WorkflowHandler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := h.store.GetWorkflow(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrWorkflowNotFound) {
+			ErrorResponse(w, "Workflow not found", http.StatusNotFound)
+			return
+		}
+
+		StoreErrorResponse(w, err, "Failed to get workflow")
+		return
+	}
+
+	h.writeWorkflow(w, r, workflow, http.StatusOK)
+}
+
+func (h *WorkflowHandler) writeWorkflow(w http.ResponseWriter, r *http.Request, workflow *domain.Workflow, statusCode int) {
+	response, err := h.workflowToResponse(r.Context(), workflow)
+	if err != nil {
+		StoreErrorResponse(w, err, "Failed to get workflow steps")
+		return
+	}
+
+	if err := WriteResponse(w, r, response, statusCode); err != nil {
+		h.logger.Error("Failed to write response", "error", err)
+		return
+	}
+}
+
+// workflowToResponse looks up each step's job to report per-step and
+// aggregate status.
+func (h *WorkflowHandler) workflowToResponse(ctx context.Context, workflow *domain.Workflow) (WorkflowResponse, error) {
+	response := WorkflowResponse{
+		ID:        workflow.ID,
+		Name:      workflow.Name,
+		CreatedAt: workflow.CreatedAt.Format(time.RFC3339),
+		Steps:     make([]WorkflowStepResponse, 0, len(workflow.Steps)),
+	}
+
+	statuses := make([]domain.JobStatus, 0, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		job, err := h.jobs.store.GetJob(ctx, step.JobID)
+		if err != nil {
+			return WorkflowResponse{}, err
+		}
+
+		statuses = append(statuses, job.Status)
+		response.Steps = append(response.Steps, WorkflowStepResponse{
+			Name:      step.Name,
+			JobID:     step.JobID,
+			Status:    string(job.Status),
+			DependsOn: step.DependsOn,
+		})
+	}
+	response.Status = string(domain.AggregateWorkflowStatus(statuses))
+
+	return response, nil
+}
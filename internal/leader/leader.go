@@ -0,0 +1,162 @@
+// Package leader picks the one server instance that runs the periodic work
+// which must not run twice at once: the sweeper, the lease reaper and the
+// cron scheduler.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Elector reports whether this instance currently leads.
+type Elector interface {
+	IsLeader() bool
+}
+
+// Single is the Elector of a server running on its own, which always leads.
+type Single struct{}
+
+func (Single) IsLeader() bool {
+	return true
+}
+
+// RedisConfig selects the Redis key a RedisElector contends for.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Key holds the leader's ID while it leads
+	Key string
+	// TTL is how long leadership outlives the leader's last renewal, and so
+	// how long failover takes at most
+	TTL time.Duration
+	// ID names this instance; it must be unique among the contenders
+	ID string
+}
+
+// acquireScript takes the key if it is free and renews it if this instance
+// already holds it, in one step so no other instance can take it in between.
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false or holder == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the key only if this instance still holds it.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisElector leads while it holds a Redis key with a TTL, renewing it every
+// third of the TTL. A leader that stops renewing (crashed, or cut off from
+// Redis) lets the key expire, and another instance takes over on its next
+// attempt. Locally, leadership lapses one TTL after the last successful
+// renewal started, so a leader cut off from Redis stops leading before the
+// key can be taken by anyone else.
+type RedisElector struct {
+	client *redis.Client
+	config RedisConfig
+	logger *slog.Logger
+
+	mu         sync.Mutex
+	validUntil time.Time
+}
+
+func NewRedisElector(config RedisConfig, logger *slog.Logger) *RedisElector {
+	return &RedisElector{
+		client: redis.NewClient(&redis.Options{
+			Addr:                  config.Addr,
+			Password:              config.Password,
+			DB:                    config.DB,
+			ContextTimeoutEnabled: true,
+		}),
+		config: config,
+		logger: logger,
+	}
+}
+
+func (e *RedisElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return time.Now().Before(e.validUntil)
+}
+
+// Run contends for leadership until ctx is done, then gives it up so another
+// instance can take over without waiting for the key to expire.
+func (e *RedisElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.attempt(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt takes or renews leadership.
+func (e *RedisElector) attempt(ctx context.Context) {
+	wasLeader := e.IsLeader()
+
+	start := time.Now()
+	attemptCtx, cancel := context.WithTimeout(ctx, e.config.TTL/3)
+	defer cancel()
+	held, err := acquireScript.Run(attemptCtx, e.client, []string{e.config.Key}, e.config.ID, e.config.TTL.Milliseconds()).Int()
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.Warn("Leader election error", "event", "leader_election_error", "key", e.config.Key, "error", err)
+		}
+		// Still leading until validUntil, in case the next attempt works
+		return
+	}
+
+	e.mu.Lock()
+	if held == 1 {
+		e.validUntil = start.Add(e.config.TTL)
+	} else {
+		e.validUntil = time.Time{}
+	}
+	e.mu.Unlock()
+
+	switch {
+	case held == 1 && !wasLeader:
+		e.logger.Info("Leadership acquired", "event", "leader_elected", "key", e.config.Key, "id", e.config.ID)
+	case held != 1 && wasLeader:
+		e.logger.Warn("Leadership lost", "event", "leader_lost", "key", e.config.Key, "id", e.config.ID)
+	}
+}
+
+// resign releases the key if this instance holds it.
+func (e *RedisElector) resign() {
+	wasLeader := e.IsLeader()
+
+	e.mu.Lock()
+	e.validUntil = time.Time{}
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := releaseScript.Run(ctx, e.client, []string{e.config.Key}, e.config.ID).Err(); err != nil {
+		e.logger.Warn("Leader election error releasing leadership", "event", "leader_election_error", "key", e.config.Key, "error", err)
+	} else if wasLeader {
+		e.logger.Info("Leadership released", "event", "leader_released", "key", e.config.Key, "id", e.config.ID)
+	}
+
+	e.client.Close()
+}
@@ -0,0 +1,226 @@
+// Package notify tells people when jobs need attention. A Notifier watches the
+// event bus for the situations its Rules name, a job reaching the dead-letter
+// queue or the failure rate crossing a threshold, and sends a short message
+// through each of its Senders, such as a Slack channel or an email inbox.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+)
+
+// pendingBuffer is how many notifications may wait to be sent before new ones
+// are dropped.
+const pendingBuffer = 256
+
+// Notification is a message for people, with a one-line subject.
+type Notification struct {
+	Subject string
+	Text    string
+}
+
+// Sender delivers notifications somewhere people will see them.
+type Sender interface {
+	// Name identifies the sender in logs
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+// Rules choose what is worth a notification.
+type Rules struct {
+	// Notify when a job moves to the dead-letter queue
+	DeadLettered bool
+	// Notify when the share of attempts that failed over FailureWindow
+	// reaches FailureRate, and again when it falls back below; zero for none
+	FailureRate   float64
+	FailureWindow time.Duration
+	// Fewest attempts in the window for the failure rate to be judged, so a
+	// few failures on a quiet server don't notify
+	FailureMinAttempts int
+}
+
+// outcome is an attempt ending, kept while it is within the failure window.
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// Notifier turns events into notifications. Handle only decides; Run does the
+// sending from its own goroutine, so a slow sender never holds up the
+// publisher. When the senders fall too far behind, notifications are dropped
+// and logged.
+type Notifier struct {
+	senders []Sender
+	rules   Rules
+	logger  *slog.Logger
+
+	pending chan Notification
+
+	mu       sync.Mutex
+	outcomes []outcome
+	// Whether the failure rate is at or above its threshold
+	firing bool
+}
+
+func NewNotifier(senders []Sender, rules Rules, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		senders: senders,
+		rules:   rules,
+		logger:  logger,
+		pending: make(chan Notification, pendingBuffer),
+	}
+}
+
+func (n *Notifier) Handle(ctx context.Context, event events.Event) {
+	switch event.Type {
+	case events.JobDead:
+		if n.rules.DeadLettered {
+			n.queue(deadLettered(event))
+		}
+	case events.JobCompleted, events.JobFailed:
+		if n.rules.FailureRate > 0 {
+			n.record(event.At, event.Type == events.JobFailed)
+		}
+	}
+}
+
+// Run sends notifications until ctx is cancelled, and checks every so often
+// whether the failure rate has fallen back below its threshold while no
+// attempts ended.
+func (n *Notifier) Run(ctx context.Context) {
+	interval := 10 * time.Second
+	if n.rules.FailureWindow > 0 {
+		interval = min(interval, n.rules.FailureWindow)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.logger.Info("Notifier shutting down", "event", "notifier_stopped")
+			return
+		case notification := <-n.pending:
+			n.send(ctx, notification)
+		case <-ticker.C:
+			if n.rules.FailureRate > 0 {
+				n.record(time.Time{}, false)
+			}
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, notification Notification) {
+	for _, sender := range n.senders {
+		if err := sender.Send(ctx, notification); err != nil {
+			n.logger.Error("Failed to send notification", "event", "notification_failed", "sender", sender.Name(), "subject", notification.Subject, "error", err)
+			continue
+		}
+		n.logger.Debug("Notification sent", "event", "notification_sent", "sender", sender.Name(), "subject", notification.Subject)
+	}
+}
+
+func (n *Notifier) queue(notification Notification) {
+	select {
+	case n.pending <- notification:
+	default:
+		n.logger.Warn("Notifier is behind, dropping notification", "event", "notification_dropped", "subject", notification.Subject)
+	}
+}
+
+// record adds an attempt that ended at at, unless at is zero, and notifies
+// when the failure rate has crossed its threshold since the last check.
+func (n *Notifier) record(at time.Time, failed bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now().UTC()
+	if !at.IsZero() {
+		n.outcomes = append(n.outcomes, outcome{at: at, failed: failed})
+	}
+
+	cutoff := now.Add(-n.rules.FailureWindow)
+	kept := 0
+	for kept < len(n.outcomes) && n.outcomes[kept].at.Before(cutoff) {
+		kept++
+	}
+	n.outcomes = n.outcomes[kept:]
+
+	attempts := len(n.outcomes)
+	failures := 0
+	for _, o := range n.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+
+	rate := 0.0
+	if attempts > 0 {
+		rate = float64(failures) / float64(attempts)
+	}
+
+	// Too few attempts to judge keeps an alert firing rather than flapping
+	above := rate >= n.rules.FailureRate
+	if attempts < n.rules.FailureMinAttempts {
+		above = n.firing && attempts > 0
+	}
+	if above == n.firing {
+		return
+	}
+	n.firing = above
+
+	window := n.rules.FailureWindow.String()
+	if above {
+		n.logger.Warn("Job failure rate above threshold", "event", "failure_rate_high", "rate", rate, "threshold", n.rules.FailureRate, "attempts", attempts, "window", window)
+		n.queue(Notification{
+			Subject: fmt.Sprintf("Job failure rate at %.0f%%", rate*100),
+			Text:    fmt.Sprintf("%d of the %d job attempts in the last %s failed, at or above the %.0f%% threshold.", failures, attempts, window, n.rules.FailureRate*100),
+		})
+		return
+	}
+
+	n.logger.Info("Job failure rate back below threshold", "event", "failure_rate_resolved", "rate", rate, "threshold", n.rules.FailureRate, "attempts", attempts, "window", window)
+	text := fmt.Sprintf("%d of the %d job attempts in the last %s failed, below the %.0f%% threshold.", failures, attempts, window, n.rules.FailureRate*100)
+	if attempts == 0 {
+		text = fmt.Sprintf("No job attempts ended in the last %s.", window)
+	}
+	n.queue(Notification{
+		Subject: fmt.Sprintf("Job failure rate back to %.0f%%", rate*100),
+		Text:    text,
+	})
+}
+
+func deadLettered(event events.Event) Notification {
+	job := event.JobID
+	if event.JobType != "" {
+		job = fmt.Sprintf("%s (%s)", event.JobID, event.JobType)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Job %s moved to the dead-letter queue", job)
+	switch {
+	case event.Reason != "":
+		fmt.Fprintf(&text, " (%s)", strings.ReplaceAll(event.Reason, "_", " "))
+	case event.Attempt == 1:
+		text.WriteString(" after 1 attempt")
+	case event.Attempt > 1:
+		fmt.Fprintf(&text, " after %d attempts", event.Attempt)
+	}
+	if event.Error != "" {
+		fmt.Fprintf(&text, ": %s", event.Error)
+	}
+	text.WriteString(".")
+
+	subject := "Job dead-lettered"
+	if event.JobType != "" {
+		subject = fmt.Sprintf("%s job dead-lettered", event.JobType)
+	}
+
+	return Notification{Subject: subject, Text: text.String()}
+}
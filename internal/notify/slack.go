@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// requestTimeout bounds each request a sender makes.
+const requestTimeout = 10 * time.Second
+
+// SlackSender posts notifications to a Slack incoming webhook.
+type SlackSender struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlackSender(webhookURL string) *SlackSender {
+	return &SlackSender{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (s *SlackSender) Name() string {
+	return "slack"
+}
+
+func (s *SlackSender) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", notification.Subject, notification.Text),
+	})
+	if err != nil {
+		return fmt.Errorf("encode slack message: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build slack request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %d", response.StatusCode)
+	}
+
+	return nil
+}
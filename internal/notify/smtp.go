@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is the mail server notifications are sent through and who they
+// go to.
+type SMTPConfig struct {
+	// host:port of the server; STARTTLS is used when it offers it
+	Addr string
+	// Credentials for PLAIN auth, left empty for none
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// SMTPSender emails notifications.
+type SMTPSender struct {
+	config SMTPConfig
+	auth   smtp.Auth
+}
+
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	sender := &SMTPSender{config: config}
+	if config.Username != "" {
+		host, _, _ := net.SplitHostPort(config.Addr)
+		sender.auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+
+	return sender
+}
+
+func (s *SMTPSender) Name() string {
+	return "smtp"
+}
+
+// Send mails notification to every recipient. net/smtp takes no context, so
+// ctx is only checked before sending.
+func (s *SMTPSender) Send(ctx context.Context, notification Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", headerValue(notification.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(notification.Text, "\n", "\r\n"))
+	message.WriteString("\r\n")
+
+	return smtp.SendMail(s.config.Addr, s.auth, s.config.From, s.config.To, []byte(message.String()))
+}
+
+// headerValue keeps a header on one line, whatever the text it came from.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
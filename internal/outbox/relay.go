@@ -0,0 +1,194 @@
+// Package outbox carries out the side effects handlers write to their job's
+// outbox. The messages are stored in the same update that completes the job,
+// so once a job is completed its side effects happen even if the server stops
+// right after; the relay retries them until they succeed or every attempt
+// has failed. Delivery is at least once.
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// Headers set on every webhook the relay sends, so receivers can drop
+// repeats of a message.
+const (
+	MessageIDHeader = "X-Outbox-Message"
+	JobIDHeader     = "X-Job-ID"
+)
+
+// requestTimeout bounds each webhook attempt.
+const requestTimeout = 10 * time.Second
+
+// retryBaseDelay is the delay before the second attempt, doubling for each
+// attempt after it.
+const retryBaseDelay = time.Second
+
+// Options tune a Relay.
+type Options struct {
+	// Attempts before a message is abandoned
+	MaxAttempts int
+	// How often the outbox is checked for retries that have come due
+	PollInterval time.Duration
+}
+
+// Relay delivers outbox messages. It is subscribed to the bus to wake up as
+// jobs complete, and publishes the events handlers emit on the same bus.
+type Relay struct {
+	outbox  store.Outbox
+	bus     *events.Bus
+	logger  *slog.Logger
+	options Options
+	client  *http.Client
+
+	wake chan struct{}
+}
+
+func NewRelay(outbox store.Outbox, bus *events.Bus, logger *slog.Logger, options Options) *Relay {
+	return &Relay{
+		outbox:  outbox,
+		bus:     bus,
+		logger:  logger,
+		options: options,
+		client:  &http.Client{Timeout: requestTimeout},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Handle wakes Run when a job completes, as its outbox messages were stored
+// with it.
+func (r *Relay) Handle(ctx context.Context, event events.Event) {
+	if event.Type != events.JobCompleted {
+		return
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers messages as jobs complete and retries failed ones as they
+// come due, until ctx is cancelled. Messages left in the outbox are
+// delivered on the next start.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay shutting down", "event", "outbox_relay_stopped")
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+
+		messages, err := r.outbox.GetDueOutboxMessages(ctx, time.Now().UTC())
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("Failed to read outbox", "event", "outbox_read_failed", "error", err)
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			if ctx.Err() != nil {
+				break
+			}
+			r.attempt(ctx, &message)
+		}
+	}
+}
+
+// attempt delivers message once and records the outcome in the outbox.
+func (r *Relay) attempt(ctx context.Context, message *domain.OutboxMessage) {
+	deliverErr := r.deliver(ctx, message)
+	if deliverErr != nil && ctx.Err() != nil {
+		// Interrupted by shutdown; the attempt doesn't count
+		return
+	}
+
+	// The outcome is recorded even as shutdown begins
+	ctx = context.WithoutCancel(ctx)
+
+	if deliverErr == nil {
+		r.remove(ctx, message)
+		r.logger.Debug("Outbox message delivered", "event", "outbox_delivered", "message_id", message.ID, "job_id", message.JobID, "kind", message.Kind, "target", message.Target, "attempt", message.Attempts+1)
+		return
+	}
+
+	message.Attempts++
+	message.LastError = deliverErr.Error()
+
+	if message.Attempts >= r.options.MaxAttempts {
+		r.remove(ctx, message)
+		r.logger.Error("Outbox message abandoned", "event", "outbox_abandoned", "message_id", message.ID, "job_id", message.JobID, "kind", message.Kind, "target", message.Target, "attempts", message.Attempts, "error", deliverErr)
+		return
+	}
+
+	delay := domain.RetryDelay(message.Attempts, retryBaseDelay)
+	message.NextAttemptAt = time.Now().UTC().Add(delay)
+	if err := r.outbox.UpdateOutboxMessage(ctx, message); err != nil {
+		r.logger.Error("Failed to reschedule outbox message", "event", "outbox_update_failed", "message_id", message.ID, "error", err)
+		return
+	}
+	r.logger.Warn("Outbox message failed, will retry", "event", "outbox_failed", "message_id", message.ID, "job_id", message.JobID, "kind", message.Kind, "target", message.Target, "attempt", message.Attempts, "retry_in", delay, "error", deliverErr)
+}
+
+func (r *Relay) remove(ctx context.Context, message *domain.OutboxMessage) {
+	if err := r.outbox.DeleteOutboxMessage(ctx, message.ID); err != nil && !errors.Is(err, store.ErrOutboxMessageNotFound) {
+		r.logger.Error("Failed to remove outbox message", "event", "outbox_update_failed", "message_id", message.ID, "error", err)
+	}
+}
+
+func (r *Relay) deliver(ctx context.Context, message *domain.OutboxMessage) error {
+	switch message.Kind {
+	case domain.OutboxWebhook:
+		return r.send(ctx, message)
+	case domain.OutboxEvent:
+		r.bus.Publish(ctx, events.Event{
+			Type:    events.JobEmitted,
+			JobID:   message.JobID,
+			JobType: message.JobType,
+			Name:    message.Target,
+			Data:    message.Payload,
+		})
+		return nil
+	default:
+		return fmt.Errorf("unknown outbox message kind %q", message.Kind)
+	}
+}
+
+func (r *Relay) send(ctx context.Context, message *domain.OutboxMessage) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, message.Target, bytes.NewReader(message.Payload))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(MessageIDHeader, message.ID)
+	request.Header.Set(JobIDHeader, message.JobID)
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", response.StatusCode)
+	}
+
+	return nil
+}
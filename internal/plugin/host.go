@@ -0,0 +1,288 @@
+// Package plugin runs job handlers compiled to WebAssembly. Every *.wasm file
+// in the plugins directory handles the job type named after the file (e.g.
+// thumbnail.wasm handles "thumbnail"), and the directory is rescanned so
+// plugins can be added or replaced without rebuilding or restarting the
+// server.
+//
+// The host ABI is deliberately small. A plugin exports
+//
+//	alloc(size i32) -> ptr i32        reserve size bytes for the payload
+//	handle(ptr i32, len i32) -> i32   run the job; 0 ok, 1 retryable, 2 permanent
+//
+// and may import from the "workstream" module
+//
+//	set_result(ptr i32, len i32)      the result on success, the error otherwise
+//	log(ptr i32, len i32)             add a line to the job's logs
+//
+// WASI (wasi_snapshot_preview1) is available too, so plugins can be built
+// with standard toolchains; reactor modules have _initialize run first. Each
+// job gets a fresh instance, so plugins keep no state between jobs.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/worker"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Values returned by a plugin's handle export.
+const (
+	statusOK        = 0
+	statusRetryable = 1
+	statusPermanent = 2
+)
+
+// loaded is a compiled plugin and the modification time it was compiled at.
+type loaded struct {
+	module  wazero.CompiledModule
+	modTime time.Time
+}
+
+// Host compiles the plugins in a directory and registers a handler for each.
+type Host struct {
+	runtime  wazero.Runtime
+	registry *worker.Registry
+	dir      string
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	plugins map[string]loaded
+}
+
+func NewHost(ctx context.Context, dir string, registry *worker.Registry, logger *slog.Logger) (*Host, error) {
+	// Timeouts and cancellation interrupt a running plugin
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("instantiate WASI: %w", err)
+	}
+
+	_, err := runtime.NewHostModuleBuilder("workstream").
+		NewFunctionBuilder().WithFunc(setResult).Export("set_result").
+		NewFunctionBuilder().WithFunc(logLine).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("instantiate host module: %w", err)
+	}
+
+	return &Host{
+		runtime:  runtime,
+		registry: registry,
+		dir:      dir,
+		logger:   logger,
+		plugins:  make(map[string]loaded),
+	}, nil
+}
+
+// Load compiles plugins that are new or changed since the last call and
+// registers their handlers. A plugin that fails to compile is logged and
+// skipped, leaving any previous version of it in place. Plugins whose file
+// was removed keep running until the server restarts.
+func (h *Host) Load(ctx context.Context) error {
+	paths, err := filepath.Glob(filepath.Join(h.dir, "*.wasm"))
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		jobType := strings.TrimSuffix(filepath.Base(path), ".wasm")
+		previous, ok := h.plugins[jobType]
+		if ok && previous.modTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		binary, err := os.ReadFile(path)
+		if err != nil {
+			h.logger.Error("Failed to read plugin", "event", "plugin_load_failed", "job_type", jobType, "path", path, "error", err)
+			continue
+		}
+
+		module, err := h.runtime.CompileModule(ctx, binary)
+		if err != nil {
+			h.logger.Error("Failed to compile plugin", "event", "plugin_load_failed", "job_type", jobType, "path", path, "error", err)
+			continue
+		}
+
+		if err := checkExports(module); err != nil {
+			module.Close(ctx)
+			h.logger.Error("Plugin does not implement the host ABI", "event", "plugin_load_failed", "job_type", jobType, "path", path, "error", err)
+			continue
+		}
+
+		h.plugins[jobType] = loaded{module: module, modTime: info.ModTime()}
+		h.registry.Register(jobType, h.handler(module))
+
+		// Jobs already running keep the old module; it is not closed so they
+		// can finish
+		if ok {
+			h.logger.Info("Plugin reloaded", "event", "plugin_reloaded", "job_type", jobType, "path", path)
+		} else {
+			h.logger.Info("Plugin loaded", "event", "plugin_loaded", "job_type", jobType, "path", path)
+		}
+	}
+
+	return nil
+}
+
+// Run rescans the plugins directory every interval until ctx is done.
+func (h *Host) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.Load(ctx); err != nil {
+				h.logger.Error("Failed to scan plugins directory", "event", "plugin_scan_failed", "dir", h.dir, "error", err)
+			}
+		}
+	}
+}
+
+// Close releases the runtime and every compiled plugin.
+func (h *Host) Close(ctx context.Context) error {
+	return h.runtime.Close(ctx)
+}
+
+func checkExports(module wazero.CompiledModule) error {
+	exports := module.ExportedFunctions()
+	for _, name := range []string{"alloc", "handle"} {
+		if _, ok := exports[name]; !ok {
+			return fmt.Errorf("missing export %q", name)
+		}
+	}
+	if len(module.ExportedMemories()) == 0 {
+		return errors.New("missing exported memory")
+	}
+	return nil
+}
+
+// call carries per-job state into the host functions.
+type call struct {
+	output []byte
+	logger *slog.Logger
+}
+
+type callKey struct{}
+
+func (h *Host) handler(module wazero.CompiledModule) worker.HandlerFunc {
+	return func(ctx context.Context, job *domain.Job) error {
+		state := &call{logger: worker.JobLogger(ctx)}
+		ctx = context.WithValue(ctx, callKey{}, state)
+
+		// An empty name lets instances of the same plugin run concurrently
+		config := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+		instance, err := h.runtime.InstantiateModule(ctx, module, config)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return worker.Permanent(fmt.Errorf("instantiate plugin: %w", err))
+		}
+		defer instance.Close(context.WithoutCancel(ctx))
+
+		payload := job.Payload
+		ptr, err := instance.ExportedFunction("alloc").Call(ctx, uint64(len(payload)))
+		if err != nil {
+			return trapError(ctx, "alloc", err)
+		}
+		if !instance.Memory().Write(uint32(ptr[0]), payload) {
+			return fmt.Errorf("plugin alloc returned out-of-range pointer %d", ptr[0])
+		}
+
+		status, err := instance.ExportedFunction("handle").Call(ctx, ptr[0], uint64(len(payload)))
+		if err != nil {
+			return trapError(ctx, "handle", err)
+		}
+
+		switch status[0] {
+		case statusOK:
+			job.Result = result(state.output)
+			return nil
+		case statusRetryable:
+			return worker.Retryable(pluginError(state.output))
+		case statusPermanent:
+			return worker.Permanent(pluginError(state.output))
+		default:
+			return fmt.Errorf("plugin returned unknown status %d", status[0])
+		}
+	}
+}
+
+// trapError reports a failed call into the plugin, preferring the context's
+// error when the call was interrupted by a timeout or cancellation.
+func trapError(ctx context.Context, export string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("plugin %s trapped: %w", export, err)
+}
+
+func pluginError(output []byte) error {
+	if len(output) == 0 {
+		return errors.New("plugin failed")
+	}
+	return errors.New(string(output))
+}
+
+// result keeps JSON output as-is and wraps anything else in a JSON string.
+func result(output []byte) json.RawMessage {
+	if len(output) == 0 {
+		return nil
+	}
+	if json.Valid(output) {
+		return json.RawMessage(output)
+	}
+
+	encoded, err := json.Marshal(string(output))
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+func setResult(ctx context.Context, module api.Module, ptr, length uint32) {
+	state, ok := ctx.Value(callKey{}).(*call)
+	if !ok {
+		return
+	}
+	if output, ok := module.Memory().Read(ptr, length); ok {
+		// Read aliases guest memory, which is gone once the instance closes
+		state.output = append([]byte(nil), output...)
+	}
+}
+
+func logLine(ctx context.Context, module api.Module, ptr, length uint32) {
+	state, ok := ctx.Value(callKey{}).(*call)
+	if !ok {
+		return
+	}
+	if line, ok := module.Memory().Read(ptr, length); ok {
+		state.logger.Info(string(line), "event", "plugin_log")
+	}
+}
@@ -0,0 +1,278 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQPConfig selects the exchanges and queues an AMQPQueue declares on the
+// broker.
+type AMQPConfig struct {
+	URL string
+	// Exchange is a topic exchange job IDs are published to, with the
+	// routing key returned by Route
+	Exchange string
+	Route    func(ctx context.Context, jobID string) string
+	// Queue is bound to Exchange for every key in BindingKeys
+	Queue       string
+	BindingKeys []string
+	// Dead jobs are published to DeadLetterExchange, which RabbitMQ also
+	// uses for messages rejected from Queue, and collected in
+	// DeadLetterQueue
+	DeadLetterExchange string
+	DeadLetterQueue    string
+	// MaxLen caps Queue; zero means unbounded
+	MaxLen int
+	// How many unacknowledged deliveries the broker sends ahead
+	Prefetch int
+}
+
+// AMQPQueue is a Queue backed by a RabbitMQ queue. Publishes wait for a
+// publisher confirm, and deliveries stay unacknowledged until Ack, so work
+// held when the server crashes is redelivered after it restarts.
+type AMQPQueue struct {
+	conn       *amqp.Connection
+	publish    *amqp.Channel
+	inspect    *amqp.Channel
+	deliveries <-chan amqp.Delivery
+	config     AMQPConfig
+
+	mu sync.Mutex
+	// Deliveries not yet acknowledged, by job ID
+	unacked map[string][]amqp.Delivery
+	closed  bool
+}
+
+// NewAMQPQueue connects to the broker and declares the exchanges, queues and
+// bindings.
+func NewAMQPQueue(config AMQPConfig) (*AMQPQueue, error) {
+	conn, err := amqp.Dial(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to AMQP broker: %w", err)
+	}
+
+	q, err := newAMQPQueue(conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func newAMQPQueue(conn *amqp.Connection, config AMQPConfig) (*AMQPQueue, error) {
+	publish, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := publish.Confirm(false); err != nil {
+		return nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+
+	if err := publish.ExchangeDeclare(config.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return nil, fmt.Errorf("declare exchange %s: %w", config.Exchange, err)
+	}
+	if err := publish.ExchangeDeclare(config.DeadLetterExchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return nil, fmt.Errorf("declare exchange %s: %w", config.DeadLetterExchange, err)
+	}
+
+	// reject-publish nacks publishes to a full queue, which Enqueue reports
+	// as ErrFull
+	args := amqp.Table{"x-dead-letter-exchange": config.DeadLetterExchange}
+	if config.MaxLen > 0 {
+		args["x-max-length"] = int64(config.MaxLen)
+		args["x-overflow"] = "reject-publish"
+	}
+	if _, err := publish.QueueDeclare(config.Queue, true, false, false, false, args); err != nil {
+		return nil, fmt.Errorf("declare queue %s: %w", config.Queue, err)
+	}
+	for _, key := range config.BindingKeys {
+		if err := publish.QueueBind(config.Queue, key, config.Exchange, false, nil); err != nil {
+			return nil, fmt.Errorf("bind queue %s to %s: %w", config.Queue, key, err)
+		}
+	}
+
+	if _, err := publish.QueueDeclare(config.DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return nil, fmt.Errorf("declare queue %s: %w", config.DeadLetterQueue, err)
+	}
+	if err := publish.QueueBind(config.DeadLetterQueue, "#", config.DeadLetterExchange, false, nil); err != nil {
+		return nil, fmt.Errorf("bind queue %s: %w", config.DeadLetterQueue, err)
+	}
+
+	consume, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := consume.Qos(config.Prefetch, 0, false); err != nil {
+		return nil, err
+	}
+	deliveries, err := consume.Consume(config.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("consume %s: %w", config.Queue, err)
+	}
+
+	// A failed passive declare closes its channel, so Len gets its own
+	inspect, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AMQPQueue{
+		conn:       conn,
+		publish:    publish,
+		inspect:    inspect,
+		deliveries: deliveries,
+		config:     config,
+		unacked:    make(map[string][]amqp.Delivery),
+	}, nil
+}
+
+func (q *AMQPQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	acked, err := q.publishConfirmed(ctx, q.config.Exchange, q.config.Route(ctx, jobID), amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "text/plain",
+		Body:         []byte(jobID),
+	})
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrFull
+	}
+
+	return nil
+}
+
+func (q *AMQPQueue) Dequeue(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case delivery, ok := <-q.deliveries:
+		if !ok {
+			if q.isClosed() {
+				return "", ErrClosed
+			}
+			return "", errors.New("AMQP delivery channel closed")
+		}
+
+		jobID := string(delivery.Body)
+		q.mu.Lock()
+		q.unacked[jobID] = append(q.unacked[jobID], delivery)
+		q.mu.Unlock()
+
+		return jobID, nil
+	}
+}
+
+// Ack acknowledges the oldest unacknowledged delivery for jobID.
+func (q *AMQPQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	deliveries := q.unacked[jobID]
+	if len(deliveries) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	delivery := deliveries[0]
+	if len(deliveries) == 1 {
+		delete(q.unacked, jobID)
+	} else {
+		q.unacked[jobID] = deliveries[1:]
+	}
+	q.mu.Unlock()
+
+	return delivery.Ack(false)
+}
+
+// DeadLetter publishes jobID to the dead-letter exchange with the job's
+// routing key, so consumers of the dead-letter queue see every job the
+// server moves to its own DLQ.
+func (q *AMQPQueue) DeadLetter(ctx context.Context, jobID string, reason string) error {
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	acked, err := q.publishConfirmed(ctx, q.config.DeadLetterExchange, q.config.Route(ctx, jobID), amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "text/plain",
+		Headers:      amqp.Table{"x-dead-reason": reason},
+		Timestamp:    time.Now().UTC(),
+		Body:         []byte(jobID),
+	})
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("dead letter for job %s was not confirmed", jobID)
+	}
+
+	return nil
+}
+
+// publishConfirmed publishes msg and waits for the broker to confirm it,
+// reporting whether it was acked rather than nacked.
+func (q *AMQPQueue) publishConfirmed(ctx context.Context, exchange, key string, msg amqp.Publishing) (bool, error) {
+	confirmation, err := q.publish.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return false, err
+	}
+
+	return confirmation.WaitContext(ctx)
+}
+
+// Ping declares the queue passively, which fails if the broker is
+// unreachable or the queue is gone.
+func (q *AMQPQueue) Ping(ctx context.Context) error {
+	if q.conn.IsClosed() {
+		return amqp.ErrClosed
+	}
+
+	_, err := q.inspect.QueueDeclarePassive(q.config.Queue, true, false, false, false, nil)
+	return err
+}
+
+// Len is the number of messages ready for delivery in the queue.
+func (q *AMQPQueue) Len() int {
+	info, err := q.inspect.QueueDeclarePassive(q.config.Queue, true, false, false, false, nil)
+	if err != nil {
+		return 0
+	}
+
+	return info.Messages
+}
+
+func (q *AMQPQueue) Cap() int {
+	return q.config.MaxLen
+}
+
+// Close stops this instance using the queue. Unacknowledged deliveries are
+// returned to the queue by the broker.
+func (q *AMQPQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	return q.conn.Close()
+}
+
+func (q *AMQPQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Backoff bounds how long EnqueueWithBackoff waits out a full queue.
+type Backoff struct {
+	// Initial is the first wait; each later wait is half as long again, up
+	// to Max
+	Initial time.Duration
+	Max     time.Duration
+	// Attempts caps how many times Enqueue is tried
+	Attempts int
+}
+
+// EnqueueWithBackoff enqueues jobID, waiting and trying again while q is
+// full. It returns how many attempts it made, and an error wrapping ErrFull
+// if the queue stayed full for all of them. Other errors are returned
+// straight away. onFull, if not nil, is called before each wait.
+func EnqueueWithBackoff(ctx context.Context, q Queue, jobID string, backoff Backoff, onFull func(attempt int, wait time.Duration)) (int, error) {
+	wait := backoff.Initial
+
+	for attempt := 1; ; attempt++ {
+		err := q.Enqueue(ctx, jobID)
+		if !errors.Is(err, ErrFull) {
+			return attempt, err
+		}
+		if attempt >= backoff.Attempts {
+			return attempt, fmt.Errorf("enqueue job %s after %d attempts: %w", jobID, attempt, err)
+		}
+
+		if onFull != nil {
+			onFull(attempt, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(time.Duration(float64(wait)*1.5), backoff.Max)
+	}
+}
@@ -0,0 +1,467 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fsync policies for DiskQueue.
+const (
+	// SyncAlways fsyncs every append and cursor update before returning
+	SyncAlways = "always"
+	// SyncInterval fsyncs every SyncInterval, risking that much on a crash
+	SyncInterval = "interval"
+	// SyncNever leaves flushing to the operating system
+	SyncNever = "never"
+)
+
+const (
+	segmentExt = ".seg"
+	cursorFile = "cursor"
+)
+
+// DiskConfig selects where a DiskQueue keeps its segments and how durably.
+type DiskConfig struct {
+	Dir string
+	// Entries per segment file; a segment is removed once all of its
+	// entries are acknowledged
+	SegmentSize  int
+	Capacity     int
+	Sync         string
+	SyncInterval time.Duration
+}
+
+// DiskQueue is a Queue persisted to append-only segment files. Each entry has
+// a sequence number; the cursor file records the first one not yet
+// acknowledged, and everything from there on is delivered again after a
+// restart. The jobs themselves survive only in a persisted job store, which
+// the server sets up with this queue.
+type DiskQueue struct {
+	config DiskConfig
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// Entries written but not yet dequeued, in order, and how many of them
+	// each ID has
+	pending []diskEntry
+	held    map[string]int
+	// Sequence numbers dequeued but not yet acknowledged, by job ID
+	inflight      map[string][]uint64
+	inflightCount int
+	acked         map[uint64]bool
+	// Every entry before committed is acknowledged
+	committed uint64
+	nextSeq   uint64
+	segment   *os.File
+	dirty     bool
+	closed    bool
+	// Closed and replaced whenever an entry is added or the queue closes
+	wake chan struct{}
+	done chan struct{}
+}
+
+type diskEntry struct {
+	seq   uint64
+	jobID string
+}
+
+// NewDiskQueue opens the queue in config.Dir, creating it if needed, and
+// reloads entries that were not acknowledged before the last shutdown.
+func NewDiskQueue(config DiskConfig, logger *slog.Logger) (*DiskQueue, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	q := &DiskQueue{
+		config:   config,
+		logger:   logger,
+		inflight: make(map[string][]uint64),
+		acked:    make(map[uint64]bool),
+		held:     make(map[string]int),
+		wake:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	if config.Sync == SyncInterval {
+		go q.syncLoop()
+	}
+
+	return q, nil
+}
+
+// load reads the cursor and every segment, keeping entries from the cursor
+// on, and opens the newest segment for appending.
+func (q *DiskQueue) load() error {
+	data, err := os.ReadFile(filepath.Join(q.config.Dir, cursorFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		q.committed, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse queue cursor: %w", err)
+		}
+	}
+	q.nextSeq = q.committed
+
+	bases, err := q.segments()
+	if err != nil {
+		return err
+	}
+
+	for _, base := range bases {
+		jobIDs, err := readSegment(q.segmentPath(base))
+		if err != nil {
+			return err
+		}
+		for i, jobID := range jobIDs {
+			seq := base + uint64(i)
+			if seq >= q.committed {
+				q.pending = append(q.pending, diskEntry{seq: seq, jobID: jobID})
+				q.held[jobID]++
+			}
+		}
+		q.nextSeq = max(q.nextSeq, base+uint64(len(jobIDs)))
+	}
+
+	if len(bases) > 0 {
+		last := bases[len(bases)-1]
+		// A full segment is left for rotation on the next append
+		if q.nextSeq-last < uint64(q.config.SegmentSize) {
+			q.segment, err = os.OpenFile(q.segmentPath(last), os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(q.pending) > 0 {
+		q.logger.Info("Disk queue reloaded", "event", "disk_queue_loaded", "dir", q.config.Dir, "entries", len(q.pending))
+	}
+
+	return nil
+}
+
+// segments returns the base sequence number of every segment file, oldest
+// first.
+func (q *DiskQueue) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(q.config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var bases []uint64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		base, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	slices.Sort(bases)
+
+	return bases, nil
+}
+
+// readSegment returns the job IDs in a segment, truncating a partial final
+// line left by a crash mid-append.
+func readSegment(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		if err := os.Truncate(path, int64(end)); err != nil {
+			return nil, err
+		}
+		data = data[:end]
+	}
+
+	var jobIDs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		jobIDs = append(jobIDs, scanner.Text())
+	}
+
+	return jobIDs, scanner.Err()
+}
+
+func (q *DiskQueue) segmentPath(base uint64) string {
+	return filepath.Join(q.config.Dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+func (q *DiskQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if q.config.Capacity > 0 && len(q.pending)+q.inflightCount >= q.config.Capacity {
+		return ErrFull
+	}
+
+	if q.segment == nil || q.nextSeq%uint64(q.config.SegmentSize) == 0 {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.segment.WriteString(jobID + "\n"); err != nil {
+		return err
+	}
+	if err := q.synced(q.segment); err != nil {
+		return err
+	}
+
+	q.pending = append(q.pending, diskEntry{seq: q.nextSeq, jobID: jobID})
+	q.held[jobID]++
+	q.nextSeq++
+	q.notify()
+
+	return nil
+}
+
+// rotate closes the current segment and starts a new one at nextSeq.
+func (q *DiskQueue) rotate() error {
+	if q.segment != nil {
+		if err := q.segment.Sync(); err != nil {
+			return err
+		}
+		if err := q.segment.Close(); err != nil {
+			return err
+		}
+	}
+
+	segment, err := os.OpenFile(q.segmentPath(q.nextSeq), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	q.segment = segment
+
+	return nil
+}
+
+func (q *DiskQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return "", ErrClosed
+		}
+		if len(q.pending) > 0 {
+			entry := q.pending[0]
+			q.pending = q.pending[1:]
+			if q.held[entry.jobID]--; q.held[entry.jobID] <= 0 {
+				delete(q.held, entry.jobID)
+			}
+			q.inflight[entry.jobID] = append(q.inflight[entry.jobID], entry.seq)
+			q.inflightCount++
+			q.mu.Unlock()
+			return entry.jobID, nil
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// Ack acknowledges the oldest entry dequeued for jobID, advancing the cursor
+// past every acknowledged entry in a row and removing spent segments.
+func (q *DiskQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	seqs := q.inflight[jobID]
+	if len(seqs) == 0 {
+		return nil
+	}
+	if len(seqs) == 1 {
+		delete(q.inflight, jobID)
+	} else {
+		q.inflight[jobID] = seqs[1:]
+	}
+	q.inflightCount--
+
+	q.acked[seqs[0]] = true
+	committed := q.committed
+	for q.acked[q.committed] {
+		delete(q.acked, q.committed)
+		q.committed++
+	}
+	if q.committed == committed {
+		return nil
+	}
+
+	if err := q.writeCursor(); err != nil {
+		return err
+	}
+
+	return q.removeSegments()
+}
+
+// writeCursor replaces the cursor file atomically.
+func (q *DiskQueue) writeCursor() error {
+	path := filepath.Join(q.config.Dir, cursorFile)
+	tmp, err := os.CreateTemp(q.config.Dir, cursorFile+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(q.committed, 10)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if q.config.Sync == SyncAlways {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// removeSegments deletes segments whose entries are all acknowledged,
+// keeping the one being appended to.
+func (q *DiskQueue) removeSegments() error {
+	bases, err := q.segments()
+	if err != nil {
+		return err
+	}
+
+	size := uint64(q.config.SegmentSize)
+	for _, base := range bases {
+		if base+size > q.committed {
+			break
+		}
+		if q.segment != nil && q.segment.Name() == q.segmentPath(base) {
+			break
+		}
+		if err := os.Remove(q.segmentPath(base)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// synced applies the fsync policy after a write to f.
+func (q *DiskQueue) synced(f *os.File) error {
+	switch q.config.Sync {
+	case SyncAlways:
+		return f.Sync()
+	case SyncInterval:
+		q.dirty = true
+	}
+
+	return nil
+}
+
+// syncLoop fsyncs the current segment every SyncInterval while it has
+// unsynced writes.
+func (q *DiskQueue) syncLoop() {
+	ticker := time.NewTicker(q.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			if q.dirty && q.segment != nil {
+				if err := q.segment.Sync(); err != nil {
+					q.logger.Error("Failed to sync disk queue segment", "event", "disk_queue_sync_error", "error", err)
+				} else {
+					q.dirty = false
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
+
+// notify wakes every waiting Dequeue. The caller must hold q.mu.
+func (q *DiskQueue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// Holds reports whether jobID has an entry not yet dequeued.
+func (q *DiskQueue) Holds(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.held[jobID] > 0
+}
+
+// Len is the number of entries not yet dequeued.
+func (q *DiskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+func (q *DiskQueue) Cap() int {
+	return q.config.Capacity
+}
+
+// Close syncs and closes the current segment. Unacknowledged entries stay on
+// disk and are delivered again when the queue is reopened.
+func (q *DiskQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+	q.notify()
+
+	if q.segment == nil {
+		return nil
+	}
+	if err := q.segment.Sync(); err != nil {
+		q.segment.Close()
+		return err
+	}
+
+	return q.segment.Close()
+}
@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func openDiskQueue(t *testing.T, dir string, capacity int) *DiskQueue {
+	t.Helper()
+
+	q, err := NewDiskQueue(DiskConfig{
+		Dir:          dir,
+		SegmentSize:  2,
+		Capacity:     capacity,
+		Sync:         SyncAlways,
+		SyncInterval: time.Second,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewDiskQueue: %v", err)
+	}
+	return q
+}
+
+// drain dequeues every entry the queue holds.
+func drain(t *testing.T, q *DiskQueue) []string {
+	t.Helper()
+
+	var jobIDs []string
+	for q.Len() > 0 {
+		jobID, err := q.Dequeue(context.Background())
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	return jobIDs
+}
+
+func TestDiskQueueReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// acked entries are dequeued and acknowledged before the restart
+		acked int
+		// torn is appended to the newest segment, as a crash mid-append
+		// leaves it
+		torn string
+		want []string
+	}{
+		{name: "nothing acknowledged", want: []string{"a", "b", "c"}},
+		{name: "acknowledged entries are not redelivered", acked: 2, want: []string{"c"}},
+		{name: "partial last line", torn: "d-partial", want: []string{"a", "b", "c"}},
+		{name: "partial last line after acks", acked: 1, torn: "d", want: []string{"b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			q := openDiskQueue(t, dir, 0)
+			for _, jobID := range []string{"a", "b", "c"} {
+				if err := q.Enqueue(ctx, jobID); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+			}
+			for range tt.acked {
+				jobID, err := q.Dequeue(ctx)
+				if err != nil {
+					t.Fatalf("Dequeue: %v", err)
+				}
+				if err := q.Ack(ctx, jobID); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+			}
+			// Dequeued but unacknowledged entries are delivered again
+			if _, err := q.Dequeue(ctx); err != nil {
+				t.Fatalf("Dequeue: %v", err)
+			}
+			if err := q.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if tt.torn != "" {
+				segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+				if err != nil || len(segments) == 0 {
+					t.Fatalf("segments = %v, %v", segments, err)
+				}
+				newest := segments[len(segments)-1]
+				file, err := os.OpenFile(newest, os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				file.WriteString(tt.torn)
+				file.Close()
+			}
+
+			reopened := openDiskQueue(t, dir, 0)
+			if got := drain(t, reopened); !slices.Equal(got, tt.want) {
+				t.Fatalf("reloaded %v, want %v", got, tt.want)
+			}
+
+			// An append after the reload starts its own line
+			if err := reopened.Enqueue(ctx, "e"); err != nil {
+				t.Fatalf("Enqueue after reload: %v", err)
+			}
+			reopened.Close()
+			again := openDiskQueue(t, dir, 0)
+			defer again.Close()
+			if got := drain(t, again); !slices.Equal(got, append(slices.Clone(tt.want), "e")) {
+				t.Fatalf("second reload %v, want %v then e", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskQueueCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		ack     bool
+		wantErr error
+	}{
+		{name: "in-flight entries hold capacity", ack: false, wantErr: ErrFull},
+		{name: "an acknowledged entry frees its slot", ack: true, wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openDiskQueue(t, t.TempDir(), 2)
+			defer q.Close()
+
+			for _, jobID := range []string{"a", "b"} {
+				if err := q.Enqueue(ctx, jobID); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+			}
+			jobID, err := q.Dequeue(ctx)
+			if err != nil {
+				t.Fatalf("Dequeue: %v", err)
+			}
+			if tt.ack {
+				if err := q.Ack(ctx, jobID); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+			}
+
+			if err := q.Enqueue(ctx, "c"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Enqueue = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// HeapQueue is an in-process Queue that dispatches by priority rather than
+// arrival: Dequeue returns the ID of the highest-priority ready job, oldest
+// first among equals. IDs of scheduled jobs are held until their run time,
+// so they can be enqueued at submission instead of waiting for the sweeper.
+type HeapQueue struct {
+	capacity int
+	getJob   func(ctx context.Context, jobID string) (*domain.Job, error)
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// IDs whose job may run now, by priority
+	ready readyHeap
+	// IDs whose job is scheduled for later, by run time
+	delayed delayedHeap
+	nextSeq uint64
+	closed  bool
+	// How many times each ID is held, ready or delayed
+	held map[string]int
+}
+
+type heapEntry struct {
+	jobID    string
+	priority domain.Priority
+	runAt    time.Time
+	seq      uint64
+}
+
+// NewHeapQueue holds up to capacity ready IDs; scheduled IDs don't count
+// until they are due. getJob looks up the job an ID belongs to; IDs it can't
+// find are treated as normal priority and ready.
+func NewHeapQueue(capacity int, getJob func(ctx context.Context, jobID string) (*domain.Job, error)) *HeapQueue {
+	q := &HeapQueue{
+		capacity: capacity,
+		getJob:   getJob,
+		held:     make(map[string]int),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	return q
+}
+
+func (q *HeapQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry := heapEntry{jobID: jobID, priority: domain.PriorityNormal}
+	if job, err := q.getJob(ctx, jobID); err == nil {
+		entry.priority = job.Priority
+		if job.RunAt != nil {
+			entry.runAt = *job.RunAt
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	entry.seq = q.nextSeq
+	q.nextSeq++
+
+	if entry.runAt.After(time.Now()) {
+		heap.Push(&q.delayed, entry)
+		q.held[jobID]++
+		// A waiting Dequeue may need to wake earlier for this one
+		q.cond.Broadcast()
+		return nil
+	}
+
+	if q.capacity > 0 && q.ready.Len() >= q.capacity {
+		return ErrFull
+	}
+	heap.Push(&q.ready, entry)
+	q.held[jobID]++
+	q.cond.Signal()
+
+	return nil
+}
+
+func (q *HeapQueue) Dequeue(ctx context.Context) (string, error) {
+	// Wake the wait below when ctx is done; taking the lock first means the
+	// broadcast can't slip in between the ctx check and cond.Wait
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.cond.Broadcast()
+	})
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		wait := q.promoteDue(time.Now())
+		if q.ready.Len() > 0 {
+			jobID := heap.Pop(&q.ready).(heapEntry).jobID
+			if q.held[jobID]--; q.held[jobID] <= 0 {
+				delete(q.held, jobID)
+			}
+			return jobID, nil
+		}
+		if q.closed {
+			return "", ErrClosed
+		}
+
+		if wait > 0 {
+			timer := time.AfterFunc(wait, func() {
+				q.mu.Lock()
+				defer q.mu.Unlock()
+				q.cond.Broadcast()
+			})
+			q.cond.Wait()
+			timer.Stop()
+		} else {
+			q.cond.Wait()
+		}
+	}
+}
+
+// promoteDue moves delayed entries whose run time has arrived to the ready
+// heap, returning how long until the next one is due, or zero if none are
+// left. The caller must hold q.mu.
+func (q *HeapQueue) promoteDue(now time.Time) time.Duration {
+	for q.delayed.Len() > 0 {
+		next := q.delayed[0]
+		if next.runAt.After(now) {
+			return next.runAt.Sub(now)
+		}
+		heap.Pop(&q.delayed)
+		heap.Push(&q.ready, next)
+	}
+
+	return 0
+}
+
+// Len is the number of IDs ready to be dequeued.
+func (q *HeapQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.promoteDue(time.Now())
+
+	return q.ready.Len()
+}
+
+func (q *HeapQueue) Cap() int {
+	return q.capacity
+}
+
+// Close stops the queue accepting IDs. Held scheduled IDs are dropped; their
+// jobs stay pending in the store for the sweeper.
+func (q *HeapQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+
+	return nil
+}
+
+func (q *HeapQueue) Holds(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.held[jobID] > 0
+}
+
+// HoldsDelayed reports that HeapQueue holds scheduled IDs until they are due.
+func (q *HeapQueue) HoldsDelayed() bool {
+	return true
+}
+
+// readyHeap orders entries by priority, highest first, then by arrival.
+type readyHeap []heapEntry
+
+func (h readyHeap) Len() int { return len(h) }
+func (h readyHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h readyHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *readyHeap) Push(x any)   { *h = append(*h, x.(heapEntry)) }
+func (h *readyHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// delayedHeap orders entries by run time, earliest first.
+type delayedHeap []heapEntry
+
+func (h delayedHeap) Len() int { return len(h) }
+func (h delayedHeap) Less(i, j int) bool {
+	if !h[i].runAt.Equal(h[j].runAt) {
+		return h[i].runAt.Before(h[j].runAt)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h delayedHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *delayedHeap) Push(x any)   { *h = append(*h, x.(heapEntry)) }
+func (h *delayedHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
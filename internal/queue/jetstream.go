@@ -0,0 +1,215 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStreamConfig selects the stream and durable consumer a JetStreamQueue
+// reads. Like every broker-backed queue, they must not be shared with other
+// server instances: each has its own job store.
+type JetStreamConfig struct {
+	URL     string
+	Stream  string
+	Subject string
+	// Durable names the pull consumer
+	Durable string
+	// MaxLen caps the stream; zero means unbounded
+	MaxLen int
+	// Unacknowledged messages are redelivered after AckWait, up to
+	// MaxDeliver deliveries in total
+	AckWait    time.Duration
+	MaxDeliver int
+	// How long one fetch blocks before checking for shutdown
+	BlockTimeout time.Duration
+}
+
+// JetStreamQueue is a Queue backed by a NATS JetStream work-queue stream read
+// through a durable pull consumer. Dequeued IDs are redelivered until
+// acknowledged with Ack.
+type JetStreamQueue struct {
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	consumer jetstream.Consumer
+	config   JetStreamConfig
+	logger   *slog.Logger
+
+	mu sync.Mutex
+	// Messages dequeued by this instance but not yet acknowledged, by job ID
+	messages map[string][]jetstream.Msg
+	closed   bool
+}
+
+// NewJetStreamQueue connects to NATS and creates or updates the stream and
+// durable consumer.
+func NewJetStreamQueue(ctx context.Context, config JetStreamConfig, logger *slog.Logger) (*JetStreamQueue, error) {
+	conn, err := nats.Connect(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", config.URL, err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	maxMsgs := int64(-1)
+	if config.MaxLen > 0 {
+		maxMsgs = int64(config.MaxLen)
+	}
+
+	// Work-queue retention removes a message once it is acknowledged, and
+	// DiscardNew rejects publishes once the stream is full
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      config.Stream,
+		Subjects:  []string{config.Subject},
+		Retention: jetstream.WorkQueuePolicy,
+		MaxMsgs:   maxMsgs,
+		Discard:   jetstream.DiscardNew,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create stream %s: %w", config.Stream, err)
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:    config.Durable,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    config.AckWait,
+		MaxDeliver: config.MaxDeliver,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create consumer %s: %w", config.Durable, err)
+	}
+
+	return &JetStreamQueue{
+		conn:     conn,
+		js:       js,
+		consumer: consumer,
+		config:   config,
+		logger:   logger,
+		messages: make(map[string][]jetstream.Msg),
+	}, nil
+}
+
+func (q *JetStreamQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	_, err := q.js.Publish(ctx, q.config.Subject, []byte(jobID))
+	if err != nil && strings.Contains(err.Error(), "maximum messages exceeded") {
+		return ErrFull
+	}
+
+	return err
+}
+
+func (q *JetStreamQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if q.isClosed() {
+			return "", ErrClosed
+		}
+
+		msg, err := q.consumer.Next(jetstream.FetchMaxWait(q.config.BlockTimeout))
+		if errors.Is(err, nats.ErrTimeout) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		jobID := string(msg.Data())
+		if metadata, err := msg.Metadata(); err == nil && metadata.NumDelivered > 1 {
+			q.logger.Info("Queue message redelivered", "event", "queue_message_redelivered", "job_id", jobID, "deliveries", metadata.NumDelivered)
+		}
+
+		q.mu.Lock()
+		q.messages[jobID] = append(q.messages[jobID], msg)
+		q.mu.Unlock()
+
+		return jobID, nil
+	}
+}
+
+// Ack acknowledges the oldest unacknowledged message this instance dequeued
+// for jobID, removing it from the stream.
+func (q *JetStreamQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	msgs := q.messages[jobID]
+	if len(msgs) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	msg := msgs[0]
+	if len(msgs) == 1 {
+		delete(q.messages, jobID)
+	} else {
+		q.messages[jobID] = msgs[1:]
+	}
+	q.mu.Unlock()
+
+	return msg.DoubleAck(ctx)
+}
+
+// Ping fetches the consumer's info, which needs both the connection and the
+// JetStream API.
+func (q *JetStreamQueue) Ping(ctx context.Context) error {
+	_, err := q.consumer.Info(ctx)
+	return err
+}
+
+// Len is the number of messages not yet delivered to any instance.
+func (q *JetStreamQueue) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	info, err := q.consumer.Info(ctx)
+	if err != nil {
+		return 0
+	}
+
+	return int(info.NumPending)
+}
+
+func (q *JetStreamQueue) Cap() int {
+	return q.config.MaxLen
+}
+
+// Close stops this instance using the queue. Messages stay in the stream for
+// other instances, and unacknowledged ones are redelivered after AckWait.
+func (q *JetStreamQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.conn.Close()
+
+	return nil
+}
+
+func (q *JetStreamQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
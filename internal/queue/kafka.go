@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig selects the topics a KafkaQueue publishes to and the consumer
+// group it reads them with.
+type KafkaConfig struct {
+	Brokers []string
+	// Topics are every topic Route can return; all are consumed
+	Topics []string
+	// Route picks the topic for a job ID
+	Route func(ctx context.Context, jobID string) string
+	// GroupID names the consumer group
+	GroupID string
+}
+
+// KafkaQueue is a Queue backed by Kafka topics read through a consumer group.
+// A partition's offset is committed only once every message before it has
+// been acknowledged with Ack, so work in flight when the server crashes is
+// redelivered.
+type KafkaQueue struct {
+	writer *kafka.Writer
+	reader *kafka.Reader
+	route  func(ctx context.Context, jobID string) string
+
+	mu sync.Mutex
+	// Messages dequeued but not yet acknowledged, by job ID
+	messages map[string][]kafka.Message
+	// Fetched messages by partition in offset order, until committed
+	partitions map[kafkaPartition]*kafkaOffsets
+	closed     bool
+}
+
+type kafkaPartition struct {
+	topic     string
+	partition int
+}
+
+type kafkaOffsets struct {
+	fetched []kafka.Message
+	acked   map[int64]bool
+}
+
+func NewKafkaQueue(config KafkaConfig) *KafkaQueue {
+	return &KafkaQueue{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			BatchTimeout:           10 * time.Millisecond,
+		},
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     config.Brokers,
+			GroupID:     config.GroupID,
+			GroupTopics: config.Topics,
+			StartOffset: kafka.FirstOffset,
+		}),
+		route:      config.Route,
+		messages:   make(map[string][]kafka.Message),
+		partitions: make(map[kafkaPartition]*kafkaOffsets),
+	}
+}
+
+// Enqueue publishes jobID to its routed topic. Kafka has no capacity limit,
+// so Enqueue never returns ErrFull.
+func (q *KafkaQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	return q.writer.WriteMessages(ctx, kafka.Message{
+		Topic: q.route(ctx, jobID),
+		Key:   []byte(jobID),
+		Value: []byte(jobID),
+	})
+}
+
+func (q *KafkaQueue) Dequeue(ctx context.Context) (string, error) {
+	if q.isClosed() {
+		return "", ErrClosed
+	}
+
+	message, err := q.reader.FetchMessage(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if q.isClosed() {
+			return "", ErrClosed
+		}
+		return "", err
+	}
+
+	jobID := string(message.Value)
+	key := kafkaPartition{topic: message.Topic, partition: message.Partition}
+
+	q.mu.Lock()
+	q.messages[jobID] = append(q.messages[jobID], message)
+	offsets, ok := q.partitions[key]
+	if !ok {
+		offsets = &kafkaOffsets{acked: make(map[int64]bool)}
+		q.partitions[key] = offsets
+	}
+	offsets.fetched = append(offsets.fetched, message)
+	q.mu.Unlock()
+
+	return jobID, nil
+}
+
+// Ack marks the oldest unacknowledged message dequeued for jobID as done and
+// commits its partition up to the first message still in flight.
+func (q *KafkaQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	messages := q.messages[jobID]
+	if len(messages) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	message := messages[0]
+	if len(messages) == 1 {
+		delete(q.messages, jobID)
+	} else {
+		q.messages[jobID] = messages[1:]
+	}
+
+	offsets := q.partitions[kafkaPartition{topic: message.Topic, partition: message.Partition}]
+	offsets.acked[message.Offset] = true
+
+	var commit *kafka.Message
+	for len(offsets.fetched) > 0 && offsets.acked[offsets.fetched[0].Offset] {
+		commit = &offsets.fetched[0]
+		delete(offsets.acked, commit.Offset)
+		offsets.fetched = offsets.fetched[1:]
+	}
+	q.mu.Unlock()
+
+	if commit == nil {
+		return nil
+	}
+
+	return q.reader.CommitMessages(ctx, *commit)
+}
+
+// Ping dials the first broker that answers.
+func (q *KafkaQueue) Ping(ctx context.Context) error {
+	var errs []error
+	for _, broker := range q.reader.Config().Brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Len is the consumer lag of the partitions this instance last read.
+func (q *KafkaQueue) Len() int {
+	return int(max(q.reader.Stats().Lag, 0))
+}
+
+// Cap is always zero: Kafka topics are unbounded.
+func (q *KafkaQueue) Cap() int {
+	return 0
+}
+
+// Close stops this instance using the queue. Uncommitted messages are
+// redelivered to the rest of the consumer group.
+func (q *KafkaQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	return errors.Join(q.writer.Close(), q.reader.Close())
+}
+
+func (q *KafkaQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
@@ -0,0 +1,208 @@
+// Package queue carries job IDs from the code that makes jobs runnable to the
+// workers that run them.
+//
+// A queued ID is a wake-up token rather than an assignment: the job store
+// stays the source of truth, and a worker that dequeues a token claims
+// whichever pending job should run next. Dropping a token is therefore safe,
+// since the sweeper re-enqueues pending jobs, which lets Enqueue fail fast
+// instead of blocking producers.
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrFull   = errors.New("queue is full")
+	ErrClosed = errors.New("queue is closed")
+)
+
+type Queue interface {
+	// Enqueue adds jobID without waiting for room, returning ErrFull if
+	// there is none.
+	Enqueue(ctx context.Context, jobID string) error
+	// Dequeue waits for a job ID. It returns ErrClosed once the queue is
+	// closed and drained, or ctx's error if ctx is done first.
+	Dequeue(ctx context.Context) (string, error)
+	// Len is the number of queued IDs.
+	Len() int
+	// Cap is how many IDs the queue holds before Enqueue returns ErrFull;
+	// zero means unbounded.
+	Cap() int
+	// Close stops the queue accepting IDs. Queued IDs can still be dequeued.
+	Close() error
+}
+
+// Acker is implemented by queues that redeliver dequeued IDs until they are
+// acknowledged, so a token held by a crashed consumer is not lost.
+type Acker interface {
+	// Ack acknowledges one dequeued jobID once its work is done.
+	Ack(ctx context.Context, jobID string) error
+}
+
+// Ack acknowledges jobID if q redelivers unacknowledged IDs, and does nothing
+// otherwise.
+func Ack(ctx context.Context, q Queue, jobID string) error {
+	acker, ok := q.(Acker)
+	if !ok {
+		return nil
+	}
+
+	return acker.Ack(ctx, jobID)
+}
+
+// DeadLetterer is implemented by queues that mirror the dead-letter queue to
+// their broker, so tooling watching the broker sees jobs that went dead.
+type DeadLetterer interface {
+	DeadLetter(ctx context.Context, jobID string, reason string) error
+}
+
+// DeadLetter reports a dead job to q if it mirrors the dead-letter queue, and
+// does nothing otherwise.
+func DeadLetter(ctx context.Context, q Queue, jobID string, reason string) error {
+	deadLetterer, ok := q.(DeadLetterer)
+	if !ok {
+		return nil
+	}
+
+	return deadLetterer.DeadLetter(ctx, jobID, reason)
+}
+
+// DelayHolder is implemented by queues that hold IDs of scheduled jobs until
+// they are due, rather than handing them out straight away.
+type DelayHolder interface {
+	HoldsDelayed() bool
+}
+
+// HoldsDelayed reports whether IDs of jobs not yet due can be enqueued on q.
+// For other queues, scheduled jobs are left for the sweeper to enqueue once
+// due.
+func HoldsDelayed(q Queue) bool {
+	holder, ok := q.(DelayHolder)
+	return ok && holder.HoldsDelayed()
+}
+
+// Holder is implemented by queues that know which IDs they hold, so
+// producers that re-enqueue periodically, like the sweeper, can skip jobs
+// whose wake-up is already queued.
+type Holder interface {
+	// Holds reports whether jobID is queued and not yet dequeued.
+	Holds(jobID string) bool
+}
+
+// Holds reports whether q is known to hold jobID. It is always false for
+// queues that don't track their IDs.
+func Holds(q Queue, jobID string) bool {
+	holder, ok := q.(Holder)
+	return ok && holder.Holds(jobID)
+}
+
+// Pinger is implemented by queues backed by an external broker, so health
+// checks can tell whether the broker is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the broker behind q. It is always nil for in-process queues.
+func Ping(ctx context.Context, q Queue) error {
+	pinger, ok := q.(Pinger)
+	if !ok {
+		return nil
+	}
+
+	return pinger.Ping(ctx)
+}
+
+// ChannelQueue is an in-process Queue backed by a buffered channel.
+type ChannelQueue struct {
+	ch     chan string
+	mu     sync.RWMutex
+	closed bool
+
+	heldMu sync.Mutex
+	// How many times each ID is in ch
+	held map[string]int
+}
+
+func NewChannelQueue(capacity int) *ChannelQueue {
+	return &ChannelQueue{
+		ch:   make(chan string, capacity),
+		held: make(map[string]int),
+	}
+}
+
+func (q *ChannelQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// The read lock keeps Close from closing the channel mid-send
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	// Counted before the send so a fast Dequeue can't uncount it first
+	q.hold(jobID, 1)
+	select {
+	case q.ch <- jobID:
+		return nil
+	default:
+		q.hold(jobID, -1)
+		return ErrFull
+	}
+}
+
+func (q *ChannelQueue) Dequeue(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case jobID, ok := <-q.ch:
+		if !ok {
+			return "", ErrClosed
+		}
+		q.hold(jobID, -1)
+		return jobID, nil
+	}
+}
+
+func (q *ChannelQueue) hold(jobID string, delta int) {
+	q.heldMu.Lock()
+	defer q.heldMu.Unlock()
+
+	q.held[jobID] += delta
+	if q.held[jobID] <= 0 {
+		delete(q.held, jobID)
+	}
+}
+
+func (q *ChannelQueue) Holds(jobID string) bool {
+	q.heldMu.Lock()
+	defer q.heldMu.Unlock()
+
+	return q.held[jobID] > 0
+}
+
+func (q *ChannelQueue) Len() int {
+	return len(q.ch)
+}
+
+func (q *ChannelQueue) Cap() int {
+	return cap(q.ch)
+}
+
+func (q *ChannelQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig selects the stream and consumer group a RedisQueue reads.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	Stream   string
+	Group    string
+	// Consumer names this server within the group
+	Consumer string
+	// MaxLen caps the stream; zero means unbounded
+	MaxLen int
+	// Entries left unacknowledged this long by a crashed consumer are
+	// claimed by the next consumer to dequeue
+	ClaimIdle time.Duration
+	// How long one read blocks before checking for shutdown
+	BlockTimeout time.Duration
+}
+
+// RedisQueue is a Queue backed by a Redis stream read through a consumer
+// group. Dequeued IDs stay pending in the group until acknowledged with Ack.
+// The stream only carries wake-ups for jobs in this server's own job store,
+// so it must not be shared with other server instances.
+type RedisQueue struct {
+	client *redis.Client
+	config RedisConfig
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// Stream entry IDs dequeued by this consumer but not yet acknowledged,
+	// by job ID
+	entries   map[string][]string
+	lastClaim time.Time
+	closed    bool
+}
+
+// NewRedisQueue connects to Redis and creates the stream and consumer group
+// if they don't already exist.
+func NewRedisQueue(ctx context.Context, config RedisConfig, logger *slog.Logger) (*RedisQueue, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:                  config.Addr,
+		Password:              config.Password,
+		DB:                    config.DB,
+		ContextTimeoutEnabled: true,
+	})
+
+	err := client.XGroupCreateMkStream(ctx, config.Stream, config.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		client.Close()
+		return nil, fmt.Errorf("create consumer group %s on %s: %w", config.Group, config.Stream, err)
+	}
+
+	return &RedisQueue{
+		client:  client,
+		config:  config,
+		logger:  logger,
+		entries: make(map[string][]string),
+	}, nil
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	// The length check and the add are not atomic, so concurrent producers
+	// may overshoot MaxLen slightly
+	if q.config.MaxLen > 0 {
+		length, err := q.client.XLen(ctx, q.config.Stream).Result()
+		if err != nil {
+			return err
+		}
+		if length >= int64(q.config.MaxLen) {
+			return ErrFull
+		}
+	}
+
+	return q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.config.Stream,
+		Values: map[string]any{"job_id": jobID},
+	}).Err()
+}
+
+func (q *RedisQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if q.isClosed() {
+			return "", ErrClosed
+		}
+
+		if q.claimDue() {
+			jobID, ok, err := q.claim(ctx)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return jobID, nil
+			}
+		}
+
+		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.config.Group,
+			Consumer: q.config.Consumer,
+			Streams:  []string{q.config.Stream, ">"},
+			Count:    1,
+			Block:    q.config.BlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			// Block timed out with nothing to read
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", err
+		}
+
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				return q.track(message), nil
+			}
+		}
+	}
+}
+
+// Ack acknowledges the oldest unacknowledged entry this consumer dequeued
+// for jobID and removes it from the stream.
+func (q *RedisQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	ids := q.entries[jobID]
+	if len(ids) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	entryID := ids[0]
+	if len(ids) == 1 {
+		delete(q.entries, jobID)
+	} else {
+		q.entries[jobID] = ids[1:]
+	}
+	q.mu.Unlock()
+
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, q.config.Stream, q.config.Group, entryID)
+	pipe.XDel(ctx, q.config.Stream, entryID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *RedisQueue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
+
+// Len is the number of entries not yet delivered to any consumer.
+func (q *RedisQueue) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	length, err := q.client.XLen(ctx, q.config.Stream).Result()
+	if err != nil {
+		return 0
+	}
+	pending, err := q.client.XPending(ctx, q.config.Stream, q.config.Group).Result()
+	if err != nil {
+		return 0
+	}
+
+	return int(max(length-pending.Count, 0))
+}
+
+func (q *RedisQueue) Cap() int {
+	return q.config.MaxLen
+}
+
+// Close stops this instance using the queue. Entries stay in the stream for
+// other consumers, and unacknowledged ones are claimed once they go idle.
+func (q *RedisQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	return q.client.Close()
+}
+
+func (q *RedisQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
+
+// claimDue reports whether it is time to look for entries abandoned by other
+// consumers, at most once per ClaimIdle.
+func (q *RedisQueue) claimDue() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if time.Since(q.lastClaim) < q.config.ClaimIdle {
+		return false
+	}
+	q.lastClaim = time.Now()
+
+	return true
+}
+
+// claim takes over one entry that has been pending longer than ClaimIdle,
+// which means the consumer that read it most likely crashed.
+func (q *RedisQueue) claim(ctx context.Context) (string, bool, error) {
+	messages, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.config.Stream,
+		Group:    q.config.Group,
+		Consumer: q.config.Consumer,
+		MinIdle:  q.config.ClaimIdle,
+		Start:    "0-0",
+		Count:    1,
+	}).Result()
+	if err != nil {
+		return "", false, err
+	}
+	if len(messages) == 0 {
+		return "", false, nil
+	}
+
+	jobID := q.track(messages[0])
+	q.logger.Info("Claimed abandoned queue entry", "event", "queue_entry_claimed", "job_id", jobID, "entry_id", messages[0].ID)
+
+	return jobID, true, nil
+}
+
+// track records a delivered entry so Ack can find it, returning its job ID.
+func (q *RedisQueue) track(message redis.XMessage) string {
+	jobID, _ := message.Values["job_id"].(string)
+
+	q.mu.Lock()
+	q.entries[jobID] = append(q.entries[jobID], message.ID)
+	q.mu.Unlock()
+
+	return jobID
+}
@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// DefaultName is the queue jobs go to unless submitted to or routed to
+// another one.
+const DefaultName = "default"
+
+// Routing decides which named queue a job belongs to.
+type Routing struct {
+	// Queue names by job type
+	routes map[string]string
+}
+
+func NewRouting(routes map[string]string) *Routing {
+	return &Routing{routes: routes}
+}
+
+// QueueFor returns the queue the job was submitted to, else the queue its
+// type is routed to, else DefaultName.
+func (r *Routing) QueueFor(job *domain.Job) string {
+	if job.Queue != "" {
+		return job.Queue
+	}
+	if name, ok := r.routes[job.Type]; ok {
+		return name
+	}
+	return DefaultName
+}
+
+// Router is a Queue over several named queues: each enqueued ID goes to the
+// queue its job belongs to. Workers dequeue from one named queue via Named;
+// Router's own Dequeue reads the default queue.
+type Router struct {
+	queues  map[string]Queue
+	routing *Routing
+	getJob  func(ctx context.Context, jobID string) (*domain.Job, error)
+}
+
+// NewRouter routes between queues, which must include DefaultName. getJob
+// looks up the job an ID belongs to; IDs it can't find go to the default
+// queue.
+func NewRouter(queues map[string]Queue, routing *Routing, getJob func(ctx context.Context, jobID string) (*domain.Job, error)) *Router {
+	return &Router{
+		queues:  queues,
+		routing: routing,
+		getJob:  getJob,
+	}
+}
+
+func (r *Router) Routing() *Routing {
+	return r.routing
+}
+
+// Named returns the queue called name, or nil if there is none.
+func (r *Router) Named(name string) Queue {
+	return r.queues[name]
+}
+
+// Names returns every queue name, sorted.
+func (r *Router) Names() []string {
+	return slices.Sorted(maps.Keys(r.queues))
+}
+
+func (r *Router) Enqueue(ctx context.Context, jobID string) error {
+	name := DefaultName
+	if job, err := r.getJob(ctx, jobID); err == nil {
+		name = r.routing.QueueFor(job)
+	}
+
+	q, ok := r.queues[name]
+	if !ok {
+		q = r.queues[DefaultName]
+	}
+
+	return q.Enqueue(ctx, jobID)
+}
+
+func (r *Router) Dequeue(ctx context.Context) (string, error) {
+	return r.queues[DefaultName].Dequeue(ctx)
+}
+
+// Holds reports whether any of the queues holds jobID.
+func (r *Router) Holds(jobID string) bool {
+	for _, q := range r.queues {
+		if Holds(q, jobID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks the broker behind every queue.
+func (r *Router) Ping(ctx context.Context) error {
+	var errs []error
+	for _, q := range r.queues {
+		errs = append(errs, Ping(ctx, q))
+	}
+	return errors.Join(errs...)
+}
+
+// Len is the total across all queues.
+func (r *Router) Len() int {
+	total := 0
+	for _, q := range r.queues {
+		total += q.Len()
+	}
+	return total
+}
+
+// Cap is the total across all queues, or zero if any is unbounded.
+func (r *Router) Cap() int {
+	total := 0
+	for _, q := range r.queues {
+		if q.Cap() == 0 {
+			return 0
+		}
+		total += q.Cap()
+	}
+	return total
+}
+
+func (r *Router) Close() error {
+	var errs []error
+	for _, q := range r.queues {
+		errs = append(errs, q.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Exists reports whether q has a queue called name. A Queue that is not a
+// Router only has the default queue.
+func Exists(q Queue, name string) bool {
+	router, ok := q.(*Router)
+	if !ok {
+		return name == DefaultName
+	}
+	return router.Named(name) != nil
+}
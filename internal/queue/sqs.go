@@ -0,0 +1,260 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQSConfig selects the SQS queue an SQSQueue reads and writes.
+type SQSConfig struct {
+	QueueURL string
+	Region   string
+	// Endpoint overrides the SQS endpoint, e.g. for LocalStack
+	Endpoint string
+	// A received message is hidden from other consumers for
+	// VisibilityTimeout; unless acknowledged with Ack by then, it is
+	// redelivered
+	VisibilityTimeout time.Duration
+	// How long one receive long-polls before checking for shutdown, up to
+	// 20 seconds
+	WaitTime time.Duration
+	// When DeadLetterQueueURL is set, messages received MaxReceiveCount times
+	// without being acknowledged are redriven to it, and dead jobs are sent
+	// to it too
+	DeadLetterQueueURL string
+	MaxReceiveCount    int
+}
+
+// SQSQueue is a Queue backed by Amazon SQS, so the server can run without
+// local queue state. A received message's visibility timeout acts as its
+// claim.
+type SQSQueue struct {
+	client *sqs.Client
+	config SQSConfig
+
+	mu sync.Mutex
+	// Receipt handles of messages received but not yet acknowledged, by
+	// job ID
+	receipts map[string][]string
+	closed   bool
+}
+
+// NewSQSQueue loads AWS credentials from the environment and, when a
+// dead-letter queue is configured, sets the queue's redrive policy.
+func NewSQSQueue(ctx context.Context, config SQSConfig) (*SQSQueue, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(config.Region))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	client := sqs.NewFromConfig(awsConfig, func(o *sqs.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+		}
+	})
+
+	q := &SQSQueue{
+		client:   client,
+		config:   config,
+		receipts: make(map[string][]string),
+	}
+
+	if config.DeadLetterQueueURL != "" {
+		if err := q.setRedrivePolicy(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// setRedrivePolicy points the queue's redrive policy at the dead-letter queue.
+func (q *SQSQueue) setRedrivePolicy(ctx context.Context) error {
+	attributes, err := q.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.config.DeadLetterQueueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return fmt.Errorf("get dead-letter queue ARN: %w", err)
+	}
+
+	policy, err := json.Marshal(map[string]string{
+		"deadLetterTargetArn": attributes.Attributes[string(types.QueueAttributeNameQueueArn)],
+		"maxReceiveCount":     strconv.Itoa(q.config.MaxReceiveCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl: aws.String(q.config.QueueURL),
+		Attributes: map[string]string{
+			string(types.QueueAttributeNameRedrivePolicy): string(policy),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("set redrive policy: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue sends jobID to the queue. SQS queues are unbounded, so Enqueue
+// never returns ErrFull.
+func (q *SQSQueue) Enqueue(ctx context.Context, jobID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	_, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.config.QueueURL),
+		MessageBody: aws.String(jobID),
+	})
+
+	return err
+}
+
+func (q *SQSQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if q.isClosed() {
+			return "", ErrClosed
+		}
+
+		output, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.config.QueueURL),
+			MaxNumberOfMessages: 1,
+			VisibilityTimeout:   int32(q.config.VisibilityTimeout.Seconds()),
+			WaitTimeSeconds:     int32(q.config.WaitTime.Seconds()),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", err
+		}
+		if len(output.Messages) == 0 {
+			continue
+		}
+
+		message := output.Messages[0]
+		jobID := aws.ToString(message.Body)
+
+		q.mu.Lock()
+		q.receipts[jobID] = append(q.receipts[jobID], aws.ToString(message.ReceiptHandle))
+		q.mu.Unlock()
+
+		return jobID, nil
+	}
+}
+
+// Ack deletes the oldest unacknowledged message received for jobID.
+func (q *SQSQueue) Ack(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	receipts := q.receipts[jobID]
+	if len(receipts) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	receipt := receipts[0]
+	if len(receipts) == 1 {
+		delete(q.receipts, jobID)
+	} else {
+		q.receipts[jobID] = receipts[1:]
+	}
+	q.mu.Unlock()
+
+	_, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.config.QueueURL),
+		ReceiptHandle: aws.String(receipt),
+	})
+
+	return err
+}
+
+// DeadLetter sends jobID to the dead-letter queue, if one is configured, so
+// it collects dead jobs alongside redriven messages.
+func (q *SQSQueue) DeadLetter(ctx context.Context, jobID string, reason string) error {
+	if q.config.DeadLetterQueueURL == "" {
+		return nil
+	}
+
+	_, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.config.DeadLetterQueueURL),
+		MessageBody: aws.String(jobID),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"dead_reason": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(reason),
+			},
+		},
+	})
+
+	return err
+}
+
+func (q *SQSQueue) Ping(ctx context.Context) error {
+	_, err := q.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.config.QueueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	return err
+}
+
+// Len is SQS's approximate count of messages available for retrieval.
+func (q *SQSQueue) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	output, err := q.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.config.QueueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0
+	}
+
+	count, err := strconv.Atoi(output.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// Cap is always zero: SQS queues are unbounded.
+func (q *SQSQueue) Cap() int {
+	return 0
+}
+
+// Close stops this instance using the queue. Unacknowledged messages become
+// visible to other consumers once their visibility timeout lapses.
+func (q *SQSQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+
+	return nil
+}
+
+func (q *SQSQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
@@ -0,0 +1,241 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WaitBuckets are the upper bounds, in seconds, of the time-in-queue
+// histogram buckets.
+var WaitBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// tracer starts a span for each enqueue, as part of the trace of whatever
+// caused it.
+var tracer = otel.Tracer("github.com/karprabha/job-queue-backend/internal/queue")
+
+// maxTracked bounds how many enqueue times an Instrumented queue remembers.
+// IDs enqueued here but never dequeued in this process (a broker queue's
+// backlog at shutdown, or a queue dropped at shutdown) are never matched, so
+// without a bound they would accumulate.
+const maxTracked = 10000
+
+// Stats collects enqueue and dequeue counts and time-in-queue for every
+// queue instrumented with it.
+type Stats struct {
+	mu     sync.Mutex
+	queues []*Instrumented
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+// QueueStats is one queue's counters at a point in time.
+type QueueStats struct {
+	Name     string
+	Depth    int
+	Capacity int
+	Enqueued int
+	// Enqueues refused because the queue was full
+	Rejected int
+	Dequeued int
+	Wait     Histogram
+}
+
+// Histogram counts observations into cumulative buckets, one per
+// WaitBuckets bound.
+type Histogram struct {
+	Buckets []int
+	Count   int
+	Sum     float64
+}
+
+func newHistogram() Histogram {
+	return Histogram{Buckets: make([]int, len(WaitBuckets))}
+}
+
+func (h *Histogram) observe(seconds float64) {
+	for i, bound := range WaitBuckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
+}
+
+func (h *Histogram) add(other Histogram) {
+	for i := range h.Buckets {
+		h.Buckets[i] += other.Buckets[i]
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
+
+// Snapshot returns every instrumented queue's counters, sorted by name.
+func (s *Stats) Snapshot() []QueueStats {
+	s.mu.Lock()
+	queues := slices.Clone(s.queues)
+	s.mu.Unlock()
+
+	snapshot := make([]QueueStats, 0, len(queues))
+	for _, q := range queues {
+		snapshot = append(snapshot, q.stats())
+	}
+	slices.SortFunc(snapshot, func(a, b QueueStats) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return snapshot
+}
+
+// Total sums the counters of every instrumented queue.
+func (s *Stats) Total() QueueStats {
+	total := QueueStats{Wait: newHistogram()}
+	for _, q := range s.Snapshot() {
+		total.Enqueued += q.Enqueued
+		total.Rejected += q.Rejected
+		total.Dequeued += q.Dequeued
+		total.Wait.add(q.Wait)
+	}
+
+	return total
+}
+
+// Instrumented is a Queue that records its traffic in a Stats. Time in
+// queue is measured for IDs enqueued and dequeued through the same
+// Instrumented.
+type Instrumented struct {
+	Queue
+	name string
+
+	mu       sync.Mutex
+	enqueued int
+	rejected int
+	dequeued int
+	wait     Histogram
+	// Enqueue times of IDs not yet dequeued, oldest first per ID
+	enqueuedAt map[string][]time.Time
+	tracked    int
+}
+
+// Instrument wraps q so its traffic is reported by stats under name.
+func Instrument(q Queue, name string, stats *Stats) *Instrumented {
+	instrumented := &Instrumented{
+		Queue:      q,
+		name:       name,
+		wait:       newHistogram(),
+		enqueuedAt: make(map[string][]time.Time),
+	}
+
+	stats.mu.Lock()
+	stats.queues = append(stats.queues, instrumented)
+	stats.mu.Unlock()
+
+	return instrumented
+}
+
+func (q *Instrumented) Enqueue(ctx context.Context, jobID string) error {
+	ctx, span := tracer.Start(ctx, "enqueue "+q.name, trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("messaging.destination.name", q.name),
+		attribute.String("job.id", jobID),
+	))
+	defer span.End()
+
+	err := q.Queue.Enqueue(ctx, jobID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch {
+	case err == nil:
+		q.enqueued++
+		if q.tracked < maxTracked {
+			q.enqueuedAt[jobID] = append(q.enqueuedAt[jobID], time.Now())
+			q.tracked++
+		}
+	case errors.Is(err, ErrFull):
+		q.rejected++
+	}
+
+	return err
+}
+
+func (q *Instrumented) Dequeue(ctx context.Context) (string, error) {
+	jobID, err := q.Queue.Dequeue(ctx)
+	if err != nil {
+		return jobID, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dequeued++
+	if times := q.enqueuedAt[jobID]; len(times) > 0 {
+		q.wait.observe(time.Since(times[0]).Seconds())
+		if len(times) == 1 {
+			delete(q.enqueuedAt, jobID)
+		} else {
+			q.enqueuedAt[jobID] = times[1:]
+		}
+		q.tracked--
+	}
+
+	return jobID, nil
+}
+
+// Ack, DeadLetter, Holds and HoldsDelayed pass through to the wrapped queue, so
+// instrumenting a queue doesn't hide its optional capabilities.
+
+func (q *Instrumented) Ack(ctx context.Context, jobID string) error {
+	return Ack(ctx, q.Queue, jobID)
+}
+
+func (q *Instrumented) DeadLetter(ctx context.Context, jobID string, reason string) error {
+	return DeadLetter(ctx, q.Queue, jobID, reason)
+}
+
+func (q *Instrumented) Ping(ctx context.Context) error {
+	return Ping(ctx, q.Queue)
+}
+
+func (q *Instrumented) Holds(jobID string) bool {
+	return Holds(q.Queue, jobID)
+}
+
+func (q *Instrumented) HoldsDelayed() bool {
+	return HoldsDelayed(q.Queue)
+}
+
+func (q *Instrumented) stats() QueueStats {
+	q.mu.Lock()
+	stats := QueueStats{
+		Name:     q.name,
+		Enqueued: q.enqueued,
+		Rejected: q.rejected,
+		Dequeued: q.dequeued,
+		Wait: Histogram{
+			Buckets: slices.Clone(q.wait.Buckets),
+			Count:   q.wait.Count,
+			Sum:     q.wait.Sum,
+		},
+	}
+	q.mu.Unlock()
+
+	stats.Depth = q.Len()
+	stats.Capacity = q.Cap()
+
+	return stats
+}
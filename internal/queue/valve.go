@@ -0,0 +1,177 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPaused is returned by Enqueue while the queue is paused. The job stays
+// pending in the store and is enqueued by the catch-up after Resume.
+var ErrPaused = errors.New("queue is paused")
+
+// Valve pauses every queue wrapped with it at once, for planned downstream
+// maintenance: while paused, enqueues are refused with ErrPaused so new work
+// is only persisted to the store, and dequeues wait for Resume.
+type Valve struct {
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	// Enqueues refused since the last Pause
+	buffered int
+	// Closed while the valve is open
+	open chan struct{}
+}
+
+func NewValve() *Valve {
+	open := make(chan struct{})
+	close(open)
+
+	return &Valve{open: open}
+}
+
+// ValveStatus is whether the valve is paused, since when, and how many
+// enqueues it has refused since then.
+type ValveStatus struct {
+	Paused   bool
+	PausedAt time.Time
+	Buffered int
+}
+
+// Pause closes the valve. It returns false if it was already paused.
+func (v *Valve) Pause() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.paused {
+		return false
+	}
+
+	v.paused = true
+	v.pausedAt = time.Now().UTC()
+	v.buffered = 0
+	v.open = make(chan struct{})
+
+	return true
+}
+
+// Resume opens the valve. It returns false if it was not paused.
+func (v *Valve) Resume() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.paused {
+		return false
+	}
+
+	v.paused = false
+	close(v.open)
+
+	return true
+}
+
+func (v *Valve) Paused() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.paused
+}
+
+func (v *Valve) Status() ValveStatus {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return ValveStatus{
+		Paused:   v.paused,
+		PausedAt: v.pausedAt,
+		Buffered: v.buffered,
+	}
+}
+
+// admit reports whether an enqueue may go through, counting it as buffered
+// if not.
+func (v *Valve) admit() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.paused {
+		v.buffered++
+		return false
+	}
+
+	return true
+}
+
+func (v *Valve) wait() <-chan struct{} {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.open
+}
+
+// Wrap returns q controlled by the valve.
+func (v *Valve) Wrap(q Queue) *Valved {
+	return &Valved{Queue: q, valve: v}
+}
+
+// Valved is a Queue whose traffic a Valve can pause.
+type Valved struct {
+	Queue
+	valve *Valve
+}
+
+func (q *Valved) Enqueue(ctx context.Context, jobID string) error {
+	if !q.valve.admit() {
+		return ErrPaused
+	}
+
+	return q.Queue.Enqueue(ctx, jobID)
+}
+
+// Dequeue waits while the valve is paused. An ID that was already being
+// dequeued when it paused is held until Resume.
+func (q *Valved) Dequeue(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-q.valve.wait():
+	}
+
+	jobID, err := q.Queue.Dequeue(ctx)
+	if err != nil {
+		return jobID, err
+	}
+
+	select {
+	case <-ctx.Done():
+		// Put the ID back for whoever dequeues after Resume, settling the
+		// delivery first so an acking queue doesn't redeliver it as well
+		detached := context.WithoutCancel(ctx)
+		Ack(detached, q.Queue, jobID)
+		q.Queue.Enqueue(detached, jobID)
+		return "", ctx.Err()
+	case <-q.valve.wait():
+		return jobID, nil
+	}
+}
+
+func (q *Valved) Ack(ctx context.Context, jobID string) error {
+	return Ack(ctx, q.Queue, jobID)
+}
+
+func (q *Valved) DeadLetter(ctx context.Context, jobID string, reason string) error {
+	return DeadLetter(ctx, q.Queue, jobID, reason)
+}
+
+func (q *Valved) Ping(ctx context.Context) error {
+	return Ping(ctx, q.Queue)
+}
+
+func (q *Valved) Holds(jobID string) bool {
+	return Holds(q.Queue, jobID)
+}
+
+func (q *Valved) HoldsDelayed() bool {
+	return HoldsDelayed(q.Queue)
+}
@@ -0,0 +1,87 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BurstyLimiter is a token bucket: tokens refill at a steady rate up to
+// burst, so short spikes of up to burst calls go through at once while the
+// long-run rate stays bounded.
+type BurstyLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // Tokens per second
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewBurstyLimiter allows rate calls per second with bursts of up to burst
+// calls. The bucket starts full.
+func NewBurstyLimiter(rate float64, burst int) *BurstyLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &BurstyLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Take blocks until a token is available or ctx is done. It returns the time
+// spent waiting, or ctx's error if it gave up.
+func (l *BurstyLimiter) Take(ctx context.Context) (time.Duration, error) {
+	wait := l.reserve()
+	if wait == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+	for ; wait > 0; wait = l.reserve() {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(start), ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return time.Since(start), nil
+}
+
+// Allow takes a token if one is available, without waiting, and reports
+// whether it did.
+func (l *BurstyLimiter) Allow() bool {
+	return l.reserve() == 0
+}
+
+// reserve takes a token if one is available and returns zero, or otherwise
+// how long until the next token.
+func (l *BurstyLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.lastFill).Seconds()*l.rate)
+	l.lastFill = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// refund gives back a token taken by Allow that went unused.
+func (l *BurstyLimiter) refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+1)
+}
@@ -0,0 +1,47 @@
+package ratelimiter
+
+// RetryBudget caps how many failed jobs are retried per minute, across all
+// job types and per type, so a downstream outage failing every job doesn't
+// turn into a storm of retries hammering it as it comes back. Each cap is a
+// token bucket holding a minute's worth of retries.
+type RetryBudget struct {
+	// Nil when there is no global cap
+	global *BurstyLimiter
+	byType map[string]*BurstyLimiter
+}
+
+// NewRetryBudget allows perMinute retries in total and perMinuteByType
+// retries of each listed type. Zero (or a missing type) means no cap.
+func NewRetryBudget(perMinute int, perMinuteByType map[string]int) *RetryBudget {
+	budget := &RetryBudget{
+		byType: make(map[string]*BurstyLimiter),
+	}
+	if perMinute > 0 {
+		budget.global = NewBurstyLimiter(float64(perMinute)/60, perMinute)
+	}
+	for jobType, limit := range perMinuteByType {
+		if limit > 0 {
+			budget.byType[jobType] = NewBurstyLimiter(float64(limit)/60, limit)
+		}
+	}
+
+	return budget
+}
+
+// Allow spends one retry of jobType if both its type's budget and the global
+// one have a retry left, and reports whether it did. Nothing is spent when
+// it refuses.
+func (b *RetryBudget) Allow(jobType string) bool {
+	typeLimiter := b.byType[jobType]
+	if typeLimiter != nil && !typeLimiter.Allow() {
+		return false
+	}
+	if b.global != nil && !b.global.Allow() {
+		if typeLimiter != nil {
+			typeLimiter.refund()
+		}
+		return false
+	}
+
+	return true
+}
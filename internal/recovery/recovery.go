@@ -4,35 +4,111 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
 	"github.com/karprabha/job-queue-backend/internal/store"
 )
 
-// RecoverJobs performs startup recovery:
-// 1. Moves processing jobs back to pending (they were interrupted during crash)
-// 2. Re-enqueues all pending jobs (including newly recovered ones)
-// 3. Respects backpressure (waits if queue is full, no jobs dropped)
-func RecoverJobs(
+// Recovery states.
+const (
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+)
+
+// Status is how far startup recovery has got.
+type Status struct {
+	State      string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	// Processing jobs of the previous run moved back to pending
+	ProcessingRecovered int
+	// Due pending jobs to re-enqueue, and how many have been so far
+	PendingTotal      int
+	PendingReEnqueued int
+	// Error is why recovery failed
+	Error string
+}
+
+// Remaining is how many due pending jobs are still to be re-enqueued.
+func (s Status) Remaining() int {
+	return s.PendingTotal - s.PendingReEnqueued
+}
+
+// Progress tracks startup recovery, so readiness can wait for it and
+// GET /admin/recovery can report it.
+type Progress struct {
+	mu     sync.Mutex
+	status Status
+}
+
+func NewProgress() *Progress {
+	return &Progress{
+		status: Status{
+			State:     StateRunning,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+}
+
+func (p *Progress) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.status
+}
+
+// Done reports whether recovery completed.
+func (p *Progress) Done() bool {
+	return p.Status().State == StateCompleted
+}
+
+func (p *Progress) update(f func(status *Status)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f(&p.status)
+}
+
+func (p *Progress) finish(err error) {
+	p.update(func(status *Status) {
+		finishedAt := time.Now().UTC()
+		status.FinishedAt = &finishedAt
+		if err != nil {
+			status.State = StateFailed
+			status.Error = err.Error()
+			return
+		}
+		status.State = StateCompleted
+	})
+}
+
+// RecoverProcessingJobs moves jobs left processing by the previous run back
+// to pending; they were in flight when it stopped. It must finish before
+// workers start, or it would take back their claims too. A job whose attempts
+// crashed the server too many times in a row is quarantined instead.
+func RecoverProcessingJobs(
 	ctx context.Context,
 	jobStore store.JobStore,
-	jobQueue chan string,
+	bus *events.Bus,
 	logger *slog.Logger,
+	progress *Progress,
 ) error {
 	logger.Info("Starting recovery", "event", "recovery_started")
 
-	// Step 1: Move processing jobs back to pending
-	// These jobs were in-flight when the process crashed
 	processingJobs, err := jobStore.GetProcessingJobs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get processing jobs: %w", err)
+		err = fmt.Errorf("failed to get processing jobs: %w", err)
+		progress.finish(err)
+		return err
 	}
 
-	processingRecovered := 0
 	for _, job := range processingJobs {
-		// Use UpdateStatus to respect state transition rules
-		err := jobStore.UpdateStatus(ctx, job.ID, domain.StatusPending, nil)
+		status, err := jobStore.RecoverJob(ctx, job.ID)
 		if err != nil {
 			logger.Error("Failed to recover processing job",
 				"event", "recovery_error",
@@ -41,79 +117,121 @@ func RecoverJobs(
 			// Continue with other jobs - don't fail entire recovery
 			continue
 		}
-		processingRecovered++
-		logger.Info("Recovered processing job",
-			"event", "job_recovered",
-			"job_id", job.ID)
+		progress.update(func(status *Status) {
+			status.ProcessingRecovered++
+		})
+		bus.Publish(ctx, events.ForJob(events.JobRecovered, &job))
+
+		// Its attempts keep taking the whole server down
+		if status == domain.StatusQuarantined {
+			logger.Warn("Recovered job crashed too many times in a row, quarantined",
+				"event", "job_quarantined",
+				"job_id", job.ID,
+				"attempt", job.Attempts)
+			event := events.ForJob(events.JobQuarantined, &job)
+			event.Reason = "recovered"
+			bus.Publish(ctx, event)
+		}
 	}
 
-	// Step 2: Re-enqueue all pending jobs (including newly recovered ones)
+	return nil
+}
+
+// ReEnqueuePendingJobs enqueues every due pending job, including the ones
+// just recovered, waiting while the queue is full so none is dropped. It runs
+// alongside the workers, which drain the queue as it fills.
+func ReEnqueuePendingJobs(
+	ctx context.Context,
+	jobStore store.JobStore,
+	jobQueue queue.Queue,
+	bus *events.Bus,
+	logger *slog.Logger,
+	progress *Progress,
+) error {
+	err := reEnqueuePendingJobs(ctx, jobStore, jobQueue, logger, progress)
+	progress.finish(err)
+	if err != nil {
+		return err
+	}
+
+	status := progress.Status()
+	bus.Publish(ctx, events.Event{Type: events.RecoveryCompleted, Data: map[string]int{
+		"processing_recovered": status.ProcessingRecovered,
+		"pending_re_enqueued":  status.PendingReEnqueued,
+	}})
+
+	return nil
+}
+
+func reEnqueuePendingJobs(
+	ctx context.Context,
+	jobStore store.JobStore,
+	jobQueue queue.Queue,
+	logger *slog.Logger,
+	progress *Progress,
+) error {
 	pendingJobs, err := jobStore.GetPendingJobs(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get pending jobs: %w", err)
 	}
 
-	pendingReEnqueued := 0
+	// Scheduled jobs are left for the sweeper to enqueue once due
+	now := time.Now().UTC()
+	dueJobs := make([]domain.Job, 0, len(pendingJobs))
 	for _, job := range pendingJobs {
+		if job.Due(now) {
+			dueJobs = append(dueJobs, job)
+		}
+	}
+	progress.update(func(status *Status) {
+		status.PendingTotal = len(dueJobs)
+	})
+
+	for _, job := range dueJobs {
 		if err := reEnqueueWithBackpressure(ctx, job.ID, jobQueue, logger); err != nil {
 			return fmt.Errorf("failed to re-enqueue job %s: %w", job.ID, err)
 		}
-		pendingReEnqueued++
+		progress.update(func(status *Status) {
+			status.PendingReEnqueued++
+		})
 	}
 
-	logger.Info("Recovery completed",
-		"event", "recovery_completed",
-		"processing_recovered", processingRecovered,
-		"pending_re_enqueued", pendingReEnqueued)
-
 	return nil
 }
 
-// reEnqueueWithBackpressure attempts to enqueue a job with exponential backoff
-// if the queue is full. This ensures no jobs are dropped during recovery.
+// recoveryBackoff waits out a full queue for up to about half a minute per
+// job, so no jobs are dropped during recovery.
+var recoveryBackoff = queue.Backoff{
+	Initial:  50 * time.Millisecond,
+	Max:      5 * time.Second,
+	Attempts: 10,
+}
+
+// reEnqueueWithBackpressure enqueues a job, backing off while the queue is
+// full.
 func reEnqueueWithBackpressure(
 	ctx context.Context,
 	jobID string,
-	jobQueue chan string,
+	jobQueue queue.Queue,
 	logger *slog.Logger,
 ) error {
-	backoff := 50 * time.Millisecond
-	maxBackoff := 5 * time.Second
-	maxAttempts := 10
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case jobQueue <- jobID:
-			if attempt > 0 {
-				logger.Info("Job re-enqueued after backoff",
-					"event", "job_re_enqueued",
-					"job_id", jobID,
-					"attempt", attempt+1)
-			}
-			return nil // Success!
-		default:
-			if attempt < maxAttempts-1 {
-				logger.Info("Queue full during recovery, backing off",
-					"event", "recovery_backpressure",
-					"job_id", jobID,
-					"attempt", attempt+1,
-					"backoff_ms", backoff.Milliseconds())
-
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(backoff):
-					// Exponential backoff with cap
-					backoff = time.Duration(float64(backoff) * 1.5)
-					if backoff > maxBackoff {
-						backoff = maxBackoff
-					}
-				}
-			}
-		}
+	attempts, err := queue.EnqueueWithBackoff(ctx, jobQueue, jobID, recoveryBackoff, func(attempt int, wait time.Duration) {
+		logger.Info("Queue full during recovery, backing off",
+			"event", "recovery_backpressure",
+			"job_id", jobID,
+			"attempt", attempt,
+			"backoff_ms", wait.Milliseconds())
+	})
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("failed to enqueue job %s after %d attempts: queue persistently full", jobID, maxAttempts)
+	if attempts > 1 {
+		logger.Info("Job re-enqueued after backoff",
+			"event", "job_re_enqueued",
+			"job_id", jobID,
+			"attempt", attempts)
+	}
+
+	return nil
 }
@@ -0,0 +1,177 @@
+// Package remote implements the job lifecycle for workers running outside
+// this process. The HTTP lease API and the gRPC worker stream are both thin
+// transports over Service.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// Service leases jobs to remote workers and records the outcome they report.
+// Every call after Lease carries the execution token the job was leased
+// with, so a worker whose lease lapsed cannot overwrite the claim that
+// replaced it.
+type Service struct {
+	jobStore store.JobStore
+	events   *events.Bus
+	logger   *slog.Logger
+	jobQueue queue.Queue
+}
+
+func NewService(jobStore store.JobStore, bus *events.Bus, logger *slog.Logger, jobQueue queue.Queue) *Service {
+	return &Service{
+		jobStore: jobStore,
+		events:   bus,
+		logger:   logger,
+		jobQueue: jobQueue,
+	}
+}
+
+// Lease claims up to limit due pending jobs of the given types for workerID,
+// each held for visibility unless extended.
+func (s *Service) Lease(ctx context.Context, types []string, limit int, workerID string, visibility time.Duration) ([]domain.Job, error) {
+	jobs, err := s.jobStore.LeaseJobs(ctx, types, limit, workerID, visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		s.logger.Info("Job leased", "event", "job_leased", "job_id", job.ID, "job_type", job.Type, "worker_id", workerID, "attempt", job.Attempts)
+		event := events.ForJob(events.JobStarted, job)
+		event.Worker = workerID
+		s.events.Publish(ctx, event)
+	}
+
+	return jobs, nil
+}
+
+// Extend pushes the lease holding token out by visibility from now.
+func (s *Service) Extend(ctx context.Context, jobID string, token string, visibility time.Duration) (time.Time, error) {
+	return s.jobStore.ExtendLease(ctx, jobID, token, visibility)
+}
+
+// Abandon expires the lease holding token immediately, so the lease reaper
+// offers the job again on its next pass instead of after the visibility
+// timeout. Used when a streaming worker disconnects mid-job.
+func (s *Service) Abandon(ctx context.Context, jobID string, token string) error {
+	_, err := s.jobStore.ExtendLease(ctx, jobID, token, 0)
+	return err
+}
+
+// Progress records how far the worker holding token has got.
+func (s *Service) Progress(ctx context.Context, jobID string, token string, percent int, message string) error {
+	err := s.jobStore.UpdateProgress(ctx, jobID, token, percent, message)
+	if errors.Is(err, store.ErrJobNotProcessing) {
+		return store.ErrLeaseLost
+	}
+	return err
+}
+
+// Ack completes the job leased with token, storing the optional result. The
+// store checks the token in the same update, so a lease reaped in the
+// meantime is rejected with store.ErrLeaseLost.
+func (s *Service) Ack(ctx context.Context, jobID string, token string, result json.RawMessage) error {
+	// Read first for the attempt to report; the update below fails if the
+	// claim changed since
+	job, err := s.leasedJob(ctx, jobID, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.jobStore.CompleteJob(ctx, jobID, token, result, nil); err != nil {
+		return err
+	}
+	s.logger.Info("Job completed", "event", "job_completed", "job_id", jobID, "attempt", job.Attempts)
+	s.events.Publish(ctx, events.ForJob(events.JobCompleted, job))
+
+	s.resolveDependents(ctx, jobID)
+
+	return nil
+}
+
+// Nack fails the attempt. The job is retried with backoff while it has
+// retries left, unless the failure is permanent.
+func (s *Service) Nack(ctx context.Context, jobID string, token string, lastError string, permanent bool) error {
+	if lastError == "" {
+		lastError = "Job rejected by remote worker"
+	}
+	errorClass := domain.ErrorClassRetryable
+	if permanent {
+		errorClass = domain.ErrorClassPermanent
+	}
+
+	job, err := s.leasedJob(ctx, jobID, token)
+	if err != nil {
+		return err
+	}
+
+	status, err := s.jobStore.FailJob(ctx, jobID, token, lastError, errorClass, permanent)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Job failed", "event", "job_failed", "job_id", jobID, "attempt", job.Attempts, "error", lastError)
+
+	event := events.ForJob(events.JobFailed, job)
+	event.Error = lastError
+	event.ErrorClass = errorClass
+	s.events.Publish(ctx, event)
+
+	if status == domain.StatusDead {
+		s.logger.Warn("Job exhausted its retries and moved to the dead-letter queue", "event", "job_dead", "job_id", jobID, "attempts", job.Attempts)
+		event.Type = events.JobDead
+		s.events.Publish(ctx, event)
+		s.resolveDependents(ctx, jobID)
+	}
+
+	return nil
+}
+
+// leasedJob returns the job, or store.ErrLeaseLost unless token is the
+// execution token of its current claim.
+func (s *Service) leasedJob(ctx context.Context, jobID string, token string) (*domain.Job, error) {
+	job, err := s.jobStore.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != domain.StatusProcessing || token == "" || job.ExecutionToken != token {
+		return nil, store.ErrLeaseLost
+	}
+
+	return job, nil
+}
+
+// resolveDependents releases or fails the blocked jobs that depend on jobID
+// now that it has finished, and enqueues the ones that became pending.
+func (s *Service) resolveDependents(ctx context.Context, jobID string) {
+	unblocked, failed, err := s.jobStore.ResolveDependents(ctx, jobID)
+	if err != nil {
+		s.logger.Error("Failed to resolve dependent jobs", "event", "job_dependents_error", "job_id", jobID, "error", err)
+	}
+	for _, id := range unblocked {
+		switch err := s.jobQueue.Enqueue(ctx, id); {
+		case err == nil:
+		case errors.Is(err, queue.ErrPaused):
+			// Enqueued by the catch-up on resume
+			s.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", id)
+		default:
+			// The job stays pending; the sweeper will enqueue it once
+			// there is room
+			s.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", id, "error", err)
+		}
+	}
+	for _, id := range failed {
+		s.logger.Warn("Dependent job failed", "event", "job_dependency_failed", "job_id", id, "dependency_id", jobID)
+		s.events.Publish(ctx, events.Event{Type: events.JobDead, JobID: id, Reason: "dependency_failed"})
+	}
+}
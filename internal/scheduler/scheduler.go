@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/drain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/leader"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/robfig/cron/v3"
+)
+
+// maxCatchUpRuns bounds how many missed ticks a catch_up schedule replays at
+// once, so a long outage can't flood the queue.
+const maxCatchUpRuns = 100
+
+// Parse validates a standard 5-field cron expression (or descriptor such as
+// @daily) and the IANA timezone it is evaluated in. An empty timezone is UTC.
+func Parse(cronExpr, timezone string) (cron.Schedule, *time.Location, error) {
+	spec, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	return spec, location, nil
+}
+
+// Next returns the first tick of spec after t, evaluated in location.
+func Next(spec cron.Schedule, location *time.Location, t time.Time) time.Time {
+	return spec.Next(t.In(location)).UTC()
+}
+
+// templateData is what payload templates can reference.
+type templateData struct {
+	ScheduleID  string
+	ScheduledAt string
+}
+
+// RenderPayload executes the schedule's payload template for one tick. The
+// template is JSON that may use {{.ScheduleID}} and {{.ScheduledAt}}.
+func RenderPayload(payloadTemplate json.RawMessage, scheduleID string, scheduledAt time.Time) (json.RawMessage, error) {
+	if len(payloadTemplate) == 0 {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("payload").Option("missingkey=error").Parse(string(payloadTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	data := templateData{
+		ScheduleID:  scheduleID,
+		ScheduledAt: scheduledAt.UTC().Format(time.RFC3339),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("payload template does not render valid JSON")
+	}
+
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+// Scheduler materializes jobs from recurring schedules as they come due.
+type Scheduler struct {
+	scheduleStore store.ScheduleStore
+	jobStore      store.JobStore
+	events        *events.Bus
+	drain         *drain.Controller
+	jobQueue      queue.Queue
+	logger        *slog.Logger
+	interval      time.Duration
+	// Only the leader fires schedules
+	elector leader.Elector
+}
+
+func NewScheduler(scheduleStore store.ScheduleStore, jobStore store.JobStore, bus *events.Bus, drain *drain.Controller, jobQueue queue.Queue, logger *slog.Logger, interval time.Duration, elector leader.Elector) *Scheduler {
+	return &Scheduler{
+		scheduleStore: scheduleStore,
+		jobStore:      jobStore,
+		events:        bus,
+		drain:         drain,
+		jobQueue:      jobQueue,
+		logger:        logger,
+		interval:      interval,
+		elector:       elector,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Scheduler shutting down", "event", "scheduler_stopped")
+			return
+		case <-ticker.C:
+			if !s.elector.IsLeader() {
+				continue
+			}
+
+			schedules, err := s.scheduleStore.GetSchedules(ctx)
+			if err != nil {
+				s.logger.Error("Scheduler error getting schedules", "event", "scheduler_error", "error", err)
+				continue
+			}
+
+			now := time.Now().UTC()
+			for _, schedule := range schedules {
+				if schedule.NextRunAt.After(now) {
+					continue
+				}
+				s.fire(ctx, &schedule, now)
+			}
+		}
+	}
+}
+
+// fire creates the jobs for every tick of schedule due by now, according to
+// its missed-run policy, and advances it to the next future tick.
+func (s *Scheduler) fire(ctx context.Context, schedule *domain.Schedule, now time.Time) {
+	spec, location, err := Parse(schedule.CronExpr, schedule.Timezone)
+	if err != nil {
+		s.logger.Error("Scheduler error parsing schedule", "event", "scheduler_error", "schedule_id", schedule.ID, "error", err)
+		return
+	}
+
+	var ticks []time.Time
+	next := schedule.NextRunAt
+	for !next.After(now) {
+		if len(ticks) < maxCatchUpRuns {
+			ticks = append(ticks, next)
+		}
+		next = Next(spec, location, next)
+	}
+
+	if schedule.MissedRunPolicy != domain.MissedRunCatchUp && len(ticks) > 1 {
+		s.logger.Info("Skipping missed schedule runs", "event", "schedule_runs_skipped", "schedule_id", schedule.ID, "skipped", len(ticks)-1)
+		ticks = ticks[len(ticks)-1:]
+	}
+
+	if err := s.scheduleStore.SetRunTimes(ctx, schedule.ID, ticks[len(ticks)-1], next); err != nil {
+		s.logger.Error("Scheduler error updating schedule", "event", "scheduler_error", "schedule_id", schedule.ID, "error", err)
+		return
+	}
+
+	if !s.drain.Accepting() {
+		s.logger.Info("Server is draining, schedule run skipped", "event", "schedule_run_skipped", "schedule_id", schedule.ID)
+		return
+	}
+
+	for _, tick := range ticks {
+		s.createJob(ctx, schedule, tick)
+	}
+}
+
+func (s *Scheduler) createJob(ctx context.Context, schedule *domain.Schedule, scheduledAt time.Time) {
+	payload, err := RenderPayload(schedule.PayloadTemplate, schedule.ID, scheduledAt)
+	if err != nil {
+		s.logger.Error("Scheduler error rendering payload", "event", "scheduler_error", "schedule_id", schedule.ID, "error", err)
+		return
+	}
+
+	job := domain.NewJob(schedule.JobType, payload)
+	if err := s.jobStore.CreateJob(ctx, job); err != nil {
+		s.logger.Error("Scheduler error creating job", "event", "scheduler_error", "schedule_id", schedule.ID, "error", err)
+		return
+	}
+	s.logger.Info("Job created from schedule", "event", "job_created", "job_id", job.ID, "schedule_id", schedule.ID, "scheduled_at", scheduledAt)
+
+	s.events.Publish(ctx, events.ForJob(events.JobCreated, job))
+
+	switch err := s.jobQueue.Enqueue(ctx, job.ID); {
+	case err == nil:
+		s.logger.Info("Job enqueued", "event", "job_enqueued", "job_id", job.ID)
+	case errors.Is(err, queue.ErrPaused):
+		// Enqueued by the catch-up on resume
+		s.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", job.ID)
+	default:
+		// Job stays pending; the sweeper will enqueue it once there is room
+		s.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", job.ID, "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package schema validates job payloads against JSON Schemas registered per
+// job type.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// FieldError describes one way a payload failed its schema. Field is a JSON
+// pointer into the payload; an empty Field refers to the payload as a whole.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Validate when a payload does not satisfy the
+// schema registered for its job type.
+type ValidationError struct {
+	JobType string
+	Fields  []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("payload does not match schema for job type %q", e.JobType)
+}
+
+type entry struct {
+	source   json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// Registry holds one compiled schema per job type. Types without a schema
+// accept any payload.
+type Registry struct {
+	schemas map[string]entry
+	mu      sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]entry),
+	}
+}
+
+// Set compiles source and registers it for jobType, replacing any previous
+// schema. A schema that fails to compile leaves the registry unchanged.
+func (r *Registry) Set(jobType string, source json.RawMessage) error {
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(source))
+	if err != nil {
+		return fmt.Errorf("invalid schema JSON: %w", err)
+	}
+
+	url := "mem://schemas/" + jobType
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, document); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas[jobType] = entry{source: source, compiled: compiled}
+
+	return nil
+}
+
+func (r *Registry) Get(jobType string) (json.RawMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.schemas[jobType]
+	if !ok {
+		return nil, ErrSchemaNotFound
+	}
+
+	return e.source, nil
+}
+
+func (r *Registry) Delete(jobType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.schemas[jobType]; !ok {
+		return ErrSchemaNotFound
+	}
+	delete(r.schemas, jobType)
+
+	return nil
+}
+
+// Types returns the job types that have a schema, sorted.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.schemas))
+	for jobType := range r.schemas {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// Validate checks payload against the schema for jobType. It returns nil when
+// no schema is registered and a *ValidationError listing every failing field
+// otherwise.
+func (r *Registry) Validate(jobType string, payload json.RawMessage) error {
+	r.mu.RLock()
+	e, ok := r.schemas[jobType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	// An omitted payload is validated as JSON null so "type": "object"
+	// schemas reject it
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return &ValidationError{JobType: jobType, Fields: []FieldError{{Message: "payload is not valid JSON"}}}
+	}
+
+	err = e.compiled.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return &ValidationError{JobType: jobType, Fields: []FieldError{{Message: err.Error()}}}
+	}
+
+	return &ValidationError{JobType: jobType, Fields: fieldErrors(validationErr.BasicOutput())}
+}
+
+// fieldErrors flattens the library's basic output into one entry per failing
+// keyword, skipping the summary units that only say a subschema failed.
+func fieldErrors(output *jsonschema.OutputUnit) []FieldError {
+	var fields []FieldError
+	for _, unit := range output.Errors {
+		if unit.Error == nil || len(unit.Errors) > 0 {
+			continue
+		}
+		message := unit.Error.String()
+		if strings.HasPrefix(message, "validation failed") {
+			continue
+		}
+		fields = append(fields, FieldError{Field: unit.InstanceLocation, Message: message})
+	}
+
+	if len(fields) == 0 && output.Error != nil {
+		fields = append(fields, FieldError{Field: output.InstanceLocation, Message: output.Error.String()})
+	}
+
+	return fields
+}
@@ -0,0 +1,255 @@
+// Package slo tracks success-rate objectives per job type. A job that
+// completes counts towards its type's objective and one that reaches the
+// dead-letter queue counts against it; failed attempts that are retried into
+// success don't count, as the caller still got a result. Over a rolling
+// window, the share of failures an objective allows is its error budget, and
+// the burn rate is how fast that budget is being spent: 1 spends it exactly
+// over the window, 10 ten times as fast.
+package slo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// evaluateInterval is how often budgets are checked for being exhausted or
+// restored.
+const evaluateInterval = 10 * time.Second
+
+// BurnWindows are the windows burn rates are reported over, besides the
+// objective window itself. Ones as long as the objective window are left
+// out.
+var BurnWindows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour}
+
+// BurnRate is how fast the error budget was spent over Window.
+type BurnRate struct {
+	Window time.Duration
+	Rate   float64
+}
+
+// Status is where one job type stands against its objective over the window.
+type Status struct {
+	JobType string
+	// Share of jobs that should succeed, e.g. 0.99
+	Target float64
+	Window time.Duration
+	// Jobs completed and dead-lettered within the window
+	Good int
+	Bad  int
+	// 1 when no job finished within the window
+	SuccessRate float64
+	// Share of the error budget left: 1 untouched, 0 or less once spent
+	BudgetRemaining float64
+	BurnRates       []BurnRate
+	Exhausted       bool
+}
+
+// Tracker counts job outcomes for the job types with an objective and
+// publishes an event on the bus when a type's error budget runs out, and
+// again when failures age out of the window and it recovers.
+type Tracker struct {
+	objectives map[string]float64
+	jobStore   store.JobStore
+	bus        *events.Bus
+	logger     *slog.Logger
+
+	mu sync.Mutex
+	// Outcomes per minute by job type: completed jobs are good, failed ones
+	// dead-lettered
+	series    map[string]*domain.ThroughputSeries
+	exhausted map[string]bool
+}
+
+// NewTracker tracks objectives, success-rate targets between 0 and 1 by job
+// type, over window. jobStore gives the type of jobs whose events don't carry
+// it.
+func NewTracker(objectives map[string]float64, window time.Duration, jobStore store.JobStore, bus *events.Bus, logger *slog.Logger) *Tracker {
+	series := make(map[string]*domain.ThroughputSeries, len(objectives))
+	for jobType := range objectives {
+		series[jobType] = domain.NewThroughputSeries(int(window / time.Minute))
+	}
+
+	return &Tracker{
+		objectives: objectives,
+		jobStore:   jobStore,
+		bus:        bus,
+		logger:     logger,
+		series:     series,
+		exhausted:  make(map[string]bool),
+	}
+}
+
+func (t *Tracker) Handle(ctx context.Context, event events.Event) {
+	if event.Type != events.JobCompleted && event.Type != events.JobDead || len(t.objectives) == 0 {
+		return
+	}
+
+	jobType := event.JobType
+	if jobType == "" {
+		job, err := t.jobStore.GetJob(ctx, event.JobID)
+		if err != nil {
+			t.logger.Error("Failed to get job type for SLO", "event", "slo_error", "job_id", event.JobID, "error", err)
+			return
+		}
+		jobType = job.Type
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series, ok := t.series[jobType]
+	if !ok {
+		return
+	}
+	if event.Type == events.JobCompleted {
+		series.AddCompleted(event.At)
+	} else {
+		series.AddFailed(event.At)
+	}
+}
+
+// Run checks the error budgets until ctx is cancelled. The events are
+// published from here rather than from Handle, so they never nest inside the
+// publishing of the job event that caused them.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(evaluateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.logger.Info("SLO tracker shutting down", "event", "slo_tracker_stopped")
+			return
+		case <-ticker.C:
+			t.evaluate(ctx)
+		}
+	}
+}
+
+func (t *Tracker) evaluate(ctx context.Context) {
+	for _, status := range t.Status() {
+		t.mu.Lock()
+		changed := status.Exhausted != t.exhausted[status.JobType]
+		t.exhausted[status.JobType] = status.Exhausted
+		t.mu.Unlock()
+		if !changed {
+			continue
+		}
+
+		attrs := []any{"job_type", status.JobType, "target", status.Target, "success_rate", status.SuccessRate, "budget_remaining", status.BudgetRemaining, "window", WindowName(status.Window)}
+		event := events.Event{
+			Type:    events.SLOBudgetExhausted,
+			JobType: status.JobType,
+			Data: map[string]any{
+				"target":           status.Target,
+				"window":           WindowName(status.Window),
+				"success_rate":     status.SuccessRate,
+				"budget_remaining": status.BudgetRemaining,
+				"good":             status.Good,
+				"bad":              status.Bad,
+			},
+		}
+		if status.Exhausted {
+			t.logger.Warn("SLO error budget exhausted", append([]any{"event", "slo_budget_exhausted"}, attrs...)...)
+		} else {
+			event.Type = events.SLOBudgetRestored
+			t.logger.Info("SLO error budget restored", append([]any{"event", "slo_budget_restored"}, attrs...)...)
+		}
+		t.bus.Publish(ctx, event)
+	}
+}
+
+// Status reports every objective as of now, by job type.
+func (t *Tracker) Status() []Status {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]Status, 0, len(t.objectives))
+	for jobType, target := range t.objectives {
+		series := t.series[jobType]
+		// Newest first, so each burn window sums a prefix
+		points := series.Points(now, series.Minutes())
+		slices.Reverse(points)
+
+		status := Status{
+			JobType: jobType,
+			Target:  target,
+			Window:  time.Duration(series.Minutes()) * time.Minute,
+		}
+
+		windows := make([]time.Duration, 0, len(BurnWindows)+1)
+		for _, window := range BurnWindows {
+			if window < status.Window {
+				windows = append(windows, window)
+			}
+		}
+		windows = append(windows, status.Window)
+
+		minute := 0
+		for _, window := range windows {
+			for ; minute < int(window/time.Minute); minute++ {
+				status.Good += points[minute].Completed
+				status.Bad += points[minute].Failed
+			}
+			status.BurnRates = append(status.BurnRates, BurnRate{
+				Window: window,
+				Rate:   burnRate(status.Good, status.Bad, target),
+			})
+		}
+
+		status.SuccessRate = 1
+		status.BudgetRemaining = 1
+		if total := status.Good + status.Bad; total > 0 {
+			status.SuccessRate = float64(status.Good) / float64(total)
+			status.BudgetRemaining = round(1 - float64(status.Bad)/((1-target)*float64(total)))
+		}
+		status.Exhausted = status.Bad > 0 && status.BudgetRemaining <= 0
+
+		statuses = append(statuses, status)
+	}
+
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.JobType, b.JobType)
+	})
+	return statuses
+}
+
+// burnRate is the error rate of good and bad outcomes over the error rate
+// target allows.
+func burnRate(good, bad int, target float64) float64 {
+	if good+bad == 0 {
+		return 0
+	}
+	return round(float64(bad) / float64(good+bad) / (1 - target))
+}
+
+// round drops the float error of 1 - target, so spending exactly the budget
+// reads as 0 left rather than 1e-15.
+func round(ratio float64) float64 {
+	return math.Round(ratio*1e6) / 1e6
+}
+
+// WindowName formats a window in whole hours or minutes where it can, as
+// "6h" rather than "6h0m0s".
+func WindowName(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
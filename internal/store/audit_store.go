@@ -0,0 +1,162 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// AuditFilter narrows the audit entries returned. Zero fields match
+// everything.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	// Most entries returned, newest first
+	Limit int
+}
+
+func (f AuditFilter) matches(entry domain.AuditEntry) bool {
+	return (f.Actor == "" || entry.Actor == f.Actor) &&
+		(f.Action == "" || entry.Action == f.Action) &&
+		(f.Target == "" || entry.Target == f.Target) &&
+		(f.Since.IsZero() || !entry.At.Before(f.Since)) &&
+		(f.Until.IsZero() || entry.At.Before(f.Until))
+}
+
+type AuditStore interface {
+	AppendAudit(ctx context.Context, entry *domain.AuditEntry) error
+	// GetAudit returns the entries matching filter, newest first.
+	GetAudit(ctx context.Context, filter AuditFilter) ([]domain.AuditEntry, error)
+}
+
+// InMemoryAuditStore keeps the latest maxEntries audit entries in memory and,
+// when given a path, appends every entry to that file as a line of JSON,
+// synced before AppendAudit returns. The file is never truncated; rotating
+// and archiving it is left to the operator.
+type InMemoryAuditStore struct {
+	file       *os.File
+	maxEntries int
+	// Oldest first
+	entries []domain.AuditEntry
+	mu      sync.RWMutex
+}
+
+// NewInMemoryAuditStore loads the latest maxEntries entries from the file at
+// path, creating it if needed. An empty path keeps entries in memory only.
+func NewInMemoryAuditStore(path string, maxEntries int) (*InMemoryAuditStore, error) {
+	s := &InMemoryAuditStore{
+		maxEntries: maxEntries,
+		entries:    make([]domain.AuditEntry, 0),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry domain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		// A missing body is saved as null, which decodes as the raw "null"
+		if string(entry.Request) == "null" {
+			entry.Request = nil
+		}
+		s.add(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s.file = file
+	return s, nil
+}
+
+func (s *InMemoryAuditStore) AppendAudit(ctx context.Context, entry *domain.AuditEntry) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	s.add(*entry)
+	return nil
+}
+
+func (s *InMemoryAuditStore) GetAudit(ctx context.Context, filter AuditFilter) ([]domain.AuditEntry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]domain.AuditEntry, 0)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+		if filter.matches(s.entries[i]) {
+			entries = append(entries, s.entries[i])
+		}
+	}
+
+	return entries, nil
+}
+
+// Close closes the audit file, if any.
+func (s *InMemoryAuditStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// add keeps entry, dropping the oldest beyond maxEntries. The caller holds
+// s.mu or has s to itself.
+func (s *InMemoryAuditStore) add(entry domain.AuditEntry) {
+	s.entries = append(s.entries, entry)
+	if over := len(s.entries) - s.maxEntries; over > 0 {
+		s.entries = append(s.entries[:0], s.entries[over:]...)
+	}
+}
@@ -0,0 +1,260 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+)
+
+// EventFilter narrows the events returned. Zero fields match everything.
+type EventFilter struct {
+	// Only events with a higher ID
+	After int64
+	Types map[events.Type]bool
+	// Only events about jobs of this type
+	JobType string
+	// Most events returned, oldest first
+	Limit int
+}
+
+// Matches reports whether event passes the filter, regardless of Limit.
+func (f EventFilter) Matches(event events.Event) bool {
+	return event.ID > f.After &&
+		(len(f.Types) == 0 || f.Types[event.Type]) &&
+		(f.JobType == "" || event.JobType == f.JobType)
+}
+
+type EventLog interface {
+	AppendEvent(ctx context.Context, event events.Event) error
+	// GetEvents returns the events matching filter, oldest first, and
+	// whether more matching events follow them.
+	GetEvents(ctx context.Context, filter EventFilter) ([]events.Event, bool, error)
+	// LastEventID is the ID of the newest event recorded, or zero.
+	LastEventID() int64
+}
+
+// InMemoryEventLog keeps the latest events published on the bus, at most
+// maxEntries and none older than retention, so clients that lost their
+// stream can catch up. When given a path it also appends every event to that
+// file as a line of JSON and loads it back at startup. Events are written
+// without syncing, so a crash may lose the last few; the file is rewritten
+// with only the retained events once it holds twice maxEntries.
+type InMemoryEventLog struct {
+	path       string
+	file       *os.File
+	fileLines  int
+	maxEntries int
+	retention  time.Duration
+	logger     *slog.Logger
+	// Oldest first, by ID
+	entries []events.Event
+	// Highest ID seen, even if its event was since dropped
+	lastID int64
+	mu     sync.RWMutex
+}
+
+// NewInMemoryEventLog loads the retained events from the file at path,
+// creating it if needed and dropping a partial last line left by a crash. An empty path keeps events in memory only; a zero
+// retention keeps them regardless of age.
+func NewInMemoryEventLog(path string, maxEntries int, retention time.Duration, logger *slog.Logger) (*InMemoryEventLog, error) {
+	s := &InMemoryEventLog{
+		path:       path,
+		maxEntries: maxEntries,
+		retention:  retention,
+		logger:     logger,
+		entries:    make([]events.Event, 0),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Only the last line can be torn by a crash; a bad line before it fails
+	// the load
+	valid := bytes.LastIndexByte(data, '\n') + 1
+	for start, line := 0, 1; start < valid; line++ {
+		end := start + bytes.IndexByte(data[start:valid], '\n')
+		var event events.Event
+		if err := json.Unmarshal(data[start:end], &event); err != nil {
+			if end+1 < valid {
+				return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+			}
+			valid = start
+			break
+		}
+		s.add(event, time.Now())
+		s.fileLines++
+		start = end + 1
+	}
+	if valid < len(data) {
+		logger.Warn("Dropped a torn last line from the event log", "event", "event_log_truncated", "path", path, "bytes", len(data)-valid)
+		if err := os.Truncate(path, int64(valid)); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	s.file = file
+	return s, nil
+}
+
+// Handle records every event published on the bus.
+func (s *InMemoryEventLog) Handle(ctx context.Context, event events.Event) {
+	if err := s.AppendEvent(context.WithoutCancel(ctx), event); err != nil {
+		s.logger.Error("Failed to record event in event log", "event", "event_log_error", "event_type", event.Type, "event_id", event.ID, "error", err)
+	}
+}
+
+func (s *InMemoryEventLog) AppendEvent(ctx context.Context, event events.Event) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		s.fileLines++
+	}
+
+	s.add(event, time.Now())
+
+	if s.file != nil && s.fileLines >= 2*s.maxEntries {
+		if err := s.compact(); err != nil {
+			return fmt.Errorf("compact event log: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryEventLog) GetEvents(ctx context.Context, filter EventFilter) ([]events.Event, bool, error) {
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]events.Event, 0)
+	for _, event := range s.entries {
+		if !filter.Matches(event) {
+			continue
+		}
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			return result, true, nil
+		}
+		result = append(result, event)
+	}
+
+	return result, false, nil
+}
+
+func (s *InMemoryEventLog) LastEventID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastID
+}
+
+// Close closes the event log file, if any.
+func (s *InMemoryEventLog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// add appends event and drops events beyond maxEntries or older than
+// retention. The bus delivers events to the log in ID order. The caller holds
+// s.mu or has s to itself.
+func (s *InMemoryEventLog) add(event events.Event, now time.Time) {
+	s.lastID = max(s.lastID, event.ID)
+	s.entries = append(s.entries, event)
+
+	drop := max(len(s.entries)-s.maxEntries, 0)
+	if s.retention > 0 {
+		cutoff := now.Add(-s.retention)
+		for drop < len(s.entries) && s.entries[drop].At.Before(cutoff) {
+			drop++
+		}
+	}
+	if drop > 0 {
+		s.entries = append(s.entries[:0], s.entries[drop:]...)
+	}
+}
+
+// compact rewrites the file with only the retained events, replacing it
+// atomically. The caller holds s.mu.
+func (s *InMemoryEventLog) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, event := range s.entries {
+		data, err := json.Marshal(event)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	s.fileLines = len(s.entries)
+	return nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+)
+
+func TestEventLogReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage changes the file the way a crash or a bad disk might
+		damage  func(data []byte) []byte
+		wantErr bool
+		wantIDs []int64
+	}{
+		{
+			name:    "clean shutdown",
+			damage:  func(data []byte) []byte { return data },
+			wantIDs: []int64{1, 2, 3},
+		},
+		{
+			name:    "partial last line",
+			damage:  func(data []byte) []byte { return append(data, `{"id":4,"ty`...) },
+			wantIDs: []int64{1, 2, 3},
+		},
+		{
+			name:    "garbled last line",
+			damage:  func(data []byte) []byte { return append(data, "\x00\x00\n"...) },
+			wantIDs: []int64{1, 2, 3},
+		},
+		{
+			name:    "garbled line before the last",
+			damage:  func(data []byte) []byte { return append([]byte("\x00\x00\n"), data...) },
+			wantErr: true,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+
+			eventLog, err := NewInMemoryEventLog(path, 100, 0, logger)
+			if err != nil {
+				t.Fatalf("NewInMemoryEventLog: %v", err)
+			}
+			for id := range int64(3) {
+				if err := eventLog.AppendEvent(ctx, events.Event{ID: id + 1, Type: events.JobCreated}); err != nil {
+					t.Fatalf("AppendEvent: %v", err)
+				}
+			}
+			eventLog.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			reloaded, err := NewInMemoryEventLog(path, 100, 0, logger)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("reload succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			defer reloaded.Close()
+
+			got, _, err := reloaded.GetEvents(ctx, EventFilter{})
+			if err != nil {
+				t.Fatalf("GetEvents: %v", err)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("reloaded %d events, want %v", len(got), tt.wantIDs)
+			}
+			for i, event := range got {
+				if event.ID != tt.wantIDs[i] {
+					t.Fatalf("event %d has ID %d, want %d", i, event.ID, tt.wantIDs[i])
+				}
+			}
+			if last := reloaded.LastEventID(); last != 3 {
+				t.Fatalf("LastEventID = %d, want 3", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package store
+
+import "math"
+
+// FairShare divides claims between job types in proportion to their weights
+// (stride scheduling), so one type flooding the queue cannot starve the
+// others. Each type has a pass value that advances by 1/weight every time one
+// of its jobs is claimed; the next claim goes to the type with the lowest
+// pass among those with claimable work. Types without a weight get 1.
+//
+// FairShare is not safe for concurrent use; the job store calls it with its
+// lock held.
+type FairShare struct {
+	weights map[string]int
+	pass    map[string]float64
+	// virtualTime is the pass of the most recent claim. A type that was idle
+	// starts from here rather than from its old pass, so it cannot bank
+	// credit while it has no work and then monopolise the workers.
+	virtualTime float64
+}
+
+func NewFairShare(weights map[string]int) *FairShare {
+	return &FairShare{
+		weights: weights,
+		pass:    make(map[string]float64),
+	}
+}
+
+// next returns the type with the lowest pass among types, or "" if types is
+// empty.
+func (f *FairShare) next(types map[string]bool) string {
+	next := ""
+	nextPass := math.Inf(1)
+	for jobType := range types {
+		pass := math.Max(f.pass[jobType], f.virtualTime)
+		if pass < nextPass || (pass == nextPass && jobType < next) {
+			next = jobType
+			nextPass = pass
+		}
+	}
+	return next
+}
+
+// charge records a claim of a jobType job.
+func (f *FairShare) charge(jobType string) {
+	weight := f.weights[jobType]
+	if weight <= 0 {
+		weight = 1
+	}
+
+	pass := math.Max(f.pass[jobType], f.virtualTime)
+	f.virtualTime = pass
+	f.pass[jobType] = pass + 1/float64(weight)
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// dependencyStatusLocked returns the status a new job with dependencies
+// starts in: pending if every dependency has completed, blocked otherwise.
+func (s *InMemoryJobStore) dependencyStatusLocked(job *domain.Job) (domain.JobStatus, error) {
+	status := domain.StatusPending
+
+	for _, dependencyID := range job.DependsOn {
+		dependency, ok := s.jobs[dependencyID]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrDependencyMissing, dependencyID)
+		}
+
+		switch dependency.Status {
+		case domain.StatusCompleted:
+		case domain.StatusDead, domain.StatusCancelled, domain.StatusExpired:
+			if job.DependencyPolicy != domain.DependencyHold {
+				return "", fmt.Errorf("%w: %s", ErrDependencyFailed, dependencyID)
+			}
+			status = domain.StatusBlocked
+		default:
+			status = domain.StatusBlocked
+		}
+	}
+
+	return status, nil
+}
+
+// ResolveDependents updates the blocked jobs that depend on jobID after it
+// reached a final state. When it completed, dependents whose dependencies
+// have all completed become pending. When it died, expired or was cancelled,
+// dependents with the fail policy move to dead, which cascades to their own
+// dependents. A batch parent completes once its last child is resolved. It
+// returns the IDs of jobs made pending and moved to dead.
+func (s *InMemoryJobStore) ResolveDependents(ctx context.Context, jobID string) ([]string, []string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, nil, err
+	}
+
+	var unblocked, failed []string
+
+	resolved := []string{jobID}
+	for len(resolved) > 0 {
+		dependencyID := resolved[0]
+		resolved = resolved[1:]
+
+		dependency, ok := s.jobs[dependencyID]
+		if !ok {
+			continue
+		}
+
+		if parentID, done := s.resolveChildLocked(&dependency); done {
+			resolved = append(resolved, parentID)
+		}
+
+		for id, job := range s.jobs {
+			if job.Status != domain.StatusBlocked || !dependsOn(&job, dependencyID) {
+				continue
+			}
+
+			switch dependency.Status {
+			case domain.StatusCompleted:
+				if !s.dependenciesCompletedLocked(&job) {
+					continue
+				}
+				job.Status = domain.StatusPending
+				unblocked = append(unblocked, id)
+			case domain.StatusDead, domain.StatusCancelled, domain.StatusExpired:
+				if job.DependencyPolicy == domain.DependencyHold {
+					continue
+				}
+				lastError := fmt.Sprintf("Dependency %s is %s", dependencyID, dependency.Status)
+				job.Status = domain.StatusDead
+				job.LastError = &lastError
+				job.ErrorClass = domain.ErrorClassDependency
+				failed = append(failed, id)
+				resolved = append(resolved, id)
+			default:
+				continue
+			}
+
+			touch(&job)
+			s.putLocked(job)
+		}
+	}
+
+	return unblocked, failed, s.journalErrLocked()
+}
+
+// resolveChildLocked counts a finished child towards its parent's batch
+// progress and completes the parent once every child is done, reporting the
+// parent's ID if it did.
+func (s *InMemoryJobStore) resolveChildLocked(child *domain.Job) (string, bool) {
+	parent, ok := s.jobs[child.ParentID]
+	if !ok || parent.Status != domain.StatusBlocked || parent.Batch == nil {
+		return "", false
+	}
+
+	// Batch is shared with copies handed out by GetJob, so replace it
+	// rather than updating it in place
+	progress := *parent.Batch
+	switch child.Status {
+	case domain.StatusCompleted:
+		progress.Completed++
+	case domain.StatusDead, domain.StatusCancelled, domain.StatusExpired:
+		progress.Failed++
+	default:
+		return "", false
+	}
+	parent.Batch = &progress
+
+	if progress.Done() {
+		parent.Status = domain.StatusCompleted
+		parent.Result, _ = json.Marshal(map[string]int{
+			"total":     progress.Total,
+			"completed": progress.Completed,
+			"failed":    progress.Failed,
+		})
+	}
+	touch(&parent)
+	s.putLocked(parent)
+
+	return parent.ID, progress.Done()
+}
+
+// CreateBatch stores parent as blocked with one child per entry in children,
+// all under one lock so the batch appears at once.
+func (s *InMemoryJobStore) CreateBatch(ctx context.Context, parent *domain.Job, children []*domain.Job) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	parent.Status = domain.StatusBlocked
+	parent.Batch = &domain.BatchProgress{Total: len(children)}
+	s.putLocked(*parent)
+
+	for _, child := range children {
+		child.ParentID = parent.ID
+		s.putLocked(*child)
+	}
+
+	// A batch that didn't reach the file is not accepted
+	if err := s.journalErrLocked(); err != nil {
+		s.deleteLocked(parent.ID)
+		for _, child := range children {
+			s.deleteLocked(child.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *InMemoryJobStore) dependenciesCompletedLocked(job *domain.Job) bool {
+	for _, dependencyID := range job.DependsOn {
+		if dependency, ok := s.jobs[dependencyID]; !ok || dependency.Status != domain.StatusCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+func dependsOn(job *domain.Job, dependencyID string) bool {
+	for _, id := range job.DependsOn {
+		if id == dependencyID {
+			return true
+		}
+	}
+	return false
+}
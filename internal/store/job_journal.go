@@ -0,0 +1,289 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+)
+
+// minJournalCompaction is the fewest lines a job store file holds before it
+// is compacted, so a small store isn't rewritten on every other change.
+const minJournalCompaction = 1000
+
+// journalRecord is one line of a job store file: a job or outbox message as
+// it is now, or the ID of one that was deleted.
+type journalRecord struct {
+	Job           *domain.Job           `json:"job,omitempty"`
+	DeletedJob    string                `json:"deleted_job,omitempty"`
+	Outbox        *domain.OutboxMessage `json:"outbox,omitempty"`
+	DeletedOutbox string                `json:"deleted_outbox,omitempty"`
+}
+
+// JournalConfig selects the file an InMemoryJobStore persists to and how
+// durably, with the same fsync policies as the disk queue. An empty Path
+// keeps the store in memory only.
+type JournalConfig struct {
+	Path string
+	// queue.SyncAlways, queue.SyncInterval or queue.SyncNever
+	Sync         string
+	SyncInterval time.Duration
+}
+
+// jobJournal appends every change to an InMemoryJobStore to a file, so its
+// jobs and outbox survive a restart.
+type jobJournal struct {
+	config JournalConfig
+	file   *os.File
+	lines  int
+	dirty  bool
+	done   chan struct{}
+	// The first write that failed; the store refuses changes after it, as
+	// the file no longer matches
+	err error
+}
+
+// loadJournal applies the records in the configured file to s, creating the
+// file and its directory if needed, and opens it for appending. A partial
+// final line left by a crash mid-append is truncated, like a torn record in
+// the disk queue; a bad line anywhere else fails the load.
+func (s *InMemoryJobStore) loadJournal(config JournalConfig) error {
+	switch config.Sync {
+	case queue.SyncAlways, queue.SyncInterval, queue.SyncNever:
+	default:
+		return fmt.Errorf("unknown job store sync policy %q", config.Sync)
+	}
+
+	path := config.Path
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	valid := bytes.LastIndexByte(data, '\n') + 1
+	lines := 0
+	for start := 0; start < valid; lines++ {
+		end := start + bytes.IndexByte(data[start:valid], '\n')
+		var record journalRecord
+		if err := json.Unmarshal(data[start:end], &record); err != nil {
+			if end+1 < valid {
+				return fmt.Errorf("%s line %d: %w", path, lines+1, err)
+			}
+			// A crash can garble the last line even when its newline reached
+			// the disk
+			valid = start
+			break
+		}
+		start = end + 1
+
+		switch {
+		case record.Job != nil:
+			s.jobs[record.Job.ID] = *record.Job
+		case record.DeletedJob != "":
+			delete(s.jobs, record.DeletedJob)
+		case record.Outbox != nil:
+			s.outbox[record.Outbox.ID] = *record.Outbox
+		case record.DeletedOutbox != "":
+			delete(s.outbox, record.DeletedOutbox)
+		}
+	}
+
+	if valid < len(data) {
+		if err := os.Truncate(path, int64(valid)); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	s.journal = &jobJournal{config: config, file: file, lines: lines, done: make(chan struct{})}
+	if config.Sync == queue.SyncInterval {
+		go s.syncJournalLoop(s.journal)
+	}
+	return nil
+}
+
+// putLocked stores job and records it in the journal. The caller holds s.mu.
+func (s *InMemoryJobStore) putLocked(job domain.Job) {
+	s.jobs[job.ID] = job
+	s.journalLocked(journalRecord{Job: &job})
+}
+
+// deleteLocked removes a job and records it in the journal. The caller holds
+// s.mu.
+func (s *InMemoryJobStore) deleteLocked(jobID string) {
+	delete(s.jobs, jobID)
+	s.journalLocked(journalRecord{DeletedJob: jobID})
+}
+
+// putOutboxLocked stores message and records it in the journal. The caller
+// holds s.mu.
+func (s *InMemoryJobStore) putOutboxLocked(message domain.OutboxMessage) {
+	s.outbox[message.ID] = message
+	s.journalLocked(journalRecord{Outbox: &message})
+}
+
+// deleteOutboxLocked removes an outbox message and records it in the
+// journal. The caller holds s.mu.
+func (s *InMemoryJobStore) deleteOutboxLocked(id string) {
+	delete(s.outbox, id)
+	s.journalLocked(journalRecord{DeletedOutbox: id})
+}
+
+// journalErrLocked returns the error that stopped the journal, if any. The
+// caller holds s.mu.
+func (s *InMemoryJobStore) journalErrLocked() error {
+	if s.journal == nil || s.journal.err == nil {
+		return nil
+	}
+	return fmt.Errorf("job store file: %w", s.journal.err)
+}
+
+// journalLocked appends record to the file, applies the sync policy, and
+// compacts the file once it holds twice as many lines as the store has
+// entries. A failed write stops the journal; the change that caused it and
+// every later one return the error, and Ping reports it. The caller holds
+// s.mu.
+func (s *InMemoryJobStore) journalLocked(record journalRecord) {
+	j := s.journal
+	if j == nil || j.err != nil {
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		j.err = err
+		return
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		j.err = err
+		return
+	}
+	j.lines++
+
+	switch j.config.Sync {
+	case queue.SyncAlways:
+		if err := j.file.Sync(); err != nil {
+			j.err = err
+			return
+		}
+	case queue.SyncInterval:
+		j.dirty = true
+	}
+
+	if j.lines >= minJournalCompaction && j.lines >= 2*(len(s.jobs)+len(s.outbox)) {
+		if err := s.compactJournalLocked(); err != nil {
+			j.err = fmt.Errorf("compact: %w", err)
+		}
+	}
+}
+
+// compactJournalLocked rewrites the file with one line per job and outbox
+// message, replacing it atomically. The caller holds s.mu.
+func (s *InMemoryJobStore) compactJournalLocked() error {
+	j := s.journal
+	tmpPath := j.config.Path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, job := range s.jobs {
+		if err := encoder.Encode(journalRecord{Job: &job}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	for _, message := range s.outbox {
+		if err := encoder.Encode(journalRecord{Outbox: &message}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, j.config.Path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.config.Path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = file
+	j.lines = len(s.jobs) + len(s.outbox)
+	// Everything in the new file was synced before the rename
+	j.dirty = false
+	return nil
+}
+
+// syncJournalLoop fsyncs the file every SyncInterval while it has unsynced
+// writes, until the store is closed.
+func (s *InMemoryJobStore) syncJournalLoop(j *jobJournal) {
+	ticker := time.NewTicker(j.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if j.dirty && j.err == nil {
+				if err := j.file.Sync(); err != nil {
+					j.err = err
+				} else {
+					j.dirty = false
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close syncs and closes the job store file, if any.
+func (s *InMemoryJobStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j := s.journal
+	if j == nil || j.file == nil {
+		return nil
+	}
+	close(j.done)
+	err := j.file.Sync()
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	j.file = nil
+	if j.err == nil {
+		j.err = os.ErrClosed
+	}
+	return err
+}
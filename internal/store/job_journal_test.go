@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+)
+
+func openJournalStore(t *testing.T, path string) (*InMemoryJobStore, error) {
+	t.Helper()
+
+	return NewInMemoryJobStore(JournalConfig{Path: path, Sync: queue.SyncAlways}, 0, time.Minute, nil, 0)
+}
+
+func TestJournalReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage changes the file the way a crash or a bad disk might
+		damage   func(data []byte) []byte
+		wantErr  bool
+		wantJobs []string
+	}{
+		{
+			name:     "clean shutdown",
+			damage:   func(data []byte) []byte { return data },
+			wantJobs: []string{"a", "c"},
+		},
+		{
+			name:     "partial last line",
+			damage:   func(data []byte) []byte { return append(data, `{"job":{"ID":"d","Sta`...) },
+			wantJobs: []string{"a", "c"},
+		},
+		{
+			name:     "garbled last line",
+			damage:   func(data []byte) []byte { return append(data, "\x00\x00\x00\n"...) },
+			wantJobs: []string{"a", "c"},
+		},
+		{
+			name:    "garbled line before the last",
+			damage:  func(data []byte) []byte { return append([]byte("\x00\x00\x00\n"), data...) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "jobs.jsonl")
+
+			s, err := openJournalStore(t, path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			for _, name := range []string{"a", "b", "c"} {
+				if err := s.CreateJob(ctx, pendingJob(name, domain.PriorityNormal, 0)); err != nil {
+					t.Fatalf("CreateJob: %v", err)
+				}
+			}
+			if err := s.DeleteJob(ctx, "b"); err != nil {
+				t.Fatalf("DeleteJob: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			reloaded, err := openJournalStore(t, path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("reload succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			defer reloaded.Close()
+
+			jobs, err := reloaded.GetJobs(ctx)
+			if err != nil {
+				t.Fatalf("GetJobs: %v", err)
+			}
+			if len(jobs) != len(tt.wantJobs) {
+				t.Fatalf("reloaded %d jobs, want %v", len(jobs), tt.wantJobs)
+			}
+			for _, id := range tt.wantJobs {
+				if _, err := reloaded.GetJob(ctx, id); err != nil {
+					t.Errorf("GetJob(%s): %v", id, err)
+				}
+			}
+
+			// The damaged tail is gone, so the next record starts a line
+			if err := reloaded.CreateJob(ctx, pendingJob("e", domain.PriorityNormal, 0)); err != nil {
+				t.Fatalf("CreateJob after reload: %v", err)
+			}
+			reloaded.Close()
+			again, err := openJournalStore(t, path)
+			if err != nil {
+				t.Fatalf("second reload: %v", err)
+			}
+			defer again.Close()
+			if _, err := again.GetJob(ctx, "e"); err != nil {
+				t.Fatalf("job written after the reload: %v", err)
+			}
+		})
+	}
+}
+
+func TestJournalRefusesChangesOnceStopped(t *testing.T) {
+	ctx := context.Background()
+	s, err := openJournalStore(t, filepath.Join(t.TempDir(), "jobs.jsonl"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := s.CreateJob(ctx, pendingJob("a", domain.PriorityNormal, 0)); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	s.Close()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "CreateJob", call: func() error { return s.CreateJob(ctx, pendingJob("b", domain.PriorityNormal, 0)) }},
+		{name: "DeleteJob", call: func() error { return s.DeleteJob(ctx, "a") }},
+		{name: "CancelJob", call: func() error { return s.CancelJob(ctx, "a") }},
+		{
+			name: "ClaimNextJob",
+			call: func() error {
+				_, err := s.ClaimNextJob(ctx, "worker", nil)
+				return err
+			},
+		},
+		{name: "Ping", call: func() error { return s.Ping(ctx) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, os.ErrClosed) {
+				t.Fatalf("err = %v, want os.ErrClosed", err)
+			}
+		})
+	}
+
+	if _, err := s.GetJob(ctx, "b"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("refused job was stored: %v", err)
+	}
+	if job, err := s.GetJob(ctx, "a"); err != nil || job.Status != domain.StatusPending {
+		t.Fatalf("job a = %v, %v, want it pending and untouched", job, err)
+	}
+}
+
+func TestJournalSyncPolicy(t *testing.T) {
+	tests := []struct {
+		sync    string
+		wantErr bool
+	}{
+		{sync: queue.SyncAlways},
+		{sync: queue.SyncInterval},
+		{sync: queue.SyncNever},
+		{sync: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sync, func(t *testing.T) {
+			s, err := NewInMemoryJobStore(JournalConfig{
+				Path:         filepath.Join(t.TempDir(), "jobs.jsonl"),
+				Sync:         tt.sync,
+				SyncInterval: time.Millisecond,
+			}, 0, time.Minute, nil, 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("opened with an unknown sync policy")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+
+			if err := s.CreateJob(context.Background(), pendingJob("a", domain.PriorityNormal, 0)); err != nil {
+				t.Fatalf("CreateJob: %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+		})
+	}
+}
@@ -2,35 +2,174 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"log/slog"
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/karprabha/job-queue-backend/internal/domain"
 )
 
+var (
+	ErrJobNotFound       = errors.New("job not found in store")
+	ErrInvalidTransition = errors.New("invalid state transition")
+	ErrJobNotProcessing  = errors.New("job is not processing")
+	ErrDuplicateJob      = errors.New("job with the same unique key is already active")
+	ErrDependencyMissing = errors.New("dependency job not found")
+	ErrDependencyFailed  = errors.New("dependency job has already failed")
+	ErrLeaseLost         = errors.New("job lease is no longer held")
+)
+
+// DuplicateJobError is returned by CreateJob when a pending or processing job
+// already holds the new job's unique key. It matches ErrDuplicateJob.
+type DuplicateJobError struct {
+	ExistingJobID string
+}
+
+func (e *DuplicateJobError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrDuplicateJob, e.ExistingJobID)
+}
+
+func (e *DuplicateJobError) Is(target error) bool {
+	return target == ErrDuplicateJob
+}
+
+// JobStore holds every job and is the source of truth for its state; queues
+// only carry wake-ups. Workers, remote workers and the sweeper change jobs
+// concurrently, so each method must be atomic. A store shared between server
+// instances must do each claim and status change as a single conditional
+// update (a row lock, or a compare-and-swap on status and version), never as
+// a read followed by a write.
 type JobStore interface {
+	// CreateJob stores a new job. A job with dependencies that have not all
+	// completed is stored (and left) as blocked.
 	CreateJob(ctx context.Context, job *domain.Job) error
+	// CreateBatch stores a blocked batch parent together with its children.
+	CreateBatch(ctx context.Context, parent *domain.Job, children []*domain.Job) error
 	DeleteJob(ctx context.Context, jobID string) error
+	// DeletePendingJob deletes a job only if it is pending and has never
+	// been claimed, returning ErrInvalidTransition otherwise. It rolls back a
+	// submission without pulling a job out from under a worker.
+	DeletePendingJob(ctx context.Context, jobID string) error
+	GetJob(ctx context.Context, jobID string) (*domain.Job, error)
 	GetJobs(ctx context.Context) ([]domain.Job, error)
-	ClaimJob(ctx context.Context, jobID string) (*domain.Job, error)
+	// ClaimNextJob claims the best job that accept allows; a nil accept
+	// allows every job. Two callers must never claim the same job: a shared
+	// store claims with a compare-and-swap on the job's status and version.
+	ClaimNextJob(ctx context.Context, claimedBy string, accept func(job *domain.Job) bool) (*domain.Job, error)
+	// LeaseJobs claims up to limit due pending jobs of the given types for a
+	// remote worker, each with a lease of visibility, under the same contract
+	// as ClaimNextJob
+	LeaseJobs(ctx context.Context, types []string, limit int, claimedBy string, visibility time.Duration) ([]domain.Job, error)
 	UpdateStatus(ctx context.Context, jobID string, status domain.JobStatus, lastError *string) error
+	CancelJob(ctx context.Context, jobID string) error
+	// UpdateProgress records progress for the claim holding the execution
+	// token, returning ErrLeaseLost if that claim is no longer current.
+	UpdateProgress(ctx context.Context, jobID string, token string, percent int, message string) error
+	// CompleteJob and FailJob record the outcome of the claim holding the
+	// execution token, returning ErrLeaseLost if that claim is no longer
+	// current (the job was reaped and possibly claimed again). CompleteJob
+	// adds the attempt's outbox messages in the same update.
+	CompleteJob(ctx context.Context, jobID string, token string, result json.RawMessage, outbox []domain.OutboxMessage) error
+	FailJob(ctx context.Context, jobID string, token string, lastError string, errorClass string, permanent bool) (domain.JobStatus, error)
+	GetDeadJobs(ctx context.Context) ([]domain.Job, error)
+	RequeueDeadJob(ctx context.Context, jobID string) error
+	GetQuarantinedJobs(ctx context.Context) ([]domain.Job, error)
+	// ReleaseQuarantinedJob moves a quarantined job back to pending.
+	ReleaseQuarantinedJob(ctx context.Context, jobID string) error
+	ResolveDependents(ctx context.Context, jobID string) (unblocked []string, failed []string, err error)
 	GetFailedJobs(ctx context.Context) ([]domain.Job, error)
+	// RetryFailedJob moves a failed job back to pending now, without waiting
+	// for its retry delay. Any other status is ErrInvalidTransition.
+	RetryFailedJob(ctx context.Context, jobID string) error
 	GetPendingJobs(ctx context.Context) ([]domain.Job, error)
 	GetProcessingJobs(ctx context.Context) ([]domain.Job, error)
-	RetryFailedJobs(ctx context.Context, metricStore MetricStore, logger *slog.Logger) error
+	// CountJobs returns how many jobs are in each status
+	CountJobs(ctx context.Context) (map[domain.JobStatus]int, error)
+	// RetryFailedJobs moves failed jobs whose retry delay has passed back to
+	// pending, those due longest first, and returns their IDs. Jobs allow
+	// refuses stay failed for a later call; a nil allow allows every job
+	RetryFailedJobs(ctx context.Context, allow func(job *domain.Job) bool) ([]string, error)
+	// RequeueStuckJobs returns jobs processing for longer than olderThan to
+	// pending, returning them as they were when taken back
+	RequeueStuckJobs(ctx context.Context, olderThan time.Duration) ([]domain.Job, error)
+	// CancelProcessingJob records that a worker stopped the attempt of a
+	// processing job because it was cancelled.
+	CancelProcessingJob(ctx context.Context, jobID string, token string) error
+	// ReleaseJob hands a processing job back to pending without counting the
+	// attempt, for work interrupted by shutdown.
+	ReleaseJob(ctx context.Context, jobID string, token string) error
+	// RenewLease extends the lease of the claim holding the execution token,
+	// returning ErrLeaseLost if that claim is no longer current.
+	RenewLease(ctx context.Context, jobID string, token string) (time.Time, error)
+	// ExtendLease is RenewLease with an explicit extension.
+	ExtendLease(ctx context.Context, jobID string, token string, extension time.Duration) (time.Time, error)
+	// ReapExpiredLeases returns processing jobs whose lease lapsed to pending,
+	// or quarantines them. Reaped jobs are returned with the attempt that was
+	// taken back and the status they moved to.
+	ReapExpiredLeases(ctx context.Context) ([]domain.Job, error)
+	// ReapStuckJobs takes back jobs processing for longer than their type's
+	// threshold: to pending if they have retries left, otherwise to dead.
+	// Jobs that keep getting stuck are quarantined instead of requeued.
+	ReapStuckJobs(ctx context.Context, thresholds StuckThresholds) (requeued []domain.Job, dead []domain.Job, quarantined []domain.Job, err error)
+	// RecoverJob returns a job left processing by a previous run to pending,
+	// or quarantines it, and returns the status it moved to.
+	RecoverJob(ctx context.Context, jobID string) (domain.JobStatus, error)
+	// ExpireJobs moves pending and blocked jobs whose expiry has passed to
+	// expired, returning their IDs.
+	ExpireJobs(ctx context.Context) ([]string, error)
+	Ping(ctx context.Context) error
+	// Shared reports whether every process using the store sees the same
+	// jobs, as an upgrade needs to hand work to the new process.
+	Shared() bool
 }
 
 type InMemoryJobStore struct {
 	jobs map[string]domain.Job
-	mu   sync.RWMutex
+	// Side effects of completed jobs waiting for the outbox relay, guarded
+	// by mu with the jobs they belong to
+	outbox map[string]domain.OutboxMessage
+	mu     sync.RWMutex
+	// priorityAging raises a pending job's effective priority by one level
+	// for every interval it waits, so low-priority work is never starved
+	priorityAging time.Duration
+	// leaseDuration is how long a claim lasts without a heartbeat
+	leaseDuration time.Duration
+	// fairShare, when set, picks which job type is claimed next; priority
+	// then orders jobs within that type
+	fairShare *FairShare
+	// quarantineAfter is how many attempts in a row may crash before a job
+	// is quarantined; zero never quarantines
+	quarantineAfter int
+	// journal, when set, records every change to jobs and outbox
+	journal *jobJournal
 }
 
-func NewInMemoryJobStore() *InMemoryJobStore {
-	return &InMemoryJobStore{
-		jobs: make(map[string]domain.Job),
+// NewInMemoryJobStore returns a job store that keeps its jobs in memory and,
+// when journal.Path is set, also appends every change to that file and
+// loads it back at startup, so jobs survive a restart. How many of the last
+// changes a crash of the machine may lose depends on journal.Sync. The file
+// is compacted once it holds twice as many lines as jobs.
+func NewInMemoryJobStore(journal JournalConfig, priorityAging, leaseDuration time.Duration, fairShare *FairShare, quarantineAfter int) (*InMemoryJobStore, error) {
+	s := &InMemoryJobStore{
+		jobs:            make(map[string]domain.Job),
+		outbox:          make(map[string]domain.OutboxMessage),
+		priorityAging:   priorityAging,
+		leaseDuration:   leaseDuration,
+		fairShare:       fairShare,
+		quarantineAfter: quarantineAfter,
 	}
+	if journal.Path == "" {
+		return s, nil
+	}
+
+	if err := s.loadJournal(journal); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 func canTransition(from, to domain.JobStatus) bool {
@@ -41,15 +180,84 @@ func canTransition(from, to domain.JobStatus) bool {
 		return true
 	case from == domain.StatusProcessing && to == domain.StatusFailed:
 		return true
+	case from == domain.StatusProcessing && to == domain.StatusDead:
+		return true
+	case from == domain.StatusDead && to == domain.StatusPending:
+		return true
 	case from == domain.StatusFailed && to == domain.StatusPending:
 		return true
 	case from == domain.StatusProcessing && to == domain.StatusPending:
 		return true // Allow for recovery: processing -> pending
+	case from == domain.StatusPending && to == domain.StatusCancelled:
+		return true
+	case from == domain.StatusFailed && to == domain.StatusCancelled:
+		return true
+	case from == domain.StatusBlocked && to == domain.StatusPending:
+		return true
+	case from == domain.StatusBlocked && to == domain.StatusDead:
+		return true
+	case from == domain.StatusBlocked && to == domain.StatusCancelled:
+		return true
+	case from == domain.StatusPending && to == domain.StatusExpired:
+		return true
+	case from == domain.StatusBlocked && to == domain.StatusExpired:
+		return true
+	case from == domain.StatusQuarantined && to == domain.StatusCancelled:
+		return true
 	default:
 		return false
 	}
 }
 
+// markFailed moves job to failed and schedules the next attempt with
+// backoff, or moves it to dead once it has no retries left or the failure is
+// permanent.
+func markFailed(job *domain.Job, permanent bool) {
+	job.NextRetryAt = nil
+	job.Redelivered = false
+	if permanent || job.Attempts > job.MaxRetries {
+		job.Status = domain.StatusDead
+		return
+	}
+
+	job.Status = domain.StatusFailed
+	nextRetryAt := time.Now().UTC().Add(job.NextRetryDelay())
+	job.NextRetryAt = &nextRetryAt
+}
+
+// recordCrash counts an attempt that panicked, timed out or lost its worker.
+// A job that would run again (failed or pending) is quarantined instead once
+// quarantineAfter attempts in a row have crashed, since retrying it would
+// only take down another worker.
+func (s *InMemoryJobStore) recordCrash(job *domain.Job) {
+	job.Crashes++
+	if s.quarantineAfter <= 0 || job.Crashes < s.quarantineAfter {
+		return
+	}
+	if job.Status == domain.StatusFailed || job.Status == domain.StatusPending {
+		job.Status = domain.StatusQuarantined
+		job.NextRetryAt = nil
+	}
+}
+
+// holdsClaim reports whether token is the execution token of the job's
+// current claim: the job is processing and has not been reaped and claimed
+// again since. Attempt numbers can repeat (a released attempt is not
+// counted), tokens never do.
+func holdsClaim(job *domain.Job, token string) bool {
+	return job.Status == domain.StatusProcessing && token != "" && job.ExecutionToken == token
+}
+
+// touch records a mutation so clients can detect changes via Version/UpdatedAt.
+func touch(job *domain.Job) {
+	job.Version++
+	job.UpdatedAt = time.Now().UTC()
+	// Only a processing job holds a lease
+	if job.Status != domain.StatusProcessing {
+		job.LeaseExpiresAt = nil
+	}
+}
+
 func (s *InMemoryJobStore) CreateJob(ctx context.Context, job *domain.Job) error {
 	select {
 	case <-ctx.Done():
@@ -60,7 +268,36 @@ func (s *InMemoryJobStore) CreateJob(ctx context.Context, job *domain.Job) error
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.jobs[job.ID] = *job
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	// Checked under the same lock as the insert so concurrent submissions
+	// can't both win
+	if job.UniqueKey != "" {
+		for _, existing := range s.jobs {
+			active := existing.Status == domain.StatusPending || existing.Status == domain.StatusProcessing
+			if active && existing.UniqueKey == job.UniqueKey {
+				return &DuplicateJobError{ExistingJobID: existing.ID}
+			}
+		}
+	}
+
+	if len(job.DependsOn) > 0 {
+		status, err := s.dependencyStatusLocked(job)
+		if err != nil {
+			return err
+		}
+		job.Status = status
+	}
+
+	s.putLocked(*job)
+
+	// A job that didn't reach the file is not accepted
+	if err := s.journalErrLocked(); err != nil {
+		s.deleteLocked(job.ID)
+		return err
+	}
 
 	return nil
 }
@@ -75,14 +312,64 @@ func (s *InMemoryJobStore) DeleteJob(ctx context.Context, jobID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
 	_, ok := s.jobs[jobID]
 	if !ok {
-		return errors.New("job not found in store")
+		return ErrJobNotFound
 	}
 
-	delete(s.jobs, jobID)
+	s.deleteLocked(jobID)
 
-	return nil
+	return s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) DeletePendingJob(ctx context.Context, jobID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusPending || job.Deliveries > 0 {
+		return ErrInvalidTransition
+	}
+
+	s.deleteLocked(jobID)
+
+	return s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return &job, nil
 }
 
 func (s *InMemoryJobStore) GetJobs(ctx context.Context) ([]domain.Job, error) {
@@ -107,7 +394,10 @@ func (s *InMemoryJobStore) GetJobs(ctx context.Context) ([]domain.Job, error) {
 	return jobs, nil
 }
 
-func (s *InMemoryJobStore) ClaimJob(ctx context.Context, jobID string) (*domain.Job, error) {
+// ClaimNextJob claims the due pending job with the highest effective
+// priority, oldest first among equals, among those accept allows. It returns
+// nil if none is available.
+func (s *InMemoryJobStore) ClaimNextJob(ctx context.Context, claimedBy string, accept func(job *domain.Job) bool) (*domain.Job, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -117,18 +407,136 @@ func (s *InMemoryJobStore) ClaimJob(ctx context.Context, jobID string) (*domain.
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	job, ok := s.jobs[jobID]
-	if !ok || job.Status != domain.StatusPending {
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if accept == nil {
+		accept = func(*domain.Job) bool { return true }
+	}
+	best := s.nextJobLocked(now, s.processingByKeyLocked(), accept)
+	if best == nil {
 		return nil, nil
 	}
 
+	return s.claimLocked(*best, now, claimedBy, s.leaseDuration), s.journalErrLocked()
+}
+
+// nextJobLocked returns the claimable job with the highest effective priority
+// among those accept allows, or nil if there is none. With fair share
+// enabled, the job type is chosen first.
+func (s *InMemoryJobStore) nextJobLocked(now time.Time, processingByKey map[string]int, accept func(job *domain.Job) bool) *domain.Job {
+	if s.fairShare != nil {
+		types := s.claimableTypesLocked(now, processingByKey, accept)
+		if len(types) == 0 {
+			return nil
+		}
+		jobType := s.fairShare.next(types)
+		acceptAny := accept
+		accept = func(candidate *domain.Job) bool {
+			return candidate.Type == jobType && acceptAny(candidate)
+		}
+	}
+
+	var best *domain.Job
+	bestPriority := 0
+	for _, job := range s.jobs {
+		if job.Status != domain.StatusPending || !job.Due(now) || job.Expired(now) || !accept(&job) {
+			continue
+		}
+
+		// Skip jobs whose concurrency key is already at its limit
+		if job.ConcurrencyKey != "" && processingByKey[job.ConcurrencyKey] >= job.ConcurrencyLimit {
+			continue
+		}
+
+		priority := s.effectivePriority(&job, now)
+		if best == nil || priority > bestPriority || (priority == bestPriority && job.CreatedAt.Before(best.CreatedAt)) {
+			candidate := job
+			best = &candidate
+			bestPriority = priority
+		}
+	}
+
+	return best
+}
+
+// claimableTypesLocked returns the types that have a job nextJobLocked could
+// claim.
+func (s *InMemoryJobStore) claimableTypesLocked(now time.Time, processingByKey map[string]int, accept func(job *domain.Job) bool) map[string]bool {
+	types := make(map[string]bool)
+	for _, job := range s.jobs {
+		if types[job.Type] || job.Status != domain.StatusPending || !job.Due(now) || job.Expired(now) || !accept(&job) {
+			continue
+		}
+		if job.ConcurrencyKey != "" && processingByKey[job.ConcurrencyKey] >= job.ConcurrencyLimit {
+			continue
+		}
+		types[job.Type] = true
+	}
+	return types
+}
+
+// processingByKeyLocked counts processing jobs per concurrency key.
+func (s *InMemoryJobStore) processingByKeyLocked() map[string]int {
+	counts := make(map[string]int)
+	for _, job := range s.jobs {
+		if job.Status == domain.StatusProcessing && job.ConcurrencyKey != "" {
+			counts[job.ConcurrencyKey]++
+		}
+	}
+	return counts
+}
+
+// effectivePriority is the job's priority plus one level per aging interval
+// it has been waiting since it became pending.
+func (s *InMemoryJobStore) effectivePriority(job *domain.Job, now time.Time) int {
+	priority := int(job.Priority)
+	if s.priorityAging <= 0 {
+		return priority
+	}
+
+	// Scheduled jobs only start waiting once they are due
+	waitingSince := job.UpdatedAt
+	if job.RunAt != nil && job.RunAt.After(waitingSince) {
+		waitingSince = *job.RunAt
+	}
+
+	return priority + int(now.Sub(waitingSince)/s.priorityAging)
+}
+
+// claimLocked claims job, a copy of a pending job read from the store, and
+// returns nil if the stored job has changed since it was read. This is the
+// compare-and-swap on status and version every claim path goes through; a
+// shared store does it as one conditional update.
+func (s *InMemoryJobStore) claimLocked(job domain.Job, startedAt time.Time, claimedBy string, lease time.Duration) *domain.Job {
+	current, ok := s.jobs[job.ID]
+	if !ok || current.Status != domain.StatusPending || current.Version != job.Version {
+		return nil
+	}
+
 	job.Status = domain.StatusProcessing
 	job.Attempts++
-	s.jobs[jobID] = job
+	job.Deliveries++
+	job.ExecutionToken = uuid.NewString()
+	job.StartedAt = &startedAt
+	job.ClaimedBy = claimedBy
+	job.ClaimedAt = &startedAt
+	if s.fairShare != nil {
+		s.fairShare.charge(job.Type)
+	}
+	leaseExpiresAt := startedAt.Add(lease)
+	job.LeaseExpiresAt = &leaseExpiresAt
+	// Progress is per attempt
+	job.ProgressPercent = 0
+	job.ProgressMessage = ""
+	touch(&job)
+	s.putLocked(job)
 
 	jobCopy := job
 
-	return &jobCopy, nil
+	return &jobCopy
 }
 
 func (s *InMemoryJobStore) UpdateStatus(ctx context.Context, jobID string, status domain.JobStatus, lastError *string) error {
@@ -141,23 +549,507 @@ func (s *InMemoryJobStore) UpdateStatus(ctx context.Context, jobID string, statu
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
 	job, ok := s.jobs[jobID]
 	if !ok {
-		return errors.New("job not found in store")
+		return ErrJobNotFound
 	}
 
 	// Validate transition
 	if !canTransition(job.Status, status) {
-		return errors.New("invalid state transition")
+		return ErrInvalidTransition
+	}
+
+	// A processing job sent back to pending (recovered after a crash) may
+	// have run
+	if job.Status == domain.StatusProcessing && status == domain.StatusPending {
+		job.Redelivered = true
 	}
 
 	job.Status = status
 	if lastError != nil {
 		job.LastError = lastError
 	}
-	s.jobs[jobID] = job
+	switch status {
+	case domain.StatusFailed:
+		markFailed(&job, false)
+	case domain.StatusPending:
+		job.NextRetryAt = nil
+	}
+	touch(&job)
+	s.putLocked(job)
 
-	return nil
+	return s.journalErrLocked()
+}
+
+// CancelJob cancels a pending, failed, blocked or quarantined job.
+func (s *InMemoryJobStore) CancelJob(ctx context.Context, jobID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if !canTransition(job.Status, domain.StatusCancelled) {
+		return ErrInvalidTransition
+	}
+
+	job.Status = domain.StatusCancelled
+	job.NextRetryAt = nil
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+// UpdateProgress records how far the claim holding token has got on a
+// processing job. Percent is clamped to 0-100.
+func (s *InMemoryJobStore) UpdateProgress(ctx context.Context, jobID string, token string, percent int, message string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusProcessing {
+		return ErrJobNotProcessing
+	}
+	// A worker whose lease lapsed must not overwrite the new claim's progress
+	if !holdsClaim(&job, token) {
+		return ErrLeaseLost
+	}
+
+	job.ProgressPercent = min(max(percent, 0), 100)
+	job.ProgressMessage = message
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+// CompleteJob marks a processing job completed and stores its result and
+// outbox messages in the same update, so a completed job is never observed
+// without them, and they are never kept for an attempt that didn't complete.
+func (s *InMemoryJobStore) CompleteJob(ctx context.Context, jobID string, token string, result json.RawMessage, outbox []domain.OutboxMessage) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	// The lease may have lapsed and the job been claimed again since
+	if !holdsClaim(&job, token) {
+		return ErrLeaseLost
+	}
+
+	job.Status = domain.StatusCompleted
+	job.Result = result
+	job.Crashes = 0
+	touch(&job)
+	s.putLocked(job)
+	for _, message := range outbox {
+		s.putOutboxLocked(message)
+	}
+
+	return s.journalErrLocked()
+}
+
+// FailJob records why a processing job's attempt failed and returns the
+// status it moved to: failed if it will be retried, dead otherwise. Permanent
+// failures go to dead regardless of the retries left. Panics and timeouts
+// count as crashes, so a job that keeps causing them is quarantined instead
+// of retried.
+func (s *InMemoryJobStore) FailJob(ctx context.Context, jobID string, token string, lastError string, errorClass string, permanent bool) (domain.JobStatus, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return "", err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return "", ErrJobNotFound
+	}
+
+	if !holdsClaim(&job, token) {
+		return "", ErrLeaseLost
+	}
+
+	markFailed(&job, permanent)
+	if errorClass == domain.ErrorClassPanic || errorClass == domain.ErrorClassTimeout {
+		s.recordCrash(&job)
+	} else {
+		job.Crashes = 0
+	}
+	job.LastError = &lastError
+	job.ErrorClass = errorClass
+	touch(&job)
+	s.putLocked(job)
+
+	return job.Status, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) GetDeadJobs(ctx context.Context) ([]domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range s.jobs {
+		if job.Status == domain.StatusDead {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// RequeueDeadJob moves a dead job back to pending with a fresh set of retries.
+func (s *InMemoryJobStore) RequeueDeadJob(ctx context.Context, jobID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusDead {
+		return ErrInvalidTransition
+	}
+
+	job.Status = domain.StatusPending
+	// A job that died with a dependency waits for it again
+	if len(job.DependsOn) > 0 && !s.dependenciesCompletedLocked(&job) {
+		job.Status = domain.StatusBlocked
+	}
+	// The child no longer counts as failed while its parent is still waiting
+	if parent, ok := s.jobs[job.ParentID]; ok && parent.Status == domain.StatusBlocked && parent.Batch != nil {
+		progress := *parent.Batch
+		progress.Failed--
+		parent.Batch = &progress
+		touch(&parent)
+		s.putLocked(parent)
+	}
+	job.Attempts = 0
+	job.NextRetryAt = nil
+	job.Redelivered = false
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+// ReapStuckJobs counts each reaped attempt as a crash.
+func (s *InMemoryJobStore) ReapStuckJobs(ctx context.Context, thresholds StuckThresholds) ([]domain.Job, []domain.Job, []domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	now := time.Now().UTC()
+
+	var requeued, dead, quarantined []domain.Job
+	for _, job := range s.jobs {
+		if job.Status != domain.StatusProcessing || job.StartedAt == nil {
+			continue
+		}
+
+		threshold := thresholds.For(job.Type)
+		if threshold <= 0 || now.Sub(*job.StartedAt) < threshold {
+			continue
+		}
+
+		lastError := fmt.Sprintf("Job stuck in processing for over %s", threshold)
+		job.LastError = &lastError
+		job.ErrorClass = domain.ErrorClassStuck
+		job.StartedAt = nil
+		// The stuck handler may still finish its work
+		job.Redelivered = true
+
+		// The stuck attempt counts, so a job that always hangs ends up dead
+		if job.Attempts > job.MaxRetries {
+			job.Status = domain.StatusDead
+		} else {
+			job.Status = domain.StatusPending
+		}
+		s.recordCrash(&job)
+		touch(&job)
+		s.putLocked(job)
+		switch job.Status {
+		case domain.StatusDead:
+			dead = append(dead, job)
+		case domain.StatusQuarantined:
+			quarantined = append(quarantined, job)
+		default:
+			requeued = append(requeued, job)
+		}
+	}
+
+	return requeued, dead, quarantined, s.journalErrLocked()
+}
+
+// RecoverJob counts the attempt interrupted by the previous run as a crash:
+// a job that keeps taking the whole server down is quarantined rather than
+// run again.
+func (s *InMemoryJobStore) RecoverJob(ctx context.Context, jobID string) (domain.JobStatus, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return "", err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return "", ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusProcessing {
+		return "", ErrJobNotProcessing
+	}
+
+	job.Status = domain.StatusPending
+	job.StartedAt = nil
+	job.NextRetryAt = nil
+	// The interrupted attempt may have done some of its work
+	job.Redelivered = true
+	s.recordCrash(&job)
+	touch(&job)
+	s.putLocked(job)
+
+	return job.Status, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) GetQuarantinedJobs(ctx context.Context) ([]domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range s.jobs {
+		if job.Status == domain.StatusQuarantined {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// ReleaseQuarantinedJob moves a quarantined job back to pending with a fresh
+// set of retries and its crashes forgotten, for once the handler is fixed.
+func (s *InMemoryJobStore) ReleaseQuarantinedJob(ctx context.Context, jobID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusQuarantined {
+		return ErrInvalidTransition
+	}
+
+	job.Status = domain.StatusPending
+	job.Attempts = 0
+	job.Crashes = 0
+	job.NextRetryAt = nil
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) ExpireJobs(ctx context.Context) ([]string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	var expired []string
+	for jobID, job := range s.jobs {
+		// A batch parent is waiting on children that carry its expiry
+		// themselves, not to run
+		if job.Batch != nil || !job.Expired(now) || !canTransition(job.Status, domain.StatusExpired) {
+			continue
+		}
+
+		lastError := fmt.Sprintf("Job expired at %s before it started", job.ExpiresAt.Format(time.RFC3339))
+		job.Status = domain.StatusExpired
+		job.LastError = &lastError
+		job.ErrorClass = domain.ErrorClassExpired
+		job.NextRetryAt = nil
+		touch(&job)
+		s.putLocked(job)
+		expired = append(expired, jobID)
+	}
+
+	return expired, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) CancelProcessingJob(ctx context.Context, jobID string, token string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	// Not a general transition: only the worker holding the job may do this
+	if !holdsClaim(&job, token) {
+		return ErrLeaseLost
+	}
+
+	job.Status = domain.StatusCancelled
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) ReleaseJob(ctx context.Context, jobID string, token string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if !holdsClaim(&job, token) {
+		return ErrLeaseLost
+	}
+
+	job.Status = domain.StatusPending
+	job.Attempts--
+	job.StartedAt = nil
+	job.Redelivered = true
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
 }
 
 func (s *InMemoryJobStore) GetFailedJobs(ctx context.Context) ([]domain.Job, error) {
@@ -220,7 +1112,68 @@ func (s *InMemoryJobStore) GetProcessingJobs(ctx context.Context) ([]domain.Job,
 	return jobs, nil
 }
 
-func (s *InMemoryJobStore) RetryFailedJobs(ctx context.Context, metricStore MetricStore, logger *slog.Logger) error {
+func (s *InMemoryJobStore) CountJobs(ctx context.Context) (map[domain.JobStatus]int, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[domain.JobStatus]int)
+	for _, job := range s.jobs {
+		counts[job.Status]++
+	}
+
+	return counts, nil
+}
+
+func (s *InMemoryJobStore) RetryFailedJobs(ctx context.Context, allow func(job *domain.Job) bool) ([]string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	due := make([]domain.Job, 0)
+	for _, job := range s.jobs {
+		// Only retry once the backoff for the last attempt has elapsed
+		if job.Status == domain.StatusFailed && job.Attempts <= job.MaxRetries && (job.NextRetryAt == nil || !job.NextRetryAt.After(now)) {
+			due = append(due, job)
+		}
+	}
+
+	// Jobs allow refuses this time are the ones that waited least
+	sort.Slice(due, func(i, j int) bool {
+		return retryDueAt(&due[i]).Before(retryDueAt(&due[j]))
+	})
+
+	retried := make([]string, 0, len(due))
+	for _, job := range due {
+		if allow != nil && !allow(&job) {
+			continue
+		}
+		job.Status = domain.StatusPending
+		job.NextRetryAt = nil
+		touch(&job)
+		s.putLocked(job)
+		retried = append(retried, job.ID)
+	}
+
+	return retried, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) RetryFailedJob(ctx context.Context, jobID string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -230,17 +1183,86 @@ func (s *InMemoryJobStore) RetryFailedJobs(ctx context.Context, metricStore Metr
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for jobID, job := range s.jobs {
-		if job.Status == domain.StatusFailed && job.Attempts <= job.MaxRetries {
-			job.Status = domain.StatusPending
-			s.jobs[jobID] = job
-			err := metricStore.IncrementJobsRetried(ctx)
-			if err != nil {
-				return err
-			}
-			logger.Info("Job retried", "event", "job_retried", "job_id", jobID)
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.Status != domain.StatusFailed {
+		return ErrInvalidTransition
+	}
+
+	job.Status = domain.StatusPending
+	job.NextRetryAt = nil
+	touch(&job)
+	s.putLocked(job)
+
+	return s.journalErrLocked()
+}
+
+// retryDueAt is when a failed job became due for its retry.
+func retryDueAt(job *domain.Job) time.Time {
+	if job.NextRetryAt == nil {
+		return job.UpdatedAt
+	}
+	return *job.NextRetryAt
+}
+
+// RequeueStuckJobs moves jobs that have been processing for longer than
+// olderThan back to pending and returns them.
+func (s *InMemoryJobStore) RequeueStuckJobs(ctx context.Context, olderThan time.Duration) ([]domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().UTC().Add(-olderThan)
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range s.jobs {
+		if job.Status != domain.StatusProcessing || job.StartedAt == nil || job.StartedAt.After(cutoff) {
+			continue
 		}
+
+		job.Status = domain.StatusPending
+		job.StartedAt = nil
+		job.Redelivered = true
+		touch(&job)
+		s.putLocked(job)
+		jobs = append(jobs, job)
 	}
 
-	return nil
+	return jobs, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) Ping(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Acquiring the lock proves the store is not wedged
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Changes no longer reach the file, so they would be lost on restart
+	return s.journalErrLocked()
+}
+
+// Shared is false: jobs live and die with this process's memory.
+func (s *InMemoryJobStore) Shared() bool {
+	return false
 }
@@ -0,0 +1,463 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// newTestStore returns an in-memory store without a file.
+func newTestStore(t *testing.T, priorityAging time.Duration) *InMemoryJobStore {
+	t.Helper()
+
+	s, err := NewInMemoryJobStore(JournalConfig{}, priorityAging, time.Minute, nil, 0)
+	if err != nil {
+		t.Fatalf("NewInMemoryJobStore: %v", err)
+	}
+	return s
+}
+
+// pendingJob returns a pending job of the given priority that has been
+// waiting for age.
+func pendingJob(name string, priority domain.Priority, age time.Duration) *domain.Job {
+	job := domain.NewJob("test", nil)
+	job.ID = name
+	job.Priority = priority
+	job.CreatedAt = job.CreatedAt.Add(-age)
+	job.UpdatedAt = job.CreatedAt
+	return job
+}
+
+func TestClaimNextJobOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		priorityAging time.Duration
+		jobs          []*domain.Job
+		want          []string
+	}{
+		{
+			name: "higher priority first",
+			jobs: []*domain.Job{
+				pendingJob("low", domain.PriorityLow, 3*time.Minute),
+				pendingJob("high", domain.PriorityHigh, time.Minute),
+				pendingJob("normal", domain.PriorityNormal, 2*time.Minute),
+			},
+			want: []string{"high", "normal", "low"},
+		},
+		{
+			name: "oldest first within a priority",
+			jobs: []*domain.Job{
+				pendingJob("newer", domain.PriorityNormal, time.Minute),
+				pendingJob("oldest", domain.PriorityNormal, 3*time.Minute),
+				pendingJob("older", domain.PriorityNormal, 2*time.Minute),
+			},
+			want: []string{"oldest", "older", "newer"},
+		},
+		{
+			name: "without aging a waiting low job stays behind",
+			jobs: []*domain.Job{
+				pendingJob("low", domain.PriorityLow, time.Hour),
+				pendingJob("high", domain.PriorityHigh, 0),
+			},
+			want: []string{"high", "low"},
+		},
+		{
+			name:          "aging lifts a waiting low job above a fresh high one",
+			priorityAging: 10 * time.Minute,
+			jobs: []*domain.Job{
+				pendingJob("low", domain.PriorityLow, time.Hour),
+				pendingJob("high", domain.PriorityHigh, 0),
+			},
+			want: []string{"low", "high"},
+		},
+		{
+			name:          "aging short of a level keeps priority order",
+			priorityAging: time.Hour,
+			jobs: []*domain.Job{
+				pendingJob("normal", domain.PriorityNormal, 30*time.Minute),
+				pendingJob("high", domain.PriorityHigh, 0),
+			},
+			want: []string{"high", "normal"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, tt.priorityAging)
+			for _, job := range tt.jobs {
+				if err := s.CreateJob(ctx, job); err != nil {
+					t.Fatalf("CreateJob(%s): %v", job.ID, err)
+				}
+			}
+
+			var got []string
+			for {
+				job, err := s.ClaimNextJob(ctx, "worker", nil)
+				if err != nil {
+					t.Fatalf("ClaimNextJob: %v", err)
+				}
+				if job == nil {
+					break
+				}
+				got = append(got, job.ID)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("claimed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimNextJobSkips(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name   string
+		job    func() *domain.Job
+		accept func(job *domain.Job) bool
+		want   bool
+	}{
+		{
+			name: "pending job",
+			job:  func() *domain.Job { return pendingJob("job", domain.PriorityNormal, 0) },
+			want: true,
+		},
+		{
+			name: "not yet due",
+			job: func() *domain.Job {
+				job := pendingJob("job", domain.PriorityNormal, 0)
+				job.RunAt = &future
+				return job
+			},
+			want: false,
+		},
+		{
+			name:   "refused by accept",
+			job:    func() *domain.Job { return pendingJob("job", domain.PriorityNormal, 0) },
+			accept: func(job *domain.Job) bool { return job.Type != "test" },
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			if err := s.CreateJob(ctx, tt.job()); err != nil {
+				t.Fatalf("CreateJob: %v", err)
+			}
+
+			job, err := s.ClaimNextJob(ctx, "worker", tt.accept)
+			if err != nil {
+				t.Fatalf("ClaimNextJob: %v", err)
+			}
+			if got := job != nil; got != tt.want {
+				t.Fatalf("claimed = %v, want %v", got, tt.want)
+			}
+			if job == nil {
+				return
+			}
+			if job.Status != domain.StatusProcessing || job.Attempts != 1 || job.ExecutionToken == "" || job.LeaseExpiresAt == nil {
+				t.Fatalf("claimed job = %+v, want processing with one attempt, a token and a lease", job)
+			}
+		})
+	}
+}
+
+func TestClaimRace(t *testing.T) {
+	const jobs = 200
+
+	tests := []struct {
+		name string
+		// claim claims the next jobs; none means there are none left
+		claim func(ctx context.Context, s *InMemoryJobStore) ([]domain.Job, error)
+	}{
+		{
+			name: "ClaimNextJob",
+			claim: func(ctx context.Context, s *InMemoryJobStore) ([]domain.Job, error) {
+				job, err := s.ClaimNextJob(ctx, "worker", nil)
+				if job == nil {
+					return nil, err
+				}
+				return []domain.Job{*job}, err
+			},
+		},
+		{
+			name: "LeaseJobs",
+			claim: func(ctx context.Context, s *InMemoryJobStore) ([]domain.Job, error) {
+				return s.LeaseJobs(ctx, []string{"test"}, 3, "remote", time.Minute)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			for i := range jobs {
+				job := domain.NewJob("test", nil)
+				job.Priority = domain.Priority(i % 3)
+				if err := s.CreateJob(ctx, job); err != nil {
+					t.Fatalf("CreateJob: %v", err)
+				}
+			}
+
+			var mu sync.Mutex
+			claims := make(map[string]int)
+			var wg sync.WaitGroup
+			for range 16 {
+				wg.Go(func() {
+					for {
+						claimed, err := tt.claim(ctx, s)
+						if err != nil {
+							t.Errorf("claim: %v", err)
+							return
+						}
+						if len(claimed) == 0 {
+							return
+						}
+						mu.Lock()
+						for _, job := range claimed {
+							claims[job.ID]++
+						}
+						mu.Unlock()
+					}
+				})
+			}
+			wg.Wait()
+
+			if len(claims) != jobs {
+				t.Fatalf("claimed %d distinct jobs, want %d", len(claims), jobs)
+			}
+			for id, n := range claims {
+				if n != 1 {
+					t.Errorf("job %s claimed %d times", id, n)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimLockedComparesStatusAndVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(job *domain.Job)
+		want   bool
+	}{
+		{name: "unchanged", change: func(*domain.Job) {}, want: true},
+		{name: "version moved on", change: func(job *domain.Job) { touch(job) }, want: false},
+		{name: "no longer pending", change: func(job *domain.Job) { job.Status = domain.StatusCancelled }, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			job := pendingJob("job", domain.PriorityNormal, 0)
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob: %v", err)
+			}
+
+			// Another caller changes the job between the read and the claim
+			read := s.jobs[job.ID]
+			current := s.jobs[job.ID]
+			tt.change(&current)
+			s.jobs[job.ID] = current
+
+			claimed := s.claimLocked(read, time.Now(), "worker", time.Minute)
+			if got := claimed != nil; got != tt.want {
+				t.Fatalf("claimed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// reclaimed returns a job claimed twice, the first lease having lapsed and
+// been reaped in between, and the token of each claim.
+func reclaimed(t *testing.T, s *InMemoryJobStore) (jobID, stale, current string) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := s.CreateJob(ctx, pendingJob("job", domain.PriorityNormal, 0)); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	first, err := s.ClaimNextJob(ctx, "worker-1", nil)
+	if err != nil || first == nil {
+		t.Fatalf("first claim = %v, %v", first, err)
+	}
+	if _, err := s.ExtendLease(ctx, first.ID, first.ExecutionToken, 0); err != nil {
+		t.Fatalf("ExtendLease: %v", err)
+	}
+	if reaped, err := s.ReapExpiredLeases(ctx); err != nil || len(reaped) != 1 {
+		t.Fatalf("ReapExpiredLeases = %d jobs, %v", len(reaped), err)
+	}
+	second, err := s.ClaimNextJob(ctx, "worker-2", nil)
+	if err != nil || second == nil {
+		t.Fatalf("second claim = %v, %v", second, err)
+	}
+	if second.ExecutionToken == first.ExecutionToken {
+		t.Fatal("second claim reused the first claim's token")
+	}
+
+	return first.ID, first.ExecutionToken, second.ExecutionToken
+}
+
+func TestStaleTokenRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error
+	}{
+		{
+			name: "CompleteJob",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				return s.CompleteJob(ctx, jobID, token, nil, nil)
+			},
+		},
+		{
+			name: "FailJob",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				_, err := s.FailJob(ctx, jobID, token, "boom", "", false)
+				return err
+			},
+		},
+		{
+			name: "ReleaseJob",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				return s.ReleaseJob(ctx, jobID, token)
+			},
+		},
+		{
+			name: "CancelProcessingJob",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				return s.CancelProcessingJob(ctx, jobID, token)
+			},
+		},
+		{
+			name: "ExtendLease",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				_, err := s.ExtendLease(ctx, jobID, token, time.Minute)
+				return err
+			},
+		},
+		{
+			name: "UpdateProgress",
+			call: func(ctx context.Context, s *InMemoryJobStore, jobID, token string) error {
+				return s.UpdateProgress(ctx, jobID, token, 50, "halfway")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			jobID, stale, current := reclaimed(t, s)
+
+			for _, token := range []string{stale, ""} {
+				if err := tt.call(ctx, s, jobID, token); !errors.Is(err, ErrLeaseLost) {
+					t.Fatalf("with token %q: err = %v, want ErrLeaseLost", token, err)
+				}
+			}
+
+			job, err := s.GetJob(ctx, jobID)
+			if err != nil {
+				t.Fatalf("GetJob: %v", err)
+			}
+			if job.Status != domain.StatusProcessing || job.ExecutionToken != current || job.ProgressMessage != "" {
+				t.Fatalf("job = %+v, want the second claim untouched", job)
+			}
+
+			if err := tt.call(ctx, s, jobID, current); err != nil {
+				t.Fatalf("with the current token: %v", err)
+			}
+		})
+	}
+}
+
+func TestReapStuckJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		thresholds StuckThresholds
+		want       domain.JobStatus
+	}{
+		{
+			name:       "under the threshold",
+			maxRetries: 3,
+			thresholds: StuckThresholds{Default: time.Hour},
+			want:       domain.StatusProcessing,
+		},
+		{
+			name:       "stuck with retries left",
+			maxRetries: 3,
+			thresholds: StuckThresholds{Default: time.Nanosecond},
+			want:       domain.StatusPending,
+		},
+		{
+			name:       "stuck on the last attempt",
+			maxRetries: 0,
+			thresholds: StuckThresholds{Default: time.Nanosecond},
+			want:       domain.StatusDead,
+		},
+		{
+			name:       "type threshold overrides the default",
+			maxRetries: 3,
+			thresholds: StuckThresholds{Default: time.Nanosecond, ByType: map[string]time.Duration{"test": time.Hour}},
+			want:       domain.StatusProcessing,
+		},
+		{
+			name:       "zero threshold never reaps",
+			maxRetries: 3,
+			thresholds: StuckThresholds{},
+			want:       domain.StatusProcessing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			job := pendingJob("job", domain.PriorityNormal, 0)
+			job.MaxRetries = tt.maxRetries
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob: %v", err)
+			}
+			if claimed, err := s.ClaimNextJob(ctx, "worker", nil); err != nil || claimed == nil {
+				t.Fatalf("ClaimNextJob = %v, %v", claimed, err)
+			}
+			time.Sleep(time.Millisecond)
+
+			requeued, dead, _, err := s.ReapStuckJobs(ctx, tt.thresholds)
+			if err != nil {
+				t.Fatalf("ReapStuckJobs: %v", err)
+			}
+
+			stored, err := s.GetJob(ctx, job.ID)
+			if err != nil {
+				t.Fatalf("GetJob: %v", err)
+			}
+			if stored.Status != tt.want {
+				t.Fatalf("status = %s, want %s", stored.Status, tt.want)
+			}
+			wantRequeued, wantDead := 0, 0
+			switch tt.want {
+			case domain.StatusPending:
+				wantRequeued = 1
+			case domain.StatusDead:
+				wantDead = 1
+			}
+			if len(requeued) != wantRequeued || len(dead) != wantDead {
+				t.Fatalf("requeued %d and dead %d, want %d and %d", len(requeued), len(dead), wantRequeued, wantDead)
+			}
+			if tt.want != domain.StatusProcessing && stored.ErrorClass != domain.ErrorClassStuck {
+				t.Fatalf("error class = %q, want %q", stored.ErrorClass, domain.ErrorClassStuck)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/leader"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+)
+
+// RenewLease pushes the job's lease out by the lease duration. Heartbeats
+// don't bump the job's version since nothing visible about the job changes.
+func (s *InMemoryJobStore) RenewLease(ctx context.Context, jobID string, token string) (time.Time, error) {
+	return s.ExtendLease(ctx, jobID, token, s.leaseDuration)
+}
+
+// ExtendLease is RenewLease with a caller-chosen extension, for remote
+// workers that picked their own visibility timeout.
+func (s *InMemoryJobStore) ExtendLease(ctx context.Context, jobID string, token string, extension time.Duration) (time.Time, error) {
+	select {
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return time.Time{}, err
+	}
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return time.Time{}, ErrJobNotFound
+	}
+
+	// The lease may have lapsed and the job been claimed again since
+	if !holdsClaim(&job, token) {
+		return time.Time{}, ErrLeaseLost
+	}
+
+	leaseExpiresAt := time.Now().UTC().Add(extension)
+	job.LeaseExpiresAt = &leaseExpiresAt
+	s.putLocked(job)
+
+	return leaseExpiresAt, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) LeaseJobs(ctx context.Context, types []string, limit int, claimedBy string, visibility time.Duration) ([]domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, jobType := range types {
+		wanted[jobType] = true
+	}
+	accept := func(job *domain.Job) bool {
+		return wanted[job.Type]
+	}
+
+	now := time.Now().UTC()
+	processingByKey := s.processingByKeyLocked()
+
+	jobs := make([]domain.Job, 0, limit)
+	for len(jobs) < limit {
+		next := s.nextJobLocked(now, processingByKey, accept)
+		if next == nil {
+			break
+		}
+
+		job := s.claimLocked(*next, now, claimedBy, visibility)
+		if job == nil {
+			break
+		}
+		if job.ConcurrencyKey != "" {
+			processingByKey[job.ConcurrencyKey]++
+		}
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) ReapExpiredLeases(ctx context.Context) ([]domain.Job, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range s.jobs {
+		if job.Status != domain.StatusProcessing || job.LeaseExpiresAt == nil || job.LeaseExpiresAt.After(now) {
+			continue
+		}
+
+		job.Status = domain.StatusPending
+		job.StartedAt = nil
+		job.Redelivered = true
+		// The worker stopped heartbeating, likely because the job took it down
+		s.recordCrash(&job)
+		touch(&job)
+		s.putLocked(job)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, s.journalErrLocked()
+}
+
+// LeaseReaper periodically returns jobs whose worker stopped heartbeating
+// (crashed or wedged) to pending, so they don't wait for the next restart.
+type LeaseReaper struct {
+	jobStore JobStore
+	events   *events.Bus
+	logger   *slog.Logger
+	interval time.Duration
+	jobQueue queue.Queue
+	// Only the leader reaps
+	elector leader.Elector
+}
+
+func NewLeaseReaper(jobStore JobStore, bus *events.Bus, logger *slog.Logger, interval time.Duration, jobQueue queue.Queue, elector leader.Elector) *LeaseReaper {
+	return &LeaseReaper{
+		jobStore: jobStore,
+		events:   bus,
+		logger:   logger,
+		interval: interval,
+		jobQueue: jobQueue,
+		elector:  elector,
+	}
+}
+
+func (r *LeaseReaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Lease reaper shutting down", "event", "lease_reaper_stopped")
+			return
+		case <-ticker.C:
+			if !r.elector.IsLeader() {
+				continue
+			}
+
+			jobs, err := r.jobStore.ReapExpiredLeases(ctx)
+			if err != nil {
+				r.logger.Error("Lease reaper error reaping jobs", "event", "lease_reaper_error", "error", err)
+				continue
+			}
+
+			for _, job := range jobs {
+				// A handler still running the attempt here is cancelled
+				event := events.ForJob(events.JobReleased, &job)
+				event.Reason = "lease_expired"
+
+				if job.Status == domain.StatusQuarantined {
+					r.logger.Warn("Job lease expired too many times in a row, quarantined", "event", "job_quarantined", "job_id", job.ID, "attempt", job.Attempts, "crashes", job.Crashes)
+					r.events.Publish(ctx, event)
+					event.Type = events.JobQuarantined
+					r.events.Publish(ctx, event)
+					continue
+				}
+
+				r.logger.Warn("Job lease expired, returned to pending", "event", "job_lease_expired", "job_id", job.ID, "attempt", job.Attempts)
+				r.events.Publish(ctx, event)
+
+				// If the queue is full the job stays pending; the sweeper will
+				// enqueue it once there is room
+				r.jobQueue.Enqueue(ctx, job.ID)
+			}
+		}
+	}
+}
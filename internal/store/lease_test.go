@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+func TestReapExpiredLeases(t *testing.T) {
+	tests := []struct {
+		name string
+		// extension is how far the worker's last heartbeat pushed the lease
+		extension  time.Duration
+		wantReaped bool
+	}{
+		{name: "heartbeat keeps the lease", extension: time.Hour, wantReaped: false},
+		{name: "lapsed lease is reaped", extension: 0, wantReaped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			if err := s.CreateJob(ctx, pendingJob("job", domain.PriorityNormal, 0)); err != nil {
+				t.Fatalf("CreateJob: %v", err)
+			}
+			job, err := s.ClaimNextJob(ctx, "worker", nil)
+			if err != nil || job == nil {
+				t.Fatalf("ClaimNextJob = %v, %v", job, err)
+			}
+			if _, err := s.ExtendLease(ctx, job.ID, job.ExecutionToken, tt.extension); err != nil {
+				t.Fatalf("ExtendLease: %v", err)
+			}
+
+			reaped, err := s.ReapExpiredLeases(ctx)
+			if err != nil {
+				t.Fatalf("ReapExpiredLeases: %v", err)
+			}
+			if got := len(reaped) == 1; got != tt.wantReaped {
+				t.Fatalf("reaped %d jobs, want reaped = %v", len(reaped), tt.wantReaped)
+			}
+
+			stored, err := s.GetJob(ctx, job.ID)
+			if err != nil {
+				t.Fatalf("GetJob: %v", err)
+			}
+			if !tt.wantReaped {
+				if stored.Status != domain.StatusProcessing {
+					t.Fatalf("status = %s, want processing", stored.Status)
+				}
+				return
+			}
+			if stored.Status != domain.StatusPending || !stored.Redelivered || stored.LeaseExpiresAt != nil {
+				t.Fatalf("job = %+v, want pending, redelivered and without a lease", stored)
+			}
+		})
+	}
+}
+
+func TestLeaseJobs(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		limit int
+		want  int
+	}{
+		{name: "up to the limit", types: []string{"test"}, limit: 2, want: 2},
+		{name: "no more than are pending", types: []string{"test"}, limit: 10, want: 3},
+		{name: "only the requested types", types: []string{"other"}, limit: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(t, 0)
+			for _, name := range []string{"a", "b", "c"} {
+				if err := s.CreateJob(ctx, pendingJob(name, domain.PriorityNormal, 0)); err != nil {
+					t.Fatalf("CreateJob: %v", err)
+				}
+			}
+
+			before := time.Now()
+			leased, err := s.LeaseJobs(ctx, tt.types, tt.limit, "remote", 30*time.Second)
+			if err != nil {
+				t.Fatalf("LeaseJobs: %v", err)
+			}
+			if len(leased) != tt.want {
+				t.Fatalf("leased %d jobs, want %d", len(leased), tt.want)
+			}
+			for _, job := range leased {
+				if job.LeaseExpiresAt == nil || job.LeaseExpiresAt.Before(before.Add(30*time.Second)) {
+					t.Errorf("job %s lease expires at %v, want the requested visibility", job.ID, job.LeaseExpiresAt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// maxLogMessageBytes caps a single captured message.
+const maxLogMessageBytes = 2048
+
+type LogStore interface {
+	AppendLog(ctx context.Context, jobID string, attempt int, entry domain.LogEntry) error
+	GetLogs(ctx context.Context, jobID string) ([]domain.AttemptLog, error)
+	DeleteLogs(ctx context.Context, jobID string) error
+}
+
+// InMemoryLogStore keeps per-attempt execution logs with size caps: at most
+// maxEntries lines per attempt, the latest maxAttempts attempts per job, and
+// the most recently logged-to maxJobs jobs.
+type InMemoryLogStore struct {
+	mu          sync.Mutex
+	logs        map[string][]domain.AttemptLog
+	order       []string // job IDs, oldest first, for retention
+	maxEntries  int
+	maxAttempts int
+	maxJobs     int
+}
+
+func NewInMemoryLogStore(maxEntries, maxAttempts, maxJobs int) *InMemoryLogStore {
+	return &InMemoryLogStore{
+		logs:        make(map[string][]domain.AttemptLog),
+		maxEntries:  maxEntries,
+		maxAttempts: maxAttempts,
+		maxJobs:     maxJobs,
+	}
+}
+
+func (s *InMemoryLogStore) AppendLog(ctx context.Context, jobID string, attempt int, entry domain.LogEntry) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if len(entry.Message) > maxLogMessageBytes {
+		entry.Message = entry.Message[:maxLogMessageBytes] + "...(truncated)"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, ok := s.logs[jobID]
+	if !ok {
+		s.order = append(s.order, jobID)
+		s.evictLocked()
+	}
+
+	last := len(attempts) - 1
+	if last < 0 || attempts[last].Attempt != attempt {
+		attempts = append(attempts, domain.AttemptLog{Attempt: attempt})
+		if len(attempts) > s.maxAttempts {
+			attempts = attempts[len(attempts)-s.maxAttempts:]
+		}
+		last = len(attempts) - 1
+	}
+
+	if len(attempts[last].Entries) >= s.maxEntries {
+		attempts[last].Truncated = true
+	} else {
+		attempts[last].Entries = append(attempts[last].Entries, entry)
+	}
+
+	s.logs[jobID] = attempts
+
+	return nil
+}
+
+// evictLocked drops the oldest jobs' logs once more than maxJobs are held.
+func (s *InMemoryLogStore) evictLocked() {
+	for len(s.order) > s.maxJobs {
+		delete(s.logs, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func (s *InMemoryLogStore) GetLogs(ctx context.Context, jobID string) ([]domain.AttemptLog, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Return deep copies so callers can't race with appends
+	attempts := s.logs[jobID]
+	logs := make([]domain.AttemptLog, len(attempts))
+	for i, attempt := range attempts {
+		logs[i] = attempt
+		logs[i].Entries = append([]domain.LogEntry(nil), attempt.Entries...)
+	}
+
+	return logs, nil
+}
+
+func (s *InMemoryLogStore) DeleteLogs(ctx context.Context, jobID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.logs[jobID]; !ok {
+		return nil
+	}
+
+	delete(s.logs, jobID)
+	for i, id := range s.order {
+		if id == jobID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
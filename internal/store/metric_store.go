@@ -2,57 +2,187 @@ package store
 
 import (
 	"context"
+	"maps"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/karprabha/job-queue-backend/internal/domain"
 )
 
 type MetricStore interface {
+	// GetMetrics returns the counters together with the gauges of jobs in
+	// progress and dead, which are counted from the job store
 	GetMetrics(ctx context.Context) (*domain.Metric, error)
 	IncrementJobsCreated(ctx context.Context) error
 	DecrementJobsCreated(ctx context.Context) error
 	IncrementJobsCompleted(ctx context.Context) error
+	// IncrementJobsFailed counts a failed attempt; jobs currently failed
+	// and waiting to retry are counted from the job store instead
 	IncrementJobsFailed(ctx context.Context) error
+	IncrementJobsCancelled(ctx context.Context) error
+	IncrementJobsExpired(ctx context.Context) error
+	IncrementJobsPanicked(ctx context.Context) error
+	IncrementJobsReaped(ctx context.Context) error
+	IncrementFailureClass(ctx context.Context, errorClass string) error
 	IncrementJobsRetried(ctx context.Context) error
-	IncrementJobsInProgress(ctx context.Context) error
+	// IncrementJobsStolen counts a job taken by a worker of another queue
+	IncrementJobsStolen(ctx context.Context) error
+	// IncrementJobsSlow counts an attempt flagged as slow
+	IncrementJobsSlow(ctx context.Context) error
+	// AddWorkerCount adjusts the worker count by delta, so several pools
+	// can report into it
+	AddWorkerCount(ctx context.Context, delta int) error
+	// IncrementWorkerScaleEvents counts an autoscaler resize; direction is
+	// "up" or "down"
+	IncrementWorkerScaleEvents(ctx context.Context, direction string) error
+	// RecordSweep adds one sweeper run to the sweeper metrics
+	RecordSweep(ctx context.Context, result SweepResult) error
+	// ObserveJobDuration records how long a handler for jobType ran
+	ObserveJobDuration(ctx context.Context, jobType string, duration time.Duration) error
+	// ObserveJobWait records how long a job waited from becoming due to its
+	// first start
+	ObserveJobWait(ctx context.Context, jobType string, wait time.Duration) error
+	// ObserveJobProcessing records how long an attempt took from its start to
+	// its outcome
+	ObserveJobProcessing(ctx context.Context, jobType string, duration time.Duration) error
+	// RecordDepthAlert counts a queue depth alert firing, or one resolving
+	// when firing is false
+	RecordDepthAlert(ctx context.Context, firing bool) error
+	// GetThroughput returns the jobs created, completed and failed in each of
+	// the last minutes minutes, oldest first, ending with the current one
+	GetThroughput(ctx context.Context, minutes int) ([]domain.ThroughputPoint, error)
+	Ping(ctx context.Context) error
 }
 
+// InMemoryMetricStore keeps each counter in its own atomic, so recording
+// one outcome doesn't wait for another. Gauges that follow job states (jobs
+// in progress, jobs dead) are not kept at all but counted from the job store
+// when read, so they can't drift from it. Only the histograms, failure
+// classes and throughput series, which are maps and rings, share a mutex.
 type InMemoryMetricStore struct {
-	mu      sync.RWMutex
-	metrics *domain.Metric
+	jobStore JobStore
+
+	jobsCreated      atomic.Int64
+	jobsCompleted    atomic.Int64
+	jobsFailed       atomic.Int64
+	jobsRetried      atomic.Int64
+	jobsCancelled    atomic.Int64
+	jobsExpired      atomic.Int64
+	jobsPanicked     atomic.Int64
+	jobsReaped       atomic.Int64
+	jobsStolen       atomic.Int64
+	jobsSlow         atomic.Int64
+	workerCount      atomic.Int64
+	workerScaleUps   atomic.Int64
+	workerScaleDowns atomic.Int64
+
+	sweeperRuns            atomic.Int64
+	sweeperJobsRetried     atomic.Int64
+	sweeperRetriesDeferred atomic.Int64
+	sweeperJobsEnqueued    atomic.Int64
+	sweeperSkippedFull     atomic.Int64
+	sweeperDuration        atomic.Int64
+	sweeperLastDuration    atomic.Int64
+
+	queueDepthAlerts       atomic.Int64
+	queueDepthAlertsFiring atomic.Int64
+
+	mu              sync.RWMutex
+	failuresByClass map[string]int
+	jobDurations    map[string]*domain.Histogram
+	jobWaits        map[string]*domain.Histogram
+	jobProcessing   map[string]*domain.Histogram
+	// Bounds of the job duration and latency histograms
+	buckets []float64
+	// Per-minute job counts
+	throughput *domain.ThroughputSeries
 }
 
-func NewInMemoryMetricStore() *InMemoryMetricStore {
+// NewInMemoryMetricStore counts job state gauges from jobStore, buckets job
+// durations and latencies by buckets, or by domain.DurationBuckets when it is
+// empty, and keeps per-minute job counts for throughputRetention.
+func NewInMemoryMetricStore(jobStore JobStore, buckets []float64, throughputRetention time.Duration) *InMemoryMetricStore {
+	if len(buckets) == 0 {
+		buckets = domain.DurationBuckets
+	}
+
 	return &InMemoryMetricStore{
-		metrics: domain.NewMetric(),
+		jobStore:        jobStore,
+		failuresByClass: make(map[string]int),
+		jobDurations:    make(map[string]*domain.Histogram),
+		jobWaits:        make(map[string]*domain.Histogram),
+		jobProcessing:   make(map[string]*domain.Histogram),
+		buckets:         buckets,
+		throughput:      domain.NewThroughputSeries(int(throughputRetention / time.Minute)),
 	}
 }
 
 func (s *InMemoryMetricStore) GetMetrics(ctx context.Context) (*domain.Metric, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-		s.mu.RLock()
-		defer s.mu.RUnlock()
-		// Return a copy to prevent external mutation of internal state
-		m := *s.metrics
-		return &m, nil
+	counts, err := s.jobStore.CountJobs(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	m := &domain.Metric{
+		TotalJobsCreated:       int(s.jobsCreated.Load()),
+		JobsCompleted:          int(s.jobsCompleted.Load()),
+		JobsFailed:             int(s.jobsFailed.Load()),
+		JobsRetried:            int(s.jobsRetried.Load()),
+		JobsInProgress:         counts[domain.StatusProcessing],
+		JobsCancelled:          int(s.jobsCancelled.Load()),
+		JobsExpired:            int(s.jobsExpired.Load()),
+		JobsPanicked:           int(s.jobsPanicked.Load()),
+		JobsDead:               counts[domain.StatusDead],
+		JobsQuarantined:        counts[domain.StatusQuarantined],
+		JobsReaped:             int(s.jobsReaped.Load()),
+		WorkerCount:            int(s.workerCount.Load()),
+		WorkerScaleUps:         int(s.workerScaleUps.Load()),
+		WorkerScaleDowns:       int(s.workerScaleDowns.Load()),
+		SweeperRuns:            int(s.sweeperRuns.Load()),
+		SweeperJobsRetried:     int(s.sweeperJobsRetried.Load()),
+		SweeperRetriesDeferred: int(s.sweeperRetriesDeferred.Load()),
+		SweeperJobsEnqueued:    int(s.sweeperJobsEnqueued.Load()),
+		SweeperSkippedFull:     int(s.sweeperSkippedFull.Load()),
+		SweeperDuration:        time.Duration(s.sweeperDuration.Load()),
+		SweeperLastDuration:    time.Duration(s.sweeperLastDuration.Load()),
+		QueueDepthAlerts:       int(s.queueDepthAlerts.Load()),
+		QueueDepthAlertsFiring: int(s.queueDepthAlertsFiring.Load()),
+		JobsStolen:             int(s.jobsStolen.Load()),
+		JobsSlow:               int(s.jobsSlow.Load()),
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Copies, so callers can't mutate internal state
+	m.FailuresByClass = maps.Clone(s.failuresByClass)
+	m.JobDurations = cloneHistograms(s.jobDurations)
+	m.JobWaits = cloneHistograms(s.jobWaits)
+	m.JobProcessing = cloneHistograms(s.jobProcessing)
+	return m, nil
 }
 
-func (s *InMemoryMetricStore) IncrementJobsCreated(ctx context.Context) error {
+// add adds delta to counter unless ctx is done.
+func add(ctx context.Context, counter *atomic.Int64, delta int64) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		s.metrics.TotalJobsCreated++
+		counter.Add(delta)
 		return nil
 	}
+}
+
+func (s *InMemoryMetricStore) IncrementJobsCreated(ctx context.Context) error {
+	if err := add(ctx, &s.jobsCreated, 1); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	s.throughput.AddCreated(time.Now())
+	return nil
 }
 
 func (s *InMemoryMetricStore) DecrementJobsCreated(ctx context.Context) error {
@@ -60,17 +190,58 @@ func (s *InMemoryMetricStore) DecrementJobsCreated(ctx context.Context) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		s.mu.Lock()
-		defer s.mu.Unlock()
+	}
 
-		if s.metrics.TotalJobsCreated > 0 {
-			s.metrics.TotalJobsCreated--
+	// Stop at zero rather than go negative
+	for {
+		created := s.jobsCreated.Load()
+		if created == 0 || s.jobsCreated.CompareAndSwap(created, created-1) {
+			return nil
 		}
-		return nil
 	}
 }
 
 func (s *InMemoryMetricStore) IncrementJobsCompleted(ctx context.Context) error {
+	if err := add(ctx, &s.jobsCompleted, 1); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throughput.AddCompleted(time.Now())
+	return nil
+}
+
+func (s *InMemoryMetricStore) IncrementJobsFailed(ctx context.Context) error {
+	if err := add(ctx, &s.jobsFailed, 1); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throughput.AddFailed(time.Now())
+	return nil
+}
+
+func (s *InMemoryMetricStore) IncrementJobsCancelled(ctx context.Context) error {
+	return add(ctx, &s.jobsCancelled, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementJobsExpired(ctx context.Context) error {
+	return add(ctx, &s.jobsExpired, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementJobsPanicked(ctx context.Context) error {
+	return add(ctx, &s.jobsPanicked, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementJobsReaped(ctx context.Context) error {
+	return add(ctx, &s.jobsReaped, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementFailureClass(ctx context.Context, errorClass string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -78,27 +249,64 @@ func (s *InMemoryMetricStore) IncrementJobsCompleted(ctx context.Context) error
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
-		s.metrics.JobsCompleted++
-		s.metrics.JobsInProgress--
+		s.failuresByClass[errorClass]++
 		return nil
 	}
 }
 
-func (s *InMemoryMetricStore) IncrementJobsFailed(ctx context.Context) error {
+func (s *InMemoryMetricStore) IncrementJobsRetried(ctx context.Context) error {
+	return add(ctx, &s.jobsRetried, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementJobsStolen(ctx context.Context) error {
+	return add(ctx, &s.jobsStolen, 1)
+}
+
+func (s *InMemoryMetricStore) IncrementJobsSlow(ctx context.Context) error {
+	return add(ctx, &s.jobsSlow, 1)
+}
+
+func (s *InMemoryMetricStore) AddWorkerCount(ctx context.Context, delta int) error {
+	return add(ctx, &s.workerCount, int64(delta))
+}
+
+func (s *InMemoryMetricStore) IncrementWorkerScaleEvents(ctx context.Context, direction string) error {
+	if direction == "up" {
+		return add(ctx, &s.workerScaleUps, 1)
+	}
+	return add(ctx, &s.workerScaleDowns, 1)
+}
+
+func (s *InMemoryMetricStore) RecordSweep(ctx context.Context, result SweepResult) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		s.metrics.JobsFailed++
-		s.metrics.JobsInProgress--
+		s.sweeperRuns.Add(1)
+		s.sweeperJobsRetried.Add(int64(result.Retried))
+		s.sweeperRetriesDeferred.Add(int64(result.RetriesDeferred))
+		s.sweeperJobsEnqueued.Add(int64(result.Enqueued))
+		s.sweeperSkippedFull.Add(int64(result.SkippedFull))
+		s.sweeperDuration.Add(int64(result.Duration))
+		s.sweeperLastDuration.Store(int64(result.Duration))
 		return nil
 	}
 }
 
-func (s *InMemoryMetricStore) IncrementJobsRetried(ctx context.Context) error {
+func (s *InMemoryMetricStore) ObserveJobDuration(ctx context.Context, jobType string, duration time.Duration) error {
+	return s.observe(ctx, s.jobDurations, jobType, duration)
+}
+
+func (s *InMemoryMetricStore) ObserveJobWait(ctx context.Context, jobType string, wait time.Duration) error {
+	return s.observe(ctx, s.jobWaits, jobType, wait)
+}
+
+func (s *InMemoryMetricStore) ObserveJobProcessing(ctx context.Context, jobType string, duration time.Duration) error {
+	return s.observe(ctx, s.jobProcessing, jobType, duration)
+}
+
+// observe adds duration to jobType's histogram in histograms.
+func (s *InMemoryMetricStore) observe(ctx context.Context, histograms map[string]*domain.Histogram, jobType string, duration time.Duration) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -106,21 +314,54 @@ func (s *InMemoryMetricStore) IncrementJobsRetried(ctx context.Context) error {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
-		s.metrics.JobsRetried++
-		s.metrics.JobsFailed--
+		histogram, ok := histograms[jobType]
+		if !ok {
+			histogram = domain.NewHistogram(s.buckets)
+			histograms[jobType] = histogram
+		}
+		histogram.Observe(duration.Seconds())
 		return nil
 	}
 }
 
-func (s *InMemoryMetricStore) IncrementJobsInProgress(ctx context.Context) error {
+func cloneHistograms(histograms map[string]*domain.Histogram) map[string]*domain.Histogram {
+	clone := make(map[string]*domain.Histogram, len(histograms))
+	for jobType, histogram := range histograms {
+		clone[jobType] = histogram.Clone()
+	}
+	return clone
+}
+
+func (s *InMemoryMetricStore) GetThroughput(ctx context.Context, minutes int) ([]domain.ThroughputPoint, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
-		s.mu.Lock()
-		defer s.mu.Unlock()
+		s.mu.RLock()
+		defer s.mu.RUnlock()
 
-		s.metrics.JobsInProgress++
+		return s.throughput.Points(time.Now(), minutes), nil
+	}
+}
+
+func (s *InMemoryMetricStore) RecordDepthAlert(ctx context.Context, firing bool) error {
+	if !firing {
+		return add(ctx, &s.queueDepthAlertsFiring, -1)
+	}
+	if err := add(ctx, &s.queueDepthAlerts, 1); err != nil {
+		return err
+	}
+	s.queueDepthAlertsFiring.Add(1)
+	return nil
+}
+
+func (s *InMemoryMetricStore) Ping(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
 		return nil
 	}
 }
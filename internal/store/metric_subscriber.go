@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+)
+
+// MetricSubscriber keeps the job counters of a MetricStore from the events
+// published on the bus, so the code moving jobs between states only has to
+// publish what it did. Gauges of jobs in a state are counted from the job
+// store instead, so no event needs to undo another.
+type MetricSubscriber struct {
+	metricStore MetricStore
+	logger      *slog.Logger
+}
+
+func NewMetricSubscriber(metricStore MetricStore, logger *slog.Logger) *MetricSubscriber {
+	return &MetricSubscriber{
+		metricStore: metricStore,
+		logger:      logger,
+	}
+}
+
+func (s *MetricSubscriber) Handle(ctx context.Context, event events.Event) {
+	// Counters must follow state changes that were already stored, even
+	// when the request or worker that made them is going away
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	switch event.Type {
+	case events.JobCreated:
+		err = s.metricStore.IncrementJobsCreated(ctx)
+	case events.JobCompleted:
+		err = s.metricStore.IncrementJobsCompleted(ctx)
+	case events.JobFailed:
+		err = s.metricStore.IncrementJobsFailed(ctx)
+		if err == nil {
+			err = s.metricStore.IncrementFailureClass(ctx, event.ErrorClass)
+		}
+	case events.JobRetried:
+		err = s.metricStore.IncrementJobsRetried(ctx)
+	case events.JobPanicked:
+		err = s.metricStore.IncrementJobsPanicked(ctx)
+	case events.JobCancelled:
+		err = s.metricStore.IncrementJobsCancelled(ctx)
+	case events.JobExpired:
+		err = s.metricStore.IncrementJobsExpired(ctx)
+	case events.JobReaped:
+		err = s.metricStore.IncrementJobsReaped(ctx)
+	case events.JobStolen:
+		err = s.metricStore.IncrementJobsStolen(ctx)
+	case events.SweepCompleted:
+		if result, ok := event.Data.(SweepResult); ok {
+			err = s.metricStore.RecordSweep(ctx, result)
+		}
+	}
+
+	if err != nil {
+		s.logger.Error("Failed to record event in metrics", "event", "metric_error", "event_type", event.Type, "job_id", event.JobID, "error", err)
+	}
+}
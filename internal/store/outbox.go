@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+// Outbox holds the side effects of completed jobs until the relay has
+// carried them out. Messages are only added by JobStore.CompleteJob, in the
+// same update that completes their job, so a store shared between instances
+// must keep them in the same database and transaction as its jobs.
+type Outbox interface {
+	// GetDueOutboxMessages returns the messages whose next attempt is due at
+	// now, oldest first.
+	GetDueOutboxMessages(ctx context.Context, now time.Time) ([]domain.OutboxMessage, error)
+	// UpdateOutboxMessage records a failed attempt at a message.
+	UpdateOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error
+	DeleteOutboxMessage(ctx context.Context, id string) error
+}
+
+func (s *InMemoryJobStore) GetDueOutboxMessages(ctx context.Context, now time.Time) ([]domain.OutboxMessage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	messages := make([]domain.OutboxMessage, 0)
+	for _, message := range s.outbox {
+		if !message.NextAttemptAt.After(now) {
+			messages = append(messages, message)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return messages, nil
+}
+
+func (s *InMemoryJobStore) UpdateOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	if _, ok := s.outbox[message.ID]; !ok {
+		return ErrOutboxMessageNotFound
+	}
+	s.putOutboxLocked(*message)
+
+	return s.journalErrLocked()
+}
+
+func (s *InMemoryJobStore) DeleteOutboxMessage(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journalErrLocked(); err != nil {
+		return err
+	}
+
+	if _, ok := s.outbox[id]; !ok {
+		return ErrOutboxMessageNotFound
+	}
+	s.deleteOutboxLocked(id)
+
+	return s.journalErrLocked()
+}
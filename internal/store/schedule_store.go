@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found in store")
+
+type ScheduleStore interface {
+	CreateSchedule(ctx context.Context, schedule *domain.Schedule) error
+	GetSchedule(ctx context.Context, scheduleID string) (*domain.Schedule, error)
+	GetSchedules(ctx context.Context) ([]domain.Schedule, error)
+	DeleteSchedule(ctx context.Context, scheduleID string) error
+	// SetRunTimes records that the schedule fired at lastRunAt and is next
+	// due at nextRunAt.
+	SetRunTimes(ctx context.Context, scheduleID string, lastRunAt, nextRunAt time.Time) error
+}
+
+type InMemoryScheduleStore struct {
+	schedules map[string]domain.Schedule
+	mu        sync.RWMutex
+}
+
+func NewInMemoryScheduleStore() *InMemoryScheduleStore {
+	return &InMemoryScheduleStore{
+		schedules: make(map[string]domain.Schedule),
+	}
+}
+
+func (s *InMemoryScheduleStore) CreateSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[schedule.ID] = *schedule
+
+	return nil
+}
+
+func (s *InMemoryScheduleStore) GetSchedule(ctx context.Context, scheduleID string) (*domain.Schedule, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedule, ok := s.schedules[scheduleID]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	return &schedule, nil
+}
+
+func (s *InMemoryScheduleStore) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedules := make([]domain.Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+func (s *InMemoryScheduleStore) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schedules[scheduleID]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(s.schedules, scheduleID)
+
+	return nil
+}
+
+func (s *InMemoryScheduleStore) SetRunTimes(ctx context.Context, scheduleID string, lastRunAt, nextRunAt time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[scheduleID]
+	if !ok {
+		return ErrScheduleNotFound
+	}
+
+	schedule.LastRunAt = &lastRunAt
+	schedule.NextRunAt = nextRunAt
+	s.schedules[scheduleID] = schedule
+
+	return nil
+}
@@ -1,65 +1,362 @@
 package store
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
 	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/leader"
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/ratelimiter"
 )
 
+// sweeperBackoff briefly waits out a full queue, short enough that a sweep
+// doesn't overrun its interval.
+var sweeperBackoff = queue.Backoff{
+	Initial:  50 * time.Millisecond,
+	Max:      time.Second,
+	Attempts: 5,
+}
+
 type Sweeper interface {
 	Run(ctx context.Context)
+	// Status reports how the sweeper is configured and what its last run
+	// did.
+	Status() SweeperStatus
+	// CatchUp enqueues due pending jobs now, outside the schedule.
+	CatchUp(ctx context.Context) SweepResult
+}
+
+// SweepResult is what one sweeper run did.
+type SweepResult struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Reaped    int
+	Expired   int
+	Retried   int
+	// Failed jobs due for a retry left failed because the retry budget ran
+	// out
+	RetriesDeferred int
+	Enqueued        int
+	// Due pending jobs left for the next run because the queue stayed full
+	SkippedFull int
+	// Due pending jobs left for the next run by BatchSize
+	Deferred int
+	// Err is set when the run stopped early
+	Err error
+}
+
+type SweeperStatus struct {
+	Schedule  SweeperSchedule
+	Runs      int
+	LastRun   *SweepResult
+	NextRunAt time.Time
 }
 
 type InMemorySweeper struct {
-	jobStore    JobStore
-	metricStore MetricStore
-	logger      *slog.Logger
-	interval    time.Duration
-	jobQueue    chan string
+	jobStore JobStore
+	events   *events.Bus
+	logger   *slog.Logger
+	schedule SweeperSchedule
+	jobQueue queue.Queue
+
+	stuckThresholds StuckThresholds
+	retryBudget     *ratelimiter.RetryBudget
+	// Only the leader sweeps
+	elector leader.Elector
+
+	mu        sync.Mutex
+	runs      int
+	lastRun   *SweepResult
+	nextRunAt time.Time
+}
+
+// SweeperSchedule says how often the sweeper runs and how much it enqueues
+// per run.
+type SweeperSchedule struct {
+	Interval time.Duration
+	// Each wait is Interval plus a random delay up to Jitter, so several
+	// instances drift apart instead of sweeping in lockstep
+	Jitter time.Duration
+	// BatchSize caps the pending jobs enqueued per run, highest priority
+	// and oldest first; zero means no cap
+	BatchSize int
 }
 
-func NewInMemorySweeper(jobStore JobStore, metricStore MetricStore, logger *slog.Logger, interval time.Duration, jobQueue chan string) *InMemorySweeper {
+// next returns how long to wait before the next run.
+func (s SweeperSchedule) next() time.Duration {
+	if s.Jitter <= 0 {
+		return s.Interval
+	}
+	return s.Interval + rand.N(s.Jitter+1)
+}
+
+func NewInMemorySweeper(jobStore JobStore, bus *events.Bus, logger *slog.Logger, schedule SweeperSchedule, jobQueue queue.Queue, stuckThresholds StuckThresholds, retryBudget *ratelimiter.RetryBudget, elector leader.Elector) *InMemorySweeper {
 	return &InMemorySweeper{
-		jobStore:    jobStore,
-		metricStore: metricStore,
-		logger:      logger,
-		interval:    interval,
-		jobQueue:    jobQueue,
+		jobStore:        jobStore,
+		events:          bus,
+		logger:          logger,
+		schedule:        schedule,
+		jobQueue:        jobQueue,
+		stuckThresholds: stuckThresholds,
+		retryBudget:     retryBudget,
+		elector:         elector,
 	}
 }
 
 func (s *InMemorySweeper) Run(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(s.scheduleNext())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Info("Sweeper shutting down", "event", "sweeper_stopped")
 			return
-		case <-ticker.C:
-			if err := s.jobStore.RetryFailedJobs(ctx, s.metricStore, s.logger); err != nil {
-				s.logger.Error("Sweeper error retrying failed jobs", "event", "sweeper_error", "error", err)
-				continue
+		case <-timer.C:
+			if s.elector.IsLeader() {
+				s.record(ctx, s.sweep(ctx))
 			}
+			timer.Reset(s.scheduleNext())
+		}
+	}
+}
 
-			jobs, err := s.jobStore.GetPendingJobs(ctx)
-			if err != nil {
-				s.logger.Error("Sweeper error getting pending jobs", "event", "sweeper_error", "error", err)
-				continue
-			}
+// scheduleNext picks the wait before the next run and notes when that is.
+func (s *InMemorySweeper) scheduleNext() time.Duration {
+	wait := s.schedule.next()
 
-			for _, job := range jobs {
-				select {
-				case <-ctx.Done():
-					s.logger.Info("Sweeper shutting down", "event", "sweeper_stopped")
-					return
-				case s.jobQueue <- job.ID:
-					s.logger.Info("Job enqueued by sweeper", "event", "job_enqueued", "job_id", job.ID)
-				default:
-					s.logger.Info("Job queue is full, job not added", "event", "job_enqueue_failed", "job_id", job.ID)
-				}
-			}
+	s.mu.Lock()
+	s.nextRunAt = time.Now().UTC().Add(wait)
+	s.mu.Unlock()
+
+	return wait
+}
+
+func (s *InMemorySweeper) Status() SweeperStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := SweeperStatus{
+		Schedule:  s.schedule,
+		Runs:      s.runs,
+		NextRunAt: s.nextRunAt,
+	}
+	if s.lastRun != nil {
+		lastRun := *s.lastRun
+		status.LastRun = &lastRun
+	}
+
+	return status
+}
+
+// record keeps result for Status and publishes it.
+func (s *InMemorySweeper) record(ctx context.Context, result SweepResult) {
+	s.mu.Lock()
+	s.runs++
+	s.lastRun = &result
+	s.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	s.events.Publish(ctx, events.Event{Type: events.SweepCompleted, Data: result})
+}
+
+// CatchUp enqueues due pending jobs straight away, highest priority and
+// oldest first, as a run would, for use after the queue resumes from a
+// pause.
+func (s *InMemorySweeper) CatchUp(ctx context.Context) SweepResult {
+	result := SweepResult{StartedAt: time.Now().UTC()}
+	s.enqueuePending(ctx, &result)
+	result.Duration = time.Since(result.StartedAt)
+
+	return result
+}
+
+func (s *InMemorySweeper) sweep(ctx context.Context) (result SweepResult) {
+	result.StartedAt = time.Now().UTC()
+	defer func() {
+		result.Duration = time.Since(result.StartedAt)
+	}()
+
+	result.Reaped = s.reapStuckJobs(ctx)
+	result.Expired = s.expireJobs(ctx)
+
+	// Retries beyond the budget wait for a later run, so a downstream outage
+	// isn't met with every failed job at once
+	retried, err := s.jobStore.RetryFailedJobs(ctx, func(job *domain.Job) bool {
+		if s.retryBudget.Allow(job.Type) {
+			return true
 		}
+		result.RetriesDeferred++
+		return false
+	})
+	if err != nil {
+		s.logger.Error("Sweeper error retrying failed jobs", "event", "sweeper_error", "error", err)
+		result.Err = err
+		return result
+	}
+	result.Retried = len(retried)
+	for _, jobID := range retried {
+		s.logger.Info("Job retried", "event", "job_retried", "job_id", jobID)
+		s.events.Publish(ctx, events.Event{Type: events.JobRetried, JobID: jobID})
+	}
+	if result.RetriesDeferred > 0 {
+		s.logger.Warn("Retry budget exhausted, deferring retries", "event", "retry_budget_exhausted", "retried", result.Retried, "deferred", result.RetriesDeferred)
+	}
+
+	s.enqueuePending(ctx, &result)
+
+	return result
+}
+
+// enqueuePending enqueues due pending jobs that have no wake-up queued yet,
+// at most BatchSize of them; the rest wait for the next run.
+func (s *InMemorySweeper) enqueuePending(ctx context.Context, result *SweepResult) {
+	jobs, err := s.jobStore.GetPendingJobs(ctx)
+	if err != nil {
+		s.logger.Error("Sweeper error getting pending jobs", "event", "sweeper_error", "error", err)
+		result.Err = err
+		return
+	}
+
+	now := time.Now().UTC()
+	jobs = slices.DeleteFunc(jobs, func(job domain.Job) bool {
+		// Each pending job needs only one wake-up in the queue
+		return !job.Due(now) || queue.Holds(s.jobQueue, job.ID)
+	})
+
+	// Whatever doesn't fit this run should be the least urgent
+	slices.SortFunc(jobs, func(a, b domain.Job) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(b.Priority, a.Priority)
+		}
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	if s.schedule.BatchSize > 0 && len(jobs) > s.schedule.BatchSize {
+		result.Deferred = len(jobs) - s.schedule.BatchSize
+		s.logger.Info("Sweeper batch full, deferring pending jobs", "event", "sweeper_batch_full", "batch_size", s.schedule.BatchSize, "deferred", result.Deferred)
+		jobs = jobs[:s.schedule.BatchSize]
+	}
+
+	for i, job := range jobs {
+		_, err := queue.EnqueueWithBackoff(ctx, s.jobQueue, job.ID, sweeperBackoff, nil)
+		switch {
+		case err == nil:
+			result.Enqueued++
+			s.logger.Info("Job enqueued by sweeper", "event", "job_enqueued", "job_id", job.ID)
+		case ctx.Err() != nil:
+			result.Err = ctx.Err()
+			return
+		case errors.Is(err, queue.ErrPaused):
+			// Pending jobs wait in the store for the catch-up on resume
+			s.logger.Debug("Job queue is paused, leaving pending jobs in the store", "event", "sweeper_paused", "pending", len(jobs)-i)
+			return
+		case errors.Is(err, queue.ErrFull):
+			// Workers aren't keeping up; the rest would only fail too
+			result.SkippedFull = len(jobs) - i
+			s.logger.Warn("Job queue stayed full, deferring the rest of the sweep", "event", "sweeper_backpressure", "deferred", result.SkippedFull)
+			return
+		default:
+			s.logger.Error("Sweeper error enqueueing job", "event", "job_enqueue_failed", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// StuckThresholds says how long a job may stay processing before the sweeper
+// reaps it. ByType overrides Default per job type.
+type StuckThresholds struct {
+	Default time.Duration
+	ByType  map[string]time.Duration
+}
+
+func (t StuckThresholds) For(jobType string) time.Duration {
+	if threshold, ok := t.ByType[jobType]; ok {
+		return threshold
+	}
+	return t.Default
+}
+
+// reapStuckJobs takes back jobs a hung handler has left in processing and
+// returns how many it reaped.
+func (s *InMemorySweeper) reapStuckJobs(ctx context.Context) int {
+	requeued, dead, quarantined, err := s.jobStore.ReapStuckJobs(ctx, s.stuckThresholds)
+	if err != nil {
+		s.logger.Error("Sweeper error reaping stuck jobs", "event", "sweeper_error", "error", err)
+		return 0
+	}
+
+	// Requeued jobs are pending again and get enqueued with the rest below.
+	// The JobReaped events carry the reaped attempt, so a handler still
+	// running it in this process is cancelled
+	for _, job := range requeued {
+		s.logger.Warn("Stuck job reaped and requeued", "event", "job_reaped", "job_id", job.ID, "attempt", job.Attempts)
+		s.events.Publish(ctx, events.ForJob(events.JobReaped, &job))
+	}
+
+	for _, job := range dead {
+		s.logger.Warn("Stuck job reaped with no retries left, moved to the dead-letter queue", "event", "job_reaped", "job_id", job.ID, "attempt", job.Attempts)
+		s.events.Publish(ctx, events.ForJob(events.JobReaped, &job))
+		s.events.Publish(ctx, events.Event{Type: events.JobDead, JobID: job.ID, Reason: "stuck"})
+
+		// Anything this unblocks is pending and gets enqueued below
+		_, failed, err := s.jobStore.ResolveDependents(ctx, job.ID)
+		if err != nil {
+			s.logger.Error("Sweeper error resolving dependent jobs", "event", "job_dependents_error", "job_id", job.ID, "error", err)
+			continue
+		}
+		s.publishDependentsFailed(ctx, failed)
+	}
+
+	for _, job := range quarantined {
+		s.logger.Warn("Stuck job reaped too many times in a row, quarantined", "event", "job_quarantined", "job_id", job.ID, "attempt", job.Attempts, "crashes", job.Crashes)
+		s.events.Publish(ctx, events.ForJob(events.JobReaped, &job))
+		event := events.ForJob(events.JobQuarantined, &job)
+		event.Reason = "stuck"
+		s.events.Publish(ctx, event)
+	}
+
+	return len(requeued) + len(dead) + len(quarantined)
+}
+
+// expireJobs gives up on waiting jobs whose expiry has passed and returns
+// how many expired.
+func (s *InMemorySweeper) expireJobs(ctx context.Context) int {
+	expired, err := s.jobStore.ExpireJobs(ctx)
+	if err != nil {
+		s.logger.Error("Sweeper error expiring jobs", "event", "sweeper_error", "error", err)
+		return 0
+	}
+
+	for _, jobID := range expired {
+		s.logger.Warn("Job expired before it started", "event", "job_expired", "job_id", jobID)
+		s.events.Publish(ctx, events.Event{Type: events.JobExpired, JobID: jobID})
+
+		// Anything this unblocks is pending and gets enqueued below
+		_, failed, err := s.jobStore.ResolveDependents(ctx, jobID)
+		if err != nil {
+			s.logger.Error("Sweeper error resolving dependent jobs", "event", "job_dependents_error", "job_id", jobID, "error", err)
+			continue
+		}
+		s.publishDependentsFailed(ctx, failed)
+	}
+
+	return len(expired)
+}
+
+// publishDependentsFailed publishes the dead-lettering of blocked jobs whose
+// dependency will never complete.
+func (s *InMemorySweeper) publishDependentsFailed(ctx context.Context, jobIDs []string) {
+	for _, jobID := range jobIDs {
+		s.events.Publish(ctx, events.Event{Type: events.JobDead, JobID: jobID, Reason: "dependency_failed"})
 	}
 }
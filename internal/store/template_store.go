@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrTemplateNotFound = errors.New("template not found in store")
+
+type TemplateStore interface {
+	// PutTemplate creates the template or replaces the one with its name,
+	// keeping the original CreatedAt.
+	PutTemplate(ctx context.Context, template *domain.Template) error
+	GetTemplate(ctx context.Context, name string) (*domain.Template, error)
+	GetTemplates(ctx context.Context) ([]domain.Template, error)
+	DeleteTemplate(ctx context.Context, name string) error
+}
+
+type InMemoryTemplateStore struct {
+	templates map[string]domain.Template
+	mu        sync.RWMutex
+}
+
+func NewInMemoryTemplateStore() *InMemoryTemplateStore {
+	return &InMemoryTemplateStore{
+		templates: make(map[string]domain.Template),
+	}
+}
+
+func (s *InMemoryTemplateStore) PutTemplate(ctx context.Context, template *domain.Template) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.templates[template.Name]; ok {
+		template.CreatedAt = existing.CreatedAt
+	}
+	s.templates[template.Name] = *template
+
+	return nil
+}
+
+func (s *InMemoryTemplateStore) GetTemplate(ctx context.Context, name string) (*domain.Template, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	template, ok := s.templates[name]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+
+	return &template, nil
+}
+
+func (s *InMemoryTemplateStore) GetTemplates(ctx context.Context) ([]domain.Template, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]domain.Template, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+func (s *InMemoryTemplateStore) DeleteTemplate(ctx context.Context, name string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[name]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(s.templates, name)
+
+	return nil
+}
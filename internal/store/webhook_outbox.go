@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found in outbox")
+
+// WebhookOutbox holds webhook deliveries from the moment an event is
+// published until the receiver accepts it or the dispatcher gives up.
+type WebhookOutbox interface {
+	// PutDelivery adds the delivery or records a change to it.
+	PutDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	DeleteDelivery(ctx context.Context, id string) error
+	// GetDueDeliveries returns the deliveries whose next attempt is due at
+	// now, oldest first.
+	GetDueDeliveries(ctx context.Context, now time.Time) ([]domain.WebhookDelivery, error)
+	// GetDeliveries returns the pending deliveries to a subscription, oldest
+	// first.
+	GetDeliveries(ctx context.Context, subscriptionID string) ([]domain.WebhookDelivery, error)
+	// DeleteDeliveries drops every pending delivery to a subscription.
+	DeleteDeliveries(ctx context.Context, subscriptionID string) error
+}
+
+// InMemoryWebhookOutbox keeps deliveries in memory and, when given a
+// directory, also as one file per delivery there, reloaded on start so
+// pending deliveries survive a restart.
+type InMemoryWebhookOutbox struct {
+	dir        string
+	deliveries map[string]domain.WebhookDelivery
+	mu         sync.RWMutex
+}
+
+// NewInMemoryWebhookOutbox loads the deliveries left in dir, creating it if
+// needed. An empty dir keeps deliveries in memory only.
+func NewInMemoryWebhookOutbox(dir string) (*InMemoryWebhookOutbox, error) {
+	o := &InMemoryWebhookOutbox{
+		dir:        dir,
+		deliveries: make(map[string]domain.WebhookDelivery),
+	}
+	if dir == "" {
+		return o, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var delivery domain.WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, fmt.Errorf("parse webhook delivery %s: %w", filepath.Base(path), err)
+		}
+		o.deliveries[delivery.ID] = delivery
+	}
+
+	return o, nil
+}
+
+func (o *InMemoryWebhookOutbox) PutDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.dir != "" {
+		data, err := json.Marshal(delivery)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(o.path(delivery.ID), data); err != nil {
+			return err
+		}
+	}
+	o.deliveries[delivery.ID] = *delivery
+
+	return nil
+}
+
+func (o *InMemoryWebhookOutbox) DeleteDelivery(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.deliveries[id]; !ok {
+		return ErrDeliveryNotFound
+	}
+
+	return o.delete(id)
+}
+
+func (o *InMemoryWebhookOutbox) GetDueDeliveries(ctx context.Context, now time.Time) ([]domain.WebhookDelivery, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.filter(func(delivery domain.WebhookDelivery) bool {
+		return !delivery.NextAttemptAt.After(now)
+	}), nil
+}
+
+func (o *InMemoryWebhookOutbox) GetDeliveries(ctx context.Context, subscriptionID string) ([]domain.WebhookDelivery, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.filter(func(delivery domain.WebhookDelivery) bool {
+		return delivery.SubscriptionID == subscriptionID
+	}), nil
+}
+
+func (o *InMemoryWebhookOutbox) DeleteDeliveries(ctx context.Context, subscriptionID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var errs []error
+	for id, delivery := range o.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			errs = append(errs, o.delete(id))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// filter returns the deliveries keep accepts, oldest first. The caller holds
+// o.mu.
+func (o *InMemoryWebhookOutbox) filter(keep func(domain.WebhookDelivery) bool) []domain.WebhookDelivery {
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, delivery := range o.deliveries {
+		if keep(delivery) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+
+	return deliveries
+}
+
+// delete removes a delivery and its file. The caller holds o.mu.
+func (o *InMemoryWebhookOutbox) delete(id string) error {
+	if o.dir != "" {
+		if err := os.Remove(o.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	delete(o.deliveries, id)
+
+	return nil
+}
+
+func (o *InMemoryWebhookOutbox) path(id string) string {
+	// IDs are UUIDs; anything else is kept inside dir regardless
+	return filepath.Join(o.dir, strings.ReplaceAll(id, string(filepath.Separator), "_")+".json")
+}
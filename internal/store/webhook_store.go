@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrWebhookNotFound = errors.New("webhook subscription not found in store")
+
+type WebhookStore interface {
+	// PutWebhook creates the subscription or replaces the one with its ID.
+	PutWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error
+	GetWebhook(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+	GetWebhooks(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+// InMemoryWebhookStore keeps webhook subscriptions in memory and, when given
+// a path, writes them to that file on every change so subscriptions made
+// through the API survive a restart.
+type InMemoryWebhookStore struct {
+	path          string
+	subscriptions map[string]domain.WebhookSubscription
+	mu            sync.RWMutex
+}
+
+// NewInMemoryWebhookStore loads the subscriptions saved at path, if any. An
+// empty path keeps them in memory only.
+func NewInMemoryWebhookStore(path string) (*InMemoryWebhookStore, error) {
+	s := &InMemoryWebhookStore{
+		path:          path,
+		subscriptions: make(map[string]domain.WebhookSubscription),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var subscriptions []domain.WebhookSubscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, err
+	}
+	for _, subscription := range subscriptions {
+		s.subscriptions[subscription.ID] = subscription
+	}
+
+	return s, nil
+}
+
+func (s *InMemoryWebhookStore) PutWebhook(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions[subscription.ID] = *subscription
+
+	return s.save()
+}
+
+func (s *InMemoryWebhookStore) GetWebhook(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscription, ok := s.subscriptions[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+
+	return &subscription, nil
+}
+
+func (s *InMemoryWebhookStore) GetWebhooks(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted(), nil
+}
+
+func (s *InMemoryWebhookStore) DeleteWebhook(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(s.subscriptions, id)
+
+	return s.save()
+}
+
+// sorted returns the subscriptions oldest first. The caller holds s.mu.
+func (s *InMemoryWebhookStore) sorted() []domain.WebhookSubscription {
+	subscriptions := make([]domain.WebhookSubscription, 0, len(s.subscriptions))
+	for _, subscription := range s.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions
+}
+
+// save writes every subscription to s.path, replacing the file atomically so
+// a crash mid-write leaves the previous version. The caller holds s.mu.
+func (s *InMemoryWebhookStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.sorted())
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes data to a temporary file beside path, syncs it and
+// renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+var ErrWorkflowNotFound = errors.New("workflow not found in store")
+
+type WorkflowStore interface {
+	CreateWorkflow(ctx context.Context, workflow *domain.Workflow) error
+	GetWorkflow(ctx context.Context, workflowID string) (*domain.Workflow, error)
+	GetWorkflows(ctx context.Context) ([]domain.Workflow, error)
+}
+
+type InMemoryWorkflowStore struct {
+	workflows map[string]domain.Workflow
+	mu        sync.RWMutex
+}
+
+func NewInMemoryWorkflowStore() *InMemoryWorkflowStore {
+	return &InMemoryWorkflowStore{
+		workflows: make(map[string]domain.Workflow),
+	}
+}
+
+func (s *InMemoryWorkflowStore) CreateWorkflow(ctx context.Context, workflow *domain.Workflow) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workflows[workflow.ID] = *workflow
+
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) GetWorkflow(ctx context.Context, workflowID string) (*domain.Workflow, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflow, ok := s.workflows[workflowID]
+	if !ok {
+		return nil, ErrWorkflowNotFound
+	}
+
+	return &workflow, nil
+}
+
+func (s *InMemoryWorkflowStore) GetWorkflows(ctx context.Context) ([]domain.Workflow, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflows := make([]domain.Workflow, 0, len(s.workflows))
+	for _, workflow := range s.workflows {
+		workflows = append(workflows, workflow)
+	}
+
+	return workflows, nil
+}
@@ -0,0 +1,120 @@
+// Package tracing sets up OpenTelemetry tracing and carries trace context
+// through the store, so the span of the request that submitted a job is the
+// parent of the spans of the worker that processes it.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"strings"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultServiceName names the service unless OTEL_SERVICE_NAME does.
+const defaultServiceName = "workstream"
+
+// Enabled reports whether an OTLP endpoint is configured through the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT variables.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a tracer provider exporting spans over OTLP/HTTP and the W3C
+// trace context propagator. The exporter, sampler and resource are configured
+// through the standard OTEL_* environment variables. The returned function
+// flushes and stops the exporter.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME take
+	// precedence over the default service name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetupPropagation installs the W3C trace context propagator. It is needed
+// even when no spans are exported, so a submitted job keeps the trace of the
+// producer that sent it.
+func SetupPropagation() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// InjectJob records the trace context of ctx on job, to be stored with it.
+// A job submitted outside any trace starts a new one, so every job has a
+// trace ID to correlate its logs and spans by.
+func InjectJob(ctx context.Context, job *domain.Job) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, newSpanContext())
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	job.TraceParent = carrier.Get("traceparent")
+	job.TraceState = carrier.Get("tracestate")
+}
+
+// JobContext returns ctx carrying the trace context stored on job, so spans
+// started from it continue the trace of the request that submitted the job.
+func JobContext(ctx context.Context, job *domain.Job) context.Context {
+	if job.TraceParent == "" {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{"traceparent": job.TraceParent}
+	if job.TraceState != "" {
+		carrier["tracestate"] = job.TraceState
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// TraceID returns the ID of the trace stored on job, or "" if it has none.
+func TraceID(job *domain.Job) string {
+	// traceparent is version-traceid-parentid-flags
+	fields := strings.Split(job.TraceParent, "-")
+	if len(fields) != 4 {
+		return ""
+	}
+
+	traceID, err := trace.TraceIDFromHex(fields[1])
+	if err != nil {
+		return ""
+	}
+	return traceID.String()
+}
+
+func newSpanContext() trace.SpanContext {
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	rand.Read(traceID[:])
+	rand.Read(spanID[:])
+
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+}
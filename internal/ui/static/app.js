@@ -0,0 +1,165 @@
+"use strict";
+
+const refreshInterval = 3000;
+let filters = new URLSearchParams();
+
+async function fetchJSON(url, options) {
+  const res = await fetch(url, options);
+  const body = await res.json();
+  if (!res.ok) {
+    throw new Error((body.error && body.error.message) || res.statusText);
+  }
+  return body.data;
+}
+
+function setText(id, value) {
+  document.getElementById(id).textContent = value;
+}
+
+async function loadMetrics() {
+  const m = await fetchJSON("/metrics.json");
+  setText("queue-depth", `${m.queue_depth} / ${m.queue_capacity}`);
+  setText("in-progress", m.jobs_in_progress);
+  setText("completed", m.jobs_completed);
+  setText("failed", m.jobs_failed);
+  setText("workers", m.worker_count);
+}
+
+const chartSeries = [
+  ["created", "#3366cc"],
+  ["completed", "#1b7f3a"],
+  ["failed", "#b00020"],
+];
+
+// renderChart draws jobs per minute from /metrics/timeseries as one line
+// per series, scaled to the busiest minute.
+function renderChart(timeseries) {
+  const canvas = document.getElementById("chart");
+  const ctx = canvas.getContext("2d");
+  const points = timeseries.points;
+  ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+  let peak = 1;
+  for (const point of points) {
+    peak = Math.max(peak, point.created, point.completed, point.failed);
+  }
+  setText("chart-peak", `peak ${peak}/min`);
+
+  const pad = 4;
+  const step = (canvas.width - 2 * pad) / Math.max(points.length - 1, 1);
+  const y = (value) => canvas.height - pad - (value / peak) * (canvas.height - 2 * pad);
+  ctx.lineWidth = 2;
+  for (const [key, color] of chartSeries) {
+    ctx.strokeStyle = color;
+    ctx.beginPath();
+    points.forEach((point, i) => {
+      const x = pad + i * step;
+      if (i === 0) {
+        ctx.moveTo(x, y(point[key]));
+      } else {
+        ctx.lineTo(x, y(point[key]));
+      }
+    });
+    ctx.stroke();
+  }
+}
+
+function renderThroughput(jobs) {
+  const byType = new Map();
+  for (const job of jobs) {
+    const row = byType.get(job.type) || { completed: 0, failed: 0, pending: 0, processing: 0 };
+    if (job.status in row) {
+      row[job.status]++;
+    }
+    byType.set(job.type, row);
+  }
+
+  const tbody = document.getElementById("throughput");
+  tbody.replaceChildren();
+  for (const [type, row] of [...byType.entries()].sort()) {
+    const tr = document.createElement("tr");
+    for (const value of [type, row.completed, row.failed, row.pending, row.processing]) {
+      const td = document.createElement("td");
+      td.textContent = value;
+      tr.appendChild(td);
+    }
+    tbody.appendChild(tr);
+  }
+}
+
+function actionButton(label, job, action) {
+  const button = document.createElement("button");
+  button.textContent = label;
+  button.addEventListener("click", async () => {
+    try {
+      await fetchJSON(`/jobs/${job.id}/${action}`, { method: "POST" });
+      await refresh();
+    } catch (err) {
+      alert(`${label} failed: ${err.message}`);
+    }
+  });
+  return button;
+}
+
+function renderJobs(jobs) {
+  const tbody = document.getElementById("jobs");
+  tbody.replaceChildren();
+  for (const job of jobs.slice().reverse()) {
+    const tr = document.createElement("tr");
+
+    const cells = [
+      ["id", job.id],
+      ["", job.type],
+      [`status-${job.status}`, job.status],
+      ["", new Date(job.created_at).toLocaleString()],
+    ];
+    for (const [className, value] of cells) {
+      const td = document.createElement("td");
+      td.className = className;
+      td.textContent = value;
+      tr.appendChild(td);
+    }
+
+    const actions = document.createElement("td");
+    if (job.status === "failed") {
+      actions.appendChild(actionButton("Retry", job, "retry"));
+    }
+    if (job.status === "pending" || job.status === "failed") {
+      actions.appendChild(actionButton("Cancel", job, "cancel"));
+    }
+    tr.appendChild(actions);
+
+    tbody.appendChild(tr);
+  }
+}
+
+async function refresh() {
+  try {
+    const [, timeseries, allJobs, filteredJobs] = await Promise.all([
+      loadMetrics(),
+      fetchJSON("/metrics/timeseries?window=1h"),
+      fetchJSON("/jobs"),
+      fetchJSON(`/jobs?${filters}`),
+    ]);
+    renderChart(timeseries);
+    renderThroughput(allJobs);
+    renderJobs(filteredJobs);
+    setText("updated", `updated ${new Date().toLocaleTimeString()}`);
+  } catch (err) {
+    setText("updated", `error: ${err.message}`);
+  }
+}
+
+document.getElementById("filters").addEventListener("submit", (event) => {
+  event.preventDefault();
+  filters = new URLSearchParams();
+  for (const [key, value] of new FormData(event.target)) {
+    if (value) {
+      filters.set(key, value);
+    }
+  }
+  refresh();
+});
+
+refresh();
+setInterval(refresh, refreshInterval);
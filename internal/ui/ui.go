@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Handler serves the embedded dashboard. It is mounted under /ui/ and talks to
+// the JSON API from the browser, so it has no server-side dependencies.
+func Handler() http.Handler {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The embedded directory is fixed at compile time
+		panic(err)
+	}
+
+	return http.StripPrefix("/ui/", http.FileServerFS(static))
+}
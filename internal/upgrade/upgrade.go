@@ -0,0 +1,271 @@
+// Package upgrade restarts the server without dropping connections. The
+// running process starts a new copy of its executable and hands it the
+// listening sockets as inherited file descriptors; once the new process says
+// it is serving, the old one stops accepting and shuts down as usual,
+// draining its workers. Connections waiting to be accepted stay queued on the
+// shared socket, so none are refused in between.
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listenersEnv names the inherited listeners, in the order of their file
+// descriptors after the readiness pipe.
+const listenersEnv = "WORKSTREAM_UPGRADE_LISTENERS"
+
+// readyFD is the write end of the pipe the new process reports readiness on;
+// the listeners follow it.
+const readyFD = 3
+
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+// Upgrader hands listeners from the running process to its replacement.
+type Upgrader struct {
+	pidFile string
+
+	mu sync.Mutex
+	// Listeners handed over by the previous process, not yet taken by Listen
+	inherited map[string]*os.File
+	// Write end of the previous process's readiness pipe; nil when not
+	// started by an upgrade or once readiness was reported
+	ready *os.File
+	// Listeners to hand to the next process, by name in opening order
+	names     []string
+	listeners map[string]net.Listener
+	upgrading bool
+}
+
+// New returns an Upgrader, taking over the listeners of the process that
+// started this one if it was started by an upgrade. The process ID is written
+// to pidFile, when set, once the process is ready, so supervisors can follow
+// the process that now serves.
+func New(pidFile string) *Upgrader {
+	u := &Upgrader{
+		pidFile:   pidFile,
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+	}
+
+	value, ok := os.LookupEnv(listenersEnv)
+	if !ok {
+		return u
+	}
+	// Not passed on to processes this one starts
+	os.Unsetenv(listenersEnv)
+
+	u.ready = os.NewFile(readyFD, "upgrade-ready")
+	if value != "" {
+		for i, name := range strings.Split(value, ",") {
+			u.inherited[name] = os.NewFile(uintptr(readyFD+1+i), name)
+		}
+	}
+
+	return u
+}
+
+// Inherited reports whether the process was started by an upgrade.
+func (u *Upgrader) Inherited() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.ready != nil
+}
+
+// Listen returns the TCP listener called name, taken over from the previous
+// process when it had one, or listening on addr otherwise. A listener that
+// moved to another address keeps the old one until a full restart.
+func (u *Upgrader) Listen(name string, addr string) (net.Listener, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var listener net.Listener
+	if file, ok := u.inherited[name]; ok {
+		delete(u.inherited, name)
+		inherited, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherit %s listener: %w", name, err)
+		}
+		listener = inherited
+	} else {
+		listening, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		listener = listening
+	}
+
+	u.names = append(u.names, name)
+	u.listeners[name] = listener
+	return listener, nil
+}
+
+// Ready reports that the process is serving. The previous process, if any,
+// then stops accepting and shuts down. Listeners it handed over that this
+// process didn't take are closed.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for name, file := range u.inherited {
+		file.Close()
+		delete(u.inherited, name)
+	}
+
+	if u.pidFile != "" {
+		if err := writePIDFile(u.pidFile); err != nil {
+			return fmt.Errorf("write pid file: %w", err)
+		}
+	}
+
+	if u.ready == nil {
+		return nil
+	}
+	_, err := u.ready.Write([]byte{1})
+	u.ready.Close()
+	u.ready = nil
+	if err != nil {
+		return fmt.Errorf("report readiness: %w", err)
+	}
+	return nil
+}
+
+// Upgrade starts the executable again with the same arguments and
+// environment, hands it the listeners, and waits up to timeout for it to be
+// ready. It returns the new process's ID; the caller then shuts down. If the
+// new process exits or isn't ready in time, it is killed and this process
+// carries on serving.
+func (u *Upgrader) Upgrade(timeout time.Duration) (int, error) {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return 0, ErrUpgradeInProgress
+	}
+	u.upgrading = true
+	u.mu.Unlock()
+
+	pid, err := u.upgrade(timeout)
+
+	u.mu.Lock()
+	// After a handover this process is on its way out; a second upgrade
+	// would start another copy competing with the first
+	u.upgrading = err == nil
+	u.mu.Unlock()
+
+	return pid, err
+}
+
+func (u *Upgrader) upgrade(timeout time.Duration) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("find executable: %w", err)
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("create readiness pipe: %w", err)
+	}
+	defer readyReader.Close()
+
+	// The child gets duplicates; ours are closed once it has started
+	files := []*os.File{readyWriter}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	u.mu.Lock()
+	names := append([]string(nil), u.names...)
+	for _, name := range names {
+		fileListener, ok := u.listeners[name].(interface{ File() (*os.File, error) })
+		if !ok {
+			u.mu.Unlock()
+			return 0, fmt.Errorf("%s listener can't be handed over", name)
+		}
+		file, err := fileListener.File()
+		if err != nil {
+			u.mu.Unlock()
+			return 0, fmt.Errorf("duplicate %s listener: %w", name, err)
+		}
+		files = append(files, file)
+	}
+	u.mu.Unlock()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), listenersEnv+"="+strings.Join(names, ","))
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("start new process: %w", err)
+	}
+
+	// Only the child holds the write end now, so the read ends with EOF if
+	// it exits before reporting readiness
+	readyWriter.Close()
+	files = files[1:]
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyReader.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-ready:
+		if err == nil {
+			// Reap the child if it ever exits while this process still runs
+			go cmd.Wait()
+			return cmd.Process.Pid, nil
+		}
+		if errors.Is(err, io.EOF) {
+			err = errors.New("exited before it was ready")
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, fmt.Errorf("new process %d: %w", cmd.Process.Pid, err)
+	case <-timer.C:
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, fmt.Errorf("new process %d was not ready within %s", cmd.Process.Pid, timeout)
+	}
+}
+
+// writePIDFile replaces path with the process ID in one rename, so readers
+// never see it half written.
+func writePIDFile(path string) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
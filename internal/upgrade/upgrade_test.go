@@ -0,0 +1,128 @@
+package upgrade
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// childEnv tells a copy of the test binary started by Upgrade how to behave
+// as the new process; childPIDFileEnv is the PID file it writes when ready.
+const (
+	childEnv        = "UPGRADE_TEST_CHILD"
+	childPIDFileEnv = "UPGRADE_TEST_PID_FILE"
+)
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(childEnv); mode != "" {
+		runChild(mode)
+	}
+	os.Exit(m.Run())
+}
+
+// runChild plays the new process: "serve" takes over the listener, reports
+// readiness and answers one connection, "exit" exits before it is ready and
+// "hang" never gets ready.
+func runChild(mode string) {
+	u := New(os.Getenv(childPIDFileEnv))
+
+	switch mode {
+	case "exit":
+		os.Exit(1)
+	case "hang":
+		time.Sleep(time.Minute)
+		os.Exit(1)
+	}
+
+	listener, err := u.Listen("http", "127.0.0.1:0")
+	if err != nil {
+		os.Exit(2)
+	}
+	if !u.Inherited() {
+		os.Exit(3)
+	}
+	if err := u.Ready(); err != nil {
+		os.Exit(4)
+	}
+
+	conn, err := listener.Accept()
+	if err == nil {
+		conn.Write([]byte("new\n"))
+		conn.Close()
+	}
+	os.Exit(0)
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "new process takes over the listener", mode: "serve", timeout: 10 * time.Second},
+		{name: "new process exits before it is ready", mode: "exit", timeout: 10 * time.Second, wantErr: true},
+		{name: "new process is not ready in time", mode: "hang", timeout: 200 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pidFile := filepath.Join(t.TempDir(), "server.pid")
+			t.Setenv(childEnv, tt.mode)
+			t.Setenv(childPIDFileEnv, pidFile)
+
+			u := New("")
+			listener, err := u.Listen("http", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen: %v", err)
+			}
+			defer listener.Close()
+
+			pid, err := u.Upgrade(tt.timeout)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Upgrade started process %d, want an error", pid)
+				}
+				// This process carries on serving, and may try again
+				if _, err := u.Upgrade(0); errors.Is(err, ErrUpgradeInProgress) {
+					t.Fatal("a failed upgrade blocks the next one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upgrade: %v", err)
+			}
+
+			data, err := os.ReadFile(pidFile)
+			if err != nil {
+				t.Fatalf("read PID file: %v", err)
+			}
+			if got := strings.TrimSpace(string(data)); got != strconv.Itoa(pid) {
+				t.Fatalf("PID file holds %s, want %d", got, pid)
+			}
+
+			if _, err := u.Upgrade(tt.timeout); !errors.Is(err, ErrUpgradeInProgress) {
+				t.Fatalf("second Upgrade = %v, want ErrUpgradeInProgress", err)
+			}
+
+			// The old process stops accepting; the same socket now reaches
+			// the new one
+			conn, err := net.DialTimeout("tcp", listener.Addr().String(), 5*time.Second)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil || line != "new\n" {
+				t.Fatalf("read %q, %v, want the new process's answer", line, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package version
+
+import "runtime"
+
+// These are overridden at build time, e.g.:
+//
+//	go build -ldflags "-X github.com/karprabha/job-queue-backend/internal/version.Version=v1.2.0 \
+//	  -X github.com/karprabha/job-queue-backend/internal/version.GitSHA=$(git rev-parse HEAD) \
+//	  -X github.com/karprabha/job-queue-backend/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	GitSHA    = "unknown"
+	BuildDate = "unknown"
+)
+
+type Info struct {
+	Version   string
+	GitSHA    string
+	BuildDate string
+	GoVersion string
+}
+
+func Get() Info {
+	return Info{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
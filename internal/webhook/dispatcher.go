@@ -0,0 +1,294 @@
+// Package webhook delivers the events published on the event bus to the
+// webhook subscriptions in the store. Each matching event becomes a delivery
+// in the outbox before anything is sent, and stays there until the receiver
+// accepts it or every attempt has failed, so a restart or a receiver being
+// down loses nothing. Delivery is at least once and not ordered.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// Headers set on every delivery. SignatureHeader carries the hex HMAC-SHA256
+// of the body, prefixed with "sha256=", as for job callbacks.
+const (
+	SignatureHeader      = "X-Signature-256"
+	SubscriptionIDHeader = "X-Webhook-ID"
+	DeliveryIDHeader     = "X-Webhook-Delivery"
+	EventTypeHeader      = "X-Webhook-Event"
+)
+
+// requestTimeout bounds each delivery attempt.
+const requestTimeout = 10 * time.Second
+
+// retryBaseDelay is the delay before the second attempt, doubling for each
+// attempt after it.
+const retryBaseDelay = time.Second
+
+// Options tune a Dispatcher.
+type Options struct {
+	// Deliveries sent at once
+	Concurrency int
+	// Attempts before a delivery is abandoned
+	MaxAttempts int
+	// How often the outbox is checked for retries that have come due
+	PollInterval time.Duration
+}
+
+// Dispatcher turns events into deliveries and sends them. Handle only writes
+// the outbox; Run does the sending from its own goroutines, so a slow
+// receiver never holds up the publisher.
+type Dispatcher struct {
+	webhookStore store.WebhookStore
+	outbox       store.WebhookOutbox
+	logger       *slog.Logger
+	options      Options
+	client       *http.Client
+
+	wake chan struct{}
+
+	mu sync.Mutex
+	// Deliveries being sent, so the next poll doesn't send them again
+	inflight map[string]bool
+}
+
+func NewDispatcher(webhookStore store.WebhookStore, outbox store.WebhookOutbox, logger *slog.Logger, options Options) *Dispatcher {
+	return &Dispatcher{
+		webhookStore: webhookStore,
+		outbox:       outbox,
+		logger:       logger,
+		options:      options,
+		client:       &http.Client{Timeout: requestTimeout},
+		wake:         make(chan struct{}, 1),
+		inflight:     make(map[string]bool),
+	}
+}
+
+// Handle queues a delivery of event to every subscription it matches.
+func (d *Dispatcher) Handle(ctx context.Context, event events.Event) {
+	// The event happened whatever becomes of the request that caused it
+	ctx = context.WithoutCancel(ctx)
+
+	subscriptions, err := d.webhookStore.GetWebhooks(ctx)
+	if err != nil {
+		d.logger.Error("Failed to list webhook subscriptions", "event", "webhook_list_failed", "error", err)
+		return
+	}
+
+	var body []byte
+	queued := false
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if !subscription.Matches(string(event.Type), event.JobType) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = json.Marshal(event); err != nil {
+				d.logger.Error("Failed to encode event for webhooks", "event", "webhook_encode_failed", "event_type", event.Type, "error", err)
+				return
+			}
+		}
+
+		delivery := domain.NewWebhookDelivery(subscription, string(event.Type), body, Sign(subscription.Secret, body))
+		if err := d.outbox.PutDelivery(ctx, delivery); err != nil {
+			d.logger.Error("Failed to queue webhook delivery", "event", "webhook_queue_failed", "webhook_id", subscription.ID, "event_type", event.Type, "error", err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Forget drops the deliveries still pending for a deleted subscription.
+// Attempts already in flight finish.
+func (d *Dispatcher) Forget(ctx context.Context, subscriptionID string) error {
+	return d.outbox.DeleteDeliveries(ctx, subscriptionID)
+}
+
+// Run sends deliveries as they are queued and retries failed ones as they
+// come due, until ctx is cancelled. It returns once the attempts in flight
+// have finished; deliveries left in the outbox are sent on the next start.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, d.options.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher shutting down", "event", "webhook_dispatcher_stopped")
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		deliveries, err := d.outbox.GetDueDeliveries(ctx, time.Now().UTC())
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Error("Failed to read webhook outbox", "event", "webhook_outbox_failed", "error", err)
+			}
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			if !d.claim(delivery.ID) {
+				continue
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				d.release(delivery.ID)
+				d.logger.Info("Webhook dispatcher shutting down", "event", "webhook_dispatcher_stopped")
+				return
+			}
+
+			wg.Go(func() {
+				defer func() { <-slots }()
+				defer d.release(delivery.ID)
+				d.attempt(ctx, &delivery)
+			})
+		}
+	}
+}
+
+func (d *Dispatcher) claim(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inflight[id] {
+		return false
+	}
+	d.inflight[id] = true
+	return true
+}
+
+func (d *Dispatcher) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inflight, id)
+}
+
+// attempt sends delivery once and records the outcome in the outbox.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *domain.WebhookDelivery) {
+	sendErr := d.send(ctx, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// Interrupted by shutdown; the attempt doesn't count
+		return
+	}
+
+	// The outcome is recorded even as shutdown begins
+	ctx = context.WithoutCancel(ctx)
+
+	if sendErr == nil {
+		if err := d.outbox.DeleteDelivery(ctx, delivery.ID); err != nil && !errors.Is(err, store.ErrDeliveryNotFound) {
+			d.logger.Error("Failed to remove webhook delivery", "event", "webhook_outbox_failed", "delivery_id", delivery.ID, "error", err)
+		}
+		d.logger.Debug("Webhook delivered", "event", "webhook_delivered", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "event_type", delivery.EventType, "attempt", delivery.Attempts+1)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = sendErr.Error()
+
+	if delivery.Attempts >= d.options.MaxAttempts {
+		if err := d.outbox.DeleteDelivery(ctx, delivery.ID); err != nil && !errors.Is(err, store.ErrDeliveryNotFound) {
+			d.logger.Error("Failed to remove webhook delivery", "event", "webhook_outbox_failed", "delivery_id", delivery.ID, "error", err)
+		}
+		d.logger.Error("Webhook delivery abandoned", "event", "webhook_delivery_abandoned", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "event_type", delivery.EventType, "attempts", delivery.Attempts, "error", sendErr)
+		return
+	}
+
+	delay := domain.RetryDelay(delivery.Attempts, retryBaseDelay)
+	delivery.NextAttemptAt = time.Now().UTC().Add(delay)
+
+	// A delivery forgotten while in flight stays forgotten
+	if !d.pending(ctx, delivery) {
+		return
+	}
+	if err := d.outbox.PutDelivery(ctx, delivery); err != nil {
+		d.logger.Error("Failed to reschedule webhook delivery", "event", "webhook_outbox_failed", "delivery_id", delivery.ID, "error", err)
+		return
+	}
+	d.logger.Warn("Webhook delivery failed, will retry", "event", "webhook_delivery_failed", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "event_type", delivery.EventType, "attempt", delivery.Attempts, "retry_in", delay, "error", sendErr)
+}
+
+// pending reports whether delivery is still in the outbox.
+func (d *Dispatcher) pending(ctx context.Context, delivery *domain.WebhookDelivery) bool {
+	deliveries, err := d.outbox.GetDeliveries(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return true
+	}
+	for _, pending := range deliveries {
+		if pending.ID == delivery.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SubscriptionIDHeader, delivery.SubscriptionID)
+	request.Header.Set(DeliveryIDHeader, delivery.ID)
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+	if delivery.Signature != "" {
+		request.Header.Set(SignatureHeader, delivery.Signature)
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the SignatureHeader value for body under secret, or "" when
+// secret is empty.
+func Sign(secret string, body []byte) string {
+	if secret == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/queue"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// AutoscalerConfig bounds the pool and sets how eagerly it scales.
+type AutoscalerConfig struct {
+	MinWorkers int
+	MaxWorkers int
+	Interval   time.Duration
+	// MaxLatency is how long a due job may wait for a worker before the pool
+	// grows even though the queue is short
+	MaxLatency time.Duration
+	// Cooldown is how long the pool must stay idle before it shrinks
+	Cooldown time.Duration
+}
+
+// Autoscaler resizes the pool between MinWorkers and MaxWorkers. It doubles
+// the pool while jobs back up (more queued than workers, or the oldest due
+// job waiting longer than MaxLatency) and removes one worker at a time once
+// the queue has stayed empty with idle workers for Cooldown.
+type Autoscaler struct {
+	pool        *Pool
+	jobStore    store.JobStore
+	metricStore store.MetricStore
+	gate        *Gate
+	jobQueue    queue.Queue
+	logger      *slog.Logger
+	config      AutoscalerConfig
+
+	idleSince time.Time
+}
+
+func NewAutoscaler(pool *Pool, jobStore store.JobStore, metricStore store.MetricStore, gate *Gate, jobQueue queue.Queue, logger *slog.Logger, config AutoscalerConfig) *Autoscaler {
+	return &Autoscaler{
+		pool:        pool,
+		jobStore:    jobStore,
+		metricStore: metricStore,
+		gate:        gate,
+		jobQueue:    jobQueue,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.logger.Info("Autoscaler shutting down", "event", "autoscaler_stopped")
+			return
+		case <-ticker.C:
+			a.scale(ctx)
+		}
+	}
+}
+
+func (a *Autoscaler) scale(ctx context.Context) {
+	// Jobs pile up on purpose while paused; more workers would not help
+	if a.gate.Paused() {
+		a.idleSince = time.Time{}
+		return
+	}
+
+	size := a.pool.Size()
+	depth := a.jobQueue.Len()
+
+	latency, err := a.oldestWait(ctx)
+	if err != nil {
+		a.logger.Error("Autoscaler error getting pending jobs", "event", "autoscaler_error", "error", err)
+		return
+	}
+
+	if depth > size || latency > a.config.MaxLatency {
+		a.idleSince = time.Time{}
+		target := min(a.config.MaxWorkers, max(size*2, size+1))
+		if target > size {
+			a.resize(ctx, size, target, "up", depth, latency)
+		}
+		return
+	}
+
+	metrics, err := a.metricStore.GetMetrics(ctx)
+	if err != nil {
+		a.logger.Error("Autoscaler error getting metrics", "event", "autoscaler_error", "error", err)
+		return
+	}
+
+	if depth > 0 || metrics.JobsInProgress >= size {
+		a.idleSince = time.Time{}
+		return
+	}
+
+	now := time.Now()
+	if a.idleSince.IsZero() {
+		a.idleSince = now
+		return
+	}
+
+	if now.Sub(a.idleSince) >= a.config.Cooldown && size > a.config.MinWorkers {
+		// Shrink gradually; the cooldown restarts after each step
+		a.idleSince = now
+		a.resize(ctx, size, size-1, "down", depth, latency)
+	}
+}
+
+func (a *Autoscaler) resize(ctx context.Context, from, to int, direction string, depth int, latency time.Duration) {
+	a.pool.Resize(to)
+	a.logger.Info("Worker pool autoscaled", "event", "worker_pool_autoscaled", "direction", direction, "from", from, "to", to, "queue_depth", depth, "latency", latency)
+
+	if err := a.metricStore.IncrementWorkerScaleEvents(ctx, direction); err != nil {
+		a.logger.Error("Autoscaler error incrementing scale events", "event", "metric_error", "error", err)
+	}
+}
+
+// oldestWait returns how long the longest-waiting due pending job has been
+// waiting for a worker.
+func (a *Autoscaler) oldestWait(ctx context.Context) (time.Duration, error) {
+	jobs, err := a.jobStore.GetPendingJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var oldest time.Duration
+	for _, job := range jobs {
+		if !job.Due(now) {
+			continue
+		}
+
+		// A job becomes pending (and bumps UpdatedAt) when it is created or
+		// retried; scheduled jobs only start waiting at RunAt
+		waitingSince := job.UpdatedAt
+		if job.RunAt != nil && job.RunAt.After(waitingSince) {
+			waitingSince = *job.RunAt
+		}
+		oldest = max(oldest, now.Sub(waitingSince))
+	}
+
+	return oldest, nil
+}
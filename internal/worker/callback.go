@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// CallbackSignatureHeader carries the hex HMAC-SHA256 of the callback
+// request's timestamp, a dot and its body, prefixed with "sha256=".
+const CallbackSignatureHeader = "X-Signature-256"
+
+// CallbackTimestampHeader carries the Unix time, in seconds, the callback
+// request was signed at. Receivers should reject requests whose timestamp is
+// more than CallbackSignatureTolerance away from their clock, so a captured
+// request can't be replayed later.
+const CallbackTimestampHeader = "X-Signature-Timestamp"
+
+// CallbackSignatureTolerance is how far a callback request's timestamp may
+// be from the receiver's clock for it to be accepted.
+const CallbackSignatureTolerance = 5 * time.Minute
+
+// maxCallbackResponseBytes caps how much of a callback response is read and
+// kept as the job's result.
+const maxCallbackResponseBytes = 1 << 20
+
+// callbackRequest is the body POSTed to a callback URL.
+type callbackRequest struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Attempt int             `json:"attempt"`
+	// Redelivered is set when an earlier delivery may have run the job
+	Redelivered bool `json:"redelivered,omitempty"`
+	// Token is unique to the attempt, for use as an idempotency key
+	Token string `json:"token"`
+}
+
+// Callback returns a handler that runs jobs by POSTing them to url instead of
+// executing them in process, so workers can be written in any language. A 2xx
+// response completes the job and a JSON response body becomes its result.
+// 5xx, 408 and 429 responses, timeouts and network errors are retryable;
+// other statuses fail the job permanently. When secret is set, each request
+// is signed with it over its timestamp and body in the X-Signature-256
+// header, with the timestamp in X-Signature-Timestamp.
+func Callback(url, secret string, timeout time.Duration) HandlerFunc {
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, job *domain.Job) error {
+		body, err := json.Marshal(callbackRequest{
+			ID:          job.ID,
+			Type:        job.Type,
+			Payload:     job.Payload,
+			Attempt:     job.Attempts,
+			Redelivered: job.Redelivered,
+			Token:       job.ExecutionToken,
+		})
+		if err != nil {
+			return Permanent(fmt.Errorf("encode callback request: %w", err))
+		}
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return Permanent(fmt.Errorf("build callback request: %w", err))
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Job-ID", job.ID)
+		request.Header.Set("X-Job-Attempt", strconv.Itoa(job.Attempts))
+		if secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			request.Header.Set(CallbackTimestampHeader, timestamp)
+			request.Header.Set(CallbackSignatureHeader, "sha256="+sign(secret, timestamp, body))
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			// Cancellation and shutdown are handled by the worker from ctx
+			if ctx.Err() != nil {
+				return err
+			}
+			return Retryable(fmt.Errorf("callback request failed: %w", err))
+		}
+		defer response.Body.Close()
+
+		responseBody, err := io.ReadAll(io.LimitReader(response.Body, maxCallbackResponseBytes))
+		if err != nil {
+			return Retryable(fmt.Errorf("read callback response: %w", err))
+		}
+
+		switch {
+		case response.StatusCode >= 200 && response.StatusCode < 300:
+			if json.Valid(responseBody) {
+				job.Result = responseBody
+			}
+			return nil
+		case response.StatusCode >= 500, response.StatusCode == http.StatusRequestTimeout, response.StatusCode == http.StatusTooManyRequests:
+			return Retryable(callbackStatusError(response.StatusCode, responseBody))
+		default:
+			return Permanent(callbackStatusError(response.StatusCode, responseBody))
+		}
+	}
+}
+
+func callbackStatusError(statusCode int, body []byte) error {
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return fmt.Errorf("callback returned %d", statusCode)
+	}
+	return fmt.Errorf("callback returned %d: %s", statusCode, body)
+}
+
+// sign returns the hex HMAC-SHA256 of timestamp + "." + body.
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// ErrChaosFailure is returned by handlers failed by the Chaos middleware.
+var ErrChaosFailure = errors.New("chaos: injected failure")
+
+// ChaosFault describes the faults injected into one job type's handler.
+// Rates are probabilities between 0 and 1.
+type ChaosFault struct {
+	// Each call is delayed by a random duration of up to Latency
+	Latency     time.Duration
+	FailureRate float64
+	PanicRate   float64
+}
+
+// ChaosAllTypes is the faults key that applies to job types without their
+// own entry.
+const ChaosAllTypes = "*"
+
+// Chaos injects faults into handler calls so retries, the dead-letter queue
+// and alerting can be exercised before a real incident. A call first waits
+// out its injected latency (or the job's context), then panics or fails with
+// ErrChaosFailure at the configured rates, and otherwise runs the handler.
+// It is meant for test environments only.
+func Chaos(faults map[string]ChaosFault) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			fault, ok := faults[job.Type]
+			if !ok {
+				fault, ok = faults[ChaosAllTypes]
+			}
+			if !ok {
+				return next(ctx, job)
+			}
+
+			logger := JobLogger(ctx)
+
+			if fault.Latency > 0 {
+				delay := rand.N(fault.Latency)
+				logger.Info("Chaos latency injected", "event", "chaos_latency", "job_id", job.ID, "delay_ms", delay.Milliseconds())
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+
+			roll := rand.Float64()
+			switch {
+			case roll < fault.PanicRate:
+				logger.Warn("Chaos panic injected", "event", "chaos_panic", "job_id", job.ID)
+				panic("chaos: injected panic")
+			case roll < fault.PanicRate+fault.FailureRate:
+				logger.Warn("Chaos failure injected", "event", "chaos_failure", "job_id", job.ID)
+				return ErrChaosFailure
+			}
+
+			return next(ctx, job)
+		}
+	}
+}
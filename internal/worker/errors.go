@@ -0,0 +1,48 @@
+package worker
+
+import "errors"
+
+// classifiedError wraps a handler error with whether retrying can help.
+type classifiedError struct {
+	err       error
+	permanent bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as a failure retrying cannot fix (e.g. an invalid
+// payload). The job goes straight to the dead-letter queue.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, permanent: true}
+}
+
+// Retryable marks err as transient (e.g. a timeout talking to a downstream
+// service). The job is retried with backoff while it has retries left, which
+// is also what happens to unclassified errors.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var classified *classifiedError
+	return errors.As(err, &classified) && classified.permanent
+}
+
+// isRetryable reports whether err was explicitly marked with Retryable.
+func isRetryable(err error) bool {
+	var classified *classifiedError
+	return errors.As(err, &classified) && !classified.permanent
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// Exit codes an executable can use to classify its failure, borrowed from
+// sysexits.h. Any other non-zero exit is an ordinary, retried failure.
+const (
+	// ExecExitPermanent (EX_DATAERR) fails the job without retrying.
+	ExecExitPermanent = 65
+	// ExecExitRetryable (EX_TEMPFAIL) marks the failure as transient.
+	ExecExitRetryable = 75
+)
+
+// maxExecOutputBytes caps how much stdout is kept as the job's result; a
+// command writing more fails its job.
+const maxExecOutputBytes = 1 << 20
+
+// execWaitDelay is how long a killed process's output pipes may stay open
+// (held by grandchildren) before the worker stops waiting for them.
+const execWaitDelay = 5 * time.Second
+
+// Exec returns a handler that runs the command for each job, with the
+// payload on stdin and JOB_ID, JOB_TYPE, JOB_ATTEMPT and JOB_EXECUTION_TOKEN
+// in its environment.
+// Exit status 0 completes the job; stdout becomes the result, as-is when it
+// is JSON and as a JSON string otherwise. Stdout over maxExecOutputBytes
+// fails the job permanently rather than keeping a cut-off result. Each stderr
+// line is captured in the job's logs. The process is killed when the job
+// times out or is cancelled.
+func Exec(command []string) HandlerFunc {
+	return func(ctx context.Context, job *domain.Job) error {
+		if len(command) == 0 {
+			return Permanent(errors.New("no command configured"))
+		}
+
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.WaitDelay = execWaitDelay
+		cmd.Env = append(os.Environ(),
+			"JOB_ID="+job.ID,
+			"JOB_TYPE="+job.Type,
+			"JOB_ATTEMPT="+strconv.Itoa(job.Attempts),
+			"JOB_EXECUTION_TOKEN="+job.ExecutionToken,
+		)
+		cmd.Stdin = bytes.NewReader(job.Payload)
+
+		stdout := &limitedBuffer{limit: maxExecOutputBytes}
+		cmd.Stdout = stdout
+		stderr := &lineLogger{logger: JobLogger(ctx)}
+		cmd.Stderr = stderr
+
+		if err := cmd.Start(); err != nil {
+			// A missing or non-executable binary won't fix itself on retry
+			return Permanent(fmt.Errorf("exec %s: %w", command[0], err))
+		}
+
+		err := cmd.Wait()
+		stderr.flush()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			failure := fmt.Errorf("%s exited with status %d", command[0], exitErr.ExitCode())
+			if line := stderr.lastLine(); line != "" {
+				failure = fmt.Errorf("%w: %s", failure, line)
+			}
+			switch exitErr.ExitCode() {
+			case ExecExitPermanent:
+				return Permanent(failure)
+			case ExecExitRetryable:
+				return Retryable(failure)
+			default:
+				return failure
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("exec %s: %w", command[0], err)
+		}
+
+		// The same job would write as much again, so retrying can't help
+		if stdout.Truncated() {
+			return Permanent(fmt.Errorf("%s wrote more than %d bytes to stdout", command[0], maxExecOutputBytes))
+		}
+
+		job.Result = execResult(bytes.TrimSpace(stdout.Bytes()))
+		return nil
+	}
+}
+
+// lineLogger writes each complete line written to it to the job's logs and
+// remembers the last one for the failure message.
+type lineLogger struct {
+	logger  *slog.Logger
+	mu      sync.Mutex
+	partial []byte
+	last    string
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.logLocked(l.partial[:i])
+		l.partial = l.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush logs a final line that had no trailing newline.
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.partial) > 0 {
+		l.logLocked(l.partial)
+		l.partial = nil
+	}
+}
+
+func (l *lineLogger) logLocked(line []byte) {
+	text := string(bytes.TrimRight(line, "\r"))
+	if text == "" {
+		return
+	}
+	l.last = text
+	l.logger.Info(text, "event", "exec_stderr")
+}
+
+func (l *lineLogger) lastLine() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.last
+}
+
+// execResult turns a command's stdout into a job result.
+func execResult(stdout []byte) json.RawMessage {
+	if len(stdout) == 0 {
+		return nil
+	}
+	if json.Valid(stdout) {
+		return json.RawMessage(stdout)
+	}
+
+	result, err := json.Marshal(string(stdout))
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a chatty command can't exhaust memory.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.limit - b.buffer.Len()
+	if len(p) > room {
+		b.truncated = true
+	}
+	if room > 0 {
+		b.buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// Truncated reports whether anything written was discarded.
+func (b *limitedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.truncated
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.Bytes()
+}
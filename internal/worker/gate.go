@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Gate lets workers be paused and resumed as a group. While paused, workers
+// stop pulling from the job queue so jobs stay pending. Individual job types
+// can also be paused, in which case workers skip their jobs but keep
+// processing other types.
+type Gate struct {
+	mu          sync.Mutex
+	paused      bool
+	open        chan struct{} // closed while the gate is open
+	pausedTypes map[string]bool
+}
+
+func NewGate() *Gate {
+	open := make(chan struct{})
+	close(open)
+
+	return &Gate{
+		open:        open,
+		pausedTypes: make(map[string]bool),
+	}
+}
+
+// Pause closes the gate. It returns false if the gate was already paused.
+func (g *Gate) Pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused {
+		return false
+	}
+
+	g.paused = true
+	g.open = make(chan struct{})
+
+	return true
+}
+
+// Resume opens the gate. It returns false if the gate was not paused.
+func (g *Gate) Resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		return false
+	}
+
+	g.paused = false
+	close(g.open)
+
+	return true
+}
+
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paused
+}
+
+// Wait returns a channel that is closed once the gate is open.
+func (g *Gate) Wait() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.open
+}
+
+// PauseType stops jobs of jobType from being claimed. It returns false if the
+// type was already paused.
+func (g *Gate) PauseType(jobType string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pausedTypes[jobType] {
+		return false
+	}
+
+	g.pausedTypes[jobType] = true
+
+	return true
+}
+
+// ResumeType lets jobs of jobType be claimed again. It returns false if the
+// type was not paused.
+func (g *Gate) ResumeType(jobType string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.pausedTypes[jobType] {
+		return false
+	}
+
+	delete(g.pausedTypes, jobType)
+
+	return true
+}
+
+// PausedTypes returns the paused job types.
+func (g *Gate) PausedTypes() map[string]bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return maps.Clone(g.pausedTypes)
+}
+
+// UnpausedTypes returns the job types in types that are not paused.
+func (g *Gate) UnpausedTypes(types []string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.DeleteFunc(slices.Clone(types), func(jobType string) bool {
+		return g.pausedTypes[jobType]
+	})
+}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+type jobLoggerKey struct{}
+
+// JobLogger returns the logger scoped to the job attempt running in ctx.
+// Everything logged through it goes to the server log and is also captured
+// for GET /jobs/{id}/logs. Outside a job it returns slog.Default().
+func JobLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(jobLoggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+func withJobLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, jobLoggerKey{}, logger)
+}
+
+func newJobLogger(base *slog.Logger, logStore store.LogStore, job *domain.Job) *slog.Logger {
+	return slog.New(&captureHandler{
+		next:     base.Handler(),
+		logStore: logStore,
+		jobID:    job.ID,
+		attempt:  job.Attempts,
+	})
+}
+
+// captureHandler forwards records to the server log handler and copies them
+// into the log store under the job attempt.
+type captureHandler struct {
+	next     slog.Handler
+	logStore store.LogStore
+	jobID    string
+	attempt  int
+	attrs    []slog.Attr
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// Capture everything at Info and above even if the server log is quieter
+	return level >= slog.LevelInfo || h.next.Enabled(ctx, level)
+}
+
+func (h *captureHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.next.Enabled(ctx, record.Level) {
+		if err := h.next.Handle(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	attrs := make(map[string]string, len(h.attrs)+record.NumAttrs())
+	for _, attr := range h.attrs {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = attr.Value.String()
+		return true
+	})
+
+	entry := domain.LogEntry{
+		Time:    record.Time.UTC(),
+		Level:   record.Level.String(),
+		Message: record.Message,
+		Attrs:   attrs,
+	}
+
+	// Capture even if the job context was cancelled (e.g. shutdown abort)
+	return h.logStore.AppendLog(context.WithoutCancel(ctx), h.jobID, h.attempt, entry)
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
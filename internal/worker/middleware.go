@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the span of each job attempt.
+var tracer = otel.Tracer("github.com/karprabha/job-queue-backend/internal/worker")
+
+// Middleware wraps a HandlerFunc to add behavior shared across job types.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain wraps handler so that middleware[0] runs first.
+func Chain(handler HandlerFunc, middleware ...Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// Logging records how long each handler ran and how it finished in the job's
+// captured logs.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+
+			logger := JobLogger(ctx)
+			if err != nil {
+				logger.Warn("Handler returned error", "event", "handler_error", "job_id", job.ID, "job_type", job.Type, "duration_ms", time.Since(start).Milliseconds(), "error", err)
+			} else {
+				logger.Info("Handler finished", "event", "handler_finished", "job_id", job.ID, "job_type", job.Type, "duration_ms", time.Since(start).Milliseconds())
+			}
+
+			return err
+		}
+	}
+}
+
+// Recover turns a handler panic into a *PanicError so the attempt fails
+// instead of the process crashing. Workers recover panics themselves; this
+// is for code that calls handlers outside a worker.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			return callHandler(ctx, next, job)
+		}
+	}
+}
+
+// Timeout gives each handler call a deadline of d; handlers observe it
+// through ctx.
+func Timeout(d time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next(ctx, job)
+		}
+	}
+}
+
+// Metrics records how long each handler ran in metricStore, by job type.
+func Metrics(metricStore store.MetricStore, logger *slog.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+
+			// Observed even when the attempt was cancelled
+			if observeErr := metricStore.ObserveJobDuration(context.WithoutCancel(ctx), job.Type, time.Since(start)); observeErr != nil {
+				logger.Error("Failed to observe job duration", "event", "metric_error", "job_id", job.ID, "error", observeErr)
+			}
+
+			return err
+		}
+	}
+}
+
+// Tracing runs each handler in a span that continues the trace of the
+// request that submitted the job, and marks the span failed when the handler
+// returns an error.
+func Tracing() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *domain.Job) error {
+			ctx, span := tracer.Start(tracing.JobContext(ctx, job), "process "+job.Type, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+				attribute.String("job.id", job.ID),
+				attribute.String("job.type", job.Type),
+				attribute.Int("job.attempt", job.Attempts),
+				attribute.String("messaging.destination.name", queueNameFrom(ctx)),
+			))
+			defer span.End()
+
+			err := next(ctx, job)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+type queueNameKey struct{}
+
+// withQueueName records on ctx the queue the worker running a job serves.
+func withQueueName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queueNameKey{}, name)
+}
+
+// queueNameFrom returns the queue recorded by withQueueName, or "" outside a
+// worker.
+func queueNameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(queueNameKey{}).(string)
+	return name
+}
+
+type executionTokenKey struct{}
+
+// withExecutionToken records on ctx the execution token of the job attempt
+// running in it.
+func withExecutionToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, executionTokenKey{}, token)
+}
+
+// ExecutionToken returns the execution token of the job attempt running in
+// ctx, or "" outside a job. It is unique to the attempt, so handlers written
+// with Handle, which don't see the job, can still pass it downstream as an
+// idempotency key.
+func ExecutionToken(ctx context.Context) string {
+	token, _ := ctx.Value(executionTokenKey{}).(string)
+	return token
+}
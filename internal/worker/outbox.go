@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// ErrNoOutbox is returned when writing to the outbox outside a job attempt.
+var ErrNoOutbox = errors.New("no job attempt running in context")
+
+// Outbox collects the side effects of a job attempt. They are stored in the
+// same update that completes the job and carried out afterwards by the
+// outbox relay, with retries, so a notification is neither sent for an
+// attempt that didn't complete nor lost if the server stops right after the
+// job completed. An attempt that fails drops what it wrote.
+type Outbox struct {
+	mu       sync.Mutex
+	job      *domain.Job
+	messages []domain.OutboxMessage
+}
+
+type outboxKey struct{}
+
+func withOutbox(ctx context.Context, outbox *Outbox) context.Context {
+	return context.WithValue(ctx, outboxKey{}, outbox)
+}
+
+// OutboxFrom returns the outbox of the job attempt running in ctx, or nil
+// outside a job, where its methods return ErrNoOutbox.
+func OutboxFrom(ctx context.Context) *Outbox {
+	outbox, _ := ctx.Value(outboxKey{}).(*Outbox)
+	return outbox
+}
+
+func newOutbox(job *domain.Job) *Outbox {
+	return &Outbox{job: job}
+}
+
+// Webhook asks for body to be POSTed as JSON to url once the job completes.
+func (o *Outbox) Webhook(url string, body any) error {
+	if url == "" {
+		return errors.New("webhook url is required")
+	}
+	return o.add(domain.OutboxWebhook, url, body)
+}
+
+// Emit asks for a job.emitted event named name, carrying data, to be
+// published once the job completes.
+func (o *Outbox) Emit(name string, data any) error {
+	if name == "" {
+		return errors.New("event name is required")
+	}
+	return o.add(domain.OutboxEvent, name, data)
+}
+
+func (o *Outbox) add(kind domain.OutboxKind, target string, value any) error {
+	if o == nil {
+		return ErrNoOutbox
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, *domain.NewOutboxMessage(o.job, kind, target, payload))
+	return nil
+}
+
+// Messages returns what the attempt wrote so far.
+func (o *Outbox) Messages() []domain.OutboxMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]domain.OutboxMessage(nil), o.messages...)
+}
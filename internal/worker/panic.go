@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// PanicError reports a handler panic together with the stack it unwound.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// callHandler runs handler, converting a panic into a *PanicError.
+func callHandler(ctx context.Context, handler HandlerFunc, job *domain.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &PanicError{Value: recovered, Stack: debug.Stack()}
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/store"
+)
+
+// Pool owns the worker goroutines and lets the number of workers change at
+// runtime. Workers removed by a resize finish their current job before
+// exiting; cancelling the pool context stops all of them.
+type Pool struct {
+	ctx         context.Context
+	queueName   string
+	newWorker   func(id int) *Worker
+	metricStore store.MetricStore
+	utilization *Utilization
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	stops  []chan struct{}
+	nextID int
+	wg     sync.WaitGroup
+}
+
+// NewPool creates an empty pool of the workers newWorker creates for the
+// queue called queueName, reporting what they do to utilization.
+func NewPool(ctx context.Context, queueName string, newWorker func(id int) *Worker, metricStore store.MetricStore, utilization *Utilization, logger *slog.Logger) *Pool {
+	return &Pool{
+		ctx:         ctx,
+		queueName:   queueName,
+		newWorker:   newWorker,
+		metricStore: metricStore,
+		utilization: utilization,
+		logger:      logger,
+	}
+}
+
+// Resize grows or shrinks the pool to count workers.
+func (p *Pool) Resize(count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := len(p.stops)
+
+	for len(p.stops) < count {
+		stop := make(chan struct{})
+		worker := p.newWorker(p.nextID)
+		p.nextID++
+		p.stops = append(p.stops, stop)
+		worker.activity = p.utilization.register(worker)
+
+		p.wg.Go(func() {
+			defer p.utilization.unregister(worker.activity)
+			worker.Run(p.ctx, stop)
+		})
+	}
+
+	for len(p.stops) > count {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+
+	p.utilization.setSize(p.queueName, count)
+
+	if previous == count {
+		return
+	}
+
+	if err := p.metricStore.AddWorkerCount(p.ctx, count-previous); err != nil {
+		p.logger.Error("Failed to update worker count", "event", "metric_error", "error", err)
+	}
+
+	p.logger.Info("Worker pool resized", "event", "worker_pool_resized", "from", previous, "to", count)
+}
+
+// Size returns the target number of workers.
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.stops)
+}
+
+// Drain stops every worker from taking new jobs and waits up to grace for
+// them to finish the jobs they are processing. It reports whether they all
+// finished in time; if not, cancel the pool context to abort the rest.
+func (p *Pool) Drain(grace time.Duration) bool {
+	p.Resize(0)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Wait blocks until every worker goroutine, including ones removed by a
+// resize, has exited.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/ratelimiter"
+)
+
+// HandlerFunc executes a single job attempt. Returning an error fails the
+// attempt; on success, anything the handler stores in job.Result is kept as
+// the job's result.
+type HandlerFunc func(ctx context.Context, job *domain.Job) error
+
+// FallbackAction decides what happens to jobs whose type has no handler.
+type FallbackAction string
+
+const (
+	// FallbackFail marks the job failed.
+	FallbackFail FallbackAction = "fail"
+	// FallbackPark leaves the job pending: workers don't claim jobs of a
+	// type with no handler, so it runs once one is registered, at the next
+	// start or when a plugin for the type loads.
+	FallbackPark FallbackAction = "park"
+)
+
+// ParseFallbackAction validates a fallback action name.
+func ParseFallbackAction(value string) (FallbackAction, error) {
+	switch action := FallbackAction(value); action {
+	case FallbackFail, FallbackPark:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown fallback action %q", value)
+	}
+}
+
+// Registry maps job types to the handlers that execute them.
+type Registry struct {
+	mu         sync.RWMutex
+	handlers   map[string]HandlerFunc
+	middleware []Middleware
+	fallback   FallbackAction
+
+	// Execution deadlines enforced by the worker; zero means no deadline
+	timeouts       map[string]time.Duration
+	defaultTimeout time.Duration
+
+	// Per job type limits on how often handlers start
+	rateLimits map[string]*ratelimiter.BurstyLimiter
+
+	// Job types consumed by remote workers through the lease API, which
+	// local workers never claim
+	remoteTypes map[string]bool
+}
+
+func NewRegistry(fallback FallbackAction, defaultTimeout time.Duration) *Registry {
+	return &Registry{
+		handlers:       make(map[string]HandlerFunc),
+		fallback:       fallback,
+		timeouts:       make(map[string]time.Duration),
+		defaultTimeout: defaultTimeout,
+		rateLimits:     make(map[string]*ratelimiter.BurstyLimiter),
+		remoteTypes:    make(map[string]bool),
+	}
+}
+
+// Register sets the handler for jobType, replacing any previous one. The
+// given middleware applies to this job type only and runs inside the
+// middleware added with Use.
+func (r *Registry) Register(jobType string, handler HandlerFunc, middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[jobType] = Chain(handler, middleware...)
+}
+
+// Use adds middleware that wraps every handler. The first middleware added
+// is the outermost.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Lookup returns the handler registered for jobType wrapped in the
+// registry's middleware.
+func (r *Registry) Lookup(jobType string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[jobType]
+	if !ok {
+		return nil, false
+	}
+
+	return Chain(handler, r.middleware...), true
+}
+
+// HandledTypes returns the set of job types that have a handler.
+func (r *Registry) HandledTypes() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make(map[string]bool, len(r.handlers))
+	for jobType := range r.handlers {
+		types[jobType] = true
+	}
+	return types
+}
+
+// SetTimeout overrides the default execution timeout for jobType.
+func (r *Registry) SetTimeout(jobType string, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timeouts[jobType] = timeout
+}
+
+// Timeout returns how long a jobType handler may run before the attempt fails.
+func (r *Registry) Timeout(jobType string) time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if timeout, ok := r.timeouts[jobType]; ok {
+		return timeout
+	}
+	return r.defaultTimeout
+}
+
+// SetRateLimit limits jobType to rate starts per second across all workers,
+// allowing bursts of up to burst jobs.
+func (r *Registry) SetRateLimit(jobType string, rate float64, burst int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rateLimits[jobType] = ratelimiter.NewBurstyLimiter(rate, burst)
+}
+
+// RateLimiter returns the limiter for jobType, if it has one.
+func (r *Registry) RateLimiter(jobType string) (*ratelimiter.BurstyLimiter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limiter, ok := r.rateLimits[jobType]
+	return limiter, ok
+}
+
+// SetRemote reserves jobType for remote workers: local workers leave its jobs
+// pending for POST /workers/lease.
+func (r *Registry) SetRemote(jobType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remoteTypes[jobType] = true
+}
+
+// RemoteTypes returns the set of job types reserved for remote workers.
+func (r *Registry) RemoteTypes() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return maps.Clone(r.remoteTypes)
+}
+
+// Fallback returns the action taken for jobs with no registered handler.
+func (r *Registry) Fallback() FallbackAction {
+	return r.fallback
+}
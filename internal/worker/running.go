@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/karprabha/job-queue-backend/internal/events"
+)
+
+// ErrJobCancelled is the cancellation cause of a handler context when the
+// job was cancelled through the API.
+var ErrJobCancelled = errors.New("job cancelled")
+
+// ErrJobReaped is the cancellation cause of a handler context when the store
+// took the attempt back (stuck job or expired lease) while it was running.
+var ErrJobReaped = errors.New("job attempt reaped")
+
+// runningAttempt identifies one attempt of a job. A reaped job can be claimed
+// again while the old attempt is still winding down, so both may be running.
+type runningAttempt struct {
+	jobID   string
+	attempt int
+}
+
+// RunningJobs tracks the cancel function of every attempt a worker is
+// currently processing, so an attempt can be interrupted from outside the
+// worker.
+type RunningJobs struct {
+	mu      sync.Mutex
+	cancels map[runningAttempt]context.CancelCauseFunc
+}
+
+func NewRunningJobs() *RunningJobs {
+	return &RunningJobs{
+		cancels: make(map[runningAttempt]context.CancelCauseFunc),
+	}
+}
+
+// Cancel interrupts every handler processing jobID. It reports whether the
+// job was running in this process.
+func (r *RunningJobs) Cancel(jobID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := false
+	for key, cancel := range r.cancels {
+		if key.jobID == jobID {
+			cancel(ErrJobCancelled)
+			found = true
+		}
+	}
+	return found
+}
+
+// Handle cancels the handler of an attempt the store has taken back, so it
+// stops doing work nobody will record. Subscribe it to the event bus.
+func (r *RunningJobs) Handle(_ context.Context, event events.Event) {
+	reaped := event.Type == events.JobReaped ||
+		(event.Type == events.JobReleased && (event.Reason == "lease_expired" || event.Reason == "stuck"))
+	if !reaped {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cancel, ok := r.cancels[runningAttempt{jobID: event.JobID, attempt: event.Attempt}]; ok {
+		cancel(ErrJobReaped)
+	}
+}
+
+func (r *RunningJobs) track(jobID string, attempt int, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancels[runningAttempt{jobID: jobID, attempt: attempt}] = cancel
+}
+
+func (r *RunningJobs) untrack(jobID string, attempt int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.cancels, runningAttempt{jobID: jobID, attempt: attempt})
+}
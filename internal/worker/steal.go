@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/queue"
+)
+
+// stealTimeout bounds the dequeue from a queue picked for stealing; its own
+// workers may empty it first.
+const stealTimeout = 100 * time.Millisecond
+
+// Stealer lets workers whose own named queue has been empty for a while take
+// work from other queues with a backlog. Each queue's limit caps how many
+// workers from other queues may run its jobs at once, so queues sized to
+// protect a downstream are not overrun; queues without a limit are never
+// stolen from.
+type Stealer struct {
+	router *queue.Router
+	limits map[string]int
+	idle   time.Duration
+
+	mu sync.Mutex
+	// Jobs from each queue being run by other queues' workers
+	stealing map[string]int
+}
+
+// NewStealer steals between router's queues once a worker has been idle for
+// idle, up to limits per queue name.
+func NewStealer(router *queue.Router, limits map[string]int, idle time.Duration) *Stealer {
+	return &Stealer{
+		router:   router,
+		limits:   limits,
+		idle:     idle,
+		stealing: make(map[string]int),
+	}
+}
+
+// dequeue waits for an ID on own, the queue called ownName, trying to steal
+// one from another queue each time it has waited idle. It returns the queue
+// the ID came from and that queue's name; a stolen ID holds a slot of that
+// queue's limit until release.
+func (s *Stealer) dequeue(ctx context.Context, own queue.Queue, ownName string) (queue.Queue, string, string, error) {
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, s.idle)
+		jobID, err := own.Dequeue(waitCtx)
+		cancel()
+		if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			return own, ownName, jobID, err
+		}
+
+		name, ok := s.acquire(ownName)
+		if !ok {
+			continue
+		}
+
+		victim := s.router.Named(name)
+		stealCtx, cancel := context.WithTimeout(ctx, stealTimeout)
+		jobID, err = victim.Dequeue(stealCtx)
+		cancel()
+		if err == nil {
+			return victim, name, jobID, nil
+		}
+		s.release(name)
+	}
+}
+
+// acquire picks the queue other than ownName with the most queued IDs and a
+// free slot, and takes the slot.
+func (s *Stealer) acquire(ownName string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best, bestDepth := "", 0
+	for name, limit := range s.limits {
+		if name == ownName || s.stealing[name] >= limit {
+			continue
+		}
+		q := s.router.Named(name)
+		if q == nil {
+			continue
+		}
+		if depth := q.Len(); depth > bestDepth {
+			best, bestDepth = name, depth
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+
+	s.stealing[best]++
+	return best, true
+}
+
+// release frees a slot taken by acquire.
+func (s *Stealer) release(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stealing[name]--
+}
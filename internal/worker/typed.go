@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// Validator is implemented by payload types that check their own fields.
+type Validator interface {
+	Validate() error
+}
+
+// Handle adapts a handler that takes a typed payload. The job payload is
+// decoded into T (a missing payload leaves T at its zero value) and, if T
+// implements Validator, validated. Decode and validation errors are
+// permanent failures, since retrying the same payload cannot fix them.
+func Handle[T any](fn func(ctx context.Context, payload T) error) HandlerFunc {
+	return func(ctx context.Context, job *domain.Job) error {
+		var payload T
+
+		if len(job.Payload) > 0 && string(job.Payload) != "null" {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode %s payload: %w", job.Type, err))
+			}
+		}
+
+		if validator, ok := any(&payload).(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				return Permanent(fmt.Errorf("invalid %s payload: %w", job.Type, err))
+			}
+		}
+
+		return fn(ctx, payload)
+	}
+}
@@ -0,0 +1,260 @@
+package worker
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/karprabha/job-queue-backend/internal/domain"
+)
+
+// Utilization tracks what every worker of every pool is doing and how each
+// has spent its time, busy processing a job or idle waiting for one. Shared by
+// the pools, it tells whether jobs wait because every worker is busy, when
+// more workers would help, or because the queue is slow to feed idle ones.
+type Utilization struct {
+	mu    sync.Mutex
+	pools map[string]*poolUsage
+	// Live workers, including removed ones finishing their job
+	workers map[*activity]struct{}
+}
+
+// poolUsage is a pool's target size and the time of its workers that have
+// exited.
+type poolUsage struct {
+	size        int
+	retiredBusy time.Duration
+	retiredIdle time.Duration
+}
+
+func NewUtilization() *Utilization {
+	return &Utilization{
+		pools:   make(map[string]*poolUsage),
+		workers: make(map[*activity]struct{}),
+	}
+}
+
+// WorkerStatus is what a worker is doing and how it has spent its time since
+// it started.
+type WorkerStatus struct {
+	ID        int
+	Name      string
+	Queue     string
+	StartedAt time.Time
+	// Job being processed; empty when the worker is idle
+	JobID        string
+	JobType      string
+	JobStartedAt time.Time
+	Busy         time.Duration
+	Idle         time.Duration
+}
+
+// PoolUtilization sums the workers of the pool for one queue.
+type PoolUtilization struct {
+	Queue string
+	// Target number of workers
+	Size int
+	// Workers running, which exceeds Size while removed workers finish
+	Workers int
+	// Workers processing a job
+	BusyWorkers int
+	// Share of the pool busy now, from 0 to 1
+	Saturation float64
+	// Time spent busy and idle by every worker of the pool since startup,
+	// including ones that have exited
+	Busy time.Duration
+	Idle time.Duration
+}
+
+// activity is one worker's record, updated by the worker and read by
+// Utilization. Its methods are no-ops on a nil receiver, for workers that
+// don't belong to a pool.
+type activity struct {
+	id        int
+	name      string
+	queue     string
+	startedAt time.Time
+
+	mu sync.Mutex
+	// Start of the current busy or idle stretch
+	since        time.Time
+	busy         time.Duration
+	idle         time.Duration
+	jobID        string
+	jobType      string
+	jobStartedAt time.Time
+}
+
+// begin marks the worker busy with job.
+func (a *activity) begin(job *domain.Job) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.idle += now.Sub(a.since)
+	a.since = now
+	a.jobID, a.jobType, a.jobStartedAt = job.ID, job.Type, now
+}
+
+// end marks the worker idle again.
+func (a *activity) end() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.busy += now.Sub(a.since)
+	a.since = now
+	a.jobID, a.jobType, a.jobStartedAt = "", "", time.Time{}
+}
+
+// status reports the activity as of now, counting the current stretch.
+func (a *activity) status(now time.Time) WorkerStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := WorkerStatus{
+		ID:           a.id,
+		Name:         a.name,
+		Queue:        a.queue,
+		StartedAt:    a.startedAt,
+		JobID:        a.jobID,
+		JobType:      a.jobType,
+		JobStartedAt: a.jobStartedAt,
+		Busy:         a.busy,
+		Idle:         a.idle,
+	}
+	if a.jobID != "" {
+		status.Busy += now.Sub(a.since)
+	} else {
+		status.Idle += now.Sub(a.since)
+	}
+
+	return status
+}
+
+// setSize records the target size of the pool for queue.
+func (u *Utilization) setSize(queue string, size int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pool(queue).size = size
+}
+
+// register starts tracking w, idle from now.
+func (u *Utilization) register(w *Worker) *activity {
+	now := time.Now()
+	a := &activity{
+		id:        w.id,
+		name:      w.name,
+		queue:     w.queueName,
+		startedAt: now.UTC(),
+		since:     now,
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pool(w.queueName)
+	u.workers[a] = struct{}{}
+	return a
+}
+
+// unregister stops tracking a worker that has exited, keeping its time in
+// its pool's totals.
+func (u *Utilization) unregister(a *activity) {
+	status := a.status(time.Now())
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.workers, a)
+	pool := u.pool(a.queue)
+	pool.retiredBusy += status.Busy
+	pool.retiredIdle += status.Idle
+}
+
+// pool returns the usage of the pool for queue, creating it. The caller holds
+// u.mu.
+func (u *Utilization) pool(queue string) *poolUsage {
+	pool, ok := u.pools[queue]
+	if !ok {
+		pool = &poolUsage{}
+		u.pools[queue] = pool
+	}
+	return pool
+}
+
+// Workers reports every running worker, by queue and then ID.
+func (u *Utilization) Workers() []WorkerStatus {
+	u.mu.Lock()
+	activities := make([]*activity, 0, len(u.workers))
+	for a := range u.workers {
+		activities = append(activities, a)
+	}
+	u.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]WorkerStatus, 0, len(activities))
+	for _, a := range activities {
+		statuses = append(statuses, a.status(now))
+	}
+
+	slices.SortFunc(statuses, func(a, b WorkerStatus) int {
+		return cmp.Or(cmp.Compare(a.Queue, b.Queue), cmp.Compare(a.ID, b.ID))
+	})
+	return statuses
+}
+
+// Pools sums the workers of each pool, by queue name.
+func (u *Utilization) Pools() []PoolUtilization {
+	u.mu.Lock()
+	pools := make(map[string]*PoolUtilization, len(u.pools))
+	for queue, usage := range u.pools {
+		pools[queue] = &PoolUtilization{
+			Queue: queue,
+			Size:  usage.size,
+			Busy:  usage.retiredBusy,
+			Idle:  usage.retiredIdle,
+		}
+	}
+	u.mu.Unlock()
+
+	for _, status := range u.Workers() {
+		pool, ok := pools[status.Queue]
+		if !ok {
+			continue
+		}
+		pool.Workers++
+		if status.JobID != "" {
+			pool.BusyWorkers++
+		}
+		pool.Busy += status.Busy
+		pool.Idle += status.Idle
+	}
+
+	result := make([]PoolUtilization, 0, len(pools))
+	for _, pool := range pools {
+		switch {
+		case pool.Size > 0:
+			pool.Saturation = min(float64(pool.BusyWorkers)/float64(pool.Size), 1)
+		case pool.BusyWorkers > 0:
+			// Draining: every worker left is busy
+			pool.Saturation = 1
+		}
+		result = append(result, *pool)
+	}
+
+	slices.SortFunc(result, func(a, b PoolUtilization) int {
+		return cmp.Compare(a.Queue, b.Queue)
+	})
+	return result
+}
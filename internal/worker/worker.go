@@ -2,121 +2,649 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/karprabha/job-queue-backend/internal/domain"
+	"github.com/karprabha/job-queue-backend/internal/events"
+	"github.com/karprabha/job-queue-backend/internal/queue"
 	"github.com/karprabha/job-queue-backend/internal/store"
+	"github.com/karprabha/job-queue-backend/internal/tracing"
 )
 
 type Worker struct {
 	id          int
+	name        string // Recorded on the jobs this worker claims
 	jobStore    store.JobStore
 	metricStore store.MetricStore
+	events      *events.Bus
+	logStore    store.LogStore
 	logger      *slog.Logger
-	jobQueue    chan string
+	jobQueue    queue.Queue
+	// source is the queue the worker dequeues from: jobQueue itself, or its
+	// named queue when jobQueue is a Router
+	source   queue.Queue
+	gate     *Gate
+	registry *Registry
+	running  *RunningJobs
+	// With routing set, the worker only claims jobs routed to queueName
+	routing   *queue.Routing
+	queueName string
+	// stealer, when set, lets the worker take jobs from other queues while
+	// its own is empty
+	stealer *Stealer
+	// activity records when the worker is busy, for the pool's utilization;
+	// nil outside a pool
+	activity *activity
 }
 
-func NewWorker(id int, jobStore store.JobStore, metricStore store.MetricStore, logger *slog.Logger, jobQueue chan string) *Worker {
+// NewWorker creates a worker for the named queue queueName of jobQueue. When
+// jobQueue is not a Router, queueName only names the worker. stealer may be
+// nil.
+func NewWorker(id int, jobStore store.JobStore, metricStore store.MetricStore, bus *events.Bus, logStore store.LogStore, logger *slog.Logger, jobQueue queue.Queue, gate *Gate, registry *Registry, running *RunningJobs, queueName string, stealer *Stealer) *Worker {
+	// Workers dequeue from their own queue but enqueue through the router,
+	// so the jobs they wake reach the right queue
+	source := jobQueue
+	var routing *queue.Routing
+	if router, ok := jobQueue.(*queue.Router); ok {
+		source = router.Named(queueName)
+		routing = router.Routing()
+	}
+
 	return &Worker{
 		id:          id,
+		name:        workerName(id, queueName),
 		jobStore:    jobStore,
 		metricStore: metricStore,
+		events:      bus,
+		logStore:    logStore,
 		logger:      logger,
 		jobQueue:    jobQueue,
+		source:      source,
+		gate:        gate,
+		registry:    registry,
+		running:     running,
+		routing:     routing,
+		queueName:   queueName,
+		stealer:     stealer,
+	}
+}
+
+// workerName identifies a worker across instances as host:pid/worker-id, or
+// host:pid/queue-worker-id for workers of a queue other than the default.
+func workerName(id int, queueName string) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	if queueName != "" && queueName != queue.DefaultName {
+		return fmt.Sprintf("%s:%d/%s-worker-%d", hostname, os.Getpid(), queueName, id)
+	}
+	return fmt.Sprintf("%s:%d/worker-%d", hostname, os.Getpid(), id)
+}
+
+// stopped reports whether stop has been closed.
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
 	}
 }
 
 func (w *Worker) Start(ctx context.Context) {
-	w.logger.Info("Worker started", "event", "worker_started", "worker_id", w.id)
+	w.Run(ctx, nil)
+}
+
+// Run processes jobs until ctx is cancelled or stop is closed. Closing stop
+// lets the current job finish, whereas cancelling ctx aborts it.
+func (w *Worker) Run(ctx context.Context, stop <-chan struct{}) {
+	w.publishWorker(ctx, events.WorkerStarted, "")
+
+	// Waiting on the queue ends on either shutdown or removal, but a job
+	// that has started only stops for shutdown
+	dequeueCtx, cancelDequeue := context.WithCancel(ctx)
+	defer cancelDequeue()
+	if stop != nil {
+		go func() {
+			select {
+			case <-stop:
+				cancelDequeue()
+			case <-dequeueCtx.Done():
+			}
+		}()
+	}
+
 	for {
+		// Block here while processing is paused so queued jobs stay pending
 		select {
 		case <-ctx.Done():
-			w.logger.Info("Worker shutting down", "event", "worker_stopped", "worker_id", w.id)
+			w.publishWorker(ctx, events.WorkerStopped, "shutdown")
 			return
-		case jobID, ok := <-w.jobQueue:
-			if !ok {
-				w.logger.Info("Worker shutting down because job queue is closed", "event", "worker_stopped", "worker_id", w.id)
-				return
-			}
-			job, err := w.jobStore.ClaimJob(ctx, jobID)
+		case <-stop:
+			w.publishWorker(ctx, events.WorkerStopped, "removed")
+			return
+		case <-w.gate.Wait():
+		}
 
-			if err != nil {
-				w.logger.Error("Worker error claiming job", "event", "job_claim_error", "worker_id", w.id, "job_id", jobID, "error", err)
-				continue
-			}
+		source, queueName := w.source, w.queueName
+		var jobID string
+		var err error
+		if w.stealer != nil && w.routing != nil {
+			source, queueName, jobID, err = w.stealer.dequeue(dequeueCtx, w.source, w.queueName)
+		} else {
+			jobID, err = w.source.Dequeue(dequeueCtx)
+		}
+		stolen := queueName != w.queueName
 
-			if job == nil {
-				w.logger.Info("Worker job already claimed or invalid", "event", "job_claim_failed", "worker_id", w.id, "job_id", jobID)
-				continue
+		switch {
+		case ctx.Err() != nil:
+			w.publishWorker(ctx, events.WorkerStopped, "shutdown")
+			return
+		case stopped(stop):
+			// A removed worker may still win the race for a token; hand it
+			// back rather than starting another job
+			if err == nil {
+				w.handBack(ctx, source, queueName, jobID, stolen)
 			}
+			w.publishWorker(ctx, events.WorkerStopped, "removed")
+			return
+		case errors.Is(err, queue.ErrClosed):
+			w.publishWorker(ctx, events.WorkerStopped, "queue_closed")
+			return
+		case err != nil:
+			w.logger.Error("Worker error dequeuing job", "event", "job_dequeue_error", "worker_id", w.id, "error", err)
+			continue
+		case w.gate.Paused():
+			// Processing was paused while the worker waited on the queue; the
+			// token goes back for whoever runs after resume
+			w.handBack(ctx, source, queueName, jobID, stolen)
+			continue
+		}
 
-			w.logger.Info("Job started", "event", "job_started", "worker_id", w.id, "job_id", jobID)
-			w.processJob(ctx, job)
+		if stolen {
+			w.logger.Info("Worker stealing from another queue", "event", "job_stolen", "worker_id", w.id, "queue", w.queueName, "from_queue", queueName, "job_id", jobID)
+			w.events.Publish(ctx, events.Event{Type: events.JobStolen, JobID: jobID, Worker: w.name, Queue: queueName})
+		}
+
+		w.work(ctx, source, queueName, jobID)
+		if stolen {
+			w.stealer.release(queueName)
 		}
 	}
 }
 
-func (w *Worker) processJob(ctx context.Context, job *domain.Job) {
-	timer := time.NewTimer(1 * time.Second)
-	defer timer.Stop()
+// handBack returns a token dequeued from source, the queue called queueName,
+// without claiming a job for it.
+func (w *Worker) handBack(ctx context.Context, source queue.Queue, queueName string, jobID string, stolen bool) {
+	// If the queue is full the sweeper enqueues the job again
+	source.Enqueue(ctx, jobID)
+	w.ackToken(ctx, source, jobID)
+	if stolen {
+		w.stealer.release(queueName)
+	}
+}
+
+// work claims and processes a job for the token jobID, dequeued from source,
+// the queue called queueName.
+func (w *Worker) work(ctx context.Context, source queue.Queue, queueName string, jobID string) {
+	// Each queued ID is a token for one unit of work: the worker claims
+	// whichever pending job in the queue has the highest priority, which may
+	// not be the job that was enqueued. Remote and paused types are left for
+	// others, and parked types until they get a handler
+	skipTypes := w.registry.RemoteTypes()
+	maps.Copy(skipTypes, w.gate.PausedTypes())
+	var handled map[string]bool
+	if w.registry.Fallback() == FallbackPark {
+		handled = w.registry.HandledTypes()
+	}
+	job, err := w.jobStore.ClaimNextJob(ctx, w.name, func(job *domain.Job) bool {
+		if skipTypes[job.Type] || handled != nil && !handled[job.Type] {
+			return false
+		}
+		return w.routing == nil || w.routing.QueueFor(job) == queueName
+	})
 
-	err := w.metricStore.IncrementJobsInProgress(ctx)
 	if err != nil {
-		w.logger.Error("Worker error incrementing jobs in progress", "event", "metric_error", "worker_id", w.id, "error", err)
+		// The token is acknowledged so it doesn't stay in flight, holding
+		// queue capacity; the sweeper enqueues the job again. Enqueuing a
+		// new token here would spin while the store keeps failing
+		w.logger.Error("Worker error claiming job", "event", "job_claim_error", "worker_id", w.id, "job_id", jobID, "error", err)
+		w.ackToken(ctx, source, jobID)
 		return
 	}
 
-	select {
-	case <-timer.C:
-		// Processing complete
-	case <-ctx.Done():
-		// Shutdown requested, abort processing - clean up job state
-		w.logger.Info("Worker job processing aborted due to shutdown", "event", "job_aborted", "worker_id", w.id, "job_id", job.ID)
-		
-		// Mark job as failed due to shutdown to prevent it from being stuck in processing state
-		lastError := "Job aborted due to shutdown"
-		if err := w.jobStore.UpdateStatus(ctx, job.ID, domain.StatusFailed, &lastError); err != nil {
-			w.logger.Error("Worker error updating aborted job to failed", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
-		} else {
-			// IncrementJobsFailed also decrements JobsInProgress, so this handles both metrics
-			if err := w.metricStore.IncrementJobsFailed(ctx); err != nil {
-				w.logger.Error("Worker error incrementing jobs failed for aborted job", "event", "metric_error", "worker_id", w.id, "error", err)
-			}
-		}
-		
+	if job == nil {
+		w.logger.Info("Worker found no claimable job", "event", "job_claim_failed", "worker_id", w.id, "job_id", jobID)
+		w.ackToken(ctx, source, jobID)
 		return
 	}
 
-	// Simulate failure deterministically
-	if job.Type == "email" {
-		lastError := "Email sending failed"
-		err := w.jobStore.UpdateStatus(ctx, job.ID, domain.StatusFailed, &lastError)
-		if err != nil {
-			w.logger.Error("Worker error updating job to failed", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+	w.logger.Info("Job started", "event", "job_started", "worker_id", w.id, "job_id", job.ID, "priority", job.Priority.String())
+	w.activity.begin(job)
+	w.processJob(ctx, job)
+	w.activity.end()
+	w.ackToken(ctx, source, jobID)
+
+	if job.ConcurrencyKey != "" {
+		w.wakeConcurrencyKey(ctx, job)
+	}
+}
+
+func (w *Worker) processJob(ctx context.Context, job *domain.Job) {
+	w.publishJob(ctx, events.JobStarted, job)
+	w.observeWait(ctx, job)
+
+	// The Tracing middleware names the queue on the attempt's span
+	ctx = withQueueName(ctx, w.queueName)
+	ctx = withExecutionToken(ctx, job.ExecutionToken)
+	// Side effects the handler writes are stored when the job completes
+	outbox := newOutbox(job)
+	ctx = withOutbox(ctx, outbox)
+
+	stopHeartbeat := w.startHeartbeat(ctx, job)
+	defer stopHeartbeat()
+
+	// Logs for this attempt are captured for GET /jobs/{id}/logs
+	jobLogger := newJobLogger(w.logger, w.logStore, job)
+	if traceID := tracing.TraceID(job); traceID != "" {
+		jobLogger = jobLogger.With("trace_id", traceID)
+	}
+	ctx = withJobLogger(ctx, jobLogger)
+	jobLogger.Info("Attempt started", "event", "attempt_started", "worker_id", w.id, "job_id", job.ID, "attempt", job.Attempts, "delivery", job.Deliveries, "redelivered", job.Redelivered)
+
+	handler, ok := w.registry.Lookup(job.Type)
+	if !ok {
+		w.handleUnknownType(ctx, job, jobLogger)
+		return
+	}
+
+	// Cancelling the job through the API cancels jobCtx with ErrJobCancelled;
+	// reaping this attempt cancels it with ErrJobReaped
+	jobCtx, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	w.running.track(job.ID, job.Attempts, cancelJob)
+	defer w.running.untrack(job.ID, job.Attempts)
+
+	// The worker holds the claimed job while it waits for a token, which is
+	// what keeps the whole pool under the type's rate
+	if limiter, ok := w.registry.RateLimiter(job.Type); ok {
+		waited, err := limiter.Take(jobCtx)
+		if waited > 0 {
+			jobLogger.Info("Job rate limited", "event", "job_rate_limited", "worker_id", w.id, "job_id", job.ID, "job_type", job.Type, "waited", waited)
+		}
+		if errors.Is(context.Cause(jobCtx), ErrJobCancelled) {
+			w.recordCancelled(ctx, job, jobLogger)
+			return
+		}
+		if errors.Is(context.Cause(jobCtx), ErrJobReaped) {
+			w.abandonJob(job, jobLogger)
 			return
 		}
-		w.logger.Info("Job failed", "event", "job_failed", "worker_id", w.id, "job_id", job.ID)
-
-		err = w.metricStore.IncrementJobsFailed(ctx)
 		if err != nil {
-			w.logger.Error("Worker error incrementing jobs failed", "event", "metric_error", "worker_id", w.id, "error", err)
+			w.releaseJob(ctx, job, jobLogger)
 			return
 		}
+	}
 
+	timeout := w.registry.Timeout(job.Type)
+	if job.Timeout > 0 {
+		timeout = job.Timeout
+	}
+	handlerErr := w.runHandler(jobCtx, handler, job, timeout)
+	// The lease is released by the status change below; a heartbeat racing
+	// with it would only report the lease as lost
+	stopHeartbeat()
+
+	if errors.Is(context.Cause(jobCtx), ErrJobCancelled) {
+		w.recordCancelled(ctx, job, jobLogger)
+		return
+	}
+	if errors.Is(context.Cause(jobCtx), ErrJobReaped) {
+		w.abandonJob(job, jobLogger)
+		return
+	}
+
+	if ctx.Err() != nil {
+		// The shutdown grace period ran out before the handler finished
+		w.releaseJob(ctx, job, jobLogger)
+		return
+	}
+
+	var panicErr *PanicError
+	if errors.As(handlerErr, &panicErr) {
+		// The stack goes into last_error so the failure can be debugged from the API
+		if w.failJob(ctx, job, handlerErr.Error(), domain.ErrorClassPanic) {
+			jobLogger.Error("Job handler panicked", "event", "job_panicked", "worker_id", w.id, "job_id", job.ID, "panic", fmt.Sprint(panicErr.Value))
+		}
+		w.publishJob(ctx, events.JobPanicked, job)
+		return
+	}
+
+	if errors.Is(handlerErr, errHandlerTimeout) {
+		lastError := fmt.Sprintf("Job timed out after %s", timeout)
+		if w.failJob(ctx, job, lastError, domain.ErrorClassTimeout) {
+			jobLogger.Warn("Job timed out", "event", "job_timed_out", "worker_id", w.id, "job_id", job.ID, "timeout", timeout)
+		}
+		return
+	}
+
+	if handlerErr != nil {
+		if w.failJob(ctx, job, handlerErr.Error(), errorClassOf(handlerErr)) {
+			jobLogger.Info("Job failed", "event", "job_failed", "worker_id", w.id, "job_id", job.ID, "error", handlerErr)
+		}
 		return
 	}
 
 	// Success - mark as completed
-	err = w.jobStore.UpdateStatus(ctx, job.ID, domain.StatusCompleted, nil)
+	err := w.jobStore.CompleteJob(ctx, job.ID, job.ExecutionToken, job.Result, outbox.Messages())
 	if err != nil {
 		w.logger.Error("Worker error updating job to completed", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
 		return
 	}
-	err = w.metricStore.IncrementJobsCompleted(ctx)
+	w.observeProcessing(ctx, job)
+	w.publishJob(ctx, events.JobCompleted, job)
+	jobLogger.Info("Job completed", "event", "job_completed", "worker_id", w.id, "job_id", job.ID)
+
+	w.resolveDependents(ctx, job)
+}
+
+// publishJob publishes an event of type t about job, attributed to w.
+func (w *Worker) publishJob(ctx context.Context, t events.Type, job *domain.Job) {
+	event := events.ForJob(t, job)
+	event.Worker = w.name
+	event.Queue = w.queueName
+	w.events.Publish(ctx, event)
+}
+
+func (w *Worker) publishWorker(ctx context.Context, t events.Type, reason string) {
+	w.events.Publish(ctx, events.Event{Type: t, Worker: w.name, Queue: w.queueName, Reason: reason})
+}
+
+// observeWait records how long job waited to start, from when it became due
+// to its first attempt. Retries are left out, as their wait is mostly the
+// backoff.
+func (w *Worker) observeWait(ctx context.Context, job *domain.Job) {
+	if job.Attempts != 1 || job.StartedAt == nil {
+		return
+	}
+
+	due := job.CreatedAt
+	if job.RunAt != nil && job.RunAt.After(due) {
+		due = *job.RunAt
+	}
+	if err := w.metricStore.ObserveJobWait(ctx, job.Type, max(job.StartedAt.Sub(due), 0)); err != nil {
+		w.logger.Error("Worker error observing job wait", "event", "metric_error", "worker_id", w.id, "error", err)
+	}
+}
+
+// observeProcessing records how long the attempt took from its start to the
+// outcome just stored.
+func (w *Worker) observeProcessing(ctx context.Context, job *domain.Job) {
+	if job.StartedAt == nil {
+		return
+	}
+
+	if err := w.metricStore.ObserveJobProcessing(ctx, job.Type, time.Since(*job.StartedAt)); err != nil {
+		w.logger.Error("Worker error observing job processing time", "event", "metric_error", "worker_id", w.id, "error", err)
+	}
+}
+
+// resolveDependents releases or fails the blocked jobs that depend on job now
+// that it has finished, and enqueues the ones that became pending.
+func (w *Worker) resolveDependents(ctx context.Context, job *domain.Job) {
+	unblocked, failed, err := w.jobStore.ResolveDependents(ctx, job.ID)
 	if err != nil {
-		w.logger.Error("Worker error incrementing jobs completed", "event", "metric_error", "worker_id", w.id, "error", err)
+		w.logger.Error("Worker error resolving dependent jobs", "event", "job_dependents_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+		return
+	}
+
+	for _, id := range unblocked {
+		w.logger.Info("Job unblocked", "event", "job_unblocked", "job_id", id, "dependency_id", job.ID)
+		switch err := w.jobQueue.Enqueue(ctx, id); {
+		case err == nil:
+		case errors.Is(err, queue.ErrPaused):
+			// Enqueued by the catch-up on resume
+			w.logger.Info("Job buffered while the queue is paused", "event", "job_buffered", "job_id", id)
+		default:
+			// The sweeper picks the job up on its next pass
+			w.logger.Info("Job left for the sweeper", "event", "job_enqueue_failed", "job_id", id, "error", err)
+		}
+	}
+
+	for _, id := range failed {
+		w.logger.Warn("Dependent job failed", "event", "job_dependency_failed", "job_id", id, "dependency_id", job.ID)
+		w.events.Publish(ctx, events.Event{Type: events.JobDead, JobID: id, Reason: "dependency_failed"})
+	}
+}
+
+// startHeartbeat renews the job's lease at a third of the lease duration
+// until the returned function is first called, so the lease reaper only reclaims
+// jobs whose worker has gone away.
+func (w *Worker) startHeartbeat(ctx context.Context, job *domain.Job) func() {
+	if job.LeaseExpiresAt == nil || job.StartedAt == nil {
+		return func() {}
+	}
+
+	interval := job.LeaseExpiresAt.Sub(*job.StartedAt) / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := w.jobStore.RenewLease(ctx, job.ID, job.ExecutionToken); err != nil {
+					w.logger.Warn("Worker failed to renew job lease", "event", "job_lease_renew_failed", "worker_id", w.id, "job_id", job.ID, "error", err)
+					if errors.Is(err, store.ErrLeaseLost) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return sync.OnceFunc(func() {
+		close(stop)
+		<-done
+	})
+}
+
+// ackToken acknowledges a token dequeued from source once the work it woke is
+// done.
+func (w *Worker) ackToken(ctx context.Context, source queue.Queue, jobID string) {
+	// Work aborted by shutdown stays unacknowledged for redelivery
+	if ctx.Err() != nil {
 		return
 	}
-	w.logger.Info("Job completed", "event", "job_completed", "worker_id", w.id, "job_id", job.ID)
+	if err := queue.Ack(ctx, source, jobID); err != nil {
+		w.logger.Error("Failed to acknowledge job queue token", "event", "job_ack_error", "worker_id", w.id, "job_id", jobID, "error", err)
+	}
+}
+
+// wakeConcurrencyKey enqueues a token after a keyed job finishes so a job
+// held back by the key's limit is claimed now rather than on the next sweep.
+func (w *Worker) wakeConcurrencyKey(ctx context.Context, job *domain.Job) {
+	// If the queue is full, other workers have plenty of tokens already
+	w.jobQueue.Enqueue(ctx, job.ID)
+}
+
+// errHandlerTimeout is returned by runHandler when the handler outlives its
+// execution timeout.
+var errHandlerTimeout = errors.New("handler timed out")
+
+// runHandler calls handler with a context that expires after timeout. A
+// handler that ignores its context is abandoned once the deadline passes so
+// it cannot hold the worker forever.
+func (w *Worker) runHandler(ctx context.Context, handler HandlerFunc, job *domain.Job, timeout time.Duration) error {
+	if timeout <= 0 {
+		return callHandler(ctx, handler, job)
+	}
+
+	handlerCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The handler works on its own copy so an abandoned handler cannot race
+	// with the worker on the job
+	handlerJob := *job
+	done := make(chan error, 1)
+	go func() {
+		done <- callHandler(handlerCtx, handler, &handlerJob)
+	}()
+
+	select {
+	case err := <-done:
+		job.Result = handlerJob.Result
+		if err != nil && ctx.Err() == nil && errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+			return errHandlerTimeout
+		}
+		return err
+	case <-handlerCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errHandlerTimeout
+	}
+}
+
+// errorClassOf maps a handler error to the class recorded on the job.
+func errorClassOf(err error) string {
+	switch {
+	case IsPermanent(err):
+		return domain.ErrorClassPermanent
+	case isRetryable(err):
+		return domain.ErrorClassRetryable
+	default:
+		return domain.ErrorClassHandler
+	}
+}
+
+// failJob marks the job failed with lastError and reports whether it did.
+// Permanent failures skip the remaining retries.
+func (w *Worker) failJob(ctx context.Context, job *domain.Job, lastError string, errorClass string) bool {
+	// The store rejects writes on a cancelled context, and an aborted job must
+	// still leave the processing state
+	ctx = context.WithoutCancel(ctx)
+
+	permanent := errorClass == domain.ErrorClassPermanent
+	status, err := w.jobStore.FailJob(ctx, job.ID, job.ExecutionToken, lastError, errorClass, permanent)
+	if err != nil {
+		w.logger.Error("Worker error updating job to failed", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+		return false
+	}
+	w.observeProcessing(ctx, job)
+
+	event := events.ForJob(events.JobFailed, job)
+	event.Worker = w.name
+	event.Queue = w.queueName
+	event.Error = lastError
+	event.ErrorClass = errorClass
+	w.events.Publish(ctx, event)
+
+	if status == domain.StatusDead {
+		w.logger.Warn("Job exhausted its retries and moved to the dead-letter queue", "event", "job_dead", "worker_id", w.id, "job_id", job.ID, "attempts", job.Attempts)
+		event.Type = events.JobDead
+		w.events.Publish(ctx, event)
+		if err := queue.DeadLetter(ctx, w.jobQueue, job.ID, lastError); err != nil {
+			w.logger.Error("Worker error dead-lettering job on the queue", "event", "job_dead_letter_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+		}
+		w.resolveDependents(ctx, job)
+	}
+
+	if status == domain.StatusQuarantined {
+		w.logger.Warn("Job kept crashing its worker and was quarantined", "event", "job_quarantined", "worker_id", w.id, "job_id", job.ID, "error_class", errorClass)
+		event.Type = events.JobQuarantined
+		event.Reason = errorClass
+		w.events.Publish(ctx, event)
+	}
+
+	return true
+}
+
+// releaseJob returns a job interrupted by shutdown to pending so it runs again
+// after restart, without using up one of its retries.
+func (w *Worker) releaseJob(ctx context.Context, job *domain.Job, jobLogger *slog.Logger) {
+	// The store rejects writes on a cancelled context, and an aborted job must
+	// still leave the processing state
+	ctx = context.WithoutCancel(ctx)
+
+	if err := w.jobStore.ReleaseJob(ctx, job.ID, job.ExecutionToken); err != nil {
+		w.logger.Error("Worker error releasing job", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+		return
+	}
+	jobLogger.Info("Job aborted by shutdown and returned to pending", "event", "job_aborted", "worker_id", w.id, "job_id", job.ID)
+
+	event := events.ForJob(events.JobReleased, job)
+	event.Worker = w.name
+	event.Reason = "shutdown"
+	w.events.Publish(ctx, event)
+}
+
+// abandonJob drops an attempt the store has already taken back. The job
+// belongs to whoever claims it next, so nothing is written for it.
+func (w *Worker) abandonJob(job *domain.Job, jobLogger *slog.Logger) {
+	jobLogger.Warn("Attempt reaped while processing, result discarded", "event", "job_abandoned", "worker_id", w.id, "job_id", job.ID, "attempt", job.Attempts)
+}
+
+// recordCancelled moves a job interrupted by POST /jobs/{id}/cancel to
+// cancelled, whatever its handler returned.
+func (w *Worker) recordCancelled(ctx context.Context, job *domain.Job, jobLogger *slog.Logger) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := w.jobStore.CancelProcessingJob(ctx, job.ID, job.ExecutionToken); err != nil {
+		w.logger.Error("Worker error updating job to cancelled", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+		return
+	}
+	w.observeProcessing(ctx, job)
+	jobLogger.Info("Job cancelled while processing", "event", "job_cancelled", "worker_id", w.id, "job_id", job.ID)
+
+	event := events.ForJob(events.JobCancelled, job)
+	event.Worker = w.name
+	event.From = domain.StatusProcessing
+	w.events.Publish(ctx, event)
+
+	w.resolveDependents(ctx, job)
+}
+
+// handleUnknownType applies the registry fallback to a job no handler accepts.
+func (w *Worker) handleUnknownType(ctx context.Context, job *domain.Job, jobLogger *slog.Logger) {
+	if w.registry.Fallback() == FallbackPark {
+		// Workers don't claim jobs of types without a handler when
+		// parking, so this is a safety net: the job goes back without using
+		// up an attempt, and waits unclaimed for a handler
+		if err := w.jobStore.ReleaseJob(context.WithoutCancel(ctx), job.ID, job.ExecutionToken); err != nil {
+			w.logger.Error("Worker error parking job", "event", "job_update_error", "worker_id", w.id, "job_id", job.ID, "error", err)
+			return
+		}
+		event := events.ForJob(events.JobReleased, job)
+		event.Worker = w.name
+		event.Reason = "no_handler"
+		w.events.Publish(ctx, event)
+
+		jobLogger.Warn("No handler registered for job type, job parked", "event", "job_parked", "worker_id", w.id, "job_id", job.ID, "job_type", job.Type)
+		return
+	}
+
+	lastError := fmt.Sprintf("No handler registered for job type %q", job.Type)
+	if w.failJob(ctx, job, lastError, domain.ErrorClassNoHandler) {
+		jobLogger.Info("Job failed", "event", "job_failed", "worker_id", w.id, "job_id", job.ID, "error", lastError)
+	}
 }